@@ -18,6 +18,9 @@ import (
 	"sync"
 	"syscall"
 	"time"
+
+	// Modules
+	"github.com/djthorpe/gopi/util/systemd"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -48,6 +51,7 @@ type AppInstance struct {
 	I2C      I2C
 	SPI      SPI
 	LIRC     LIRC
+	Watchdog Watchdog
 	debug    bool
 	verbose  bool
 	service  string
@@ -56,6 +60,16 @@ type AppInstance struct {
 	byname   map[string]Driver
 	bytype   map[ModuleType]Driver
 	byorder  []Driver
+	lock     sync.Mutex
+	liveness map[*Liveness]time.Time
+	interval time.Duration
+}
+
+// Liveness is a handle which a task uses to report that it is
+// making progress
+type Liveness struct {
+	app  *AppInstance
+	name string
 }
 
 // MainTask defines a function which can run as a main task
@@ -236,6 +250,20 @@ func (this *AppInstance) Run(main_task MainTask, background_tasks ...BackgroundT
 		channels[i+1] = make(chan struct{})
 	}
 
+	// when there is a hardware watchdog or the service manager expects
+	// watchdog notifications, ping while tasks which have opted in
+	// report liveness
+	var watchdog_wg sync.WaitGroup
+	watchdog_done := make(chan struct{})
+	if timeout, notify := this.watchdogTimeout(); timeout > 0 {
+		this.setLiveness(timeout)
+		watchdog_wg.Add(1)
+		go func() {
+			defer watchdog_wg.Done()
+			this.pingWatchdog(timeout, notify, watchdog_done)
+		}()
+	}
+
 	// if more than one task, then give them a channel which is signalled
 	// by the main thread for ending
 	var wg sync.WaitGroup
@@ -268,6 +296,7 @@ func (this *AppInstance) Run(main_task MainTask, background_tasks ...BackgroundT
 
 	// Now run main task
 	err := main_task(this, channels[0])
+	this.notify(systemd.STATE_STOPPING)
 
 	// Wait for other tasks to finish
 	if len(background_tasks) > 0 {
@@ -280,9 +309,23 @@ func (this *AppInstance) Run(main_task MainTask, background_tasks ...BackgroundT
 		this.Logger.Debug2("All tasks finished")
 	}
 
+	// Stop pinging the watchdog
+	close(watchdog_done)
+	watchdog_wg.Wait()
+
 	return err
 }
 
+// NewLiveness returns a handle which a task calls periodically to
+// report that it is making progress. A task opts in to liveness checks
+// on the first call to Alive, and opts out by calling Done. When there
+// is a hardware watchdog or a service manager watchdog, it is only
+// pinged while tasks which have opted in have reported within the
+// watchdog timeout
+func (this *AppInstance) NewLiveness(name string) *Liveness {
+	return &Liveness{this, name}
+}
+
 // Debug returns whether the application has the debug flag set
 func (this *AppInstance) Debug() bool {
 	return this.debug
@@ -358,6 +401,7 @@ func (this *AppInstance) Close() error {
 	this.GPIO = nil
 	this.SPI = nil
 	this.LIRC = nil
+	this.Watchdog = nil
 
 	// Return success
 	return nil
@@ -392,6 +436,27 @@ func AppendModulesByName(modules []*Module, names ...string) ([]*Module, error)
 	return modules, nil
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// LIVENESS
+
+// Alive reports that the task is making progress, and opts the task
+// in to liveness checks
+func (this *Liveness) Alive() {
+	this.app.lock.Lock()
+	defer this.app.lock.Unlock()
+	if this.app.liveness != nil {
+		this.app.liveness[this] = time.Now()
+	}
+}
+
+// Done opts the task out of liveness checks, and should be called
+// before the task returns
+func (this *Liveness) Done() {
+	this.app.lock.Lock()
+	defer this.app.lock.Unlock()
+	delete(this.app.liveness, this)
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
@@ -494,11 +559,113 @@ func (this *AppInstance) setModuleInstance(module *Module, driver Driver) error
 		if this.Input, ok = driver.(InputManager); !ok {
 			return fmt.Errorf("Module %v cannot be cast to gopi.InputManager", module)
 		}
+	case MODULE_TYPE_WATCHDOG:
+		if this.Watchdog, ok = driver.(Watchdog); !ok {
+			return fmt.Errorf("Module %v cannot be cast to gopi.Watchdog", module)
+		}
 	}
 	// success
 	return nil
 }
 
+// setLiveness starts tracking liveness for tasks which opt in,
+// which should report at half the timeout
+func (this *AppInstance) setLiveness(timeout time.Duration) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.interval = timeout / 2
+	this.liveness = make(map[*Liveness]time.Time)
+}
+
+// notAlive returns the names of tasks which have opted in to
+// liveness checks and have not reported within the timeout
+func (this *AppInstance) notAlive(timeout time.Duration) []string {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	names := make([]string, 0)
+	for liveness, ts := range this.liveness {
+		if time.Since(ts) > timeout {
+			names = append(names, liveness.name)
+		}
+	}
+	return names
+}
+
+// aliveTicker returns a channel which receives values at the interval
+// tasks should report liveness and a function to stop the ticker. The
+// channel is nil when there is no watchdog
+func (this *AppInstance) aliveTicker() (<-chan time.Time, func()) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.interval == 0 {
+		return nil, func() {}
+	} else {
+		ticker := time.NewTicker(this.interval)
+		return ticker.C, ticker.Stop
+	}
+}
+
+// watchdogTimeout returns the shorter of the hardware watchdog timeout
+// and the service manager watchdog interval, or zero if neither is
+// enabled, and whether the service manager expects notifications
+func (this *AppInstance) watchdogTimeout() (time.Duration, bool) {
+	timeout := time.Duration(0)
+	if this.Watchdog != nil {
+		timeout = this.Watchdog.Timeout()
+	}
+	if interval, err := systemd.WatchdogInterval(); err != nil {
+		if this.Logger != nil {
+			this.Logger.Warn("gopi.AppInstance.Run: %v", err)
+		}
+	} else if interval > 0 {
+		if timeout == 0 || interval < timeout {
+			timeout = interval
+		}
+		return timeout, true
+	}
+	return timeout, false
+}
+
+// pingWatchdog pings the hardware watchdog and notifies the service
+// manager at half the timeout while all tasks are alive, until done
+// is closed
+func (this *AppInstance) pingWatchdog(timeout time.Duration, notify bool, done <-chan struct{}) {
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+	for {
+		if names := this.notAlive(timeout); len(names) > 0 {
+			this.Logger.Warn("gopi.AppInstance.Run: Tasks have not reported liveness, not pinging watchdog: %v", strings.Join(names, ","))
+		} else {
+			if this.Watchdog != nil {
+				if err := this.Watchdog.Ping(); err != nil {
+					this.Logger.Error("gopi.AppInstance.Run: Watchdog: %v", err)
+				}
+			}
+			if notify {
+				this.notify(systemd.STATE_WATCHDOG)
+			}
+		}
+		select {
+		case <-ticker.C:
+			continue
+		case <-done:
+			return
+		}
+	}
+}
+
+// notify sends states to the service manager, when running
+// as a service
+func (this *AppInstance) notify(states ...string) {
+	if ok, err := systemd.Notify(states...); err != nil {
+		if this.Logger != nil {
+			this.Logger.Warn("gopi.AppInstance: Notify: %v", err)
+		}
+	} else if ok && this.Logger != nil {
+		this.Logger.Debug2("gopi.AppInstance: Notify: %v", strings.Join(states, ","))
+	}
+}
+
 func getTestlessArguments(input []string) []string {
 	output := make([]string, 0, len(input))
 	for _, arg := range input {
@@ -12,6 +12,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+
+	// Modules
+	"github.com/djthorpe/gopi/util/systemd"
 )
 
 var (
@@ -36,6 +39,9 @@ func CommandLineTool(config AppConfig, main_task MainTask, background_tasks ...B
 	}
 	defer app.Close()
 
+	// Notify the service manager that modules are opened
+	app.notify(systemd.STATE_READY)
+
 	// Run the application
 	if err := app.Run(main_task, background_tasks...); err == ErrHelp {
 		config.AppFlags.PrintUsage()
@@ -102,6 +108,12 @@ func mainRPCServer(app *AppInstance, done chan<- struct{}) error {
 	app.Logger.Info("Waiting for CTRL+C or SIGTERM to stop server")
 	app.WaitForSignal()
 
+	// Report that services are no longer serving, so that clients
+	// stop sending requests
+	if health, ok := server.(RPCHealth); ok {
+		health.SetServing("", false)
+	}
+
 	// Cancel on-going requests for all services
 	for _, module := range ModulesByType(MODULE_TYPE_SERVICE) {
 		if instance, ok := app.ModuleInstance(module.Name).(RPCService); ok == true && instance != nil {
@@ -152,8 +164,13 @@ func bgRPCDiscovery(app *AppInstance, done <-chan struct{}) error {
 		start_rpc <- DONE
 		return errors.New("rpc/discovery: missing or invalid")
 	} else {
-		// Listen for server started events
+		// Listen for server started events, and report liveness while
+		// the event loop is running
 		events := server.Subscribe()
+		liveness := app.NewLiveness("bgRPCDiscovery")
+		defer liveness.Done()
+		alive, stop := app.aliveTicker()
+		defer stop()
 		// Now we can signal the server to start
 		start_rpc <- DONE
 	FOR_LOOP:
@@ -164,6 +181,8 @@ func bgRPCDiscovery(app *AppInstance, done <-chan struct{}) error {
 					app.Logger.Debug("rpc/server: %v", server_event.Type())
 					if server_event.Type() == RPC_EVENT_SERVER_STARTED {
 						app.Logger.Info("rpc/server: Listening on %v", server.Addr())
+						// Notify the service manager that the server has started
+						app.notify(systemd.STATE_READY)
 						// Register service
 						if service := server.Service(app.service); service != nil {
 							if err := discovery.Register(service); err != nil {
@@ -172,6 +191,8 @@ func bgRPCDiscovery(app *AppInstance, done <-chan struct{}) error {
 						}
 					}
 				}
+			case <-alive:
+				liveness.Alive()
 			case <-done:
 				break FOR_LOOP
 			}
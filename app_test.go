@@ -3,6 +3,7 @@ package gopi_test
 import (
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -236,6 +237,33 @@ func TestRunSignal_005(t *testing.T) {
 	}
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// WATCHDOG TESTS
+
+func TestRunWatchdog_001(t *testing.T) {
+	// Tasks which do not report liveness do not stop watchdog pings
+	test_watchdog.Reset()
+	if app, err := gopi.NewAppInstance(gopi.NewAppConfig("test/watchdog")); err != nil {
+		t.Error(err)
+	} else if err := app.Run(SleepTask, Task001); err != nil {
+		t.Error(err)
+	} else if pings := test_watchdog.Pings(); pings < 5 {
+		t.Error("Expected watchdog pings, got", pings)
+	}
+}
+
+func TestRunWatchdog_002(t *testing.T) {
+	// A task which opts in and stops reporting liveness stops pings
+	test_watchdog.Reset()
+	if app, err := gopi.NewAppInstance(gopi.NewAppConfig("test/watchdog")); err != nil {
+		t.Error(err)
+	} else if err := app.Run(StalledTask); err != nil {
+		t.Error(err)
+	} else if pings := test_watchdog.Pings(); pings > 5 {
+		t.Error("Expected watchdog pings to stop, got", pings)
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // TASKS
 
@@ -349,3 +377,70 @@ OUTER_LOOP:
 	app.Logger.Debug("WaitTask004: now finished")
 	return nil
 }
+
+func SleepTask(app *gopi.AppInstance, done chan<- struct{}) error {
+	time.Sleep(200 * time.Millisecond)
+	done <- gopi.DONE
+	return nil
+}
+
+func StalledTask(app *gopi.AppInstance, done chan<- struct{}) error {
+	liveness := app.NewLiveness("StalledTask")
+	defer liveness.Done()
+	liveness.Alive()
+	time.Sleep(200 * time.Millisecond)
+	done <- gopi.DONE
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// MOCK WATCHDOG
+
+type watchdog struct {
+	sync.Mutex
+	pings int
+}
+
+var (
+	test_watchdog = registerWatchdog("test/watchdog")
+)
+
+// registerWatchdog registers a watchdog module with a 20ms timeout
+func registerWatchdog(name string) *watchdog {
+	this := new(watchdog)
+	gopi.RegisterModule(gopi.Module{
+		Name: name,
+		Type: gopi.MODULE_TYPE_WATCHDOG,
+		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
+			return this, nil
+		},
+	})
+	return this
+}
+
+func (this *watchdog) Close() error {
+	return nil
+}
+
+func (this *watchdog) Timeout() time.Duration {
+	return 20 * time.Millisecond
+}
+
+func (this *watchdog) Ping() error {
+	this.Lock()
+	defer this.Unlock()
+	this.pings++
+	return nil
+}
+
+func (this *watchdog) Reset() {
+	this.Lock()
+	defer this.Unlock()
+	this.pings = 0
+}
+
+func (this *watchdog) Pings() int {
+	this.Lock()
+	defer this.Unlock()
+	return this.pings
+}
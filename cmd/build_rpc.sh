@@ -39,6 +39,8 @@ COMMANDS=(
   rpc/helloworld_server.go
   rpc/helloworld_client.go
   rpc/rpc_discovery.go
+  rpc/metrics_client.go
+  rpc/rpc_client.go
 )
 
 echo "go generate github.com/djthorpe/gopi/rpc/protobuf"
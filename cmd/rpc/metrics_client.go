@@ -0,0 +1,203 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2018
+	All Rights Reserved
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+// Displays host and custom metrics from a remote service
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	tablewriter "github.com/olekukonko/tablewriter"
+
+	// Modules
+	_ "github.com/djthorpe/gopi/sys/logger"
+	_ "github.com/djthorpe/gopi/sys/rpc/grpc"
+	_ "github.com/djthorpe/gopi/sys/rpc/mdns"
+
+	// RPC Clients
+	metrics "github.com/djthorpe/gopi/rpc/grpc/metrics"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+func Main(app *gopi.AppInstance, done chan<- struct{}) error {
+
+	// Client Pool
+	pool := app.ModuleInstance("rpc/clientpool").(gopi.RPCClientPool)
+	addr, _ := app.AppFlags.GetString("addr")
+	watch, _ := app.AppFlags.GetDuration("watch")
+	stats, _ := app.AppFlags.GetBool("stats")
+
+	// Lookup any service record for application
+	ctx, _ := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	if records, err := pool.Lookup(ctx, "", addr, 1); err != nil {
+		done <- gopi.DONE
+		return err
+	} else if len(records) == 0 {
+		done <- gopi.DONE
+		return gopi.ErrDeadlineExceeded
+	} else if conn, err := pool.Connect(records[0], 0); err != nil {
+		done <- gopi.DONE
+		return err
+	} else if client, ok := pool.NewClient(metrics.SERVICE_NAME, conn).(*metrics.Client); ok == false || client == nil {
+		done <- gopi.DONE
+		return gopi.ErrAppError
+	} else if err := Run(app, client, watch, stats); err != nil {
+		done <- gopi.DONE
+		return err
+	} else if err := pool.Disconnect(conn); err != nil {
+		done <- gopi.DONE
+		return err
+	}
+
+	// Success
+	done <- gopi.DONE
+	return nil
+}
+
+func Run(app *gopi.AppInstance, client *metrics.Client, watch time.Duration, stats bool) error {
+	if watch == 0 {
+		return RunOnce(client, stats)
+	} else {
+		return RunWatch(app, client, watch, stats)
+	}
+}
+
+// RunOnce displays metrics from the remote service
+func RunOnce(client *metrics.Client, stats bool) error {
+	update := &metrics.Update{}
+	if host, err := client.HostMetrics(); err != nil {
+		return err
+	} else if l1, l5, l15, err := client.LoadAverage(); err != nil {
+		return err
+	} else if custom, err := client.ListMetrics(gopi.METRIC_TYPE_NONE); err != nil {
+		return err
+	} else {
+		update.Hostname = host.Hostname
+		update.LoadAverage = [3]float64{l1, l5, l15}
+		update.Metrics = custom
+	}
+	if stats {
+		if host_stats, err := client.HostStats(); err != nil {
+			return err
+		} else {
+			update.HostStats = host_stats
+		}
+	}
+	PrintUpdate(update)
+	return nil
+}
+
+// RunWatch displays metrics from the remote service at an
+// interval until CTRL+C is pressed
+func RunWatch(app *gopi.AppInstance, client *metrics.Client, interval time.Duration, stats bool) error {
+	stop := make(chan struct{})
+	updates := make(chan *metrics.Update)
+	errs := make(chan error)
+
+	go func() {
+		errs <- client.WatchMetrics(stop, interval, gopi.METRIC_TYPE_NONE, stats, updates)
+	}()
+	go func() {
+		app.WaitForSignal()
+		close(stop)
+	}()
+
+	for {
+		select {
+		case update := <-updates:
+			PrintUpdate(update)
+		case err := <-errs:
+			return err
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func PrintUpdate(update *metrics.Update) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Value"})
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.Append([]string{"Hostname", update.Hostname})
+	if update.Timestamp.IsZero() == false {
+		table.Append([]string{"Timestamp", update.Timestamp.Format(time.RFC3339)})
+		table.Append([]string{"Host uptime", fmt.Sprint(update.HostUptime)})
+		table.Append([]string{"Service uptime", fmt.Sprint(update.ServiceUptime)})
+	}
+	table.Append([]string{"Load Average", fmt.Sprintf("%.2f %.2f %.2f", update.LoadAverage[0], update.LoadAverage[1], update.LoadAverage[2])})
+	if stats := update.HostStats; stats != nil {
+		for _, cpu := range stats.CPU {
+			table.Append([]string{"CPU " + cpu.Name, fmt.Sprintf("%.1f%%", cpu.Usage*100)})
+		}
+		for _, cpu := range stats.Frequency {
+			table.Append([]string{"Frequency " + cpu.Name, fmt.Sprintf("%v MHz", cpu.Current/1000000)})
+		}
+		table.Append([]string{"Memory", fmt.Sprintf("%v MB available of %v MB", stats.Memory.Available>>20, stats.Memory.Total>>20)})
+		table.Append([]string{"Swap", fmt.Sprintf("%v MB free of %v MB", stats.Memory.SwapFree>>20, stats.Memory.SwapTotal>>20)})
+		for _, fs := range stats.Filesystems {
+			table.Append([]string{"Filesystem " + fs.Path, fmt.Sprintf("%v MB available of %v MB", fs.Available>>20, fs.Total>>20)})
+		}
+		for _, iface := range stats.Network {
+			table.Append([]string{"Network " + iface.Name, fmt.Sprintf("rx %.0f B/s tx %.0f B/s", iface.RxRate, iface.TxRate)})
+		}
+		for _, zone := range stats.Thermal {
+			table.Append([]string{"Thermal " + zone.Type, fmt.Sprintf("%.1f°C", zone.Celcius)})
+		}
+	}
+	for _, metric := range update.Metrics {
+		table.Append([]string{metricName(metric), metricValue(metric)})
+	}
+	table.Render()
+}
+
+func metricName(metric *gopi.Metric) string {
+	labels := make([]string, len(metric.Labels))
+	for i, label := range metric.Labels {
+		labels[i] = label.Name + "=" + label.Value
+	}
+	if len(labels) == 0 {
+		return metric.Name
+	} else {
+		return metric.Name + " {" + strings.Join(labels, ",") + "}"
+	}
+}
+
+func metricValue(metric *gopi.Metric) string {
+	rate := strings.ToLower(strings.TrimPrefix(metric.Rate.String(), "METRIC_RATE_"))
+	switch metric.Type {
+	case gopi.METRIC_TYPE_GAUGE:
+		return fmt.Sprintf("%v (min %v max %v mean %.2f)", metric.Value, metric.Summary.Min, metric.Summary.Max, metric.Mean)
+	case gopi.METRIC_TYPE_HISTOGRAM:
+		return fmt.Sprintf("count %v sum %v (min %v max %v mean %.2f)", metric.Count, metric.Sum, metric.Summary.Min, metric.Summary.Max, metric.Mean)
+	default:
+		return fmt.Sprintf("%v total, %.2f per %v", metric.Total, metric.Mean, rate)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func main() {
+	// Create the configuration
+	config := gopi.NewAppConfig("rpc/client/metrics:grpc")
+	config.AppFlags.FlagString("addr", "", "Gateway address")
+	config.AppFlags.FlagDuration("watch", 0, "Display metrics at an interval")
+	config.AppFlags.FlagBool("stats", false, "Display host statistics")
+
+	// Set the RPCServiceRecord for server discovery
+	config.Service = "metrics"
+
+	// Run the command line tool
+	os.Exit(gopi.CommandLineTool(config, Main))
+}
@@ -0,0 +1,403 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+// Lists services on a remote server and calls their methods with JSON
+// messages, using server reflection
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	gateway "github.com/djthorpe/gopi/sys/rpc/gateway"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+	tablewriter "github.com/olekukonko/tablewriter"
+	gogrpc "google.golang.org/grpc"
+	protojson "google.golang.org/protobuf/encoding/protojson"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	dynamicpb "google.golang.org/protobuf/types/dynamicpb"
+
+	// Modules
+	_ "github.com/djthorpe/gopi/sys/logger"
+	_ "github.com/djthorpe/gopi/sys/rpc/grpc"
+	_ "github.com/djthorpe/gopi/sys/rpc/mdns"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+var (
+	marshaler = protojson.MarshalOptions{Multiline: true, Indent: "  "}
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+func Main(app *gopi.AppInstance, done chan<- struct{}) error {
+
+	// Client Pool
+	pool := app.ModuleInstance("rpc/clientpool").(gopi.RPCClientPool)
+	addr, _ := app.AppFlags.GetString("addr")
+	name, _ := app.AppFlags.GetString("name")
+	service, _ := app.AppFlags.GetString("service")
+	timeout, _ := app.AppFlags.GetDuration("lookup")
+
+	// Lookup a service record for the service
+	if record, err := Lookup(app, pool, name, addr, service, timeout); err != nil {
+		done <- gopi.DONE
+		return err
+	} else if conn, err := pool.Connect(record, 0); err != nil {
+		done <- gopi.DONE
+		return err
+	} else if err := Run(app, conn); err != nil {
+		pool.Disconnect(conn)
+		done <- gopi.DONE
+		return err
+	} else if err := pool.Disconnect(conn); err != nil {
+		done <- gopi.DONE
+		return err
+	}
+
+	// Success
+	done <- gopi.DONE
+	return nil
+}
+
+// Lookup returns a service record by name or address. When a service is
+// given, records of that service type are browsed for and returned,
+// otherwise any record for the services of the client pool
+func Lookup(app *gopi.AppInstance, pool gopi.RPCClientPool, name, addr, service string, timeout time.Duration) (*gopi.RPCServiceRecord, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if service == "" {
+		if records, err := pool.Lookup(ctx, name, addr, 1); err != nil {
+			return nil, err
+		} else if len(records) == 0 {
+			return nil, gopi.ErrDeadlineExceeded
+		} else {
+			return records[0], nil
+		}
+	}
+
+	// Browse for the service type, and collect records until the timeout
+	service_type, err := gopi.RPCServiceType(service, 0)
+	if err != nil {
+		return nil, err
+	}
+	go app.ModuleInstance("mdns").(gopi.RPCServiceDiscovery).Browse(ctx, service_type)
+	records, err := pool.Lookup(ctx, name, addr, 0)
+	for _, record := range records {
+		if record.Type == service_type {
+			return record, nil
+		}
+	}
+	if err == nil {
+		err = gopi.ErrDeadlineExceeded
+	}
+	return nil, err
+}
+
+// Run lists the services when there are no arguments, describes the
+// method messages or otherwise calls the method with the remaining
+// arguments as the JSON messages
+func Run(app *gopi.AppInstance, conn gopi.RPCClientConn) error {
+	describe, _ := app.AppFlags.GetBool("describe")
+	args := app.AppFlags.Args()
+
+	grpc_conn, ok := conn.(grpc.GRPCClientConn)
+	if ok == false {
+		return gopi.ErrAppError
+	}
+	ctx, cancel := newContext(conn)
+	defer cancel()
+	services, err := gateway.Services(ctx, grpc_conn.GRPCConn())
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		PrintServices(services)
+		return nil
+	}
+	method, err := FindMethod(services, args[0])
+	if err != nil {
+		return err
+	} else if describe {
+		PrintMethod(method)
+		return nil
+	}
+	requests, err := Requests(method.Descriptor().Input(), args[1:], os.Stdin, method.Descriptor().IsStreamingClient())
+	if err != nil {
+		return err
+	}
+
+	// Make the call, cancelling streams on CTRL+C
+	if method.Descriptor().IsStreamingClient() || method.Descriptor().IsStreamingServer() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			app.WaitForSignal()
+			cancel()
+		}()
+		return CallStream(ctx, grpc_conn.GRPCConn(), method, requests)
+	} else {
+		return CallUnary(ctx, grpc_conn.GRPCConn(), method, requests[0])
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// METHODS
+
+// FindMethod returns a method by path, where the package and service
+// can be omitted when the name is unique
+func FindMethod(services []*gateway.Service, name string) (*gateway.Method, error) {
+	matched := make([]*gateway.Method, 0, 1)
+	for _, service := range services {
+		for _, method := range service.Methods {
+			if method.Path == name {
+				return method, nil
+			} else if strings.HasSuffix("."+method.Path, "."+name) {
+				matched = append(matched, method)
+			} else if strings.Contains(name, "/") == false && method.Name == name {
+				matched = append(matched, method)
+			}
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return nil, fmt.Errorf("Method not found: %v", name)
+	case 1:
+		return matched[0], nil
+	default:
+		paths := make([]string, len(matched))
+		for i, method := range matched {
+			paths[i] = method.Path
+		}
+		return nil, fmt.Errorf("Method is ambiguous: %v", strings.Join(paths, ", "))
+	}
+}
+
+// Requests returns the request messages from the arguments or, for client
+// streaming methods without arguments, the JSON messages read from the
+// input. An empty message is returned when there are no arguments
+func Requests(desc protoreflect.MessageDescriptor, args []string, r io.Reader, streaming bool) ([]*dynamicpb.Message, error) {
+	if len(args) == 0 && streaming {
+		decoder := json.NewDecoder(r)
+		requests := make([]*dynamicpb.Message, 0)
+		for {
+			var data json.RawMessage
+			if err := decoder.Decode(&data); err == io.EOF {
+				return requests, nil
+			} else if err != nil {
+				return nil, err
+			} else if request, err := newMessage(desc, string(data)); err != nil {
+				return nil, err
+			} else {
+				requests = append(requests, request)
+			}
+		}
+	} else if len(args) == 0 {
+		args = []string{"{}"}
+	} else if len(args) > 1 && streaming == false {
+		return nil, gopi.ErrHelp
+	}
+	requests := make([]*dynamicpb.Message, len(args))
+	for i, arg := range args {
+		if request, err := newMessage(desc, arg); err != nil {
+			return nil, err
+		} else {
+			requests[i] = request
+		}
+	}
+	return requests, nil
+}
+
+// CallUnary calls a method and prints the reply
+func CallUnary(ctx context.Context, conn *gogrpc.ClientConn, method *gateway.Method, request *dynamicpb.Message) error {
+	reply := dynamicpb.NewMessage(method.Descriptor().Output())
+	if err := conn.Invoke(ctx, "/"+method.Path, request, reply); err != nil {
+		return err
+	} else {
+		return printMessage(reply)
+	}
+}
+
+// CallStream sends the requests and prints each reply until the stream
+// ends or the context is cancelled
+func CallStream(ctx context.Context, conn *gogrpc.ClientConn, method *gateway.Method, requests []*dynamicpb.Message) error {
+	desc := method.Descriptor()
+	stream, err := conn.NewStream(ctx, &gogrpc.StreamDesc{
+		ClientStreams: desc.IsStreamingClient(),
+		ServerStreams: desc.IsStreamingServer(),
+	}, "/"+method.Path)
+	if err != nil {
+		return err
+	}
+	for _, request := range requests {
+		if err := stream.SendMsg(request); err != nil {
+			return err
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	for {
+		reply := dynamicpb.NewMessage(desc.Output())
+		if err := stream.RecvMsg(reply); err == io.EOF {
+			return nil
+		} else if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		} else if err := printMessage(reply); err != nil {
+			return err
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRINT
+
+func PrintServices(services []*gateway.Service) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Service", "Method", "Input", "Output"})
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoMergeCells(true)
+	for _, service := range services {
+		for _, method := range service.Methods {
+			desc := method.Descriptor()
+			table.Append([]string{service.Name, method.Name, streamName(desc.Input(), desc.IsStreamingClient()), streamName(desc.Output(), desc.IsStreamingServer())})
+		}
+	}
+	table.Render()
+}
+
+// PrintMethod prints the method and the schema of its messages
+func PrintMethod(method *gateway.Method) {
+	desc := method.Descriptor()
+	fmt.Printf("rpc %v(%v) returns (%v)\n\n", method.Path, streamName(desc.Input(), desc.IsStreamingClient()), streamName(desc.Output(), desc.IsStreamingServer()))
+	seen := make(map[protoreflect.FullName]bool)
+	printSchema(desc.Input(), seen)
+	printSchema(desc.Output(), seen)
+}
+
+func printSchema(desc protoreflect.Descriptor, seen map[protoreflect.FullName]bool) {
+	if seen[desc.FullName()] {
+		return
+	} else {
+		seen[desc.FullName()] = true
+	}
+	switch desc := desc.(type) {
+	case protoreflect.EnumDescriptor:
+		fmt.Printf("enum %v {\n", desc.FullName())
+		for i := 0; i < desc.Values().Len(); i++ {
+			value := desc.Values().Get(i)
+			fmt.Printf("  %v = %v;\n", value.Name(), value.Number())
+		}
+		fmt.Printf("}\n\n")
+	case protoreflect.MessageDescriptor:
+		fmt.Printf("message %v {\n", desc.FullName())
+		for i := 0; i < desc.Fields().Len(); i++ {
+			field := desc.Fields().Get(i)
+			fmt.Printf("  %v %v = %v;\n", fieldType(field), field.Name(), field.Number())
+		}
+		fmt.Printf("}\n\n")
+		// Print the schema of messages and enums used by the fields
+		for i := 0; i < desc.Fields().Len(); i++ {
+			field := desc.Fields().Get(i)
+			if field.IsMap() {
+				field = field.MapValue()
+			}
+			if field.Message() != nil {
+				printSchema(field.Message(), seen)
+			} else if field.Enum() != nil {
+				printSchema(field.Enum(), seen)
+			}
+		}
+	}
+}
+
+func fieldType(field protoreflect.FieldDescriptor) string {
+	if field.IsMap() {
+		return fmt.Sprintf("map<%v, %v>", fieldType(field.MapKey()), fieldType(field.MapValue()))
+	}
+	name := field.Kind().String()
+	if field.Message() != nil {
+		name = string(field.Message().FullName())
+	} else if field.Enum() != nil {
+		name = string(field.Enum().FullName())
+	}
+	if field.IsList() {
+		return "repeated " + name
+	} else {
+		return name
+	}
+}
+
+func streamName(desc protoreflect.MessageDescriptor, streaming bool) string {
+	if streaming {
+		return "stream " + string(desc.FullName())
+	} else {
+		return string(desc.FullName())
+	}
+}
+
+func printMessage(message *dynamicpb.Message) error {
+	if data, err := marshaler.Marshal(message); err != nil {
+		return err
+	} else {
+		fmt.Println(string(data))
+		return nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func newMessage(desc protoreflect.MessageDescriptor, data string) (*dynamicpb.Message, error) {
+	message := dynamicpb.NewMessage(desc)
+	if err := protojson.Unmarshal([]byte(data), message); err != nil {
+		return nil, fmt.Errorf("%v: %v", desc.FullName(), err)
+	} else {
+		return message, nil
+	}
+}
+
+// newContext returns a context with the connection timeout
+func newContext(conn gopi.RPCClientConn) (context.Context, context.CancelFunc) {
+	if conn.Timeout() == 0 {
+		return context.WithCancel(context.Background())
+	} else {
+		return context.WithTimeout(context.Background(), conn.Timeout())
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func main() {
+	// Create the configuration
+	config := gopi.NewAppConfig("rpc/clientpool")
+	config.AppFlags.SetUsageFunc(func(flags *gopi.Flags) {
+		fmt.Fprintf(os.Stderr, "Usage: %v <flags> [method [json...]]\n\n", flags.Name())
+		fmt.Fprintf(os.Stderr, "Lists services and methods, or calls a method with JSON messages. Messages for\n")
+		fmt.Fprintf(os.Stderr, "client-streaming methods are read from standard input when not given as arguments.\n\n")
+		flags.PrintDefaults()
+	})
+	config.AppFlags.FlagString("addr", "", "Gateway address")
+	config.AppFlags.FlagString("name", "", "Gateway name")
+	config.AppFlags.FlagString("service", "", "Service name to discover, or any service for the client pool when empty")
+	config.AppFlags.FlagDuration("lookup", 500*time.Millisecond, "Time to wait for gateway discovery")
+	config.AppFlags.FlagBool("describe", false, "Describe the method messages rather than calling it")
+
+	// Run the command line tool
+	os.Exit(gopi.CommandLineTool(config, Main))
+}
@@ -96,6 +96,10 @@ type InputEvent interface {
 
 	// Multi-touch slot identifier
 	Slot() uint
+
+	// Joystick or gamepad axis, and the value of the axis
+	Axis() InputAxis
+	AxisValue() float32
 }
 
 // RPCEvent is an event which is emitted by either discovery or
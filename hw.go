@@ -10,6 +10,7 @@ package gopi
 
 import (
 	"fmt"
+	"time"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -176,6 +177,18 @@ type LIRC interface {
 	PulseSend(values []uint32) error
 }
 
+// Watchdog implements a hardware watchdog, which resets the system
+// unless it is pinged within the timeout
+type Watchdog interface {
+	Driver
+
+	// Return the timeout
+	Timeout() time.Duration
+
+	// Reset the watchdog timer
+	Ping() error
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // TYPES
 
@@ -10,6 +10,7 @@ package gopi
 
 import (
 	"strings"
+	"time"
 )
 
 // InputManager allows you to open and close input devices
@@ -24,6 +25,11 @@ type InputManager interface {
 	// Close Device
 	CloseDevice(device InputDevice) error
 
+	// Create a virtual device, which injects events into the system. The
+	// size is the range of absolute positions for touchscreen and
+	// joystick devices
+	CreateVirtualDevice(name string, device_type InputDeviceType, size Size) (InputVirtualDevice, error)
+
 	/*
 		// Add a device to managed input devices
 		AddDevice(device InputDevice) error
@@ -65,6 +71,47 @@ type InputDevice interface {
 	Matches(string, InputDeviceType, InputDeviceBus) bool
 }
 
+// InputVirtualDevice is an input device created by the application
+// which injects key presses, motion and touches into the system
+type InputVirtualDevice interface {
+	InputDevice
+
+	// Inject a key press, release or repeat
+	InjectKey(key KeyCode, event_type InputEventType) error
+
+	// Inject relative motion
+	InjectRelPosition(rel Point) error
+
+	// Inject absolute position
+	InjectAbsPosition(position Point) error
+
+	// Inject a multi-touch press, release or position for a slot
+	InjectTouch(slot uint, event_type InputEventType, position Point) error
+}
+
+// InputJoystick is a joystick or gamepad input device, which reports
+// absolute axes in addition to button presses
+type InputJoystick interface {
+	InputDevice
+
+	// Absolute axes reported by the device
+	Axes() []InputAxis
+
+	// Return the current value for an axis, which is between -1.0 and 1.0
+	// for sticks and hats, or between 0.0 and 1.0 for triggers
+	AxisValue(axis InputAxis) float32
+
+	// Return and set the dead zone for an axis, as a proportion of the
+	// range of the axis. Values within the dead zone are reported as zero
+	DeadZone(axis InputAxis) float32
+	SetDeadZone(axis InputAxis, dead_zone float32) error
+
+	// Play a force feedback rumble effect for a duration, with strong and
+	// weak motor magnitudes between 0.0 and 1.0. Returns ErrNotImplemented
+	// if the device does not support force feedback
+	Rumble(strong, weak float32, duration time.Duration) error
+}
+
 // Device type (keyboard, mouse, touchscreen, etc)
 type InputDeviceType uint8
 
@@ -80,6 +127,9 @@ type KeyState uint16
 // Bus type (USB, Bluetooth, etc)
 type InputDeviceBus uint16
 
+// Absolute axis on a joystick or gamepad
+type InputAxis uint8
+
 ////////////////////////////////////////////////////////////////////////////////
 // CONSTANTS
 
@@ -136,6 +186,34 @@ const (
 	INPUT_EVENT_TOUCHPRESS    InputEventType = 0x0006
 	INPUT_EVENT_TOUCHRELEASE  InputEventType = 0x0007
 	INPUT_EVENT_TOUCHPOSITION InputEventType = 0x0008
+
+	// Device hot-plug events
+	INPUT_EVENT_DEVICEADDED   InputEventType = 0x0009
+	INPUT_EVENT_DEVICEREMOVED InputEventType = 0x000A
+
+	// Joystick and gamepad axis events
+	INPUT_EVENT_AXIS InputEventType = 0x000B
+)
+
+// Joystick and gamepad axes
+const (
+	INPUT_AXIS_NONE InputAxis = iota
+	INPUT_AXIS_X              // Left stick
+	INPUT_AXIS_Y
+	INPUT_AXIS_Z // Left trigger, or right stick
+	INPUT_AXIS_RX
+	INPUT_AXIS_RY
+	INPUT_AXIS_RZ // Right trigger, or right stick
+	INPUT_AXIS_THROTTLE
+	INPUT_AXIS_RUDDER
+	INPUT_AXIS_WHEEL
+	INPUT_AXIS_GAS
+	INPUT_AXIS_BRAKE
+	INPUT_AXIS_HAT0X // Directional pad
+	INPUT_AXIS_HAT0Y
+	INPUT_AXIS_HAT1X
+	INPUT_AXIS_HAT1Y
+	INPUT_AXIS_MAX = INPUT_AXIS_HAT1Y
 )
 
 // Input key state
@@ -250,11 +328,56 @@ func (e InputEventType) String() string {
 		return "INPUT_EVENT_TOUCHRELEASE"
 	case INPUT_EVENT_TOUCHPOSITION:
 		return "INPUT_EVENT_TOUCHPOSITION"
+	case INPUT_EVENT_DEVICEADDED:
+		return "INPUT_EVENT_DEVICEADDED"
+	case INPUT_EVENT_DEVICEREMOVED:
+		return "INPUT_EVENT_DEVICEREMOVED"
+	case INPUT_EVENT_AXIS:
+		return "INPUT_EVENT_AXIS"
 	default:
 		return "[?? Invalid InputEventType value]"
 	}
 }
 
+func (a InputAxis) String() string {
+	switch a {
+	case INPUT_AXIS_NONE:
+		return "INPUT_AXIS_NONE"
+	case INPUT_AXIS_X:
+		return "INPUT_AXIS_X"
+	case INPUT_AXIS_Y:
+		return "INPUT_AXIS_Y"
+	case INPUT_AXIS_Z:
+		return "INPUT_AXIS_Z"
+	case INPUT_AXIS_RX:
+		return "INPUT_AXIS_RX"
+	case INPUT_AXIS_RY:
+		return "INPUT_AXIS_RY"
+	case INPUT_AXIS_RZ:
+		return "INPUT_AXIS_RZ"
+	case INPUT_AXIS_THROTTLE:
+		return "INPUT_AXIS_THROTTLE"
+	case INPUT_AXIS_RUDDER:
+		return "INPUT_AXIS_RUDDER"
+	case INPUT_AXIS_WHEEL:
+		return "INPUT_AXIS_WHEEL"
+	case INPUT_AXIS_GAS:
+		return "INPUT_AXIS_GAS"
+	case INPUT_AXIS_BRAKE:
+		return "INPUT_AXIS_BRAKE"
+	case INPUT_AXIS_HAT0X:
+		return "INPUT_AXIS_HAT0X"
+	case INPUT_AXIS_HAT0Y:
+		return "INPUT_AXIS_HAT0Y"
+	case INPUT_AXIS_HAT1X:
+		return "INPUT_AXIS_HAT1X"
+	case INPUT_AXIS_HAT1Y:
+		return "INPUT_AXIS_HAT1Y"
+	default:
+		return "[?? Invalid InputAxis value]"
+	}
+}
+
 func (s KeyState) String() string {
 	if s == KEYSTATE_NONE {
 		return "KEYSTATE_NONE"
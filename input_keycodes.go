@@ -102,6 +102,7 @@ const (
 	KEYCODE_KP3              KeyCode = 0x0051
 	KEYCODE_KP0              KeyCode = 0x0052
 	KEYCODE_KPDOT            KeyCode = 0x0053
+	KEYCODE_102ND            KeyCode = 0x0056
 	KEYCODE_F11              KeyCode = 0x0057
 	KEYCODE_F12              KeyCode = 0x0058
 	KEYCODE_KPENTER          KeyCode = 0x0060
@@ -130,6 +131,7 @@ const (
 	KEYCODE_KPCOMMA          KeyCode = 0x0079
 	KEYCODE_LEFTMETA         KeyCode = 0x007D
 	KEYCODE_RIGHTMETA        KeyCode = 0x007E
+	KEYCODE_COMPOSE          KeyCode = 0x007F
 	KEYCODE_SLEEP            KeyCode = 0x008E
 	KEYCODE_WAKEUP           KeyCode = 0x008F
 	KEYCODE_KPLEFTPAREN      KeyCode = 0x00B3
@@ -177,6 +179,41 @@ const (
 	KEYCODE_BTNTOUCH  KeyCode = 0x014A
 )
 
+// Joystick and gamepad buttons
+const (
+	KEYCODE_BTNTRIGGER   KeyCode = 0x0120
+	KEYCODE_BTNTHUMB     KeyCode = 0x0121
+	KEYCODE_BTNTHUMB2    KeyCode = 0x0122
+	KEYCODE_BTNTOP       KeyCode = 0x0123
+	KEYCODE_BTNTOP2      KeyCode = 0x0124
+	KEYCODE_BTNPINKIE    KeyCode = 0x0125
+	KEYCODE_BTNBASE      KeyCode = 0x0126
+	KEYCODE_BTNBASE2     KeyCode = 0x0127
+	KEYCODE_BTNBASE3     KeyCode = 0x0128
+	KEYCODE_BTNBASE4     KeyCode = 0x0129
+	KEYCODE_BTNBASE5     KeyCode = 0x012A
+	KEYCODE_BTNBASE6     KeyCode = 0x012B
+	KEYCODE_BTNSOUTH     KeyCode = 0x0130
+	KEYCODE_BTNEAST      KeyCode = 0x0131
+	KEYCODE_BTNC         KeyCode = 0x0132
+	KEYCODE_BTNNORTH     KeyCode = 0x0133
+	KEYCODE_BTNWEST      KeyCode = 0x0134
+	KEYCODE_BTNZ         KeyCode = 0x0135
+	KEYCODE_BTNTL        KeyCode = 0x0136
+	KEYCODE_BTNTR        KeyCode = 0x0137
+	KEYCODE_BTNTL2       KeyCode = 0x0138
+	KEYCODE_BTNTR2       KeyCode = 0x0139
+	KEYCODE_BTNSELECT    KeyCode = 0x013A
+	KEYCODE_BTNSTART     KeyCode = 0x013B
+	KEYCODE_BTNMODE      KeyCode = 0x013C
+	KEYCODE_BTNTHUMBL    KeyCode = 0x013D
+	KEYCODE_BTNTHUMBR    KeyCode = 0x013E
+	KEYCODE_BTNDPADUP    KeyCode = 0x0220
+	KEYCODE_BTNDPADDOWN  KeyCode = 0x0221
+	KEYCODE_BTNDPADLEFT  KeyCode = 0x0222
+	KEYCODE_BTNDPADRIGHT KeyCode = 0x0223
+)
+
 ////////////////////////////////////////////////////////////////////////////////
 // STRINGIFY
 
@@ -350,6 +387,8 @@ func (k KeyCode) String() string {
 		return "KEYCODE_KP0"
 	case KEYCODE_KPDOT:
 		return "KEYCODE_KPDOT"
+	case KEYCODE_102ND:
+		return "KEYCODE_102ND"
 	case KEYCODE_F11:
 		return "KEYCODE_F11"
 	case KEYCODE_F12:
@@ -406,6 +445,8 @@ func (k KeyCode) String() string {
 		return "KEYCODE_LEFTMETA"
 	case KEYCODE_RIGHTMETA:
 		return "KEYCODE_RIGHTMETA"
+	case KEYCODE_COMPOSE:
+		return "KEYCODE_COMPOSE"
 	case KEYCODE_KPLEFTPAREN:
 		return "KEYCODE_KPLEFTPAREN"
 	case KEYCODE_KPRIGHTPAREN:
@@ -474,6 +515,68 @@ func (k KeyCode) String() string {
 		return "KEYCODE_BTNEXTRA"
 	case KEYCODE_BTNTOUCH:
 		return "KEYCODE_BTNTOUCH"
+	case KEYCODE_BTNTRIGGER:
+		return "KEYCODE_BTNTRIGGER"
+	case KEYCODE_BTNTHUMB:
+		return "KEYCODE_BTNTHUMB"
+	case KEYCODE_BTNTHUMB2:
+		return "KEYCODE_BTNTHUMB2"
+	case KEYCODE_BTNTOP:
+		return "KEYCODE_BTNTOP"
+	case KEYCODE_BTNTOP2:
+		return "KEYCODE_BTNTOP2"
+	case KEYCODE_BTNPINKIE:
+		return "KEYCODE_BTNPINKIE"
+	case KEYCODE_BTNBASE:
+		return "KEYCODE_BTNBASE"
+	case KEYCODE_BTNBASE2:
+		return "KEYCODE_BTNBASE2"
+	case KEYCODE_BTNBASE3:
+		return "KEYCODE_BTNBASE3"
+	case KEYCODE_BTNBASE4:
+		return "KEYCODE_BTNBASE4"
+	case KEYCODE_BTNBASE5:
+		return "KEYCODE_BTNBASE5"
+	case KEYCODE_BTNBASE6:
+		return "KEYCODE_BTNBASE6"
+	case KEYCODE_BTNSOUTH:
+		return "KEYCODE_BTNSOUTH"
+	case KEYCODE_BTNEAST:
+		return "KEYCODE_BTNEAST"
+	case KEYCODE_BTNC:
+		return "KEYCODE_BTNC"
+	case KEYCODE_BTNNORTH:
+		return "KEYCODE_BTNNORTH"
+	case KEYCODE_BTNWEST:
+		return "KEYCODE_BTNWEST"
+	case KEYCODE_BTNZ:
+		return "KEYCODE_BTNZ"
+	case KEYCODE_BTNTL:
+		return "KEYCODE_BTNTL"
+	case KEYCODE_BTNTR:
+		return "KEYCODE_BTNTR"
+	case KEYCODE_BTNTL2:
+		return "KEYCODE_BTNTL2"
+	case KEYCODE_BTNTR2:
+		return "KEYCODE_BTNTR2"
+	case KEYCODE_BTNSELECT:
+		return "KEYCODE_BTNSELECT"
+	case KEYCODE_BTNSTART:
+		return "KEYCODE_BTNSTART"
+	case KEYCODE_BTNMODE:
+		return "KEYCODE_BTNMODE"
+	case KEYCODE_BTNTHUMBL:
+		return "KEYCODE_BTNTHUMBL"
+	case KEYCODE_BTNTHUMBR:
+		return "KEYCODE_BTNTHUMBR"
+	case KEYCODE_BTNDPADUP:
+		return "KEYCODE_BTNDPADUP"
+	case KEYCODE_BTNDPADDOWN:
+		return "KEYCODE_BTNDPADDOWN"
+	case KEYCODE_BTNDPADLEFT:
+		return "KEYCODE_BTNDPADLEFT"
+	case KEYCODE_BTNDPADRIGHT:
+		return "KEYCODE_BTNDPADRIGHT"
 	default:
 		return fmt.Sprintf("KEYCODE_0x%04X", uint16(k))
 	}
@@ -16,11 +16,129 @@ import (
 // TYPES
 
 type Metric struct {
-	Rate  MetricRate
-	Type  MetricType
+	Rate   MetricRate
+	Type   MetricType
+	Name   string
+	Labels []MetricLabel
+	Mean   float64 // Mean value per hour (or whatever rate)
+	Total  uint    // Total over the past hour (or whatever rate)
+
+	// Current value of a gauge
+	Value float64
+
+	// Summary of the counter values per interval, or gauge values, or
+	// histogram observations over the rate window
+	Summary MetricSummary
+
+	// Histogram buckets, and the count and sum of all observations
+	Buckets []MetricBucket
+	Count   uint
+	Sum     float64
+}
+
+// MetricLabel distinguishes metrics with the same name
+type MetricLabel struct {
 	Name  string
-	Mean  float64 // Mean value per hour (or whatever rate)
-	Total uint    // Total over the past hour (or whatever rate)
+	Value string
+}
+
+// MetricSummary is the minimum, maximum and percentiles of
+// values over the rate window
+type MetricSummary struct {
+	Count     uint
+	Min       float64
+	Max       float64
+	Quantiles []MetricQuantile
+}
+
+// MetricQuantile is the value for a quantile between 0.0 and 1.0
+type MetricQuantile struct {
+	Quantile float64
+	Value    float64
+}
+
+// MetricBucket is the number of histogram observations less
+// than or equal to the upper bound
+type MetricBucket struct {
+	UpperBound float64
+	Count      uint
+}
+
+// MetricCPU is the utilisation of a processor, or all processors, as
+// the fraction of time in each state since the previous sample
+type MetricCPU struct {
+	Name   string
+	User   float64
+	Nice   float64
+	System float64
+	Idle   float64
+	IOWait float64
+	IRQ    float64
+	Steal  float64
+	Usage  float64
+}
+
+// MetricMemory is memory and swap usage in bytes
+type MetricMemory struct {
+	Total     uint64
+	Free      uint64
+	Available uint64
+	Buffers   uint64
+	Cached    uint64
+	SwapTotal uint64
+	SwapFree  uint64
+}
+
+// MetricFilesystem is the usage of a mounted filesystem in bytes
+type MetricFilesystem struct {
+	Device    string
+	Path      string
+	Type      string
+	Total     uint64
+	Free      uint64
+	Available uint64
+	Files     uint64
+	FilesFree uint64
+}
+
+// MetricNetwork is the throughput of a network interface, where the
+// rates are in bytes per second since the previous sample
+type MetricNetwork struct {
+	Name      string
+	RxBytes   uint64
+	TxBytes   uint64
+	RxPackets uint64
+	TxPackets uint64
+	RxErrors  uint64
+	TxErrors  uint64
+	RxRate    float64
+	TxRate    float64
+}
+
+// MetricThermal is the temperature of a thermal zone
+type MetricThermal struct {
+	Name    string
+	Type    string
+	Celcius float64
+}
+
+// MetricFrequency is the current, minimum and maximum frequency
+// of a processor in Hertz
+type MetricFrequency struct {
+	Name    string
+	Current uint64
+	Minimum uint64
+	Maximum uint64
+}
+
+// MetricSample is the aggregate of values stored for a metric
+// at a time, over an interval
+type MetricSample struct {
+	Timestamp time.Time
+	Count     uint
+	Mean      float64
+	Min       float64
+	Max       float64
 }
 
 type (
@@ -28,6 +146,25 @@ type (
 	MetricType uint
 )
 
+// MetricGauge is a metric which is set to arbitrary values
+type MetricGauge interface {
+	// Set and add to the value of the gauge
+	Set(float64)
+	Add(float64)
+
+	// Return the metric for the gauge
+	Metric() *Metric
+}
+
+// MetricHistogram is a metric which counts observed values in buckets
+type MetricHistogram interface {
+	// Observe a value
+	Observe(float64)
+
+	// Return the metric for the histogram
+	Metric() *Metric
+}
+
 /////////////////////////////////////////////////////////////////////
 // INTERFACE
 
@@ -44,8 +181,16 @@ type Metrics interface {
 	LoadAverage() (float64, float64, float64)
 
 	// Return counter channel, which when you send a value on
-	// it will increment a counter
-	NewCounter(MetricType, MetricRate, string) (chan<- uint, error)
+	// it will increment a counter. The type should be METRIC_TYPE_NONE
+	// or METRIC_TYPE_COUNTER
+	NewCounter(MetricType, MetricRate, string, ...MetricLabel) (chan<- uint, error)
+
+	// Return a gauge, which is summarized over the rate window
+	NewGauge(MetricRate, string, ...MetricLabel) (MetricGauge, error)
+
+	// Return a histogram with bucket upper bounds in increasing
+	// order, which is summarized over the rate window
+	NewHistogram(MetricRate, string, []float64, ...MetricLabel) (MetricHistogram, error)
 
 	// Return Metric for channel
 	Metric(chan<- uint) *Metric
@@ -55,6 +200,36 @@ type Metrics interface {
 	Metrics(MetricType) []*Metric
 }
 
+// HostMetrics is implemented by metrics drivers which report
+// processor, memory, filesystem, network and thermal statistics
+type HostMetrics interface {
+	// Processor utilisation for all processors, then each processor
+	CPU() ([]MetricCPU, error)
+
+	// Memory and swap usage
+	Memory() (MetricMemory, error)
+
+	// Usage of mounted filesystems
+	Filesystems() ([]MetricFilesystem, error)
+
+	// Throughput of network interfaces
+	Network() ([]MetricNetwork, error)
+
+	// Temperature of thermal zones
+	Thermal() ([]MetricThermal, error)
+
+	// Frequency of processors
+	Frequency() ([]MetricFrequency, error)
+}
+
+// MetricHistory is implemented by metrics drivers which store
+// metric values over time
+type MetricHistory interface {
+	// Return samples for a metric with labels between two times, at an
+	// interval or the finest interval stored when the interval is zero
+	Series(from, to time.Time, interval time.Duration, name string, labels ...MetricLabel) ([]MetricSample, error)
+}
+
 /////////////////////////////////////////////////////////////////////
 // CONSTANTS
 
@@ -67,6 +242,9 @@ const (
 
 const (
 	METRIC_TYPE_NONE MetricType = iota
+	METRIC_TYPE_COUNTER
+	METRIC_TYPE_GAUGE
+	METRIC_TYPE_HISTOGRAM
 )
 
 /////////////////////////////////////////////////////////////////////
@@ -74,6 +252,8 @@ const (
 
 func (v MetricRate) String() string {
 	switch v {
+	case METRIC_RATE_NONE:
+		return "METRIC_RATE_NONE"
 	case METRIC_RATE_SECOND:
 		return "METRIC_RATE_SECOND"
 	case METRIC_RATE_MINUTE:
@@ -84,3 +264,18 @@ func (v MetricRate) String() string {
 		return "[?? Invalid MetricRate value]"
 	}
 }
+
+func (v MetricType) String() string {
+	switch v {
+	case METRIC_TYPE_NONE:
+		return "METRIC_TYPE_NONE"
+	case METRIC_TYPE_COUNTER:
+		return "METRIC_TYPE_COUNTER"
+	case METRIC_TYPE_GAUGE:
+		return "METRIC_TYPE_GAUGE"
+	case METRIC_TYPE_HISTOGRAM:
+		return "METRIC_TYPE_HISTOGRAM"
+	default:
+		return "[?? Invalid MetricType value]"
+	}
+}
@@ -82,6 +82,7 @@ const (
 	MODULE_TYPE_SERVICE  // RPC Service
 	MODULE_TYPE_CLIENT   // RPC Client
 	MODULE_TYPE_KEYMAP   // Key Mapper
+	MODULE_TYPE_WATCHDOG // Hardware watchdog
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -109,6 +110,7 @@ var (
 		"service":  MODULE_TYPE_SERVICE,
 		"client":   MODULE_TYPE_CLIENT,
 		"keymap":   MODULE_TYPE_KEYMAP,
+		"watchdog": MODULE_TYPE_WATCHDOG,
 	}
 )
 
@@ -344,6 +346,8 @@ func (t ModuleType) String() string {
 		return "MODULE_TYPE_CLIENT"
 	case MODULE_TYPE_KEYMAP:
 		return "MODULE_TYPE_KEYMAP"
+	case MODULE_TYPE_WATCHDOG:
+		return "MODULE_TYPE_WATCHDOG"
 	default:
 		return "[Invalid ModuleType value]"
 	}
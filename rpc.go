@@ -103,16 +103,30 @@ type RPCServer interface {
 	ServiceWithName(service, name string, text ...string) *RPCServiceRecord
 }
 
+// RPCHealth is implemented by RPC servers which report whether
+// services are serving requests, so that services can report when they
+// are degraded, for example when hardware is missing
+type RPCHealth interface {
+	// Set whether a service, such as "mutablelogic.Metrics", is serving
+	// requests, or all services when the service is empty
+	SetServing(service string, serving bool)
+}
+
 // RPCClientPool implements a pool of client connections for communicating
 // with an RPC server and aides discovery new service records
 type RPCClientPool interface {
 	Driver
 	Publisher
 
-	// Connect and disconnect
+	// Connect and disconnect. Connections to the same service record
+	// are shared until each is disconnected
 	Connect(service *RPCServiceRecord, flags RPCFlag) (RPCClientConn, error)
 	Disconnect(RPCClientConn) error
 
+	// Connect to one of the discovered service records of a service
+	// type, preferring healthy connections and balancing between them
+	ConnectService(service string, flags RPCFlag) (RPCClientConn, error)
+
 	// Register clients and create new ones given a service name
 	RegisterClient(string, RPCNewClientFunc) error
 	NewClient(string, RPCClientConn) RPCClient
@@ -154,6 +168,8 @@ const (
 	RPC_EVENT_SERVICE_RECORD
 	RPC_EVENT_CLIENT_CONNECTED
 	RPC_EVENT_CLIENT_DISCONNECTED
+	RPC_EVENT_CLIENT_HEALTHY
+	RPC_EVENT_CLIENT_UNHEALTHY
 )
 
 const (
@@ -216,6 +232,10 @@ func (t RPCEventType) String() string {
 		return "RPC_EVENT_CLIENT_CONNECTED"
 	case RPC_EVENT_CLIENT_DISCONNECTED:
 		return "RPC_EVENT_CLIENT_DISCONNECTED"
+	case RPC_EVENT_CLIENT_HEALTHY:
+		return "RPC_EVENT_CLIENT_HEALTHY"
+	case RPC_EVENT_CLIENT_UNHEALTHY:
+		return "RPC_EVENT_CLIENT_UNHEALTHY"
 	default:
 		return "[?? Invalid RPCEventType value]"
 	}
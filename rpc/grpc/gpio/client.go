@@ -0,0 +1,389 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package gpio
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	// Framework
+	gopi "github.com/djthorpe/gopi"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+	evt "github.com/djthorpe/gopi/util/event"
+	empty "github.com/golang/protobuf/ptypes/empty"
+
+	// Protocol buffers
+	pb "github.com/djthorpe/gopi/rpc/protobuf/gpio"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Client implements gopi.GPIO for a remote device. Errors from methods
+// which do not return an error are returned by Err
+type Client struct {
+	pb.GPIOClient
+	conn   gopi.RPCClientConn
+	pubsub *evt.PubSub
+
+	// Pins are returned once, and watched pins are cancelled
+	// to stop watching them
+	lock    sync.Mutex
+	pins    *pb.PinsReply
+	watches map[gopi.GPIOPin]*watch
+	wg      sync.WaitGroup
+	err     error
+}
+
+type watch struct {
+	cancel context.CancelFunc
+}
+
+type gpio_event struct {
+	client *Client
+	pin    gopi.GPIOPin
+	edge   gopi.GPIOEdge
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+func NewClient(conn gopi.RPCClientConn) gopi.RPCClient {
+	return &Client{
+		GPIOClient: pb.NewGPIOClient(conn.(grpc.GRPCClientConn).GRPCConn()),
+		conn:       conn,
+		pubsub:     evt.NewPubSub(0),
+		watches:    make(map[gopi.GPIOPin]*watch),
+	}
+}
+
+// NewContext returns a context with the connection timeout
+func (this *Client) NewContext() (context.Context, context.CancelFunc) {
+	if this.conn.Timeout() == 0 {
+		return context.WithCancel(context.Background())
+	} else {
+		return context.WithTimeout(context.Background(), this.conn.Timeout())
+	}
+}
+
+// Close stops watching pins and closes subscriber channels, but
+// does not disconnect
+func (this *Client) Close() error {
+	this.lock.Lock()
+	for pin, watch := range this.watches {
+		watch.cancel()
+		delete(this.watches, pin)
+	}
+	this.lock.Unlock()
+
+	// Wait for watches to end
+	this.wg.Wait()
+
+	// Close subscriber channels
+	this.pubsub.Close()
+
+	// Success
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PROPERTIES
+
+func (this *Client) Conn() gopi.RPCClientConn {
+	return this.conn
+}
+
+// Err returns and clears the last error from a method which does
+// not return an error
+func (this *Client) Err() error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	err := this.err
+	this.err = nil
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CALLS
+
+func (this *Client) Ping() error {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+
+	if _, err := this.GPIOClient.Ping(ctx, &empty.Empty{}); err != nil {
+		return err
+	} else {
+		return nil
+	}
+}
+
+// NumberOfPhysicalPins returns the number of physical pins, or zero
+func (this *Client) NumberOfPhysicalPins() uint {
+	if pins, err := this.getPins(); err != nil {
+		this.setErr(err)
+		return 0
+	} else {
+		return uint(pins.PhysicalPins)
+	}
+}
+
+// Pins returns the logical pins, or nil
+func (this *Client) Pins() []gopi.GPIOPin {
+	if pins, err := this.getPins(); err != nil {
+		this.setErr(err)
+		return nil
+	} else {
+		logical := make([]gopi.GPIOPin, len(pins.Pins))
+		for i, pin := range pins.Pins {
+			logical[i] = gopi.GPIOPin(pin.Pin)
+		}
+		return logical
+	}
+}
+
+// PhysicalPin returns the logical pin for a physical pin, or
+// GPIO_PIN_NONE
+func (this *Client) PhysicalPin(physical uint) gopi.GPIOPin {
+	if pins, err := this.getPins(); err != nil {
+		this.setErr(err)
+	} else if physical > 0 {
+		for _, pin := range pins.Pins {
+			if uint(pin.Physical) == physical {
+				return gopi.GPIOPin(pin.Pin)
+			}
+		}
+	}
+	return gopi.GPIO_PIN_NONE
+}
+
+// PhysicalPinForPin returns the physical pin for a logical pin, or zero
+func (this *Client) PhysicalPinForPin(logical gopi.GPIOPin) uint {
+	if pins, err := this.getPins(); err != nil {
+		this.setErr(err)
+	} else {
+		for _, pin := range pins.Pins {
+			if gopi.GPIOPin(pin.Pin) == logical {
+				return uint(pin.Physical)
+			}
+		}
+	}
+	return 0
+}
+
+// ReadPin returns the pin state, or GPIO_LOW
+func (this *Client) ReadPin(pin gopi.GPIOPin) gopi.GPIOState {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+
+	if reply, err := this.GPIOClient.ReadPin(ctx, &pb.PinRequest{Pin: uint32(pin)}); err != nil {
+		this.setErr(err)
+		return gopi.GPIO_LOW
+	} else {
+		return gopi.GPIOState(reply.State)
+	}
+}
+
+func (this *Client) WritePin(pin gopi.GPIOPin, state gopi.GPIOState) {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+
+	if _, err := this.GPIOClient.WritePin(ctx, &pb.WritePinRequest{Pin: uint32(pin), State: pb.GPIOState(state)}); err != nil {
+		this.setErr(err)
+	}
+}
+
+// GetPinMode returns the pin mode, or GPIO_NONE
+func (this *Client) GetPinMode(pin gopi.GPIOPin) gopi.GPIOMode {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+
+	if reply, err := this.GPIOClient.GetPinMode(ctx, &pb.PinRequest{Pin: uint32(pin)}); err != nil {
+		this.setErr(err)
+		return gopi.GPIO_NONE
+	} else {
+		return gopi.GPIOMode(reply.Mode)
+	}
+}
+
+func (this *Client) SetPinMode(pin gopi.GPIOPin, mode gopi.GPIOMode) {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+
+	if _, err := this.GPIOClient.SetPinMode(ctx, &pb.SetPinModeRequest{Pin: uint32(pin), Mode: pb.GPIOMode(mode)}); err != nil {
+		this.setErr(err)
+	}
+}
+
+// SetPullMode returns ErrNotImplemented when the remote device
+// does not support it
+func (this *Client) SetPullMode(pin gopi.GPIOPin, pull gopi.GPIOPull) error {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+
+	if _, err := this.GPIOClient.SetPullMode(ctx, &pb.SetPullModeRequest{Pin: uint32(pin), Pull: pb.GPIOPull(pull)}); err != nil {
+		return grpc.ErrorFromStatus(err)
+	} else {
+		return nil
+	}
+}
+
+// Watch starts watching for rising and/or falling edges, which are
+// emitted as events, or stops watching when GPIO_EDGE_NONE is passed
+func (this *Client) Watch(pin gopi.GPIOPin, edge gopi.GPIOEdge) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	// Stop watching the pin
+	if watch, exists := this.watches[pin]; exists {
+		watch.cancel()
+		delete(this.watches, pin)
+	}
+	if edge == gopi.GPIO_EDGE_NONE {
+		return nil
+	}
+
+	// Watch the pin, and wait for the first reply which indicates the
+	// pin is being watched
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := this.GPIOClient.Watch(ctx, &pb.WatchRequest{Pin: uint32(pin), Edge: pb.GPIOEdge(edge)})
+	if err != nil {
+		cancel()
+		return grpc.ErrorFromStatus(err)
+	} else if _, err := stream.Recv(); err != nil {
+		cancel()
+		return grpc.ErrorFromStatus(err)
+	}
+
+	// Emit edges until cancelled or the stream ends
+	watch := &watch{cancel}
+	this.watches[pin] = watch
+	this.wg.Add(1)
+	go func() {
+		defer this.wg.Done()
+		defer this.unwatch(pin, watch)
+		for {
+			if reply, err := stream.Recv(); err == io.EOF || grpc.IsErrCanceled(err) {
+				return
+			} else if err != nil {
+				this.setErr(err)
+				return
+			} else {
+				this.Emit(gopi.GPIOPin(reply.Pin), gopi.GPIOEdge(reply.Edge))
+			}
+		}
+	}()
+
+	// Success
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBSUB
+
+// Subscribe to edges on watched pins
+func (this *Client) Subscribe() <-chan gopi.Event {
+	return this.pubsub.Subscribe()
+}
+
+// Unsubscribe from edges
+func (this *Client) Unsubscribe(subscriber <-chan gopi.Event) {
+	this.pubsub.Unsubscribe(subscriber)
+}
+
+// Emit an event
+func (this *Client) Emit(pin gopi.GPIOPin, edge gopi.GPIOEdge) {
+	this.pubsub.Emit(&gpio_event{client: this, pin: pin, edge: edge})
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// INTERFACE - EVENT
+
+func (this *gpio_event) Name() string {
+	return "GPIOEvent"
+}
+
+func (this *gpio_event) Source() gopi.Driver {
+	return this.client
+}
+
+func (this *gpio_event) Pin() gopi.GPIOPin {
+	return this.pin
+}
+
+func (this *gpio_event) Edge() gopi.GPIOEdge {
+	return this.edge
+}
+
+func (this *gpio_event) String() string {
+	return fmt.Sprintf("<grpc.gpio.client.Event>{ pin=%v edge=%v }", this.pin, this.edge)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// getPins returns the pins, which are only returned from the
+// remote device once
+func (this *Client) getPins() (*pb.PinsReply, error) {
+	this.lock.Lock()
+	pins := this.pins
+	this.lock.Unlock()
+	if pins != nil {
+		return pins, nil
+	}
+
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+	if reply, err := this.GPIOClient.Pins(ctx, &empty.Empty{}); err != nil {
+		return nil, err
+	} else {
+		this.lock.Lock()
+		this.pins = reply
+		this.lock.Unlock()
+		return reply, nil
+	}
+}
+
+// unwatch removes a watch when it ends
+func (this *Client) unwatch(pin gopi.GPIOPin, watch *watch) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.watches[pin] == watch {
+		watch.cancel()
+		delete(this.watches, pin)
+	}
+}
+
+func (this *Client) setErr(err error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.err = grpc.ErrorFromStatus(err)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Client) String() string {
+	return fmt.Sprintf("<grpc.gpio.client>{ conn=%v }", this.conn)
+}
@@ -0,0 +1,222 @@
+package gpio_test
+
+import (
+	"testing"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	mock "github.com/djthorpe/gopi/sys/hw/mock"
+	logger "github.com/djthorpe/gopi/sys/logger"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+
+	// RPC Services
+	gpio "github.com/djthorpe/gopi/rpc/grpc/gpio"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// CLIENT
+
+func TestClient_000(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	device := openGPIO(t, log)
+	defer device.Close()
+	client, stop := openClient(t, log, device)
+	defer stop()
+
+	// The service is registered with the server, and responds to pings
+	if services, err := client.Conn().Services(); err != nil {
+		t.Error(err)
+	} else if hasService(services, "mutablelogic.GPIO") == false {
+		t.Error("Expected mutablelogic.GPIO service, got", services)
+	}
+	if err := client.Ping(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClient_001(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	device := openGPIO(t, log)
+	defer device.Close()
+	client, stop := openClient(t, log, device)
+	defer stop()
+
+	// Pins are the same as the device
+	if client.NumberOfPhysicalPins() != device.NumberOfPhysicalPins() {
+		t.Error("Unexpected number of physical pins", client.NumberOfPhysicalPins())
+	}
+	pins := client.Pins()
+	if len(pins) != len(device.Pins()) {
+		t.Fatal("Unexpected pins", pins)
+	}
+	for _, pin := range pins {
+		if physical := client.PhysicalPinForPin(pin); physical != device.PhysicalPinForPin(pin) {
+			t.Error("Unexpected physical pin for", pin, physical)
+		} else if logical := client.PhysicalPin(physical); logical != pin {
+			t.Error("Unexpected logical pin for", physical, logical)
+		}
+	}
+	if client.PhysicalPin(1) != gopi.GPIO_PIN_NONE {
+		t.Error("Expected GPIO_PIN_NONE")
+	}
+
+	// Write and read pins, and set modes
+	pin := device.PhysicalPin(11)
+	client.SetPinMode(pin, gopi.GPIO_OUTPUT)
+	if mode := device.GetPinMode(pin); mode != gopi.GPIO_OUTPUT {
+		t.Error("Unexpected mode", mode)
+	} else if mode := client.GetPinMode(pin); mode != gopi.GPIO_OUTPUT {
+		t.Error("Unexpected mode", mode)
+	}
+	client.WritePin(pin, gopi.GPIO_HIGH)
+	if state := device.ReadPin(pin); state != gopi.GPIO_HIGH {
+		t.Error("Unexpected state", state)
+	}
+	device.WritePin(pin, gopi.GPIO_LOW)
+	if state := client.ReadPin(pin); state != gopi.GPIO_LOW {
+		t.Error("Unexpected state", state)
+	}
+	if err := client.SetPullMode(pin, gopi.GPIO_PULL_UP); err != nil {
+		t.Error(err)
+	}
+	if err := client.Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClient_002(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	device := openGPIO(t, log)
+	defer device.Close()
+	client, stop := openClient(t, log, device)
+	defer stop()
+
+	// Invalid parameters are returned as errors
+	client.WritePin(gopi.GPIO_PIN_NONE, gopi.GPIO_HIGH)
+	if err := client.Err(); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	} else if err := client.Err(); err != nil {
+		t.Error("Expected error to be cleared, got", err)
+	}
+	if err := client.SetPullMode(gopi.GPIOPin(1), gopi.GPIO_PULL_UP); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	if err := client.Watch(gopi.GPIOPin(1), gopi.GPIO_EDGE_RISING); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+}
+
+func TestClient_003(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	device := openGPIO(t, log)
+	defer device.Close()
+	client, stop := openClient(t, log, device)
+	defer stop()
+
+	// Watch for rising edges on one pin and both edges on another
+	pin1, pin2 := device.PhysicalPin(11), device.PhysicalPin(12)
+	events := client.Subscribe()
+	if err := client.Watch(pin1, gopi.GPIO_EDGE_RISING); err != nil {
+		t.Fatal(err)
+	} else if err := client.Watch(pin2, gopi.GPIO_EDGE_BOTH); err != nil {
+		t.Fatal(err)
+	}
+	device.WritePin(pin1, gopi.GPIO_HIGH)
+	device.WritePin(pin1, gopi.GPIO_LOW)
+	device.WritePin(pin2, gopi.GPIO_HIGH)
+	device.WritePin(pin2, gopi.GPIO_LOW)
+	for _, expected := range []struct {
+		pin  gopi.GPIOPin
+		edge gopi.GPIOEdge
+	}{
+		{pin1, gopi.GPIO_EDGE_RISING},
+		{pin2, gopi.GPIO_EDGE_RISING},
+		{pin2, gopi.GPIO_EDGE_FALLING},
+	} {
+		if event := waitForEvent(t, events); event.Pin() != expected.pin || event.Edge() != expected.edge {
+			t.Error("Unexpected event", event)
+		} else if event.Source() != client {
+			t.Error("Unexpected source", event.Source())
+		}
+	}
+
+	// Stop watching the pin, which ends the stream on the server
+	if err := client.Watch(pin2, gopi.GPIO_EDGE_NONE); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	device.WritePin(pin2, gopi.GPIO_HIGH)
+	device.WritePin(pin1, gopi.GPIO_HIGH)
+	if event := waitForEvent(t, events); event.Pin() != pin1 {
+		t.Error("Unexpected event", event)
+	}
+	client.Unsubscribe(events)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN
+
+func openLogger(t *testing.T) gopi.Logger {
+	if log, err := gopi.Open(logger.Config{}, nil); err != nil {
+		t.Fatal("Unable to create logger driver")
+		return nil
+	} else {
+		return log.(gopi.Logger)
+	}
+}
+
+func openGPIO(t *testing.T, log gopi.Logger) gopi.GPIO {
+	if driver, err := gopi.Open(mock.GPIO{}, log); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return driver.(gopi.GPIO)
+	}
+}
+
+// openClient serves the device on an in-process server, and returns
+// a client and a function which closes the client and server
+func openClient(t *testing.T, log gopi.Logger, device gopi.GPIO) (*gpio.Client, func()) {
+	server, err := grpc.OpenMemoryServer(grpc.Server{}, log, func(server gopi.RPCServer) gopi.Config {
+		return gpio.Service{Server: server, GPIO: device}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := server.Conn(grpc.ClientConn{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := gpio.NewClient(conn).(*gpio.Client)
+
+	return client, func() {
+		client.Close()
+		conn.Close()
+		server.Close()
+	}
+}
+
+// hasService returns true if a service name is in a list of services
+func hasService(services []string, name string) bool {
+	for _, service := range services {
+		if service == name {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForEvent(t *testing.T, events <-chan gopi.Event) gopi.GPIOEvent {
+	select {
+	case event := <-events:
+		return event.(gopi.GPIOEvent)
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for event")
+		return nil
+	}
+}
@@ -0,0 +1,58 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package gpio
+
+import (
+	// Frameworks
+	"github.com/djthorpe/gopi"
+	"github.com/djthorpe/gopi/sys/rpc/grpc"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INIT
+
+func init() {
+	// Register service/gpio:grpc
+	gopi.RegisterModule(gopi.Module{
+		Name:     "rpc/service/gpio:grpc",
+		Type:     gopi.MODULE_TYPE_SERVICE,
+		Requires: []string{"rpc/server", "gpio"},
+		Config: func(config *gopi.AppConfig) {
+			config.AppFlags.FlagString("gpio.allow", "", "Comma-separated list of method=identity pairs")
+		},
+		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
+			allow, _ := app.AppFlags.GetString("gpio.allow")
+			if authorization, err := grpc.ParseMethodAuthorization("mutablelogic.GPIO", allow); err != nil {
+				return nil, err
+			} else {
+				return gopi.Open(Service{
+					Server:        app.ModuleInstance("rpc/server").(gopi.RPCServer),
+					GPIO:          app.GPIO,
+					Authorization: authorization,
+				}, app.Logger)
+			}
+		},
+	})
+
+	// Register the client
+	gopi.RegisterModule(gopi.Module{
+		Name:     "rpc/client/gpio:grpc",
+		Type:     gopi.MODULE_TYPE_CLIENT,
+		Requires: []string{"rpc/clientpool"},
+		Run: func(app *gopi.AppInstance, _ gopi.Driver) error {
+			clientpool := app.ModuleInstance("rpc/clientpool").(gopi.RPCClientPool)
+			if clientpool == nil {
+				return gopi.ErrAppError
+			} else {
+				clientpool.RegisterClient("mutablelogic.GPIO", NewClient)
+				return nil
+			}
+		},
+	})
+}
@@ -0,0 +1,329 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package gpio
+
+import (
+	"fmt"
+	"sync"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+	"github.com/golang/protobuf/ptypes"
+	empty "github.com/golang/protobuf/ptypes/empty"
+	context "golang.org/x/net/context"
+	gogrpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+
+	// Protocol buffers
+	pb "github.com/djthorpe/gopi/rpc/protobuf/gpio"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type Service struct {
+	Server gopi.RPCServer
+	GPIO   gopi.GPIO
+
+	// Identities which can call methods
+	Authorization grpc.Authorization
+}
+
+type service struct {
+	log    gopi.Logger
+	gpio   gopi.GPIO
+	events <-chan gopi.Event
+
+	// Watchers for each pin, and closed to end streaming requests
+	lock     sync.Mutex
+	watchers map[gopi.GPIOPin][]*watcher
+	done     chan struct{}
+}
+
+// watcher receives edges on a pin for a Watch request
+type watcher struct {
+	pin    gopi.GPIOPin
+	edge   gopi.GPIOEdge
+	events chan gopi.GPIOEvent
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Number of edges queued for each Watch request, after which
+	// edges are dropped
+	WATCH_QUEUE_SIZE = 100
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+// Open the server
+func (config Service) Open(log gopi.Logger) (gopi.Driver, error) {
+	log.Debug("<grpc.gpio.service>Open{ server=%v gpio=%v }", config.Server, config.GPIO)
+
+	if config.GPIO == nil {
+		return nil, gopi.ErrBadParameter
+	}
+
+	this := new(service)
+	this.log = log
+	this.gpio = config.GPIO
+	this.watchers = make(map[gopi.GPIOPin][]*watcher)
+	this.done = make(chan struct{})
+
+	// Restrict methods to some identities
+	if len(config.Authorization) > 0 {
+		if authorizer, ok := config.Server.(grpc.GRPCAuthorizer); ok == false {
+			return nil, gopi.ErrNotImplemented
+		} else {
+			authorizer.Authorize(config.Authorization)
+		}
+	}
+
+	// Receive edges from the driver for as long as the service is
+	// open, and pass them to watchers
+	if this.events = this.gpio.Subscribe(); this.events != nil {
+		go this.receiveEvents(this.events)
+	}
+
+	// Register service with GRPC server
+	pb.RegisterGPIOServer(config.Server.(grpc.GRPCServer).GRPCServer(), this)
+
+	// Success
+	return this, nil
+}
+
+func (this *service) Close() error {
+	this.log.Debug("<grpc.gpio.service>Close{}")
+
+	// Stop receiving edges
+	if this.events != nil {
+		this.gpio.Unsubscribe(this.events)
+		this.events = nil
+	}
+
+	// Success
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// RPCService implementation
+
+func (this *service) CancelRequests() error {
+	this.log.Debug2("<grpc.gpio.service>CancelRequests{}")
+
+	// End any Watch requests
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	select {
+	case <-this.done:
+		// Already cancelled
+	default:
+		close(this.done)
+	}
+	return nil
+}
+
+func (this *service) Ping(ctx context.Context, request *empty.Empty) (*empty.Empty, error) {
+	// Simple ping method to show server is "up"
+	return &empty.Empty{}, nil
+}
+
+func (this *service) Pins(ctx context.Context, request *empty.Empty) (*pb.PinsReply, error) {
+	pins := this.gpio.Pins()
+	reply := &pb.PinsReply{
+		PhysicalPins: uint32(this.gpio.NumberOfPhysicalPins()),
+		Pins:         make([]*pb.PinsReply_Pin, len(pins)),
+	}
+	for i, pin := range pins {
+		reply.Pins[i] = &pb.PinsReply_Pin{
+			Pin:      uint32(pin),
+			Physical: uint32(this.gpio.PhysicalPinForPin(pin)),
+		}
+	}
+	return reply, nil
+}
+
+func (this *service) ReadPin(ctx context.Context, request *pb.PinRequest) (*pb.ReadPinReply, error) {
+	if pin, err := toPin(request.Pin); err != nil {
+		return nil, err
+	} else {
+		return &pb.ReadPinReply{State: pb.GPIOState(this.gpio.ReadPin(pin))}, nil
+	}
+}
+
+func (this *service) WritePin(ctx context.Context, request *pb.WritePinRequest) (*empty.Empty, error) {
+	if pin, err := toPin(request.Pin); err != nil {
+		return nil, err
+	} else if request.State > pb.GPIOState_GPIO_HIGH {
+		return nil, gogrpc.Errorf(codes.InvalidArgument, "Invalid state: %v", request.State)
+	} else {
+		this.gpio.WritePin(pin, gopi.GPIOState(request.State))
+		return &empty.Empty{}, nil
+	}
+}
+
+func (this *service) GetPinMode(ctx context.Context, request *pb.PinRequest) (*pb.GetPinModeReply, error) {
+	if pin, err := toPin(request.Pin); err != nil {
+		return nil, err
+	} else {
+		return &pb.GetPinModeReply{Mode: pb.GPIOMode(this.gpio.GetPinMode(pin))}, nil
+	}
+}
+
+func (this *service) SetPinMode(ctx context.Context, request *pb.SetPinModeRequest) (*empty.Empty, error) {
+	if pin, err := toPin(request.Pin); err != nil {
+		return nil, err
+	} else if request.Mode >= pb.GPIOMode_GPIO_NONE {
+		return nil, gogrpc.Errorf(codes.InvalidArgument, "Invalid mode: %v", request.Mode)
+	} else {
+		this.gpio.SetPinMode(pin, gopi.GPIOMode(request.Mode))
+		return &empty.Empty{}, nil
+	}
+}
+
+func (this *service) SetPullMode(ctx context.Context, request *pb.SetPullModeRequest) (*empty.Empty, error) {
+	if pin, err := toPin(request.Pin); err != nil {
+		return nil, err
+	} else if request.Pull > pb.GPIOPull_GPIO_PULL_UP {
+		return nil, gogrpc.Errorf(codes.InvalidArgument, "Invalid pull: %v", request.Pull)
+	} else if err := this.gpio.SetPullMode(pin, gopi.GPIOPull(request.Pull)); err != nil {
+		return nil, grpc.ErrorToStatus(err)
+	} else {
+		return &empty.Empty{}, nil
+	}
+}
+
+func (this *service) Watch(request *pb.WatchRequest, stream pb.GPIO_WatchServer) error {
+	this.log.Debug2("<grpc.gpio.service>Watch{ request=%v }", request)
+
+	pin, err := toPin(request.Pin)
+	if err != nil {
+		return err
+	} else if request.Edge == pb.GPIOEdge_GPIO_EDGE_NONE || request.Edge > pb.GPIOEdge_GPIO_EDGE_BOTH {
+		return gogrpc.Errorf(codes.InvalidArgument, "Invalid edge: %v", request.Edge)
+	}
+
+	// Watch the pin, and stop watching when the request ends
+	watcher, err := this.watch(pin, gopi.GPIOEdge(request.Edge))
+	if err != nil {
+		return grpc.ErrorToStatus(err)
+	}
+	defer this.unwatch(watcher)
+
+	// Indicate the pin is being watched, then send edges until the
+	// client cancels or the service ends streaming requests
+	if err := stream.Send(&pb.WatchReply{Ts: ptypes.TimestampNow(), Pin: request.Pin}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case event := <-watcher.events:
+			if err := stream.Send(&pb.WatchReply{
+				Ts:   ptypes.TimestampNow(),
+				Pin:  uint32(event.Pin()),
+				Edge: pb.GPIOEdge(event.Edge()),
+			}); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		case <-this.done:
+			return nil
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// watch adds a watcher for a pin, and watches the pin for the
+// edges of all the watchers
+func (this *service) watch(pin gopi.GPIOPin, edge gopi.GPIOEdge) (*watcher, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	watcher := &watcher{pin, edge, make(chan gopi.GPIOEvent, WATCH_QUEUE_SIZE)}
+	if err := this.gpio.Watch(pin, this.edges(pin)|edge); err != nil {
+		return nil, err
+	} else {
+		this.watchers[pin] = append(this.watchers[pin], watcher)
+		return watcher, nil
+	}
+}
+
+// unwatch removes a watcher, and stops watching the pin when there
+// are no more watchers
+func (this *service) unwatch(watcher *watcher) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	watchers := this.watchers[watcher.pin]
+	for i := range watchers {
+		if watchers[i] == watcher {
+			this.watchers[watcher.pin] = append(watchers[:i], watchers[i+1:]...)
+			break
+		}
+	}
+	if len(this.watchers[watcher.pin]) == 0 {
+		delete(this.watchers, watcher.pin)
+	}
+	if err := this.gpio.Watch(watcher.pin, this.edges(watcher.pin)); err != nil {
+		this.log.Warn("grpc.gpio.service: Watch: %v: %v", watcher.pin, err)
+	}
+}
+
+// edges returns the edges watched on a pin
+func (this *service) edges(pin gopi.GPIOPin) gopi.GPIOEdge {
+	edge := gopi.GPIO_EDGE_NONE
+	for _, watcher := range this.watchers[pin] {
+		edge |= watcher.edge
+	}
+	return edge
+}
+
+// receiveEvents passes edges to the watchers of the pin until the
+// channel is closed. Edges of GPIO_EDGE_NONE are passed to all
+// watchers, and edges are dropped for watchers which are not
+// receiving them
+func (this *service) receiveEvents(events <-chan gopi.Event) {
+	for event := range events {
+		if event, ok := event.(gopi.GPIOEvent); ok {
+			this.lock.Lock()
+			for _, watcher := range this.watchers[event.Pin()] {
+				if event.Edge() == gopi.GPIO_EDGE_NONE || event.Edge()&watcher.edge != 0 {
+					select {
+					case watcher.events <- event:
+					default:
+						this.log.Warn("grpc.gpio.service: Dropped edge on %v", event.Pin())
+					}
+				}
+			}
+			this.lock.Unlock()
+		}
+	}
+}
+
+func toPin(pin uint32) (gopi.GPIOPin, error) {
+	if pin >= uint32(gopi.GPIO_PIN_NONE) {
+		return gopi.GPIO_PIN_NONE, gogrpc.Errorf(codes.InvalidArgument, "Invalid pin: %v", pin)
+	} else {
+		return gopi.GPIOPin(pin), nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Stringify
+
+func (this *service) String() string {
+	return fmt.Sprintf("grpc.gpio.service{ gpio=%v }", this.gpio)
+}
@@ -11,6 +11,7 @@ package helloworld
 import (
 	// Frameworks
 	"github.com/djthorpe/gopi"
+	"github.com/djthorpe/gopi/sys/rpc/grpc"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -22,10 +23,19 @@ func init() {
 		Name:     "rpc/service/helloworld:grpc",
 		Type:     gopi.MODULE_TYPE_SERVICE,
 		Requires: []string{"rpc/server"},
+		Config: func(config *gopi.AppConfig) {
+			config.AppFlags.FlagString("helloworld.allow", "", "Comma-separated list of method=identity pairs")
+		},
 		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
-			return gopi.Open(Service{
-				Server: app.ModuleInstance("rpc/server").(gopi.RPCServer),
-			}, app.Logger)
+			allow, _ := app.AppFlags.GetString("helloworld.allow")
+			if authorization, err := grpc.ParseMethodAuthorization("mutablelogic.Greeter", allow); err != nil {
+				return nil, err
+			} else {
+				return gopi.Open(Service{
+					Server:        app.ModuleInstance("rpc/server").(gopi.RPCServer),
+					Authorization: authorization,
+				}, app.Logger)
+			}
 		},
 	})
 
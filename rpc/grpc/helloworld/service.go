@@ -26,6 +26,9 @@ import (
 
 type Service struct {
 	Server gopi.RPCServer
+
+	// Identities which can call methods
+	Authorization grpc.Authorization
 }
 
 type service struct {
@@ -42,6 +45,15 @@ func (config Service) Open(log gopi.Logger) (gopi.Driver, error) {
 	this := new(service)
 	this.log = log
 
+	// Restrict methods to some identities
+	if len(config.Authorization) > 0 {
+		if authorizer, ok := config.Server.(grpc.GRPCAuthorizer); ok == false {
+			return nil, gopi.ErrNotImplemented
+		} else {
+			authorizer.Authorize(config.Authorization)
+		}
+	}
+
 	// Register service with GRPC server
 	pb.RegisterGreeterServer(config.Server.(grpc.GRPCServer).GRPCServer(), this)
 
@@ -0,0 +1,228 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package i2c
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	// Framework
+	gopi "github.com/djthorpe/gopi"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+	empty "github.com/golang/protobuf/ptypes/empty"
+
+	// Protocol buffers
+	pb "github.com/djthorpe/gopi/rpc/protobuf/i2c"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Client implements gopi.I2C for a remote device. The slave address
+// is kept by the client and sent with each request
+type Client struct {
+	pb.I2CClient
+	conn gopi.RPCClientConn
+
+	lock  sync.Mutex
+	slave uint8
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	I2C_SLAVE_NONE uint8 = 0xFF
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+func NewClient(conn gopi.RPCClientConn) gopi.RPCClient {
+	return &Client{
+		I2CClient: pb.NewI2CClient(conn.(grpc.GRPCClientConn).GRPCConn()),
+		conn:      conn,
+		slave:     I2C_SLAVE_NONE,
+	}
+}
+
+// NewContext returns a context with the connection timeout
+func (this *Client) NewContext() (context.Context, context.CancelFunc) {
+	if this.conn.Timeout() == 0 {
+		return context.WithCancel(context.Background())
+	} else {
+		return context.WithTimeout(context.Background(), this.conn.Timeout())
+	}
+}
+
+// Close does not disconnect
+func (this *Client) Close() error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PROPERTIES
+
+func (this *Client) Conn() gopi.RPCClientConn {
+	return this.conn
+}
+
+// SetSlave sets the slave address for subsequent reads and writes
+func (this *Client) SetSlave(slave uint8) error {
+	if slave > I2C_SLAVE_MAX {
+		return gopi.ErrBadParameter
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.slave = slave
+	return nil
+}
+
+// GetSlave returns the slave address, or I2C_SLAVE_NONE
+func (this *Client) GetSlave() uint8 {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.slave
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CALLS
+
+func (this *Client) Ping() error {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+
+	if _, err := this.I2CClient.Ping(ctx, &empty.Empty{}); err != nil {
+		return err
+	} else {
+		return nil
+	}
+}
+
+// DetectSlave returns true if a slave was detected at an address
+func (this *Client) DetectSlave(slave uint8) (bool, error) {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+
+	if reply, err := this.I2CClient.DetectSlave(ctx, &pb.I2CSlaveRequest{Slave: uint32(slave)}); err != nil {
+		return false, grpc.ErrorFromStatus(err)
+	} else {
+		return reply.Detected, nil
+	}
+}
+
+func (this *Client) ReadUint8(reg uint8) (uint8, error) {
+	reply, err := this.read(reg, pb.I2CWidth_I2C_UINT8, 0)
+	return uint8(reply.GetValue()), err
+}
+
+func (this *Client) ReadInt8(reg uint8) (int8, error) {
+	reply, err := this.read(reg, pb.I2CWidth_I2C_INT8, 0)
+	return int8(reply.GetValue()), err
+}
+
+func (this *Client) ReadUint16(reg uint8) (uint16, error) {
+	reply, err := this.read(reg, pb.I2CWidth_I2C_UINT16, 0)
+	return uint16(reply.GetValue()), err
+}
+
+func (this *Client) ReadInt16(reg uint8) (int16, error) {
+	reply, err := this.read(reg, pb.I2CWidth_I2C_INT16, 0)
+	return int16(reply.GetValue()), err
+}
+
+func (this *Client) ReadBlock(reg, length uint8) ([]byte, error) {
+	reply, err := this.read(reg, pb.I2CWidth_I2C_BLOCK, length)
+	return reply.GetData(), err
+}
+
+func (this *Client) WriteUint8(reg, value uint8) error {
+	return this.write(reg, pb.I2CWidth_I2C_UINT8, int32(value))
+}
+
+func (this *Client) WriteInt8(reg uint8, value int8) error {
+	return this.write(reg, pb.I2CWidth_I2C_INT8, int32(value))
+}
+
+func (this *Client) WriteUint16(reg uint8, value uint16) error {
+	return this.write(reg, pb.I2CWidth_I2C_UINT16, int32(value))
+}
+
+func (this *Client) WriteInt16(reg uint8, value int16) error {
+	return this.write(reg, pb.I2CWidth_I2C_INT16, int32(value))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// read returns a register value or block of bytes, or ErrBadParameter
+// if no slave has been set
+func (this *Client) read(reg uint8, width pb.I2CWidth, length uint8) (*pb.I2CReadReply, error) {
+	slave := this.GetSlave()
+	if slave == I2C_SLAVE_NONE {
+		return nil, gopi.ErrBadParameter
+	}
+
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+
+	if reply, err := this.I2CClient.ReadRegister(ctx, &pb.I2CReadRequest{
+		Slave:  uint32(slave),
+		Reg:    uint32(reg),
+		Width:  width,
+		Length: uint32(length),
+	}); err != nil {
+		return nil, grpc.ErrorFromStatus(err)
+	} else {
+		return reply, nil
+	}
+}
+
+// write sets a register value, or returns ErrBadParameter if no slave
+// has been set
+func (this *Client) write(reg uint8, width pb.I2CWidth, value int32) error {
+	slave := this.GetSlave()
+	if slave == I2C_SLAVE_NONE {
+		return gopi.ErrBadParameter
+	}
+
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+
+	if _, err := this.I2CClient.WriteRegister(ctx, &pb.I2CWriteRequest{
+		Slave: uint32(slave),
+		Reg:   uint32(reg),
+		Width: width,
+		Value: value,
+	}); err != nil {
+		return grpc.ErrorFromStatus(err)
+	} else {
+		return nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Client) String() string {
+	if slave := this.GetSlave(); slave == I2C_SLAVE_NONE {
+		return fmt.Sprintf("<grpc.i2c.client>{ conn=%v }", this.conn)
+	} else {
+		return fmt.Sprintf("<grpc.i2c.client>{ conn=%v slave=0x%02X }", this.conn, slave)
+	}
+}
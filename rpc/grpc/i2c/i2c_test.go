@@ -0,0 +1,272 @@
+package i2c_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	logger "github.com/djthorpe/gopi/sys/logger"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+
+	// RPC Services
+	i2c "github.com/djthorpe/gopi/rpc/grpc/i2c"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// CLIENT
+
+func TestClient_000(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	device := &device{slave: i2c.I2C_SLAVE_NONE, registers: make(map[uint8][]byte)}
+	client, stop := openClient(t, log, device)
+	defer stop()
+
+	// The service is registered with the server, and responds to pings
+	if services, err := client.Conn().Services(); err != nil {
+		t.Error(err)
+	} else if hasService(services, "mutablelogic.I2C") == false {
+		t.Error("Expected mutablelogic.I2C service, got", services)
+	}
+	if err := client.Ping(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClient_001(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	device := &device{slave: i2c.I2C_SLAVE_NONE, registers: make(map[uint8][]byte)}
+	client, stop := openClient(t, log, device)
+	defer stop()
+
+	// Detect slaves
+	if detected, err := client.DetectSlave(SLAVE); err != nil {
+		t.Fatal(err)
+	} else if detected == false {
+		t.Error("Expected slave to be detected")
+	}
+	if detected, err := client.DetectSlave(SLAVE + 1); err != nil {
+		t.Fatal(err)
+	} else if detected == true {
+		t.Error("Expected slave not to be detected")
+	}
+
+	// Set slave
+	if client.GetSlave() != i2c.I2C_SLAVE_NONE {
+		t.Error("Unexpected slave", client.GetSlave())
+	}
+	if err := client.SetSlave(SLAVE); err != nil {
+		t.Fatal(err)
+	} else if client.GetSlave() != SLAVE {
+		t.Error("Unexpected slave", client.GetSlave())
+	}
+
+	// Write and read registers
+	if err := client.WriteUint8(0x00, 0xFE); err != nil {
+		t.Error(err)
+	} else if value, err := client.ReadUint8(0x00); err != nil {
+		t.Error(err)
+	} else if value != 0xFE {
+		t.Errorf("Unexpected value 0x%02X", value)
+	}
+	if err := client.WriteInt8(0x01, -2); err != nil {
+		t.Error(err)
+	} else if value, err := client.ReadInt8(0x01); err != nil {
+		t.Error(err)
+	} else if value != -2 {
+		t.Error("Unexpected value", value)
+	}
+	if err := client.WriteUint16(0x02, 0xFEDC); err != nil {
+		t.Error(err)
+	} else if value, err := client.ReadUint16(0x02); err != nil {
+		t.Error(err)
+	} else if value != 0xFEDC {
+		t.Errorf("Unexpected value 0x%04X", value)
+	}
+	if err := client.WriteInt16(0x04, -1000); err != nil {
+		t.Error(err)
+	} else if value, err := client.ReadInt16(0x04); err != nil {
+		t.Error(err)
+	} else if value != -1000 {
+		t.Error("Unexpected value", value)
+	}
+	if data, err := client.ReadBlock(0x00, 4); err != nil {
+		t.Error(err)
+	} else if bytes.Equal(data, []byte{0xFE, 0xFE, 0xFE, 0xDC}) == false {
+		t.Errorf("Unexpected data %v", data)
+	}
+	if device.slave != SLAVE {
+		t.Error("Unexpected device slave", device.slave)
+	}
+}
+
+func TestClient_002(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	device := &device{slave: i2c.I2C_SLAVE_NONE, registers: make(map[uint8][]byte)}
+	client, stop := openClient(t, log, device)
+	defer stop()
+
+	// No slave set
+	if _, err := client.ReadUint8(0x00); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	if err := client.WriteUint8(0x00, 0x00); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+
+	// Bad slaves
+	if err := client.SetSlave(i2c.I2C_SLAVE_NONE); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	if _, err := client.DetectSlave(0x80); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+
+	// Errors from the device
+	if err := client.SetSlave(SLAVE); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ReadBlock(0x00, 0); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	if _, err := client.ReadUint8(0xFF); err != gopi.ErrNotImplemented {
+		t.Error("Expected ErrNotImplemented, got", err)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// DEVICE
+
+const (
+	SLAVE = 0x77
+)
+
+// device is a slave with 256 bytes of registers, which returns
+// ErrNotImplemented for register 0xFF
+type device struct {
+	slave     uint8
+	registers map[uint8][]byte
+}
+
+func (this *device) Close() error    { return nil }
+func (this *device) GetSlave() uint8 { return this.slave }
+func (this *device) ReadUint8(reg uint8) (uint8, error) {
+	data, err := this.ReadBlock(reg, 1)
+	return data[0], err
+}
+func (this *device) ReadInt8(reg uint8) (int8, error) {
+	value, err := this.ReadUint8(reg)
+	return int8(value), err
+}
+func (this *device) ReadUint16(reg uint8) (uint16, error) {
+	data, err := this.ReadBlock(reg, 2)
+	return uint16(data[0])<<8 | uint16(data[1]), err
+}
+func (this *device) ReadInt16(reg uint8) (int16, error) {
+	value, err := this.ReadUint16(reg)
+	return int16(value), err
+}
+func (this *device) WriteUint8(reg, value uint8) error {
+	return this.write(reg, value)
+}
+func (this *device) WriteInt8(reg uint8, value int8) error {
+	return this.write(reg, uint8(value))
+}
+func (this *device) WriteUint16(reg uint8, value uint16) error {
+	return this.write(reg, uint8(value>>8), uint8(value))
+}
+func (this *device) WriteInt16(reg uint8, value int16) error {
+	return this.WriteUint16(reg, uint16(value))
+}
+
+func (this *device) SetSlave(slave uint8) error {
+	if slave > 0x7F {
+		return gopi.ErrBadParameter
+	}
+	this.slave = slave
+	return nil
+}
+
+func (this *device) DetectSlave(slave uint8) (bool, error) {
+	if slave > 0x7F {
+		return false, gopi.ErrBadParameter
+	}
+	return slave == SLAVE, nil
+}
+
+func (this *device) ReadBlock(reg, length uint8) ([]byte, error) {
+	data := make([]byte, length+1)
+	if this.slave != SLAVE || length == 0 {
+		return data, gopi.ErrBadParameter
+	} else if reg == 0xFF {
+		return data, gopi.ErrNotImplemented
+	}
+	registers := this.registers[this.slave]
+	for i := range data[:length] {
+		if int(reg)+i < len(registers) {
+			data[i] = registers[int(reg)+i]
+		}
+	}
+	return data[:length], nil
+}
+
+func (this *device) write(reg uint8, data ...uint8) error {
+	if this.slave != SLAVE {
+		return gopi.ErrBadParameter
+	} else if reg == 0xFF {
+		return gopi.ErrNotImplemented
+	}
+	if this.registers[this.slave] == nil {
+		this.registers[this.slave] = make([]byte, 0x100)
+	}
+	copy(this.registers[this.slave][reg:], data)
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// UTILITY METHODS
+
+func openLogger(t *testing.T) gopi.Logger {
+	if log, err := gopi.Open(logger.Config{}, nil); err != nil {
+		t.Fatal("Unable to create logger driver")
+		return nil
+	} else {
+		return log.(gopi.Logger)
+	}
+}
+
+// openClient serves the device on an in-process server, and returns
+// a client and a function which closes the client and server
+func openClient(t *testing.T, log gopi.Logger, device gopi.I2C) (*i2c.Client, func()) {
+	server, err := grpc.OpenMemoryServer(grpc.Server{}, log, func(server gopi.RPCServer) gopi.Config {
+		return i2c.Service{Server: server, I2C: device}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := server.Conn(grpc.ClientConn{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := i2c.NewClient(conn).(*i2c.Client)
+
+	return client, func() {
+		client.Close()
+		conn.Close()
+		server.Close()
+	}
+}
+
+// hasService returns true if a service name is in a list of services
+func hasService(services []string, name string) bool {
+	for _, service := range services {
+		if service == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package i2c
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+	empty "github.com/golang/protobuf/ptypes/empty"
+	context "golang.org/x/net/context"
+	gogrpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+
+	// Protocol buffers
+	pb "github.com/djthorpe/gopi/rpc/protobuf/i2c"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type Service struct {
+	Server gopi.RPCServer
+	I2C    gopi.I2C
+
+	// Identities which can call methods
+	Authorization grpc.Authorization
+}
+
+type service struct {
+	log gopi.Logger
+	i2c gopi.I2C
+
+	// The slave is set for each request, so requests are made
+	// one at a time
+	lock sync.Mutex
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Highest slave address
+	I2C_SLAVE_MAX = 0x7F
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+// Open the server
+func (config Service) Open(log gopi.Logger) (gopi.Driver, error) {
+	log.Debug("<grpc.i2c.service>Open{ server=%v i2c=%v }", config.Server, config.I2C)
+
+	if config.I2C == nil {
+		return nil, gopi.ErrBadParameter
+	}
+
+	this := new(service)
+	this.log = log
+	this.i2c = config.I2C
+
+	// Restrict methods to some identities
+	if len(config.Authorization) > 0 {
+		if authorizer, ok := config.Server.(grpc.GRPCAuthorizer); ok == false {
+			return nil, gopi.ErrNotImplemented
+		} else {
+			authorizer.Authorize(config.Authorization)
+		}
+	}
+
+	// Register service with GRPC server
+	pb.RegisterI2CServer(config.Server.(grpc.GRPCServer).GRPCServer(), this)
+
+	// Success
+	return this, nil
+}
+
+func (this *service) Close() error {
+	this.log.Debug("<grpc.i2c.service>Close{}")
+
+	// No resources to release
+
+	// Success
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// RPCService implementation
+
+func (this *service) CancelRequests() error {
+	// No streaming requests to end
+	return nil
+}
+
+func (this *service) Ping(ctx context.Context, request *empty.Empty) (*empty.Empty, error) {
+	// Simple ping method to show server is "up"
+	return &empty.Empty{}, nil
+}
+
+func (this *service) DetectSlave(ctx context.Context, request *pb.I2CSlaveRequest) (*pb.I2CDetectSlaveReply, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if request.Slave > I2C_SLAVE_MAX {
+		return nil, gogrpc.Errorf(codes.InvalidArgument, "Invalid slave: 0x%02X", request.Slave)
+	} else if detected, err := this.i2c.DetectSlave(uint8(request.Slave)); err != nil {
+		return nil, grpc.ErrorToStatus(err)
+	} else {
+		return &pb.I2CDetectSlaveReply{Detected: detected}, nil
+	}
+}
+
+func (this *service) ReadRegister(ctx context.Context, request *pb.I2CReadRequest) (*pb.I2CReadReply, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if err := this.setSlave(request.Slave, request.Reg); err != nil {
+		return nil, err
+	}
+	reg := uint8(request.Reg)
+	reply := &pb.I2CReadReply{}
+	switch request.Width {
+	case pb.I2CWidth_I2C_UINT8:
+		value, err := this.i2c.ReadUint8(reg)
+		reply.Value = int32(value)
+		return reply, grpc.ErrorToStatus(err)
+	case pb.I2CWidth_I2C_INT8:
+		value, err := this.i2c.ReadInt8(reg)
+		reply.Value = int32(value)
+		return reply, grpc.ErrorToStatus(err)
+	case pb.I2CWidth_I2C_UINT16:
+		value, err := this.i2c.ReadUint16(reg)
+		reply.Value = int32(value)
+		return reply, grpc.ErrorToStatus(err)
+	case pb.I2CWidth_I2C_INT16:
+		value, err := this.i2c.ReadInt16(reg)
+		reply.Value = int32(value)
+		return reply, grpc.ErrorToStatus(err)
+	case pb.I2CWidth_I2C_BLOCK:
+		if request.Length == 0 || request.Length > math.MaxUint8 {
+			return nil, gogrpc.Errorf(codes.InvalidArgument, "Invalid length: %v", request.Length)
+		}
+		data, err := this.i2c.ReadBlock(reg, uint8(request.Length))
+		reply.Data = data
+		return reply, grpc.ErrorToStatus(err)
+	default:
+		return nil, gogrpc.Errorf(codes.InvalidArgument, "Invalid width: %v", request.Width)
+	}
+}
+
+func (this *service) WriteRegister(ctx context.Context, request *pb.I2CWriteRequest) (*empty.Empty, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if err := this.setSlave(request.Slave, request.Reg); err != nil {
+		return nil, err
+	}
+	reg, value := uint8(request.Reg), request.Value
+	var err error
+	switch {
+	case request.Width == pb.I2CWidth_I2C_UINT8 && value >= 0 && value <= math.MaxUint8:
+		err = this.i2c.WriteUint8(reg, uint8(value))
+	case request.Width == pb.I2CWidth_I2C_INT8 && value >= math.MinInt8 && value <= math.MaxInt8:
+		err = this.i2c.WriteInt8(reg, int8(value))
+	case request.Width == pb.I2CWidth_I2C_UINT16 && value >= 0 && value <= math.MaxUint16:
+		err = this.i2c.WriteUint16(reg, uint16(value))
+	case request.Width == pb.I2CWidth_I2C_INT16 && value >= math.MinInt16 && value <= math.MaxInt16:
+		err = this.i2c.WriteInt16(reg, int16(value))
+	default:
+		return nil, gogrpc.Errorf(codes.InvalidArgument, "Invalid %v value: %v", request.Width, value)
+	}
+	if err != nil {
+		return nil, grpc.ErrorToStatus(err)
+	} else {
+		return &empty.Empty{}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// setSlave sets the slave for a request
+func (this *service) setSlave(slave, reg uint32) error {
+	if slave > I2C_SLAVE_MAX {
+		return gogrpc.Errorf(codes.InvalidArgument, "Invalid slave: 0x%02X", slave)
+	} else if reg > math.MaxUint8 {
+		return gogrpc.Errorf(codes.InvalidArgument, "Invalid register: 0x%02X", reg)
+	} else if err := this.i2c.SetSlave(uint8(slave)); err != nil {
+		return grpc.ErrorToStatus(err)
+	} else {
+		return nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Stringify
+
+func (this *service) String() string {
+	return fmt.Sprintf("grpc.i2c.service{ i2c=%v }", this.i2c)
+}
@@ -0,0 +1,543 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	// Framework
+	gopi "github.com/djthorpe/gopi"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+	evt "github.com/djthorpe/gopi/util/event"
+	ptypes "github.com/golang/protobuf/ptypes"
+	empty "github.com/golang/protobuf/ptypes/empty"
+
+	// Protocol buffers
+	pb "github.com/djthorpe/gopi/rpc/protobuf/input"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Client implements gopi.InputManager for a remote device. Events from
+// opened devices are streamed from the remote device, and emitted by the
+// device and the client
+type Client struct {
+	pb.InputClient
+	conn   gopi.RPCClientConn
+	pubsub *evt.PubSub
+
+	// Devices which have been opened or created
+	lock    sync.Mutex
+	devices map[uint32]*device
+}
+
+// device implements gopi.InputDevice for a remote device
+type device struct {
+	client *Client
+	pubsub *evt.PubSub
+	id     uint32
+	name   string
+	typ    gopi.InputDeviceType
+	bus    gopi.InputDeviceBus
+
+	// The device or virtual device which is the source of events.
+	// Virtual devices are closed on the remote device
+	source  gopi.InputDevice
+	virtual bool
+
+	// Position is updated from events, and streaming events is
+	// cancelled when the device is closed
+	lock     sync.Mutex
+	position gopi.Point
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// virtual implements gopi.InputVirtualDevice for a remote
+// virtual device
+type virtual struct {
+	*device
+}
+
+type input_event struct {
+	source gopi.InputDevice
+	event  *pb.InputEvent
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+func NewClient(conn gopi.RPCClientConn) gopi.RPCClient {
+	return &Client{
+		InputClient: pb.NewInputClient(conn.(grpc.GRPCClientConn).GRPCConn()),
+		conn:        conn,
+		pubsub:      evt.NewPubSub(0),
+		devices:     make(map[uint32]*device),
+	}
+}
+
+// NewContext returns a context with the connection timeout
+func (this *Client) NewContext() (context.Context, context.CancelFunc) {
+	if this.conn.Timeout() == 0 {
+		return context.WithCancel(context.Background())
+	} else {
+		return context.WithTimeout(context.Background(), this.conn.Timeout())
+	}
+}
+
+// Close closes devices and subscriber channels, but does not
+// disconnect
+func (this *Client) Close() error {
+	this.lock.Lock()
+	devices := make([]*device, 0, len(this.devices))
+	for _, device := range this.devices {
+		devices = append(devices, device)
+	}
+	this.lock.Unlock()
+
+	// Close devices, including virtual devices on the remote device
+	for _, device := range devices {
+		if err := this.closeDevice(device); err != nil {
+			return err
+		}
+	}
+
+	// Close subscriber channels
+	this.pubsub.Close()
+
+	// Success
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PROPERTIES
+
+func (this *Client) Conn() gopi.RPCClientConn {
+	return this.conn
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CALLS
+
+func (this *Client) Ping() error {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+
+	if _, err := this.InputClient.Ping(ctx, &empty.Empty{}); err != nil {
+		return err
+	} else {
+		return nil
+	}
+}
+
+// OpenDevicesByName opens remote devices by name, type and bus, and
+// streams events from them
+func (this *Client) OpenDevicesByName(name string, flags gopi.InputDeviceType, bus gopi.InputDeviceBus) ([]gopi.InputDevice, error) {
+	reply, err := this.openDevices(name, flags, bus)
+	if err != nil {
+		return nil, grpc.ErrorFromStatus(err)
+	}
+
+	devices := make([]gopi.InputDevice, 0, len(reply.Devices))
+	for _, pb_device := range reply.Devices {
+		this.lock.Lock()
+		device, exists := this.devices[pb_device.Device]
+		this.lock.Unlock()
+		if exists == false {
+			if device, err = this.openDevice(pb_device, false); err != nil {
+				return nil, err
+			}
+		}
+		devices = append(devices, device.source)
+	}
+
+	// Success
+	return devices, nil
+}
+
+// CloseDevice stops streaming events from a device, and closes
+// virtual devices on the remote device
+func (this *Client) CloseDevice(d gopi.InputDevice) error {
+	switch d := d.(type) {
+	case *device:
+		if d.client == this {
+			return this.closeDevice(d)
+		}
+	case *virtual:
+		if d.client == this {
+			return this.closeDevice(d.device)
+		}
+	}
+	return gopi.ErrNotFound
+}
+
+// CreateVirtualDevice creates a virtual device on the remote device
+// and streams events from it
+func (this *Client) CreateVirtualDevice(name string, device_type gopi.InputDeviceType, size gopi.Size) (gopi.InputVirtualDevice, error) {
+	reply, err := this.createVirtualDevice(name, device_type, size)
+	if err != nil {
+		return nil, grpc.ErrorFromStatus(err)
+	}
+	if device, err := this.openDevice(reply, true); err != nil {
+		this.closeVirtualDevice(reply.Device)
+		return nil, err
+	} else {
+		return device.source.(gopi.InputVirtualDevice), nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBSUB
+
+// Subscribe to events from opened devices
+func (this *Client) Subscribe() <-chan gopi.Event {
+	return this.pubsub.Subscribe()
+}
+
+// Unsubscribe from events
+func (this *Client) Unsubscribe(subscriber <-chan gopi.Event) {
+	this.pubsub.Unsubscribe(subscriber)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// INTERFACE - DEVICE
+
+// Close stops streaming events from the device
+func (this *device) Close() error {
+	return this.client.closeDevice(this)
+}
+
+func (this *device) Name() string {
+	return this.name
+}
+
+func (this *device) Type() gopi.InputDeviceType {
+	return this.typ
+}
+
+func (this *device) Bus() gopi.InputDeviceBus {
+	return this.bus
+}
+
+// Position returns the position from the last event with a position
+func (this *device) Position() gopi.Point {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.position
+}
+
+// Matches returns true if the device matches the name, type and bus.
+// Types may be OR'd together, and NONE or ANY matches any type or bus
+func (this *device) Matches(name string, flags gopi.InputDeviceType, bus gopi.InputDeviceBus) bool {
+	if name != "" && name != this.name {
+		return false
+	}
+	if flags != gopi.INPUT_TYPE_NONE && flags != gopi.INPUT_TYPE_ANY && flags&this.typ == 0 {
+		return false
+	}
+	if bus != gopi.INPUT_BUS_NONE && bus != gopi.INPUT_BUS_ANY && bus != this.bus {
+		return false
+	}
+	return true
+}
+
+// Subscribe to events from the device
+func (this *device) Subscribe() <-chan gopi.Event {
+	return this.pubsub.Subscribe()
+}
+
+// Unsubscribe from events
+func (this *device) Unsubscribe(subscriber <-chan gopi.Event) {
+	this.pubsub.Unsubscribe(subscriber)
+}
+
+func (this *device) String() string {
+	return fmt.Sprintf("<grpc.input.client.Device>{ name=%v type=%v bus=%v position=%v }", this.name, this.typ, this.bus, this.Position())
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// INTERFACE - VIRTUAL DEVICE
+
+// InjectKey injects a key press, release or repeat
+func (this *virtual) InjectKey(key gopi.KeyCode, event_type gopi.InputEventType) error {
+	switch event_type {
+	case gopi.INPUT_EVENT_KEYPRESS, gopi.INPUT_EVENT_KEYRELEASE, gopi.INPUT_EVENT_KEYREPEAT:
+		break
+	default:
+		return gopi.ErrBadParameter
+	}
+	return this.client.inject(&pb.InputInjectRequest{
+		Device:    this.id,
+		EventType: uint32(event_type),
+		KeyCode:   uint32(key),
+	})
+}
+
+// InjectRelPosition injects relative motion
+func (this *virtual) InjectRelPosition(rel gopi.Point) error {
+	return this.client.inject(&pb.InputInjectRequest{
+		Device:    this.id,
+		EventType: uint32(gopi.INPUT_EVENT_RELPOSITION),
+		Relative:  toProtoPoint(rel),
+	})
+}
+
+// InjectAbsPosition injects an absolute position
+func (this *virtual) InjectAbsPosition(position gopi.Point) error {
+	return this.client.inject(&pb.InputInjectRequest{
+		Device:    this.id,
+		EventType: uint32(gopi.INPUT_EVENT_ABSPOSITION),
+		Position:  toProtoPoint(position),
+	})
+}
+
+// InjectTouch injects a multi-touch press, release or position
+// for a slot
+func (this *virtual) InjectTouch(slot uint, event_type gopi.InputEventType, position gopi.Point) error {
+	switch event_type {
+	case gopi.INPUT_EVENT_TOUCHPRESS, gopi.INPUT_EVENT_TOUCHRELEASE, gopi.INPUT_EVENT_TOUCHPOSITION:
+		break
+	default:
+		return gopi.ErrBadParameter
+	}
+	return this.client.inject(&pb.InputInjectRequest{
+		Device:    this.id,
+		EventType: uint32(event_type),
+		Position:  toProtoPoint(position),
+		Slot:      uint32(slot),
+	})
+}
+
+func (this *virtual) String() string {
+	return fmt.Sprintf("<grpc.input.client.VirtualDevice>{ name=%v type=%v position=%v }", this.name, this.typ, this.Position())
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// INTERFACE - EVENT
+
+func (this *input_event) Name() string {
+	return "InputEvent"
+}
+
+func (this *input_event) Source() gopi.Driver {
+	return this.source
+}
+
+func (this *input_event) Timestamp() time.Duration {
+	if ts, err := ptypes.Duration(this.event.Ts); err != nil {
+		return 0
+	} else {
+		return ts
+	}
+}
+
+func (this *input_event) DeviceType() gopi.InputDeviceType {
+	return gopi.InputDeviceType(this.event.DeviceType)
+}
+
+func (this *input_event) EventType() gopi.InputEventType {
+	return gopi.InputEventType(this.event.EventType)
+}
+
+func (this *input_event) Device() uint32 {
+	return this.event.Device
+}
+
+func (this *input_event) Keycode() gopi.KeyCode {
+	return gopi.KeyCode(this.event.KeyCode)
+}
+
+func (this *input_event) Scancode() uint32 {
+	return this.event.ScanCode
+}
+
+func (this *input_event) Position() gopi.Point {
+	return fromProtoPoint(this.event.Position)
+}
+
+func (this *input_event) Relative() gopi.Point {
+	return fromProtoPoint(this.event.Relative)
+}
+
+func (this *input_event) Slot() uint {
+	return uint(this.event.Slot)
+}
+
+func (this *input_event) Axis() gopi.InputAxis {
+	return gopi.InputAxis(this.event.Axis)
+}
+
+func (this *input_event) AxisValue() float32 {
+	return this.event.AxisValue
+}
+
+func (this *input_event) String() string {
+	return fmt.Sprintf("<grpc.input.client.Event>{ type=%v device=%v key_code=%v position=%v relative=%v slot=%v ts=%v }", this.EventType(), this.DeviceType(), this.Keycode(), this.Position(), this.Relative(), this.Slot(), this.Timestamp())
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *Client) openDevices(name string, flags gopi.InputDeviceType, bus gopi.InputDeviceBus) (*pb.InputDevicesReply, error) {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+
+	return this.InputClient.OpenDevices(ctx, &pb.InputOpenDevicesRequest{
+		Name: name,
+		Type: uint32(flags),
+		Bus:  uint32(bus),
+	})
+}
+
+func (this *Client) createVirtualDevice(name string, device_type gopi.InputDeviceType, size gopi.Size) (*pb.InputDevice, error) {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+
+	return this.InputClient.CreateVirtualDevice(ctx, &pb.InputCreateVirtualDeviceRequest{
+		Name:   name,
+		Type:   uint32(device_type),
+		Width:  size.W,
+		Height: size.H,
+	})
+}
+
+func (this *Client) closeVirtualDevice(id uint32) error {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+
+	if _, err := this.InputClient.CloseVirtualDevice(ctx, &pb.InputDeviceRequest{Device: id}); err != nil {
+		return grpc.ErrorFromStatus(err)
+	} else {
+		return nil
+	}
+}
+
+func (this *Client) inject(request *pb.InputInjectRequest) error {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+
+	if _, err := this.InputClient.Inject(ctx, request); err != nil {
+		return grpc.ErrorFromStatus(err)
+	} else {
+		return nil
+	}
+}
+
+// openDevice streams events from a device, and waits for the first
+// reply which indicates the device is being watched
+func (this *Client) openDevice(reply *pb.InputDevice, is_virtual bool) (*device, error) {
+	device := &device{
+		client:   this,
+		pubsub:   evt.NewPubSub(0),
+		id:       reply.Device,
+		name:     reply.Name,
+		typ:      gopi.InputDeviceType(reply.Type),
+		bus:      gopi.InputDeviceBus(reply.Bus),
+		virtual:  is_virtual,
+		position: fromProtoPoint(reply.Position),
+		done:     make(chan struct{}),
+	}
+	if is_virtual {
+		device.source = &virtual{device}
+	} else {
+		device.source = device
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := this.InputClient.Events(ctx, &pb.InputEventsRequest{Devices: []uint32{device.id}})
+	if err != nil {
+		cancel()
+		return nil, grpc.ErrorFromStatus(err)
+	} else if _, err := stream.Recv(); err != nil {
+		cancel()
+		return nil, grpc.ErrorFromStatus(err)
+	}
+	device.cancel = cancel
+
+	this.lock.Lock()
+	this.devices[device.id] = device
+	this.lock.Unlock()
+
+	// Emit events until cancelled or the stream ends, which also
+	// happens when the remote device ends streaming requests
+	go func() {
+		defer close(device.done)
+		for {
+			if reply, err := stream.Recv(); err != nil {
+				return
+			} else {
+				device.emit(&input_event{device.source, reply})
+			}
+		}
+	}()
+
+	// Success
+	return device, nil
+}
+
+// closeDevice stops streaming events from a device and closes
+// the subscriber channels of the device. Virtual devices are closed
+// on the remote device
+func (this *Client) closeDevice(device *device) error {
+	this.lock.Lock()
+	_, exists := this.devices[device.id]
+	delete(this.devices, device.id)
+	this.lock.Unlock()
+	if exists == false {
+		// Already closed
+		return nil
+	}
+
+	// Wait for streaming to end
+	device.cancel()
+	<-device.done
+	device.pubsub.Close()
+
+	// Close virtual devices, which are not returned by OpenDevices
+	if device.virtual {
+		return this.closeVirtualDevice(device.id)
+	}
+
+	// Success
+	return nil
+}
+
+// emit updates the position of the device and emits an event on
+// the device and the client
+func (this *device) emit(event *input_event) {
+	switch event.EventType() {
+	case gopi.INPUT_EVENT_ABSPOSITION, gopi.INPUT_EVENT_RELPOSITION, gopi.INPUT_EVENT_TOUCHPRESS, gopi.INPUT_EVENT_TOUCHRELEASE, gopi.INPUT_EVENT_TOUCHPOSITION:
+		this.lock.Lock()
+		this.position = event.Position()
+		this.lock.Unlock()
+	}
+	this.pubsub.Emit(event)
+	this.client.pubsub.Emit(event)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Client) String() string {
+	return fmt.Sprintf("<grpc.input.client>{ conn=%v }", this.conn)
+}
@@ -0,0 +1,58 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	// Frameworks
+	"github.com/djthorpe/gopi"
+	"github.com/djthorpe/gopi/sys/rpc/grpc"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INIT
+
+func init() {
+	// Register service/input:grpc
+	gopi.RegisterModule(gopi.Module{
+		Name:     "rpc/service/input:grpc",
+		Type:     gopi.MODULE_TYPE_SERVICE,
+		Requires: []string{"rpc/server", "input"},
+		Config: func(config *gopi.AppConfig) {
+			config.AppFlags.FlagString("input.allow", "", "Comma-separated list of method=identity pairs")
+		},
+		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
+			allow, _ := app.AppFlags.GetString("input.allow")
+			if authorization, err := grpc.ParseMethodAuthorization(SERVICE_NAME, allow); err != nil {
+				return nil, err
+			} else {
+				return gopi.Open(Service{
+					Server:        app.ModuleInstance("rpc/server").(gopi.RPCServer),
+					Input:         app.Input,
+					Authorization: authorization,
+				}, app.Logger)
+			}
+		},
+	})
+
+	// Register the client
+	gopi.RegisterModule(gopi.Module{
+		Name:     "rpc/client/input:grpc",
+		Type:     gopi.MODULE_TYPE_CLIENT,
+		Requires: []string{"rpc/clientpool"},
+		Run: func(app *gopi.AppInstance, _ gopi.Driver) error {
+			clientpool := app.ModuleInstance("rpc/clientpool").(gopi.RPCClientPool)
+			if clientpool == nil {
+				return gopi.ErrAppError
+			} else {
+				clientpool.RegisterClient(SERVICE_NAME, NewClient)
+				return nil
+			}
+		},
+	})
+}
@@ -0,0 +1,260 @@
+package input_test
+
+import (
+	"testing"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	mock "github.com/djthorpe/gopi/sys/input/mock"
+	logger "github.com/djthorpe/gopi/sys/logger"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+	gogrpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+
+	// RPC Services
+	input "github.com/djthorpe/gopi/rpc/grpc/input"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// CLIENT
+
+func TestClient_000(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	manager := openInput(t, log)
+	defer manager.Close()
+	client, stop := openClient(t, log, manager, true)
+	defer stop()
+
+	// The service is registered with the server, and responds to pings
+	if services, err := client.Conn().Services(); err != nil {
+		t.Error(err)
+	} else if hasService(services, "mutablelogic.Input") == false {
+		t.Error("Expected mutablelogic.Input service, got", services)
+	}
+	if err := client.Ping(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClient_001(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	manager := openInput(t, log)
+	defer manager.Close()
+	client, stop := openClient(t, log, manager, true)
+	defer stop()
+
+	// Create a virtual keyboard on the remote device
+	keyboard, err := client.CreateVirtualDevice("keyboard", gopi.INPUT_TYPE_KEYBOARD, gopi.ZeroSize)
+	if err != nil {
+		t.Fatal(err)
+	} else if keyboard.Name() != "keyboard" || keyboard.Type() != gopi.INPUT_TYPE_KEYBOARD || keyboard.Bus() != gopi.INPUT_BUS_VIRTUAL {
+		t.Error("Unexpected device", keyboard)
+	}
+	if devices, err := manager.OpenDevicesByName("keyboard", gopi.INPUT_TYPE_ANY, gopi.INPUT_BUS_ANY); err != nil {
+		t.Error(err)
+	} else if len(devices) != 1 {
+		t.Error("Expected virtual device on the remote device, got", devices)
+	}
+
+	// Inject a key press, which is emitted by the client and the device
+	events := client.Subscribe()
+	defer client.Unsubscribe(events)
+	if err := keyboard.InjectKey(gopi.KEYCODE_A, gopi.INPUT_EVENT_KEYPRESS); err != nil {
+		t.Fatal(err)
+	}
+	if event := waitForEvent(t, events); event.Source() != keyboard {
+		t.Error("Unexpected source", event.Source())
+	} else if event.EventType() != gopi.INPUT_EVENT_KEYPRESS || event.Keycode() != gopi.KEYCODE_A {
+		t.Error("Unexpected event", event)
+	} else if event.DeviceType() != gopi.INPUT_TYPE_KEYBOARD {
+		t.Error("Unexpected device type", event.DeviceType())
+	}
+}
+
+func TestClient_002(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	manager := openInput(t, log)
+	defer manager.Close()
+	client, stop := openClient(t, log, manager, true)
+	defer stop()
+
+	// Create a virtual touchscreen, which is returned when opening devices
+	touchscreen, err := client.CreateVirtualDevice("touchscreen", gopi.INPUT_TYPE_TOUCHSCREEN, gopi.Size{W: 800, H: 480})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if devices, err := client.OpenDevicesByName("", gopi.INPUT_TYPE_TOUCHSCREEN, gopi.INPUT_BUS_ANY); err != nil {
+		t.Error(err)
+	} else if len(devices) != 1 || devices[0] != touchscreen {
+		t.Error("Unexpected devices", devices)
+	}
+
+	// Touch events update the position of the device
+	events := touchscreen.Subscribe()
+	defer touchscreen.Unsubscribe(events)
+	position := gopi.Point{X: 100, Y: 200}
+	if err := touchscreen.InjectTouch(1, gopi.INPUT_EVENT_TOUCHPRESS, position); err != nil {
+		t.Fatal(err)
+	}
+	if event := waitForEvent(t, events); event.EventType() != gopi.INPUT_EVENT_TOUCHPRESS {
+		t.Error("Unexpected event", event)
+	} else if event.Slot() != 1 || event.Position() != position {
+		t.Error("Unexpected slot or position", event)
+	} else if touchscreen.Position() != position {
+		t.Error("Unexpected device position", touchscreen.Position())
+	}
+
+	// Closing the device closes the virtual device on the remote device
+	if err := client.CloseDevice(touchscreen); err != nil {
+		t.Error(err)
+	} else if devices, _ := manager.OpenDevicesByName("touchscreen", gopi.INPUT_TYPE_ANY, gopi.INPUT_BUS_ANY); len(devices) != 0 {
+		t.Error("Expected virtual device to be closed, got", devices)
+	}
+	if err := touchscreen.InjectTouch(1, gopi.INPUT_EVENT_TOUCHRELEASE, position); err != gopi.ErrNotFound {
+		t.Error("Expected ErrNotFound, got", err)
+	}
+}
+
+func TestClient_003(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	manager := openInput(t, log)
+	defer manager.Close()
+	client, stop := openClient(t, log, manager, true)
+	defer stop()
+
+	if _, err := client.CreateVirtualDevice("touchscreen", gopi.INPUT_TYPE_TOUCHSCREEN, gopi.ZeroSize); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	if _, err := client.CreateVirtualDevice("any", gopi.INPUT_TYPE_ANY, gopi.ZeroSize); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	keyboard, err := client.CreateVirtualDevice("keyboard", gopi.INPUT_TYPE_KEYBOARD, gopi.ZeroSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keyboard.InjectKey(gopi.KEYCODE_A, gopi.INPUT_EVENT_ABSPOSITION); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	if err := keyboard.InjectRelPosition(gopi.Point{X: 1, Y: 1}); err != gopi.ErrNotImplemented {
+		t.Error("Expected ErrNotImplemented, got", err)
+	}
+	if err := client.CloseDevice(nil); err != gopi.ErrNotFound {
+		t.Error("Expected ErrNotFound, got", err)
+	}
+}
+
+func TestClient_004(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	manager := openInput(t, log)
+	defer manager.Close()
+	keyboard := openDevice(t, log, manager, "keyboard", gopi.INPUT_TYPE_KEYBOARD)
+	client, stop := openClient(t, log, manager, false)
+
+	// Virtual devices cannot be used without authorization
+	if _, err := client.CreateVirtualDevice("keyboard", gopi.INPUT_TYPE_KEYBOARD, gopi.ZeroSize); gogrpc.Code(err) != codes.PermissionDenied {
+		t.Error("Expected PermissionDenied, got", err)
+	}
+
+	// Devices which are opened are closed with the service
+	if devices, err := client.OpenDevicesByName("keyboard", gopi.INPUT_TYPE_ANY, gopi.INPUT_BUS_ANY); err != nil {
+		t.Error(err)
+	} else if len(devices) != 1 || devices[0].Name() != keyboard.Name() {
+		t.Error("Unexpected devices", devices)
+	}
+	stop()
+	if devices, _ := manager.OpenDevicesByName("keyboard", gopi.INPUT_TYPE_ANY, gopi.INPUT_BUS_ANY); len(devices) != 0 {
+		t.Error("Expected devices to be closed, got", devices)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// UTILITY METHODS
+
+func openLogger(t *testing.T) gopi.Logger {
+	if log, err := gopi.Open(logger.Config{}, nil); err != nil {
+		t.Fatal("Unable to create logger driver")
+		return nil
+	} else {
+		return log.(gopi.Logger)
+	}
+}
+
+func openInput(t *testing.T, log gopi.Logger) gopi.InputManager {
+	if driver, err := gopi.Open(mock.Input{}, log); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return driver.(gopi.InputManager)
+	}
+}
+
+// openDevice adds a device to the input manager
+func openDevice(t *testing.T, log gopi.Logger, manager gopi.InputManager, name string, device_type gopi.InputDeviceType) gopi.InputDevice {
+	if driver, err := gopi.Open(mock.Device{Name: name, Type: device_type}, log); err != nil {
+		t.Fatal(err)
+		return nil
+	} else if err := manager.(interface {
+		AddDevice(gopi.InputDevice) error
+	}).AddDevice(driver.(gopi.InputDevice)); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return driver.(gopi.InputDevice)
+	}
+}
+
+// openClient serves the input manager on an in-process server, and
+// returns a client and a function which closes the client and server.
+// When inject is true, the client is authorized to use virtual devices
+func openClient(t *testing.T, log gopi.Logger, manager gopi.InputManager, inject bool) (*input.Client, func()) {
+	authorization := grpc.Authorization{}
+	if inject {
+		authorization[input.SERVICE_NAME+"/CreateVirtualDevice"] = []string{"client"}
+		authorization[input.SERVICE_NAME+"/Inject"] = []string{"client"}
+	}
+	server, err := grpc.OpenMemoryServer(grpc.Server{
+		Authenticator: grpc.StaticTokens{"client": "abc"},
+	}, log, func(server gopi.RPCServer) gopi.Config {
+		return input.Service{Server: server, Input: manager, Authorization: authorization}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := server.Conn(grpc.ClientConn{Token: "abc", Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := input.NewClient(conn).(*input.Client)
+
+	return client, func() {
+		client.Close()
+		conn.Close()
+		server.Close()
+	}
+}
+
+// hasService returns true if a service name is in a list of services
+func hasService(services []string, name string) bool {
+	for _, service := range services {
+		if service == name {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForEvent(t *testing.T, events <-chan gopi.Event) gopi.InputEvent {
+	select {
+	case event := <-events:
+		return event.(gopi.InputEvent)
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for event")
+		return nil
+	}
+}
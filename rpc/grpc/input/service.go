@@ -0,0 +1,461 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+	ptypes "github.com/golang/protobuf/ptypes"
+	empty "github.com/golang/protobuf/ptypes/empty"
+	context "golang.org/x/net/context"
+	gogrpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+
+	// Protocol buffers
+	pb "github.com/djthorpe/gopi/rpc/protobuf/input"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type Service struct {
+	Server gopi.RPCServer
+	Input  gopi.InputManager
+
+	// Identities which can call methods. Virtual devices can only be
+	// created and injected into when the CreateVirtualDevice and Inject
+	// methods are restricted to some identities
+	Authorization grpc.Authorization
+}
+
+type service struct {
+	log    gopi.Logger
+	input  gopi.InputManager
+	events <-chan gopi.Event
+	inject bool
+	wg     sync.WaitGroup
+
+	// Devices are given an identifier when they are first returned,
+	// and devices opened or created by the service are closed with it.
+	// Watchers receive events from devices until done is closed
+	lock     sync.Mutex
+	devices  map[uint32]gopi.InputDevice
+	ids      map[gopi.InputDevice]uint32
+	opened   map[uint32]gopi.InputDevice
+	virtual  map[uint32]gopi.InputVirtualDevice
+	next     uint32
+	watchers []*watcher
+	done     chan struct{}
+}
+
+// watcher receives events for an Events request, from a set
+// of devices or from all devices when devices is empty
+type watcher struct {
+	devices map[uint32]bool
+	events  chan *pb.InputEvent
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Number of events queued for each Events request, after which
+	// events are dropped
+	EVENTS_QUEUE_SIZE = 100
+
+	// Name of the service, for authorization
+	SERVICE_NAME = "mutablelogic.Input"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+// Open the server
+func (config Service) Open(log gopi.Logger) (gopi.Driver, error) {
+	log.Debug("<grpc.input.service>Open{ server=%v input=%v }", config.Server, config.Input)
+
+	if config.Input == nil {
+		return nil, gopi.ErrBadParameter
+	}
+
+	this := new(service)
+	this.log = log
+	this.input = config.Input
+	this.devices = make(map[uint32]gopi.InputDevice)
+	this.ids = make(map[gopi.InputDevice]uint32)
+	this.opened = make(map[uint32]gopi.InputDevice)
+	this.virtual = make(map[uint32]gopi.InputVirtualDevice)
+	this.watchers = make([]*watcher, 0)
+	this.done = make(chan struct{})
+
+	// Restrict methods to some identities
+	if len(config.Authorization) > 0 {
+		if authorizer, ok := config.Server.(grpc.GRPCAuthorizer); ok == false {
+			return nil, gopi.ErrNotImplemented
+		} else {
+			authorizer.Authorize(config.Authorization)
+		}
+	}
+
+	// Virtual devices can only be created and injected into by
+	// authorized clients
+	this.inject = restricted(config.Authorization, "CreateVirtualDevice") && restricted(config.Authorization, "Inject")
+
+	// Receive events from the input manager for as long as the
+	// service is open, and pass them to watchers
+	if this.events = this.input.Subscribe(); this.events != nil {
+		this.wg.Add(1)
+		go this.receiveEvents(this.events)
+	}
+
+	// Register service with GRPC server
+	pb.RegisterInputServer(config.Server.(grpc.GRPCServer).GRPCServer(), this)
+
+	// Success
+	return this, nil
+}
+
+func (this *service) Close() error {
+	this.log.Debug("<grpc.input.service>Close{}")
+
+	// Stop receiving events, and wait for events to be passed
+	// to watchers
+	if this.events != nil {
+		this.input.Unsubscribe(this.events)
+		this.events = nil
+	}
+	this.wg.Wait()
+
+	// Close devices opened and virtual devices created by clients
+	this.lock.Lock()
+	devices := make([]gopi.InputDevice, 0, len(this.opened)+len(this.virtual))
+	for id, device := range this.opened {
+		devices = append(devices, device)
+		this.removeDevice(id)
+	}
+	for id, device := range this.virtual {
+		devices = append(devices, device)
+		this.removeDevice(id)
+	}
+	this.lock.Unlock()
+	for _, device := range devices {
+		if err := this.input.CloseDevice(device); err != nil {
+			this.log.Warn("grpc.input.service: CloseDevice: %v: %v", device.Name(), err)
+		}
+	}
+
+	// Success
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// RPCService implementation
+
+func (this *service) CancelRequests() error {
+	this.log.Debug2("<grpc.input.service>CancelRequests{}")
+
+	// End any Events requests
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	select {
+	case <-this.done:
+		// Already cancelled
+	default:
+		close(this.done)
+	}
+	return nil
+}
+
+func (this *service) Ping(ctx context.Context, request *empty.Empty) (*empty.Empty, error) {
+	// Simple ping method to show server is "up"
+	return &empty.Empty{}, nil
+}
+
+func (this *service) OpenDevices(ctx context.Context, request *pb.InputOpenDevicesRequest) (*pb.InputDevicesReply, error) {
+	if request.Type > uint32(gopi.INPUT_TYPE_ANY) {
+		return nil, gogrpc.Errorf(codes.InvalidArgument, "Invalid type: %v", request.Type)
+	} else if request.Bus > uint32(gopi.INPUT_BUS_ANY) {
+		return nil, gogrpc.Errorf(codes.InvalidArgument, "Invalid bus: %v", request.Bus)
+	}
+
+	devices, err := this.input.OpenDevicesByName(request.Name, gopi.InputDeviceType(request.Type), gopi.InputDeviceBus(request.Bus))
+	if err != nil {
+		return nil, grpc.ErrorToStatus(err)
+	}
+
+	// Devices are closed with the service
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	reply := &pb.InputDevicesReply{Devices: make([]*pb.InputDevice, len(devices))}
+	for i, device := range devices {
+		id := this.deviceId(device)
+		if _, exists := this.virtual[id]; exists == false {
+			this.opened[id] = device
+		}
+		reply.Devices[i] = toProtoDevice(id, device)
+	}
+	return reply, nil
+}
+
+func (this *service) CreateVirtualDevice(ctx context.Context, request *pb.InputCreateVirtualDeviceRequest) (*pb.InputDevice, error) {
+	if this.inject == false {
+		return nil, gogrpc.Errorf(codes.PermissionDenied, "Virtual devices require authorization")
+	}
+	switch gopi.InputDeviceType(request.Type) {
+	case gopi.INPUT_TYPE_KEYBOARD, gopi.INPUT_TYPE_MOUSE, gopi.INPUT_TYPE_TOUCHSCREEN, gopi.INPUT_TYPE_JOYSTICK, gopi.INPUT_TYPE_REMOTE:
+		break
+	default:
+		return nil, gogrpc.Errorf(codes.InvalidArgument, "Invalid type: %v", request.Type)
+	}
+
+	device, err := this.input.CreateVirtualDevice(request.Name, gopi.InputDeviceType(request.Type), gopi.Size{W: request.Width, H: request.Height})
+	if err != nil {
+		return nil, grpc.ErrorToStatus(err)
+	}
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	id := this.deviceId(device)
+	this.virtual[id] = device
+	return toProtoDevice(id, device), nil
+}
+
+func (this *service) CloseVirtualDevice(ctx context.Context, request *pb.InputDeviceRequest) (*empty.Empty, error) {
+	this.lock.Lock()
+	device, exists := this.virtual[request.Device]
+	if exists {
+		this.removeDevice(request.Device)
+	}
+	this.lock.Unlock()
+
+	if exists == false {
+		return nil, gogrpc.Errorf(codes.NotFound, "Virtual device not found: %v", request.Device)
+	} else if err := this.input.CloseDevice(device); err != nil {
+		return nil, grpc.ErrorToStatus(err)
+	} else {
+		return &empty.Empty{}, nil
+	}
+}
+
+func (this *service) Inject(ctx context.Context, request *pb.InputInjectRequest) (*empty.Empty, error) {
+	if this.inject == false {
+		return nil, gogrpc.Errorf(codes.PermissionDenied, "Virtual devices require authorization")
+	}
+
+	// The lock is not held when injecting, as events may be
+	// emitted before returning
+	this.lock.Lock()
+	device, exists := this.virtual[request.Device]
+	this.lock.Unlock()
+	if exists == false {
+		return nil, gogrpc.Errorf(codes.NotFound, "Virtual device not found: %v", request.Device)
+	}
+
+	var err error
+	event_type := gopi.InputEventType(request.EventType)
+	switch event_type {
+	case gopi.INPUT_EVENT_KEYPRESS, gopi.INPUT_EVENT_KEYRELEASE, gopi.INPUT_EVENT_KEYREPEAT:
+		if request.KeyCode > math.MaxUint16 {
+			return nil, gogrpc.Errorf(codes.InvalidArgument, "Invalid key code: %v", request.KeyCode)
+		}
+		err = device.InjectKey(gopi.KeyCode(request.KeyCode), event_type)
+	case gopi.INPUT_EVENT_RELPOSITION:
+		err = device.InjectRelPosition(fromProtoPoint(request.Relative))
+	case gopi.INPUT_EVENT_ABSPOSITION:
+		err = device.InjectAbsPosition(fromProtoPoint(request.Position))
+	case gopi.INPUT_EVENT_TOUCHPRESS, gopi.INPUT_EVENT_TOUCHRELEASE, gopi.INPUT_EVENT_TOUCHPOSITION:
+		err = device.InjectTouch(uint(request.Slot), event_type, fromProtoPoint(request.Position))
+	default:
+		return nil, gogrpc.Errorf(codes.InvalidArgument, "Invalid event type: %v", request.EventType)
+	}
+	if err != nil {
+		return nil, grpc.ErrorToStatus(err)
+	} else {
+		return &empty.Empty{}, nil
+	}
+}
+
+func (this *service) Events(request *pb.InputEventsRequest, stream pb.Input_EventsServer) error {
+	this.log.Debug2("<grpc.input.service>Events{ request=%v }", request)
+
+	// Watch the devices, and stop watching when the request ends
+	watcher, err := this.watch(request.Devices)
+	if err != nil {
+		return err
+	}
+	defer this.unwatch(watcher)
+
+	// Indicate the devices are being watched, then send events until
+	// the client cancels or the service ends streaming requests
+	if err := stream.Send(&pb.InputEvent{}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case event := <-watcher.events:
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		case <-this.done:
+			return nil
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// watch adds a watcher for devices, or returns an error if a device
+// has not been returned to a client
+func (this *service) watch(devices []uint32) (*watcher, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	watcher := &watcher{make(map[uint32]bool, len(devices)), make(chan *pb.InputEvent, EVENTS_QUEUE_SIZE)}
+	for _, id := range devices {
+		if _, exists := this.devices[id]; exists == false {
+			return nil, gogrpc.Errorf(codes.NotFound, "Device not found: %v", id)
+		}
+		watcher.devices[id] = true
+	}
+	this.watchers = append(this.watchers, watcher)
+	return watcher, nil
+}
+
+// unwatch removes a watcher
+func (this *service) unwatch(watcher *watcher) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	for i := range this.watchers {
+		if this.watchers[i] == watcher {
+			this.watchers = append(this.watchers[:i], this.watchers[i+1:]...)
+			break
+		}
+	}
+}
+
+// deviceId returns the identifier for a device, and gives the device
+// an identifier if it does not yet have one. It should be called
+// with the lock held
+func (this *service) deviceId(device gopi.InputDevice) uint32 {
+	if id, exists := this.ids[device]; exists {
+		return id
+	}
+	this.next = this.next + 1
+	this.devices[this.next] = device
+	this.ids[device] = this.next
+	return this.next
+}
+
+// removeDevice removes the identifier for a device. It should be
+// called with the lock held
+func (this *service) removeDevice(id uint32) {
+	if device, exists := this.devices[id]; exists {
+		delete(this.ids, device)
+	}
+	delete(this.devices, id)
+	delete(this.opened, id)
+	delete(this.virtual, id)
+}
+
+// restricted returns true if a method of the service can only be
+// called by some identities
+func restricted(authorization grpc.Authorization, method string) bool {
+	if _, exists := authorization[SERVICE_NAME+"/"+method]; exists {
+		return true
+	} else if _, exists := authorization[SERVICE_NAME]; exists {
+		return true
+	} else {
+		return false
+	}
+}
+
+// receiveEvents passes input events to the watchers of the device
+// until the channel is closed. Events are dropped for watchers which
+// are not receiving them
+func (this *service) receiveEvents(events <-chan gopi.Event) {
+	defer this.wg.Done()
+	for event := range events {
+		if event, ok := event.(gopi.InputEvent); ok {
+			device, ok := event.Source().(gopi.InputDevice)
+			if ok == false || device == nil {
+				continue
+			}
+			this.lock.Lock()
+			id := this.deviceId(device)
+			reply := toProtoEvent(id, event)
+			for _, watcher := range this.watchers {
+				if len(watcher.devices) == 0 || watcher.devices[id] {
+					select {
+					case watcher.events <- reply:
+					default:
+						this.log.Warn("grpc.input.service: Dropped event from %v", device.Name())
+					}
+				}
+			}
+			if event.EventType() == gopi.INPUT_EVENT_DEVICEREMOVED {
+				this.removeDevice(id)
+			}
+			this.lock.Unlock()
+		}
+	}
+}
+
+func toProtoDevice(id uint32, device gopi.InputDevice) *pb.InputDevice {
+	return &pb.InputDevice{
+		Device:   id,
+		Name:     device.Name(),
+		Type:     uint32(device.Type()),
+		Bus:      uint32(device.Bus()),
+		Position: toProtoPoint(device.Position()),
+	}
+}
+
+func toProtoEvent(id uint32, event gopi.InputEvent) *pb.InputEvent {
+	return &pb.InputEvent{
+		Ts:         ptypes.DurationProto(event.Timestamp()),
+		Device:     id,
+		DeviceType: uint32(event.DeviceType()),
+		EventType:  uint32(event.EventType()),
+		KeyCode:    uint32(event.Keycode()),
+		ScanCode:   event.Scancode(),
+		Position:   toProtoPoint(event.Position()),
+		Relative:   toProtoPoint(event.Relative()),
+		Slot:       uint32(event.Slot()),
+		Axis:       uint32(event.Axis()),
+		AxisValue:  event.AxisValue(),
+	}
+}
+
+func toProtoPoint(point gopi.Point) *pb.InputPoint {
+	return &pb.InputPoint{X: point.X, Y: point.Y}
+}
+
+func fromProtoPoint(point *pb.InputPoint) gopi.Point {
+	if point == nil {
+		return gopi.ZeroPoint
+	} else {
+		return gopi.Point{X: point.X, Y: point.Y}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Stringify
+
+func (this *service) String() string {
+	return fmt.Sprintf("grpc.input.service{ input=%v }", this.input)
+}
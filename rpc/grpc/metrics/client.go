@@ -11,10 +11,13 @@ package metrics
 import (
 	"context"
 	"fmt"
+	"io"
+	"time"
 
 	// Framework
 	gopi "github.com/djthorpe/gopi"
 	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+	"github.com/golang/protobuf/ptypes"
 
 	// Protocol buffers
 	pb "github.com/djthorpe/gopi/rpc/protobuf/metrics"
@@ -28,6 +31,17 @@ type Client struct {
 	conn gopi.RPCClientConn
 }
 
+// Update is sent by WatchMetrics at each interval
+type Update struct {
+	Timestamp     time.Time
+	Hostname      string
+	HostUptime    time.Duration
+	ServiceUptime time.Duration
+	LoadAverage   [3]float64
+	HostStats     *HostStats
+	Metrics       []*gopi.Metric
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // NEW
 
@@ -76,6 +90,125 @@ func (this *Client) HostMetrics() (*pb.HostMetricsReply, error) {
 	}
 }
 
+func (this *Client) LoadAverage() (float64, float64, float64, error) {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+
+	if reply, err := this.MetricsClient.LoadAverage(this.NewContext(), &pb.EmptyRequest{}); err != nil {
+		return 0, 0, 0, err
+	} else {
+		return reply.Load1, reply.Load5, reply.Load15, nil
+	}
+}
+
+func (this *Client) HostStats() (*HostStats, error) {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+
+	if reply, err := this.MetricsClient.HostStats(this.NewContext(), &pb.EmptyRequest{}); err != nil {
+		return nil, err
+	} else {
+		return fromProtoHostStats(reply), nil
+	}
+}
+
+// ListMetrics returns custom metrics of a type, or all custom
+// metrics for METRIC_TYPE_NONE
+func (this *Client) ListMetrics(metric_type gopi.MetricType) ([]*gopi.Metric, error) {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+
+	if reply, err := this.MetricsClient.ListMetrics(this.NewContext(), &pb.ListMetricsRequest{Type: pb.MetricType(metric_type)}); err != nil {
+		return nil, err
+	} else {
+		metrics := make([]*gopi.Metric, len(reply.Metric))
+		for i, metric := range reply.Metric {
+			metrics[i] = fromProtoMetric(metric)
+		}
+		return metrics, nil
+	}
+}
+
+// GetMetric returns a custom metric by name, which has all the labels
+func (this *Client) GetMetric(name string, labels ...gopi.MetricLabel) (*gopi.Metric, error) {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+
+	if reply, err := this.MetricsClient.GetMetric(this.NewContext(), &pb.GetMetricRequest{Name: name, Labels: toProtoLabels(labels)}); err != nil {
+		return nil, err
+	} else {
+		return fromProtoMetric(reply), nil
+	}
+}
+
+// WatchMetrics sends updates on a channel at an interval, until done
+// is signalled or the stream ends, and returns when done is signalled
+// even if updates are not being received. Host statistics are only
+// included when host_stats is true
+func (this *Client) WatchMetrics(done <-chan struct{}, interval time.Duration, metric_type gopi.MetricType, host_stats bool, updates chan<- *Update) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := this.MetricsClient.WatchMetrics(ctx, &pb.WatchMetricsRequest{
+		Interval:  ptypes.DurationProto(interval),
+		Type:      pb.MetricType(metric_type),
+		HostStats: host_stats,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Cancel the stream when done is signalled
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		if reply, err := stream.Recv(); err == io.EOF {
+			return nil
+		} else if grpc.IsErrCanceled(err) {
+			return nil
+		} else if err != nil {
+			return err
+		} else {
+			select {
+			case updates <- fromProtoWatchReply(reply):
+			case <-done:
+				return nil
+			}
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func fromProtoWatchReply(reply *pb.WatchMetricsReply) *Update {
+	update := &Update{
+		HostStats: fromProtoHostStats(reply.HostStats),
+		Metrics:   make([]*gopi.Metric, len(reply.Metric)),
+	}
+	if ts, err := ptypes.Timestamp(reply.Ts); err == nil {
+		update.Timestamp = ts
+	}
+	if host := reply.Host; host != nil {
+		update.Hostname = host.Hostname
+		update.HostUptime, _ = ptypes.Duration(host.HostUptime)
+		update.ServiceUptime, _ = ptypes.Duration(host.ServiceUptime)
+	}
+	if load := reply.LoadAverage; load != nil {
+		update.LoadAverage = [3]float64{load.Load1, load.Load5, load.Load15}
+	}
+	for i, metric := range reply.Metric {
+		update.Metrics[i] = fromProtoMetric(metric)
+	}
+	return update
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // STRINGIFY
 
@@ -0,0 +1,221 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package metrics
+
+import (
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+
+	// Protocol buffers
+	pb "github.com/djthorpe/gopi/rpc/protobuf/metrics"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// HostStats are the processor, memory, filesystem, network and
+// thermal statistics for a remote host
+type HostStats struct {
+	CPU         []gopi.MetricCPU
+	Memory      gopi.MetricMemory
+	Filesystems []gopi.MetricFilesystem
+	Network     []gopi.MetricNetwork
+	Thermal     []gopi.MetricThermal
+	Frequency   []gopi.MetricFrequency
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CUSTOM METRICS
+
+func toProtoMetric(metric *gopi.Metric) *pb.Metric {
+	if metric == nil {
+		return nil
+	}
+	reply := &pb.Metric{
+		Name:   metric.Name,
+		Type:   pb.MetricType(metric.Type),
+		Rate:   pb.MetricRate(metric.Rate),
+		Labels: toProtoLabels(metric.Labels),
+		Mean:   metric.Mean,
+		Total:  uint64(metric.Total),
+		Value:  metric.Value,
+		Summary: &pb.Metric_Summary{
+			Count:     uint64(metric.Summary.Count),
+			Min:       metric.Summary.Min,
+			Max:       metric.Summary.Max,
+			Quantiles: make([]*pb.Metric_Quantile, len(metric.Summary.Quantiles)),
+		},
+		Buckets: make([]*pb.Metric_Bucket, len(metric.Buckets)),
+		Count:   uint64(metric.Count),
+		Sum:     metric.Sum,
+	}
+	for i, q := range metric.Summary.Quantiles {
+		reply.Summary.Quantiles[i] = &pb.Metric_Quantile{Quantile: q.Quantile, Value: q.Value}
+	}
+	for i, bucket := range metric.Buckets {
+		reply.Buckets[i] = &pb.Metric_Bucket{UpperBound: bucket.UpperBound, Count: uint64(bucket.Count)}
+	}
+	return reply
+}
+
+func fromProtoMetric(metric *pb.Metric) *gopi.Metric {
+	if metric == nil {
+		return nil
+	}
+	reply := &gopi.Metric{
+		Name:    metric.Name,
+		Type:    gopi.MetricType(metric.Type),
+		Rate:    gopi.MetricRate(metric.Rate),
+		Labels:  fromProtoLabels(metric.Labels),
+		Mean:    metric.Mean,
+		Total:   uint(metric.Total),
+		Value:   metric.Value,
+		Buckets: make([]gopi.MetricBucket, len(metric.Buckets)),
+		Count:   uint(metric.Count),
+		Sum:     metric.Sum,
+	}
+	if summary := metric.Summary; summary != nil {
+		reply.Summary = gopi.MetricSummary{
+			Count:     uint(summary.Count),
+			Min:       summary.Min,
+			Max:       summary.Max,
+			Quantiles: make([]gopi.MetricQuantile, len(summary.Quantiles)),
+		}
+		for i, q := range summary.Quantiles {
+			reply.Summary.Quantiles[i] = gopi.MetricQuantile{Quantile: q.Quantile, Value: q.Value}
+		}
+	}
+	for i, bucket := range metric.Buckets {
+		reply.Buckets[i] = gopi.MetricBucket{UpperBound: bucket.UpperBound, Count: uint(bucket.Count)}
+	}
+	return reply
+}
+
+func toProtoLabels(labels []gopi.MetricLabel) []*pb.MetricLabel {
+	reply := make([]*pb.MetricLabel, len(labels))
+	for i, label := range labels {
+		reply[i] = &pb.MetricLabel{Name: label.Name, Value: label.Value}
+	}
+	return reply
+}
+
+func fromProtoLabels(labels []*pb.MetricLabel) []gopi.MetricLabel {
+	if len(labels) == 0 {
+		return nil
+	}
+	reply := make([]gopi.MetricLabel, len(labels))
+	for i, label := range labels {
+		reply[i] = gopi.MetricLabel{Name: label.Name, Value: label.Value}
+	}
+	return reply
+}
+
+// hasLabels returns true if a metric has all the labels
+func hasLabels(metric *gopi.Metric, labels []gopi.MetricLabel) bool {
+FOR_LOOP:
+	for _, label := range labels {
+		for _, other := range metric.Labels {
+			if label == other {
+				continue FOR_LOOP
+			}
+		}
+		return false
+	}
+	return true
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// HOST STATS
+
+// toProtoHostStats returns the host statistics, ignoring any
+// which cannot be read
+func toProtoHostStats(host gopi.HostMetrics) *pb.HostStatsReply {
+	reply := &pb.HostStatsReply{}
+	if cpus, err := host.CPU(); err == nil {
+		for _, cpu := range cpus {
+			reply.Cpu = append(reply.Cpu, &pb.HostStatsReply_CPU{
+				Name: cpu.Name, User: cpu.User, Nice: cpu.Nice, System: cpu.System, Idle: cpu.Idle,
+				Iowait: cpu.IOWait, Irq: cpu.IRQ, Steal: cpu.Steal, Usage: cpu.Usage,
+			})
+		}
+	}
+	if memory, err := host.Memory(); err == nil {
+		reply.Memory = &pb.HostStatsReply_Memory{
+			Total: memory.Total, Free: memory.Free, Available: memory.Available, Buffers: memory.Buffers,
+			Cached: memory.Cached, SwapTotal: memory.SwapTotal, SwapFree: memory.SwapFree,
+		}
+	}
+	if filesystems, err := host.Filesystems(); err == nil {
+		for _, fs := range filesystems {
+			reply.Filesystem = append(reply.Filesystem, &pb.HostStatsReply_Filesystem{
+				Device: fs.Device, Path: fs.Path, Type: fs.Type, Total: fs.Total, Free: fs.Free,
+				Available: fs.Available, Files: fs.Files, FilesFree: fs.FilesFree,
+			})
+		}
+	}
+	if interfaces, err := host.Network(); err == nil {
+		for _, iface := range interfaces {
+			reply.Network = append(reply.Network, &pb.HostStatsReply_Network{
+				Name: iface.Name, RxBytes: iface.RxBytes, TxBytes: iface.TxBytes, RxPackets: iface.RxPackets,
+				TxPackets: iface.TxPackets, RxErrors: iface.RxErrors, TxErrors: iface.TxErrors,
+				RxRate: iface.RxRate, TxRate: iface.TxRate,
+			})
+		}
+	}
+	if zones, err := host.Thermal(); err == nil {
+		for _, zone := range zones {
+			reply.Thermal = append(reply.Thermal, &pb.HostStatsReply_Thermal{Name: zone.Name, Type: zone.Type, Celcius: zone.Celcius})
+		}
+	}
+	if cpus, err := host.Frequency(); err == nil {
+		for _, cpu := range cpus {
+			reply.Frequency = append(reply.Frequency, &pb.HostStatsReply_Frequency{Name: cpu.Name, Current: cpu.Current, Minimum: cpu.Minimum, Maximum: cpu.Maximum})
+		}
+	}
+	return reply
+}
+
+func fromProtoHostStats(stats *pb.HostStatsReply) *HostStats {
+	if stats == nil {
+		return nil
+	}
+	reply := &HostStats{}
+	for _, cpu := range stats.Cpu {
+		reply.CPU = append(reply.CPU, gopi.MetricCPU{
+			Name: cpu.Name, User: cpu.User, Nice: cpu.Nice, System: cpu.System, Idle: cpu.Idle,
+			IOWait: cpu.Iowait, IRQ: cpu.Irq, Steal: cpu.Steal, Usage: cpu.Usage,
+		})
+	}
+	if memory := stats.Memory; memory != nil {
+		reply.Memory = gopi.MetricMemory{
+			Total: memory.Total, Free: memory.Free, Available: memory.Available, Buffers: memory.Buffers,
+			Cached: memory.Cached, SwapTotal: memory.SwapTotal, SwapFree: memory.SwapFree,
+		}
+	}
+	for _, fs := range stats.Filesystem {
+		reply.Filesystems = append(reply.Filesystems, gopi.MetricFilesystem{
+			Device: fs.Device, Path: fs.Path, Type: fs.Type, Total: fs.Total, Free: fs.Free,
+			Available: fs.Available, Files: fs.Files, FilesFree: fs.FilesFree,
+		})
+	}
+	for _, iface := range stats.Network {
+		reply.Network = append(reply.Network, gopi.MetricNetwork{
+			Name: iface.Name, RxBytes: iface.RxBytes, TxBytes: iface.TxBytes, RxPackets: iface.RxPackets,
+			TxPackets: iface.TxPackets, RxErrors: iface.RxErrors, TxErrors: iface.TxErrors,
+			RxRate: iface.RxRate, TxRate: iface.TxRate,
+		})
+	}
+	for _, zone := range stats.Thermal {
+		reply.Thermal = append(reply.Thermal, gopi.MetricThermal{Name: zone.Name, Type: zone.Type, Celcius: zone.Celcius})
+	}
+	for _, cpu := range stats.Frequency {
+		reply.Frequency = append(reply.Frequency, gopi.MetricFrequency{Name: cpu.Name, Current: cpu.Current, Minimum: cpu.Minimum, Maximum: cpu.Maximum})
+	}
+	return reply
+}
@@ -0,0 +1,11 @@
+package metrics
+
+// Export conversions for testing
+var (
+	ToProtoMetric      = toProtoMetric
+	FromProtoMetric    = fromProtoMetric
+	FromProtoLabels    = fromProtoLabels
+	HasLabels          = hasLabels
+	ToProtoHostStats   = toProtoHostStats
+	FromProtoHostStats = fromProtoHostStats
+)
@@ -11,22 +11,32 @@ package metrics
 import (
 	// Frameworks
 	"github.com/djthorpe/gopi"
+	"github.com/djthorpe/gopi/sys/rpc/grpc"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
 // INIT
 
 func init() {
-	// Register service/helloworld:grpc
+	// Register service/metrics:grpc
 	gopi.RegisterModule(gopi.Module{
 		Name:     "rpc/service/metrics:grpc",
 		Type:     gopi.MODULE_TYPE_SERVICE,
 		Requires: []string{"rpc/server", "metrics"},
+		Config: func(config *gopi.AppConfig) {
+			config.AppFlags.FlagString("metrics.allow", "", "Comma-separated list of method=identity pairs")
+		},
 		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
-			return gopi.Open(Service{
-				Server:  app.ModuleInstance("rpc/server").(gopi.RPCServer),
-				Metrics: app.ModuleInstance("metrics").(gopi.Metrics),
-			}, app.Logger)
+			allow, _ := app.AppFlags.GetString("metrics.allow")
+			if authorization, err := grpc.ParseMethodAuthorization(SERVICE_NAME, allow); err != nil {
+				return nil, err
+			} else {
+				return gopi.Open(Service{
+					Server:        app.ModuleInstance("rpc/server").(gopi.RPCServer),
+					Metrics:       app.ModuleInstance("metrics").(gopi.Metrics),
+					Authorization: authorization,
+				}, app.Logger)
+			}
 		},
 	})
 
@@ -40,7 +50,7 @@ func init() {
 			if clientpool == nil {
 				return gopi.ErrAppError
 			} else {
-				clientpool.RegisterClient("mutablelogic.Metrics", NewClient)
+				clientpool.RegisterClient(SERVICE_NAME, NewClient)
 				return nil
 			}
 		},
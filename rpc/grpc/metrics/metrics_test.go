@@ -0,0 +1,299 @@
+package metrics_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	mock "github.com/djthorpe/gopi/sys/hw/mock"
+	logger "github.com/djthorpe/gopi/sys/logger"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+	gogrpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+
+	// RPC Services
+	metrics "github.com/djthorpe/gopi/rpc/grpc/metrics"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// CLIENT
+
+func TestClient_000(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	host := openMetrics(t, log)
+	defer host.Close()
+	client, stop := openClient(t, log, host)
+	defer stop()
+
+	// The service is registered with the server, and responds to pings
+	if services, err := client.Conn().Services(); err != nil {
+		t.Error(err)
+	} else if hasService(services, "mutablelogic.Metrics") == false {
+		t.Error("Expected mutablelogic.Metrics service, got", services)
+	}
+	if err := client.Ping(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClient_001(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	host := openMetrics(t, log)
+	defer host.Close()
+	client, stop := openClient(t, log, host)
+	defer stop()
+
+	// Host metrics and load average
+	if reply, err := client.HostMetrics(); err != nil {
+		t.Error(err)
+	} else if reply.Hostname == "" || reply.HostUptime == nil || reply.ServiceUptime == nil {
+		t.Error("Unexpected host metrics", reply)
+	}
+	if load1, load5, load15, err := client.LoadAverage(); err != nil {
+		t.Error(err)
+	} else if load1 != 0.1 || load5 != 0.2 || load15 != 0.3 {
+		t.Error("Unexpected load average", load1, load5, load15)
+	}
+
+	// Host statistics are the same as the local statistics
+	if stats, err := client.HostStats(); err != nil {
+		t.Error(err)
+	} else if expected := hostStats(t, host.(gopi.HostMetrics)); reflect.DeepEqual(stats, expected) == false {
+		t.Errorf("Unexpected host statistics %+v, expected %+v", stats, expected)
+	}
+}
+
+func TestClient_002(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	host := openMetrics(t, log)
+	defer host.Close()
+	client, stop := openClient(t, log, host)
+	defer stop()
+
+	// Custom metrics are listed by type, and returned by name and labels
+	label := gopi.MetricLabel{Name: "device", Value: "sensor"}
+	if gauge, err := host.NewGauge(gopi.METRIC_RATE_MINUTE, "temperature", label); err != nil {
+		t.Fatal(err)
+	} else {
+		gauge.Set(21.5)
+	}
+	if histogram, err := host.NewHistogram(gopi.METRIC_RATE_MINUTE, "latency", []float64{0.1, 1}); err != nil {
+		t.Fatal(err)
+	} else {
+		histogram.Observe(0.5)
+	}
+	if list, err := client.ListMetrics(gopi.METRIC_TYPE_NONE); err != nil {
+		t.Error(err)
+	} else if len(list) != 2 {
+		t.Error("Unexpected metrics", list)
+	}
+	if list, err := client.ListMetrics(gopi.METRIC_TYPE_GAUGE); err != nil {
+		t.Error(err)
+	} else if len(list) != 1 || list[0].Name != "temperature" {
+		t.Error("Unexpected metrics", list)
+	}
+	if metric, err := client.GetMetric("temperature", label); err != nil {
+		t.Error(err)
+	} else if metric.Type != gopi.METRIC_TYPE_GAUGE || metric.Value != 21.5 {
+		t.Error("Unexpected metric", metric)
+	} else if len(metric.Labels) != 1 || metric.Labels[0] != label {
+		t.Error("Unexpected labels", metric.Labels)
+	}
+	if metric, err := client.GetMetric("latency"); err != nil {
+		t.Error(err)
+	} else if metric.Type != gopi.METRIC_TYPE_HISTOGRAM || metric.Count != 1 || metric.Sum != 0.5 {
+		t.Error("Unexpected metric", metric)
+	}
+
+	// Metrics which do not exist, or do not have the labels, are not found
+	if _, err := client.GetMetric("humidity"); gogrpc.Code(err) != codes.NotFound {
+		t.Error("Expected NotFound, got", err)
+	}
+	if _, err := client.GetMetric("temperature", gopi.MetricLabel{Name: "device", Value: "other"}); gogrpc.Code(err) != codes.NotFound {
+		t.Error("Expected NotFound, got", err)
+	}
+}
+
+func TestClient_003(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	host := openMetrics(t, log)
+	defer host.Close()
+	client, stop := openClient(t, log, host)
+	defer stop()
+
+	// Updates include host statistics when requested
+	done := make(chan struct{})
+	updates := make(chan *metrics.Update)
+	errs := make(chan error)
+	go func() {
+		errs <- client.WatchMetrics(done, metrics.MIN_WATCH_INTERVAL, gopi.METRIC_TYPE_NONE, true, updates)
+	}()
+	select {
+	case update := <-updates:
+		if update.Hostname == "" || update.Timestamp.IsZero() {
+			t.Error("Unexpected update", update)
+		} else if update.LoadAverage != [3]float64{0.1, 0.2, 0.3} {
+			t.Error("Unexpected load average", update.LoadAverage)
+		} else if update.HostStats == nil || len(update.HostStats.CPU) == 0 {
+			t.Error("Unexpected host statistics", update.HostStats)
+		}
+	case err := <-errs:
+		t.Fatal(err)
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for update")
+	}
+
+	// Watching returns when done is signalled, even when the
+	// updates are not being received
+	time.Sleep(2 * metrics.MIN_WATCH_INTERVAL)
+	close(done)
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for WatchMetrics to return")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONVERSIONS
+
+func TestConvert_000(t *testing.T) {
+	metric := &gopi.Metric{
+		Rate:   gopi.METRIC_RATE_HOUR,
+		Type:   gopi.METRIC_TYPE_HISTOGRAM,
+		Name:   "latency",
+		Labels: []gopi.MetricLabel{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}},
+		Mean:   1.5,
+		Total:  10,
+		Value:  2.5,
+		Summary: gopi.MetricSummary{
+			Count:     3,
+			Min:       0.5,
+			Max:       4,
+			Quantiles: []gopi.MetricQuantile{{Quantile: 0.5, Value: 1}, {Quantile: 0.99, Value: 4}},
+		},
+		Buckets: []gopi.MetricBucket{{UpperBound: 1, Count: 2}, {UpperBound: 5, Count: 3}},
+		Count:   3,
+		Sum:     6,
+	}
+	if other := metrics.FromProtoMetric(metrics.ToProtoMetric(metric)); reflect.DeepEqual(metric, other) == false {
+		t.Errorf("Unexpected metric %+v, expected %+v", other, metric)
+	}
+	if metrics.ToProtoMetric(nil) != nil || metrics.FromProtoMetric(nil) != nil {
+		t.Error("Expected nil metric")
+	}
+	if metrics.FromProtoLabels(nil) != nil {
+		t.Error("Expected nil labels")
+	}
+
+	// Metrics have all the labels
+	if metrics.HasLabels(metric, nil) == false {
+		t.Error("Expected metric to have no labels")
+	}
+	if metrics.HasLabels(metric, []gopi.MetricLabel{{Name: "b", Value: "2"}}) == false {
+		t.Error("Expected metric to have label")
+	}
+	if metrics.HasLabels(metric, []gopi.MetricLabel{{Name: "a", Value: "1"}, {Name: "b", Value: "1"}}) {
+		t.Error("Expected metric not to have labels")
+	}
+}
+
+func TestConvert_001(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	host := openMetrics(t, log)
+	defer host.Close()
+
+	if stats := metrics.FromProtoHostStats(metrics.ToProtoHostStats(host.(gopi.HostMetrics))); reflect.DeepEqual(stats, hostStats(t, host.(gopi.HostMetrics))) == false {
+		t.Error("Unexpected host statistics", stats)
+	}
+	if metrics.FromProtoHostStats(nil) != nil {
+		t.Error("Expected nil host statistics")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// UTILITY METHODS
+
+func openLogger(t *testing.T) gopi.Logger {
+	if log, err := gopi.Open(logger.Config{}, nil); err != nil {
+		t.Fatal("Unable to create logger driver")
+		return nil
+	} else {
+		return log.(gopi.Logger)
+	}
+}
+
+func openMetrics(t *testing.T, log gopi.Logger) gopi.Metrics {
+	if driver, err := gopi.Open(mock.Metrics{}, log); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return driver.(gopi.Metrics)
+	}
+}
+
+// openClient serves the metrics on an in-process server, and returns
+// a client and a function which closes the client and server
+func openClient(t *testing.T, log gopi.Logger, host gopi.Metrics) (*metrics.Client, func()) {
+	server, err := grpc.OpenMemoryServer(grpc.Server{}, log, func(server gopi.RPCServer) gopi.Config {
+		return metrics.Service{Server: server, Metrics: host}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := server.Conn(grpc.ClientConn{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := metrics.NewClient(conn).(*metrics.Client)
+
+	return client, func() {
+		conn.Close()
+		server.Close()
+	}
+}
+
+// hostStats returns the statistics for a host
+func hostStats(t *testing.T, host gopi.HostMetrics) *metrics.HostStats {
+	stats := &metrics.HostStats{}
+	var err error
+	if stats.CPU, err = host.CPU(); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Memory, err = host.Memory(); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Filesystems, err = host.Filesystems(); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Network, err = host.Network(); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Thermal, err = host.Thermal(); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Frequency, err = host.Frequency(); err != nil {
+		t.Fatal(err)
+	}
+	return stats
+}
+
+// hasService returns true if a service name is in a list of services
+func hasService(services []string, name string) bool {
+	for _, service := range services {
+		if service == name {
+			return true
+		}
+	}
+	return false
+}
@@ -11,12 +11,16 @@ package metrics
 import (
 	"fmt"
 	"os"
+	"sync"
+	"time"
 
 	// Frameworks
 	gopi "github.com/djthorpe/gopi"
 	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
 	"github.com/golang/protobuf/ptypes"
 	context "golang.org/x/net/context"
+	gogrpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
 
 	// Protocol buffers
 	pb "github.com/djthorpe/gopi/rpc/protobuf/metrics"
@@ -28,13 +32,32 @@ import (
 type Service struct {
 	Server  gopi.RPCServer
 	Metrics gopi.Metrics
+
+	// Identities which can call methods
+	Authorization grpc.Authorization
 }
 
 type service struct {
 	log     gopi.Logger
 	metrics gopi.Metrics
+
+	// Closed to end streaming requests
+	lock sync.Mutex
+	done chan struct{}
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Default and minimum interval for WatchMetrics
+	DEFAULT_WATCH_INTERVAL = time.Second
+	MIN_WATCH_INTERVAL     = 100 * time.Millisecond
+
+	// Name of the service, for authorization and clients
+	SERVICE_NAME = "mutablelogic.Metrics"
+)
+
 ////////////////////////////////////////////////////////////////////////////////
 // OPEN AND CLOSE
 
@@ -45,6 +68,16 @@ func (config Service) Open(log gopi.Logger) (gopi.Driver, error) {
 	this := new(service)
 	this.log = log
 	this.metrics = config.Metrics
+	this.done = make(chan struct{})
+
+	// Restrict methods to some identities
+	if len(config.Authorization) > 0 {
+		if authorizer, ok := config.Server.(grpc.GRPCAuthorizer); ok == false {
+			return nil, gopi.ErrNotImplemented
+		} else {
+			authorizer.Authorize(config.Authorization)
+		}
+	}
 
 	// Register service with GRPC server
 	pb.RegisterMetricsServer(config.Server.(grpc.GRPCServer).GRPCServer(), this)
@@ -66,7 +99,17 @@ func (this *service) Close() error {
 // RPCService implementation
 
 func (this *service) CancelRequests() error {
-	// No need to cancel any requests since none are streaming
+	this.log.Debug2("<grpc.metrics.service>CancelRequests{}")
+
+	// End any WatchMetrics requests
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	select {
+	case <-this.done:
+		// Already cancelled
+	default:
+		close(this.done)
+	}
 	return nil
 }
 
@@ -90,6 +133,104 @@ func (this *service) HostMetrics(ctx context.Context, request *pb.EmptyRequest)
 	}
 }
 
+func (this *service) LoadAverage(ctx context.Context, request *pb.EmptyRequest) (*pb.LoadAverageReply, error) {
+	load1, load5, load15 := this.metrics.LoadAverage()
+	return &pb.LoadAverageReply{Load1: load1, Load5: load5, Load15: load15}, nil
+}
+
+func (this *service) HostStats(ctx context.Context, request *pb.EmptyRequest) (*pb.HostStatsReply, error) {
+	if host, ok := this.metrics.(gopi.HostMetrics); ok == false {
+		return nil, gogrpc.Errorf(codes.Unimplemented, "Host statistics are not available")
+	} else {
+		return toProtoHostStats(host), nil
+	}
+}
+
+func (this *service) ListMetrics(ctx context.Context, request *pb.ListMetricsRequest) (*pb.ListMetricsReply, error) {
+	return &pb.ListMetricsReply{
+		Metric: this.listMetrics(gopi.MetricType(request.Type)),
+	}, nil
+}
+
+func (this *service) GetMetric(ctx context.Context, request *pb.GetMetricRequest) (*pb.Metric, error) {
+	labels := fromProtoLabels(request.Labels)
+	for _, metric := range this.metrics.Metrics(gopi.METRIC_TYPE_NONE) {
+		if metric.Name == request.Name && hasLabels(metric, labels) {
+			return toProtoMetric(metric), nil
+		}
+	}
+	return nil, gogrpc.Errorf(codes.NotFound, "Metric not found: %v", request.Name)
+}
+
+func (this *service) WatchMetrics(request *pb.WatchMetricsRequest, stream pb.Metrics_WatchMetricsServer) error {
+	this.log.Debug2("<grpc.metrics.service>WatchMetrics{ request=%v }", request)
+
+	// Determine the interval
+	interval := DEFAULT_WATCH_INTERVAL
+	if request.Interval != nil {
+		if duration, err := ptypes.Duration(request.Interval); err != nil {
+			return gogrpc.Errorf(codes.InvalidArgument, "Invalid interval: %v", err)
+		} else if duration < 0 {
+			return gogrpc.Errorf(codes.InvalidArgument, "Invalid interval: %v", duration)
+		} else if duration > 0 {
+			interval = duration
+		}
+	}
+	if interval < MIN_WATCH_INTERVAL {
+		interval = MIN_WATCH_INTERVAL
+	}
+
+	// Send an update immediately and then at each interval, until the
+	// client cancels or the service ends streaming requests
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if reply, err := this.watchReply(request); err != nil {
+			return err
+		} else if err := stream.Send(reply); err != nil {
+			return err
+		}
+		select {
+		case <-ticker.C:
+			continue
+		case <-stream.Context().Done():
+			return nil
+		case <-this.done:
+			return nil
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *service) listMetrics(metric_type gopi.MetricType) []*pb.Metric {
+	metrics := this.metrics.Metrics(metric_type)
+	reply := make([]*pb.Metric, len(metrics))
+	for i, metric := range metrics {
+		reply[i] = toProtoMetric(metric)
+	}
+	return reply
+}
+
+func (this *service) watchReply(request *pb.WatchMetricsRequest) (*pb.WatchMetricsReply, error) {
+	host, err := this.HostMetrics(nil, &pb.EmptyRequest{})
+	if err != nil {
+		return nil, err
+	}
+	load, _ := this.LoadAverage(nil, &pb.EmptyRequest{})
+	reply := &pb.WatchMetricsReply{
+		Ts:          ptypes.TimestampNow(),
+		Host:        host,
+		LoadAverage: load,
+		Metric:      this.listMetrics(gopi.MetricType(request.Type)),
+	}
+	if stats, ok := this.metrics.(gopi.HostMetrics); ok && request.HostStats {
+		reply.HostStats = toProtoHostStats(stats)
+	}
+	return reply, nil
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Stringify
 
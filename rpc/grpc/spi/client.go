@@ -0,0 +1,254 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package spi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	// Framework
+	gopi "github.com/djthorpe/gopi"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+	empty "github.com/golang/protobuf/ptypes/empty"
+
+	// Protocol buffers
+	pb "github.com/djthorpe/gopi/rpc/protobuf/spi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Client implements gopi.SPI for a remote device. The mode, speed and
+// bits per word are sent with each transfer. Errors from methods which
+// do not return an error are returned by Err
+type Client struct {
+	pb.SPIClient
+	conn gopi.RPCClientConn
+
+	lock     sync.Mutex
+	err      error
+	settings *pb.SPISettings
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+func NewClient(conn gopi.RPCClientConn) gopi.RPCClient {
+	return &Client{
+		SPIClient: pb.NewSPIClient(conn.(grpc.GRPCClientConn).GRPCConn()),
+		conn:      conn,
+	}
+}
+
+// NewContext returns a context with the connection timeout
+func (this *Client) NewContext() (context.Context, context.CancelFunc) {
+	if this.conn.Timeout() == 0 {
+		return context.WithCancel(context.Background())
+	} else {
+		return context.WithTimeout(context.Background(), this.conn.Timeout())
+	}
+}
+
+// Close does not disconnect
+func (this *Client) Close() error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PROPERTIES
+
+func (this *Client) Conn() gopi.RPCClientConn {
+	return this.conn
+}
+
+// Err returns and clears the last error from a method which does
+// not return an error
+func (this *Client) Err() error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	err := this.err
+	this.err = nil
+	return err
+}
+
+// Mode returns the SPI mode, or SPI_MODE_0
+func (this *Client) Mode() gopi.SPIMode {
+	if settings, err := this.getSettings(); err != nil {
+		this.setErr(err)
+		return gopi.SPI_MODE_0
+	} else {
+		return gopi.SPIMode(settings.Mode)
+	}
+}
+
+// MaxSpeedHz returns the SPI speed, or zero
+func (this *Client) MaxSpeedHz() uint32 {
+	if settings, err := this.getSettings(); err != nil {
+		this.setErr(err)
+		return 0
+	} else {
+		return settings.MaxSpeedHz
+	}
+}
+
+// BitsPerWord returns the bits per word, or zero
+func (this *Client) BitsPerWord() uint8 {
+	if settings, err := this.getSettings(); err != nil {
+		this.setErr(err)
+		return 0
+	} else {
+		return uint8(settings.BitsPerWord)
+	}
+}
+
+// SetMode sets the mode for subsequent transfers
+func (this *Client) SetMode(mode gopi.SPIMode) error {
+	if mode > gopi.SPI_MODE_3 {
+		return gopi.ErrBadParameter
+	} else {
+		return this.updateSettings(func(settings *pb.SPISettings) {
+			settings.Mode = uint32(mode)
+		})
+	}
+}
+
+// SetMaxSpeedHz sets the speed for subsequent transfers
+func (this *Client) SetMaxSpeedHz(speed uint32) error {
+	if speed == 0 {
+		return gopi.ErrBadParameter
+	} else {
+		return this.updateSettings(func(settings *pb.SPISettings) {
+			settings.MaxSpeedHz = speed
+		})
+	}
+}
+
+// SetBitsPerWord sets the bits per word for subsequent transfers
+func (this *Client) SetBitsPerWord(bits uint8) error {
+	if bits == 0 {
+		return gopi.ErrBadParameter
+	} else {
+		return this.updateSettings(func(settings *pb.SPISettings) {
+			settings.BitsPerWord = uint32(bits)
+		})
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CALLS
+
+func (this *Client) Ping() error {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+
+	if _, err := this.SPIClient.Ping(ctx, &empty.Empty{}); err != nil {
+		return err
+	} else {
+		return nil
+	}
+}
+
+// Transfer sends data and returns the data received
+func (this *Client) Transfer(send []byte) ([]byte, error) {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+
+	if settings, err := this.getSettings(); err != nil {
+		return nil, grpc.ErrorFromStatus(err)
+	} else if reply, err := this.SPIClient.Transfer(ctx, &pb.SPITransferRequest{Data: send, Settings: settings}); err != nil {
+		return nil, grpc.ErrorFromStatus(err)
+	} else {
+		return reply.Data, nil
+	}
+}
+
+func (this *Client) Read(length uint32) ([]byte, error) {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+
+	if settings, err := this.getSettings(); err != nil {
+		return nil, grpc.ErrorFromStatus(err)
+	} else if reply, err := this.SPIClient.Read(ctx, &pb.SPIReadRequest{Length: length, Settings: settings}); err != nil {
+		return nil, grpc.ErrorFromStatus(err)
+	} else {
+		return reply.Data, nil
+	}
+}
+
+func (this *Client) Write(send []byte) error {
+	this.conn.Lock()
+	defer this.conn.Unlock()
+	ctx, cancel := this.NewContext()
+	defer cancel()
+
+	if settings, err := this.getSettings(); err != nil {
+		return grpc.ErrorFromStatus(err)
+	} else if _, err := this.SPIClient.Write(ctx, &pb.SPITransferRequest{Data: send, Settings: settings}); err != nil {
+		return grpc.ErrorFromStatus(err)
+	} else {
+		return nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// getSettings returns a copy of the settings sent with each transfer,
+// which are the settings of the remote service until they are changed
+func (this *Client) getSettings() (*pb.SPISettings, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.settings == nil {
+		ctx, cancel := this.NewContext()
+		defer cancel()
+		if settings, err := this.SPIClient.Settings(ctx, &empty.Empty{}); err != nil {
+			return nil, err
+		} else {
+			this.settings = settings
+		}
+	}
+	return &pb.SPISettings{
+		Mode:        this.settings.Mode,
+		MaxSpeedHz:  this.settings.MaxSpeedHz,
+		BitsPerWord: this.settings.BitsPerWord,
+	}, nil
+}
+
+// updateSettings changes the settings sent with each transfer
+func (this *Client) updateSettings(update func(*pb.SPISettings)) error {
+	if settings, err := this.getSettings(); err != nil {
+		return grpc.ErrorFromStatus(err)
+	} else {
+		update(settings)
+		this.lock.Lock()
+		defer this.lock.Unlock()
+		this.settings = settings
+		return nil
+	}
+}
+
+func (this *Client) setErr(err error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.err = grpc.ErrorFromStatus(err)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Client) String() string {
+	return fmt.Sprintf("<grpc.spi.client>{ conn=%v }", this.conn)
+}
@@ -0,0 +1,204 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package spi
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+	empty "github.com/golang/protobuf/ptypes/empty"
+	context "golang.org/x/net/context"
+	gogrpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+
+	// Protocol buffers
+	pb "github.com/djthorpe/gopi/rpc/protobuf/spi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type Service struct {
+	Server gopi.RPCServer
+	SPI    gopi.SPI
+
+	// Identities which can call methods
+	Authorization grpc.Authorization
+}
+
+type service struct {
+	log gopi.Logger
+	spi gopi.SPI
+
+	// Settings for requests without settings
+	defaults *pb.SPISettings
+
+	// Settings are applied for each request, so requests are made
+	// one at a time
+	lock sync.Mutex
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Largest transfer, which is the default spidev buffer size
+	SPI_TRANSFER_MAX = 4096
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+// Open the server
+func (config Service) Open(log gopi.Logger) (gopi.Driver, error) {
+	log.Debug("<grpc.spi.service>Open{ server=%v spi=%v }", config.Server, config.SPI)
+
+	if config.SPI == nil {
+		return nil, gopi.ErrBadParameter
+	}
+
+	this := new(service)
+	this.log = log
+	this.spi = config.SPI
+	this.defaults = &pb.SPISettings{
+		Mode:        uint32(this.spi.Mode()),
+		MaxSpeedHz:  this.spi.MaxSpeedHz(),
+		BitsPerWord: uint32(this.spi.BitsPerWord()),
+	}
+
+	// Restrict methods to some identities
+	if len(config.Authorization) > 0 {
+		if authorizer, ok := config.Server.(grpc.GRPCAuthorizer); ok == false {
+			return nil, gopi.ErrNotImplemented
+		} else {
+			authorizer.Authorize(config.Authorization)
+		}
+	}
+
+	// Register service with GRPC server
+	pb.RegisterSPIServer(config.Server.(grpc.GRPCServer).GRPCServer(), this)
+
+	// Success
+	return this, nil
+}
+
+func (this *service) Close() error {
+	this.log.Debug("<grpc.spi.service>Close{}")
+
+	// No resources to release
+
+	// Success
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// RPCService implementation
+
+func (this *service) CancelRequests() error {
+	// No streaming requests to end
+	return nil
+}
+
+func (this *service) Ping(ctx context.Context, request *empty.Empty) (*empty.Empty, error) {
+	// Simple ping method to show server is "up"
+	return &empty.Empty{}, nil
+}
+
+func (this *service) Settings(ctx context.Context, request *empty.Empty) (*pb.SPISettings, error) {
+	return this.defaults, nil
+}
+
+func (this *service) Transfer(ctx context.Context, request *pb.SPITransferRequest) (*pb.SPITransferReply, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if len(request.Data) == 0 || len(request.Data) > SPI_TRANSFER_MAX {
+		return nil, gogrpc.Errorf(codes.InvalidArgument, "Invalid data length: %v", len(request.Data))
+	} else if err := this.setSettings(request.Settings); err != nil {
+		return nil, err
+	} else if data, err := this.spi.Transfer(request.Data); err != nil {
+		return nil, grpc.ErrorToStatus(err)
+	} else {
+		return &pb.SPITransferReply{Data: data}, nil
+	}
+}
+
+func (this *service) Read(ctx context.Context, request *pb.SPIReadRequest) (*pb.SPITransferReply, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if request.Length == 0 || request.Length > SPI_TRANSFER_MAX {
+		return nil, gogrpc.Errorf(codes.InvalidArgument, "Invalid length: %v", request.Length)
+	} else if err := this.setSettings(request.Settings); err != nil {
+		return nil, err
+	} else if data, err := this.spi.Read(request.Length); err != nil {
+		return nil, grpc.ErrorToStatus(err)
+	} else {
+		return &pb.SPITransferReply{Data: data}, nil
+	}
+}
+
+func (this *service) Write(ctx context.Context, request *pb.SPITransferRequest) (*empty.Empty, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if len(request.Data) == 0 || len(request.Data) > SPI_TRANSFER_MAX {
+		return nil, gogrpc.Errorf(codes.InvalidArgument, "Invalid data length: %v", len(request.Data))
+	} else if err := this.setSettings(request.Settings); err != nil {
+		return nil, err
+	} else if err := this.spi.Write(request.Data); err != nil {
+		return nil, grpc.ErrorToStatus(err)
+	} else {
+		return &empty.Empty{}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// setSettings applies the settings for a request, or the default settings
+// when the request has none, and should be called with the lock held
+func (this *service) setSettings(settings *pb.SPISettings) error {
+	if settings == nil {
+		settings = this.defaults
+	} else if settings.Mode > uint32(gopi.SPI_MODE_3) {
+		return gogrpc.Errorf(codes.InvalidArgument, "Invalid mode: %v", settings.Mode)
+	} else if settings.MaxSpeedHz == 0 {
+		return gogrpc.Errorf(codes.InvalidArgument, "Invalid speed: %v", settings.MaxSpeedHz)
+	} else if settings.BitsPerWord == 0 || settings.BitsPerWord > math.MaxUint8 {
+		return gogrpc.Errorf(codes.InvalidArgument, "Invalid bits per word: %v", settings.BitsPerWord)
+	}
+	if mode := gopi.SPIMode(settings.Mode); this.spi.Mode() != mode {
+		if err := this.spi.SetMode(mode); err != nil {
+			return grpc.ErrorToStatus(err)
+		}
+	}
+	if this.spi.MaxSpeedHz() != settings.MaxSpeedHz {
+		if err := this.spi.SetMaxSpeedHz(settings.MaxSpeedHz); err != nil {
+			return grpc.ErrorToStatus(err)
+		}
+	}
+	if bits := uint8(settings.BitsPerWord); this.spi.BitsPerWord() != bits {
+		if err := this.spi.SetBitsPerWord(bits); err != nil {
+			return grpc.ErrorToStatus(err)
+		}
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Stringify
+
+func (this *service) String() string {
+	return fmt.Sprintf("grpc.spi.service{ spi=%v }", this.spi)
+}
@@ -0,0 +1,241 @@
+package spi_test
+
+import (
+	"testing"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	logger "github.com/djthorpe/gopi/sys/logger"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+
+	// RPC Services
+	spi "github.com/djthorpe/gopi/rpc/grpc/spi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// CLIENT
+
+func TestClient_000(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	device := &device{mode: gopi.SPI_MODE_0, speed: 500000, bits: 8}
+	client, stop := openClient(t, log, device)
+	defer stop()
+
+	// The service is registered with the server, and responds to pings
+	if services, err := client.Conn().Services(); err != nil {
+		t.Error(err)
+	} else if hasService(services, "mutablelogic.SPI") == false {
+		t.Error("Expected mutablelogic.SPI service, got", services)
+	}
+	if err := client.Ping(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClient_001(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	device := &device{mode: gopi.SPI_MODE_0, speed: 500000, bits: 8}
+	client, stop := openClient(t, log, device)
+	defer stop()
+
+	// Settings
+	if mode := client.Mode(); mode != gopi.SPI_MODE_0 {
+		t.Error("Unexpected mode", mode)
+	}
+	if speed := client.MaxSpeedHz(); speed != 500000 {
+		t.Error("Unexpected speed", speed)
+	}
+	if bits := client.BitsPerWord(); bits != 8 {
+		t.Error("Unexpected bits per word", bits)
+	}
+	if err := client.Err(); err != nil {
+		t.Error(err)
+	}
+	if err := client.SetMode(gopi.SPI_MODE_3); err != nil {
+		t.Error(err)
+	} else if mode := client.Mode(); mode != gopi.SPI_MODE_3 {
+		t.Error("Unexpected mode", mode)
+	}
+	if err := client.SetMaxSpeedHz(1000000); err != nil {
+		t.Error(err)
+	} else if speed := client.MaxSpeedHz(); speed != 1000000 {
+		t.Error("Unexpected speed", speed)
+	}
+	if err := client.SetBitsPerWord(16); err != nil {
+		t.Error(err)
+	} else if bits := client.BitsPerWord(); bits != 16 {
+		t.Error("Unexpected bits per word", bits)
+	}
+
+	// Transfers
+	if err := client.Write([]byte{1, 2, 3}); err != nil {
+		t.Error(err)
+	} else if data, err := client.Read(2); err != nil {
+		t.Error(err)
+	} else if string(data) != string([]byte{1, 2}) {
+		t.Error("Unexpected data", data)
+	}
+	if data, err := client.Transfer([]byte{4, 5}); err != nil {
+		t.Error(err)
+	} else if string(data) != string([]byte{3, 0}) {
+		t.Error("Unexpected data", data)
+	}
+}
+
+func TestClient_002(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	device := &device{mode: gopi.SPI_MODE_0, speed: 500000, bits: 8}
+	client, stop := openClient(t, log, device)
+	defer stop()
+
+	if err := client.SetMode(gopi.SPIMode(0xFF)); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	if err := client.SetMaxSpeedHz(0); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	if _, err := client.Read(0); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	if _, err := client.Read(spi.SPI_TRANSFER_MAX + 1); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	if err := client.Write(nil); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	if err := client.Write(make([]byte, spi.SPI_TRANSFER_MAX+1)); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+
+	// Settings which the device does not support fail on transfer
+	if err := client.SetBitsPerWord(9); err != nil {
+		t.Error(err)
+	} else if err := client.Write([]byte{1}); err != gopi.ErrNotImplemented {
+		t.Error("Expected ErrNotImplemented, got", err)
+	}
+}
+
+func TestClient_003(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	device := &device{mode: gopi.SPI_MODE_0, speed: 500000, bits: 8}
+	client, stop := openClient(t, log, device)
+	defer stop()
+	other := spi.NewClient(client.Conn()).(*spi.Client)
+
+	// Settings are sent with each transfer, so settings changed by one
+	// client are not used for transfers by another client
+	if err := client.SetMode(gopi.SPI_MODE_3); err != nil {
+		t.Fatal(err)
+	} else if err := client.Write([]byte{1}); err != nil {
+		t.Error(err)
+	} else if device.mode != gopi.SPI_MODE_3 {
+		t.Error("Unexpected mode", device.mode)
+	}
+	if err := other.Write([]byte{2}); err != nil {
+		t.Error(err)
+	} else if device.mode != gopi.SPI_MODE_0 {
+		t.Error("Unexpected mode", device.mode)
+	} else if mode := other.Mode(); mode != gopi.SPI_MODE_0 {
+		t.Error("Unexpected mode", mode)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// DEVICE
+
+// device returns data which was written, and returns ErrNotImplemented
+// for bits per word other than 8 and 16
+type device struct {
+	mode  gopi.SPIMode
+	speed uint32
+	bits  uint8
+	data  []byte
+}
+
+func (this *device) Close() error       { return nil }
+func (this *device) Mode() gopi.SPIMode { return this.mode }
+func (this *device) MaxSpeedHz() uint32 { return this.speed }
+func (this *device) BitsPerWord() uint8 { return this.bits }
+func (this *device) SetMaxSpeedHz(speed uint32) error {
+	this.speed = speed
+	return nil
+}
+func (this *device) SetMode(mode gopi.SPIMode) error {
+	this.mode = mode
+	return nil
+}
+
+func (this *device) SetBitsPerWord(bits uint8) error {
+	if bits != 8 && bits != 16 {
+		return gopi.ErrNotImplemented
+	}
+	this.bits = bits
+	return nil
+}
+
+func (this *device) Transfer(send []byte) ([]byte, error) {
+	data, err := this.Read(uint32(len(send)))
+	this.data = append(this.data, send...)
+	return data, err
+}
+
+func (this *device) Read(length uint32) ([]byte, error) {
+	data := make([]byte, length)
+	n := copy(data, this.data)
+	this.data = this.data[n:]
+	return data, nil
+}
+
+func (this *device) Write(send []byte) error {
+	this.data = append(this.data, send...)
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// UTILITY METHODS
+
+func openLogger(t *testing.T) gopi.Logger {
+	if log, err := gopi.Open(logger.Config{}, nil); err != nil {
+		t.Fatal("Unable to create logger driver")
+		return nil
+	} else {
+		return log.(gopi.Logger)
+	}
+}
+
+// openClient serves the device on an in-process server, and returns
+// a client and a function which closes the client and server
+func openClient(t *testing.T, log gopi.Logger, device gopi.SPI) (*spi.Client, func()) {
+	server, err := grpc.OpenMemoryServer(grpc.Server{}, log, func(server gopi.RPCServer) gopi.Config {
+		return spi.Service{Server: server, SPI: device}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := server.Conn(grpc.ClientConn{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := spi.NewClient(conn).(*spi.Client)
+
+	return client, func() {
+		client.Close()
+		conn.Close()
+		server.Close()
+	}
+}
+
+// hasService returns true if a service name is in a list of services
+func hasService(services []string, name string) bool {
+	for _, service := range services {
+		if service == name {
+			return true
+		}
+	}
+	return false
+}
@@ -11,6 +11,10 @@ package protobuf
 
 //go:generate protoc helloworld/helloworld.proto --go_out=plugins=grpc:.
 //go:generate protoc metrics/metrics.proto --go_out=plugins=grpc:.
+//go:generate protoc gpio/gpio.proto --go_out=plugins=grpc:.
+//go:generate protoc i2c/i2c.proto --go_out=plugins=grpc:.
+//go:generate protoc spi/spi.proto --go_out=plugins=grpc:.
+//go:generate protoc input/input.proto --go_out=plugins=grpc:.
 
 /*
 	This folder contains all the protocol buffer definitions including
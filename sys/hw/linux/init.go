@@ -98,12 +98,39 @@ func init() {
 		},
 	})
 
+	// Register Watchdog
+	gopi.RegisterModule(gopi.Module{
+		Name: "linux/watchdog",
+		Type: gopi.MODULE_TYPE_WATCHDOG,
+		Config: func(config *gopi.AppConfig) {
+			config.AppFlags.FlagString("watchdog.device", DEFAULT_WATCHDOG_DEVICE, "Watchdog device")
+			config.AppFlags.FlagDuration("watchdog.timeout", 0, "Watchdog timeout, or zero for the device default")
+		},
+		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
+			device, _ := app.AppFlags.GetString("watchdog.device")
+			timeout, _ := app.AppFlags.GetDuration("watchdog.timeout")
+			return gopi.Open(Watchdog{
+				Device:  device,
+				Timeout: timeout,
+			}, app.Logger)
+		},
+	})
+
 	// Register Metrics
 	gopi.RegisterModule(gopi.Module{
 		Name: "metrics",
 		Type: gopi.MODULE_TYPE_OTHER,
+		Config: func(config *gopi.AppConfig) {
+			config.AppFlags.FlagString("metrics.store", "", "Folder for storing metrics history")
+			config.AppFlags.FlagDuration("metrics.interval", 0, "Interval between recording metrics history")
+		},
 		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
-			return gopi.Open(Metrics{}, app.Logger)
+			store, _ := app.AppFlags.GetString("metrics.store")
+			interval, _ := app.AppFlags.GetDuration("metrics.interval")
+			return gopi.Open(Metrics{
+				Store:    store,
+				Interval: interval,
+			}, app.Logger)
 		},
 	})
 }
@@ -13,23 +13,50 @@ package linux
 
 import (
 	"fmt"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/djthorpe/gopi"
+	counter "github.com/djthorpe/gopi/util/metrics"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
 // TYPES
 
-type Metrics struct{}
+type Metrics struct {
+	// Root of the proc and sys filesystems, which default
+	// to /proc and /sys
+	Proc string
+	Sys  string
+
+	// Folder for storing metric history, and the interval between
+	// recording metrics
+	Store    string
+	Interval time.Duration
+}
 
 type metrics struct {
-	log gopi.Logger
+	log      gopi.Logger
+	registry *counter.Registry
+	store    *counter.Store
+	recorder *counter.Recorder
+	proc     string
+	sys      string
+
+	// Previous samples for calculating utilisation and rates
+	lock    sync.Mutex
+	cpu     map[string]cpuSample
+	network map[string]networkSample
 }
 
 ////////////////////////////////////////////////////////////////////////////////
-// GLOBAL VARIABLES
+// CONSTANTS AND GLOBAL VARIABLES
+
+const (
+	DEFAULT_PROC_PATH = "/proc"
+	DEFAULT_SYS_PATH  = "/sys"
+)
 
 var (
 	// Timestamp for module creation
@@ -41,11 +68,32 @@ var (
 
 // Open creates a new metrics object, returns error if not possible
 func (config Metrics) Open(log gopi.Logger) (gopi.Driver, error) {
-	log.Debug("<sys.hw.linux.Metrics>Open{}")
+	log.Debug("<sys.hw.linux.Metrics>Open{ proc='%v' sys='%v' store='%v' interval=%v }", config.Proc, config.Sys, config.Store, config.Interval)
 
 	// create new driver
 	this := new(metrics)
 	this.log = log
+	this.registry = counter.NewRegistry()
+	this.proc = DEFAULT_PROC_PATH
+	this.sys = DEFAULT_SYS_PATH
+	if config.Proc != "" {
+		this.proc = config.Proc
+	}
+	if config.Sys != "" {
+		this.sys = config.Sys
+	}
+	this.cpu = make(map[string]cpuSample)
+	this.network = make(map[string]networkSample)
+
+	// Record metrics history
+	if config.Store != "" {
+		if store, err := counter.OpenStore(config.Store); err != nil {
+			return nil, err
+		} else {
+			this.store = store
+			this.recorder = counter.NewRecorder(store, this, config.Interval, log)
+		}
+	}
 
 	// return driver
 	return this, nil
@@ -54,7 +102,25 @@ func (config Metrics) Open(log gopi.Logger) (gopi.Driver, error) {
 // Close connection
 func (this *metrics) Close() error {
 	this.log.Debug("<sys.hw.linux.Metrics>Close{}")
-	return nil
+
+	// Stop recording and close the store
+	var result error
+	if this.recorder != nil {
+		this.recorder.Close()
+		if err := this.store.Close(); err != nil {
+			result = err
+		}
+	}
+
+	// Close all counter channels
+	this.registry.Close()
+
+	// Release resources
+	this.registry = nil
+	this.recorder = nil
+	this.store = nil
+
+	return result
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -83,6 +149,32 @@ func (this *metrics) LoadAverage() (float64, float64, float64) {
 	}
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// RATE METRICS INTERFACE IMPLEMENTATION
+
+func (this *metrics) NewCounter(metric_type gopi.MetricType, metric_rate gopi.MetricRate, name string, labels ...gopi.MetricLabel) (chan<- uint, error) {
+	this.log.Debug2("<sys.hw.linux.Metrics>NewCounter{ type=%v rate=%v name='%v' labels=%v }", metric_type, metric_rate, name, labels)
+	return this.registry.NewCounter(metric_type, metric_rate, name, labels...)
+}
+
+func (this *metrics) NewGauge(metric_rate gopi.MetricRate, name string, labels ...gopi.MetricLabel) (gopi.MetricGauge, error) {
+	this.log.Debug2("<sys.hw.linux.Metrics>NewGauge{ rate=%v name='%v' labels=%v }", metric_rate, name, labels)
+	return this.registry.NewGauge(metric_rate, name, labels...)
+}
+
+func (this *metrics) NewHistogram(metric_rate gopi.MetricRate, name string, bounds []float64, labels ...gopi.MetricLabel) (gopi.MetricHistogram, error) {
+	this.log.Debug2("<sys.hw.linux.Metrics>NewHistogram{ rate=%v name='%v' bounds=%v labels=%v }", metric_rate, name, bounds, labels)
+	return this.registry.NewHistogram(metric_rate, name, bounds, labels...)
+}
+
+func (this *metrics) Metric(counter chan<- uint) *gopi.Metric {
+	return this.registry.Metric(counter)
+}
+
+func (this *metrics) Metrics(metric_type gopi.MetricType) []*gopi.Metric {
+	return this.registry.Metrics(metric_type)
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // GET SYSTEM INFO STRUCTURE
 
@@ -96,9 +188,21 @@ func (this *metrics) sysinfo() *syscall.Sysinfo_t {
 	}
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// METRIC HISTORY INTERFACE IMPLEMENTATION
+
+func (this *metrics) Series(from, to time.Time, interval time.Duration, name string, labels ...gopi.MetricLabel) ([]gopi.MetricSample, error) {
+	this.log.Debug2("<sys.hw.linux.Metrics>Series{ from=%v to=%v interval=%v name='%v' labels=%v }", from, to, interval, name, labels)
+	if this.store == nil {
+		// History is not being recorded
+		return nil, gopi.ErrNotImplemented
+	}
+	return this.store.Query(counter.Key(name, labels...), from, to, interval)
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // STRINGIFY
 
 func (this *metrics) String() string {
-	return fmt.Sprintf("<sys.hw.linux.Metrics>{}")
+	return fmt.Sprintf("<sys.hw.linux.Metrics>{ proc='%v' sys='%v' }", this.proc, this.sys)
 }
@@ -0,0 +1,367 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package linux
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// cpuSample is the time spent in each state, in clock ticks, in the
+// order user, nice, system, idle, iowait, irq, softirq, steal
+type cpuSample [8]uint64
+
+// networkSample is the byte counters for an interface at a time
+type networkSample struct {
+	ts      time.Time
+	rxbytes uint64
+	txbytes uint64
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// HOST METRICS INTERFACE IMPLEMENTATION
+
+// CPU returns utilisation for all processors and then each processor,
+// since the previous call or since boot on the first call
+func (this *metrics) CPU() ([]gopi.MetricCPU, error) {
+	file, err := os.Open(filepath.Join(this.proc, "stat"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	names, samples, err := parseStat(file)
+	if err != nil {
+		return nil, err
+	}
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	cpus := make([]gopi.MetricCPU, len(names))
+	for i, name := range names {
+		cpus[i] = cpuUtilisation(name, samples[i], this.cpu[name])
+		this.cpu[name] = samples[i]
+	}
+	return cpus, nil
+}
+
+// Memory returns memory and swap usage
+func (this *metrics) Memory() (gopi.MetricMemory, error) {
+	file, err := os.Open(filepath.Join(this.proc, "meminfo"))
+	if err != nil {
+		return gopi.MetricMemory{}, err
+	}
+	defer file.Close()
+	return parseMeminfo(file)
+}
+
+// Filesystems returns usage for mounted block devices
+func (this *metrics) Filesystems() ([]gopi.MetricFilesystem, error) {
+	file, err := os.Open(filepath.Join(this.proc, "mounts"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	filesystems, err := parseMounts(file)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]gopi.MetricFilesystem, 0, len(filesystems))
+	for _, fs := range filesystems {
+		var stat syscall.Statfs_t
+		if err := syscall.Statfs(fs.Path, &stat); err != nil {
+			this.log.Debug2("<sys.hw.linux.Metrics>Filesystems: %v: %v", fs.Path, err)
+			continue
+		}
+		fs.Total = stat.Blocks * uint64(stat.Bsize)
+		fs.Free = stat.Bfree * uint64(stat.Bsize)
+		fs.Available = stat.Bavail * uint64(stat.Bsize)
+		fs.Files = stat.Files
+		fs.FilesFree = stat.Ffree
+		result = append(result, fs)
+	}
+	return result, nil
+}
+
+// Network returns throughput for network interfaces, where rates are
+// since the previous call, or zero on the first call
+func (this *metrics) Network() ([]gopi.MetricNetwork, error) {
+	file, err := os.Open(filepath.Join(this.proc, "net", "dev"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	interfaces, err := parseNetDev(file)
+	if err != nil {
+		return nil, err
+	}
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	now := time.Now()
+	for i := range interfaces {
+		iface := &interfaces[i]
+		if prev, exists := this.network[iface.Name]; exists {
+			if seconds := now.Sub(prev.ts).Seconds(); seconds > 0 {
+				iface.RxRate = float64(delta(iface.RxBytes, prev.rxbytes)) / seconds
+				iface.TxRate = float64(delta(iface.TxBytes, prev.txbytes)) / seconds
+			}
+		}
+		this.network[iface.Name] = networkSample{now, iface.RxBytes, iface.TxBytes}
+	}
+	return interfaces, nil
+}
+
+// Thermal returns the temperature of thermal zones
+func (this *metrics) Thermal() ([]gopi.MetricThermal, error) {
+	zones, err := filepath.Glob(filepath.Join(this.sys, "class", "thermal", "thermal_zone*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(zones)
+	result := make([]gopi.MetricThermal, 0, len(zones))
+	for _, zone := range zones {
+		// Temperatures are in millidegrees, and can be negative
+		if temp, err := readInt(filepath.Join(zone, "temp")); err != nil {
+			this.log.Debug2("<sys.hw.linux.Metrics>Thermal: %v: %v", zone, err)
+		} else {
+			zone_type, _ := ioutil.ReadFile(filepath.Join(zone, "type"))
+			result = append(result, gopi.MetricThermal{
+				Name:    filepath.Base(zone),
+				Type:    strings.TrimSpace(string(zone_type)),
+				Celcius: float64(temp) / 1000,
+			})
+		}
+	}
+	return result, nil
+}
+
+// Frequency returns the current, minimum and maximum frequency
+// of each processor
+func (this *metrics) Frequency() ([]gopi.MetricFrequency, error) {
+	cpus, err := filepath.Glob(filepath.Join(this.sys, "devices", "system", "cpu", "cpu[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(cpus)
+	result := make([]gopi.MetricFrequency, 0, len(cpus))
+	for _, cpu := range cpus {
+		path := filepath.Join(cpu, "cpufreq")
+		if cur, err := readUint(filepath.Join(path, "scaling_cur_freq")); err != nil {
+			this.log.Debug2("<sys.hw.linux.Metrics>Frequency: %v: %v", cpu, err)
+		} else {
+			// Frequencies are in kHz
+			min, _ := readUint(filepath.Join(path, "scaling_min_freq"))
+			max, _ := readUint(filepath.Join(path, "scaling_max_freq"))
+			result = append(result, gopi.MetricFrequency{
+				Name:    filepath.Base(cpu),
+				Current: cur * 1000,
+				Minimum: min * 1000,
+				Maximum: max * 1000,
+			})
+		}
+	}
+	return result, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PARSE PROC FILES
+
+// parseStat returns the processor names and samples from /proc/stat
+func parseStat(r io.Reader) ([]string, []cpuSample, error) {
+	names := make([]string, 0)
+	samples := make([]cpuSample, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || strings.HasPrefix(fields[0], "cpu") == false {
+			continue
+		}
+		var sample cpuSample
+		for i := range sample {
+			// Older kernels do not report all the states
+			if i+1 >= len(fields) {
+				break
+			}
+			if value, err := strconv.ParseUint(fields[i+1], 10, 64); err != nil {
+				return nil, nil, fmt.Errorf("stat: %v: %v", fields[0], err)
+			} else {
+				sample[i] = value
+			}
+		}
+		names = append(names, fields[0])
+		samples = append(samples, sample)
+	}
+	return names, samples, scanner.Err()
+}
+
+// parseMeminfo returns memory and swap usage from /proc/meminfo, where
+// values are in kilobytes
+func parseMeminfo(r io.Reader) (gopi.MetricMemory, error) {
+	var memory gopi.MetricMemory
+	fields := map[string]*uint64{
+		"MemTotal":     &memory.Total,
+		"MemFree":      &memory.Free,
+		"MemAvailable": &memory.Available,
+		"Buffers":      &memory.Buffers,
+		"Cached":       &memory.Cached,
+		"SwapTotal":    &memory.SwapTotal,
+		"SwapFree":     &memory.SwapFree,
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.Fields(scanner.Text())
+		if len(line) < 2 {
+			continue
+		}
+		if field, exists := fields[strings.TrimSuffix(line[0], ":")]; exists {
+			if value, err := strconv.ParseUint(line[1], 10, 64); err != nil {
+				return memory, fmt.Errorf("meminfo: %v: %v", line[0], err)
+			} else if len(line) > 2 && line[2] == "kB" {
+				*field = value * 1024
+			} else {
+				*field = value
+			}
+		}
+	}
+	return memory, scanner.Err()
+}
+
+// parseMounts returns mounted block devices from /proc/mounts, without
+// the usage
+func parseMounts(r io.Reader) ([]gopi.MetricFilesystem, error) {
+	filesystems := make([]gopi.MetricFilesystem, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "/dev/") == false {
+			continue
+		}
+		filesystems = append(filesystems, gopi.MetricFilesystem{
+			Device: fields[0],
+			Path:   unescapeMount(fields[1]),
+			Type:   fields[2],
+		})
+	}
+	return filesystems, scanner.Err()
+}
+
+// parseNetDev returns the counters for network interfaces
+// from /proc/net/dev
+func parseNetDev(r io.Reader) ([]gopi.MetricNetwork, error) {
+	interfaces := make([]gopi.MetricNetwork, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		colon := strings.Index(line, ":")
+		if colon < 0 {
+			// Header lines
+			continue
+		}
+		fields := strings.Fields(line[colon+1:])
+		if len(fields) < 16 {
+			return nil, fmt.Errorf("net/dev: unexpected line: %v", line)
+		}
+		values := make([]uint64, len(fields))
+		for i, field := range fields {
+			if value, err := strconv.ParseUint(field, 10, 64); err != nil {
+				return nil, fmt.Errorf("net/dev: %v", err)
+			} else {
+				values[i] = value
+			}
+		}
+		interfaces = append(interfaces, gopi.MetricNetwork{
+			Name:      strings.TrimSpace(line[:colon]),
+			RxBytes:   values[0],
+			RxPackets: values[1],
+			RxErrors:  values[2],
+			TxBytes:   values[8],
+			TxPackets: values[9],
+			TxErrors:  values[10],
+		})
+	}
+	return interfaces, scanner.Err()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// cpuUtilisation returns the fraction of time in each state between
+// two samples
+func cpuUtilisation(name string, sample, prev cpuSample) gopi.MetricCPU {
+	var ticks [len(cpuSample{})]float64
+	total := float64(0)
+	for i := range sample {
+		ticks[i] = float64(delta(sample[i], prev[i]))
+		total += ticks[i]
+	}
+	cpu := gopi.MetricCPU{Name: name}
+	if total == 0 {
+		return cpu
+	}
+	cpu.User = ticks[0] / total
+	cpu.Nice = ticks[1] / total
+	cpu.System = ticks[2] / total
+	cpu.Idle = ticks[3] / total
+	cpu.IOWait = ticks[4] / total
+	cpu.IRQ = (ticks[5] + ticks[6]) / total
+	cpu.Steal = ticks[7] / total
+	cpu.Usage = 1 - cpu.Idle - cpu.IOWait
+	return cpu
+}
+
+// delta returns the difference between counters, or zero if the
+// counter has been reset
+func delta(value, prev uint64) uint64 {
+	if value < prev {
+		return 0
+	}
+	return value - prev
+}
+
+// readUint reads an unsigned integer from a sysfs file
+func readUint(path string) (uint64, error) {
+	if value, err := ioutil.ReadFile(path); err != nil {
+		return 0, err
+	} else {
+		return strconv.ParseUint(strings.TrimSpace(string(value)), 10, 64)
+	}
+}
+
+// readInt reads a signed integer from a sysfs file
+func readInt(path string) (int64, error) {
+	if value, err := ioutil.ReadFile(path); err != nil {
+		return 0, err
+	} else {
+		return strconv.ParseInt(strings.TrimSpace(string(value)), 10, 64)
+	}
+}
+
+// unescapeMount replaces octal escapes for spaces, tabs and
+// backslashes in mount paths
+func unescapeMount(path string) string {
+	return strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`).Replace(path)
+}
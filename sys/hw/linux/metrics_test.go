@@ -0,0 +1,144 @@
+// +build linux
+
+package linux_test
+
+import (
+	"math"
+	"testing"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	linux "github.com/djthorpe/gopi/sys/hw/linux"
+	logger "github.com/djthorpe/gopi/sys/logger"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// HOST METRICS
+
+func TestMetrics_000(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	metrics := openMetrics(t, log)
+	defer metrics.Close()
+
+	// Utilisation is since boot on the first sample
+	if cpus, err := metrics.(gopi.HostMetrics).CPU(); err != nil {
+		t.Fatal(err)
+	} else if len(cpus) != 3 || cpus[0].Name != "cpu" || cpus[2].Name != "cpu1" {
+		t.Error("Unexpected processors", cpus)
+	} else if equal(cpus[0].User, 0.4) == false || equal(cpus[0].System, 0.1) == false || equal(cpus[0].IOWait, 0.1) == false || equal(cpus[0].Usage, 0.5) == false {
+		t.Error("Unexpected utilisation", cpus[0])
+	}
+
+	// No time has passed since the previous sample
+	if cpus, err := metrics.(gopi.HostMetrics).CPU(); err != nil {
+		t.Fatal(err)
+	} else if cpus[0].Usage != 0 || cpus[0].Idle != 0 {
+		t.Error("Unexpected utilisation", cpus[0])
+	}
+}
+
+func TestMetrics_001(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	metrics := openMetrics(t, log)
+	defer metrics.Close()
+
+	if memory, err := metrics.(gopi.HostMetrics).Memory(); err != nil {
+		t.Fatal(err)
+	} else if memory.Total != 948304*1024 || memory.Available != 743652*1024 || memory.SwapFree != 102396*1024 {
+		t.Error("Unexpected memory", memory)
+	}
+}
+
+func TestMetrics_002(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	metrics := openMetrics(t, log)
+	defer metrics.Close()
+
+	// Only the root filesystem is expected to exist on the host
+	if filesystems, err := metrics.(gopi.HostMetrics).Filesystems(); err != nil {
+		t.Fatal(err)
+	} else if len(filesystems) == 0 || filesystems[0].Path != "/" || filesystems[0].Device != "/dev/root" {
+		t.Error("Unexpected filesystems", filesystems)
+	} else if filesystems[0].Total == 0 || filesystems[0].Available > filesystems[0].Total {
+		t.Error("Unexpected filesystem usage", filesystems[0])
+	}
+}
+
+func TestMetrics_003(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	metrics := openMetrics(t, log)
+	defer metrics.Close()
+
+	if interfaces, err := metrics.(gopi.HostMetrics).Network(); err != nil {
+		t.Fatal(err)
+	} else if len(interfaces) != 2 || interfaces[1].Name != "eth0" {
+		t.Error("Unexpected interfaces", interfaces)
+	} else if eth0 := interfaces[1]; eth0.RxBytes != 2000000 || eth0.TxBytes != 500000 || eth0.RxErrors != 1 || eth0.TxPackets != 900 || eth0.RxRate != 0 {
+		t.Error("Unexpected counters", eth0)
+	}
+}
+
+func TestMetrics_004(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	metrics := openMetrics(t, log)
+	defer metrics.Close()
+
+	if zones, err := metrics.(gopi.HostMetrics).Thermal(); err != nil {
+		t.Fatal(err)
+	} else if len(zones) != 2 || zones[0].Type != "cpu-thermal" || equal(zones[0].Celcius, 48.312) == false {
+		t.Error("Unexpected thermal zones", zones)
+	} else if equal(zones[1].Celcius, -5.25) == false {
+		t.Error("Expected negative temperature, got", zones[1])
+	}
+
+	// Processors without cpufreq are ignored
+	if cpus, err := metrics.(gopi.HostMetrics).Frequency(); err != nil {
+		t.Fatal(err)
+	} else if len(cpus) != 1 || cpus[0].Name != "cpu0" || cpus[0].Current != 600000000 || cpus[0].Maximum != 1200000000 {
+		t.Error("Unexpected frequencies", cpus)
+	}
+}
+
+func TestMetrics_005(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	metrics, err := gopi.Open(linux.Metrics{Proc: "testdata/missing"}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer metrics.Close()
+
+	if _, err := metrics.(gopi.HostMetrics).Memory(); err == nil {
+		t.Error("Expected error for missing proc filesystem")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func openLogger(t *testing.T) gopi.Logger {
+	if log, err := gopi.Open(logger.Config{}, nil); err != nil {
+		t.Fatal("Unable to create logger driver")
+		return nil
+	} else {
+		return log.(gopi.Logger)
+	}
+}
+
+func openMetrics(t *testing.T, log gopi.Logger) gopi.Metrics {
+	if driver, err := gopi.Open(linux.Metrics{Proc: "testdata/proc", Sys: "testdata/sys"}, log); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return driver.(gopi.Metrics)
+	}
+}
+
+func equal(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,210 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package linux
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Watchdog is the hardware watchdog, which resets the system when it
+// is not pinged within the timeout
+type Watchdog struct {
+	// Device, or DEFAULT_WATCHDOG_DEVICE when empty
+	Device string
+
+	// Timeout, which is rounded to seconds, or zero to keep the
+	// device timeout
+	Timeout time.Duration
+
+	// Ioctl calls on the device, or the ioctl system call when nil
+	Ioctl WatchdogIoctl
+}
+
+// WatchdogIoctl performs ioctl calls on the watchdog device, so that
+// a file can stand in for the device when testing
+type WatchdogIoctl interface {
+	Ioctl(fd uintptr, request uintptr, data unsafe.Pointer) error
+}
+
+type watchdog struct {
+	log     gopi.Logger
+	dev     *os.File
+	ioctl   WatchdogIoctl
+	timeout time.Duration
+	lock    sync.Mutex
+}
+
+type sys_ioctl struct{}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	DEFAULT_WATCHDOG_DEVICE = "/dev/watchdog"
+)
+
+const (
+	WDIOC_KEEPALIVE  = 0x80045705 /* _IOR('W', 5, int) */
+	WDIOC_SETTIMEOUT = 0xC0045706 /* _IOWR('W', 6, int) */
+	WDIOC_GETTIMEOUT = 0x80045707 /* _IOR('W', 7, int) */
+)
+
+const (
+	// Writing any character pings the watchdog, and writing the
+	// magic character before closing disables the watchdog
+	watchdog_ping  = 0x00
+	watchdog_magic = 'V'
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+// Open the watchdog device and set the timeout. The watchdog starts
+// when the device is opened
+func (config Watchdog) Open(log gopi.Logger) (gopi.Driver, error) {
+	log.Debug("<sys.hw.linux.Watchdog>Open{ device='%v' timeout=%v }", config.Device, config.Timeout)
+
+	this := new(watchdog)
+	this.log = log
+	this.ioctl = config.Ioctl
+	if this.ioctl == nil {
+		this.ioctl = sys_ioctl{}
+	}
+	device := config.Device
+	if device == "" {
+		device = DEFAULT_WATCHDOG_DEVICE
+	}
+	if config.Timeout < 0 || (config.Timeout > 0 && config.Timeout < time.Second) {
+		return nil, gopi.ErrBadParameter
+	}
+
+	// Open the device
+	if dev, err := os.OpenFile(device, os.O_WRONLY, 0); err != nil {
+		return nil, err
+	} else {
+		this.dev = dev
+	}
+
+	// Set the timeout, and read back the timeout which the device supports
+	if config.Timeout > 0 {
+		if err := this.setTimeout(config.Timeout); err != nil {
+			this.close()
+			return nil, err
+		}
+	}
+	if timeout, err := this.getTimeout(); err != nil {
+		this.close()
+		return nil, err
+	} else if timeout == 0 {
+		this.close()
+		return nil, fmt.Errorf("Watchdog: %v: Invalid timeout", device)
+	} else {
+		this.timeout = timeout
+	}
+
+	// Success
+	return this, nil
+}
+
+// Close the driver, and disable the watchdog with the magic character
+func (this *watchdog) Close() error {
+	this.log.Debug("<sys.hw.linux.Watchdog>Close{ }")
+	return this.close()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// WATCHDOG INTERFACE IMPLEMENTATION
+
+func (this *watchdog) Timeout() time.Duration {
+	return this.timeout
+}
+
+func (this *watchdog) Ping() error {
+	this.log.Debug2("<sys.hw.linux.Watchdog>Ping{ }")
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.dev == nil {
+		return gopi.ErrOutOfOrder
+	} else if _, err := this.dev.Write([]byte{watchdog_ping}); err != nil {
+		return err
+	} else {
+		return nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *watchdog) String() string {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.dev == nil {
+		return "<sys.hw.linux.Watchdog>{ nil }"
+	} else {
+		return fmt.Sprintf("<sys.hw.linux.Watchdog>{ device='%v' timeout=%v }", this.dev.Name(), this.timeout)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *watchdog) setTimeout(timeout time.Duration) error {
+	value := int32(timeout / time.Second)
+	if err := this.ioctl.Ioctl(this.dev.Fd(), WDIOC_SETTIMEOUT, unsafe.Pointer(&value)); err != nil {
+		return os.NewSyscallError("setTimeout", err)
+	}
+	return nil
+}
+
+func (this *watchdog) getTimeout() (time.Duration, error) {
+	var value int32
+	if err := this.ioctl.Ioctl(this.dev.Fd(), WDIOC_GETTIMEOUT, unsafe.Pointer(&value)); err != nil {
+		return 0, os.NewSyscallError("getTimeout", err)
+	}
+	return time.Duration(value) * time.Second, nil
+}
+
+// close the device, writing the magic character first so the
+// watchdog is disabled
+func (this *watchdog) close() error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.dev == nil {
+		return nil
+	}
+	_, err := this.dev.Write([]byte{watchdog_magic})
+	if err_ := this.dev.Close(); err == nil {
+		err = err_
+	}
+	this.dev = nil
+	return err
+}
+
+// Ioctl calls the ioctl system call
+func (sys_ioctl) Ioctl(fd uintptr, request uintptr, data unsafe.Pointer) error {
+	if _, _, err := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(data)); err != 0 {
+		return err
+	}
+	return nil
+}
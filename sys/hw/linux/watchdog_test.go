@@ -0,0 +1,138 @@
+// +build linux
+
+package linux_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	linux "github.com/djthorpe/gopi/sys/hw/linux"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// ioctl stands in for the watchdog device ioctl calls
+type ioctl struct {
+	timeout  int32
+	requests []uintptr
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// WATCHDOG
+
+func TestWatchdog_000(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	device := createDevice(t)
+	defer os.RemoveAll(filepath.Dir(device))
+
+	// Set the timeout
+	ioctl := &ioctl{timeout: 60}
+	driver, err := gopi.Open(linux.Watchdog{Device: device, Timeout: 15 * time.Second, Ioctl: ioctl}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	watchdog := driver.(gopi.Watchdog)
+	if watchdog.Timeout() != 15*time.Second {
+		t.Error("Unexpected timeout", watchdog.Timeout())
+	}
+	if len(ioctl.requests) != 2 || ioctl.requests[0] != linux.WDIOC_SETTIMEOUT || ioctl.requests[1] != linux.WDIOC_GETTIMEOUT {
+		t.Error("Unexpected requests", ioctl.requests)
+	}
+
+	// Ping twice and then close with the magic character
+	for i := 0; i < 2; i++ {
+		if err := watchdog.Ping(); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := watchdog.Close(); err != nil {
+		t.Error(err)
+	}
+	if data, err := ioutil.ReadFile(device); err != nil {
+		t.Error(err)
+	} else if string(data) != "\x00\x00V" {
+		t.Errorf("Unexpected data: %q", data)
+	}
+	if err := watchdog.Ping(); err != gopi.ErrOutOfOrder {
+		t.Error("Expected ErrOutOfOrder, got", err)
+	}
+}
+
+func TestWatchdog_001(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	device := createDevice(t)
+	defer os.RemoveAll(filepath.Dir(device))
+
+	// Keep the device timeout
+	ioctl := &ioctl{timeout: 60}
+	if driver, err := gopi.Open(linux.Watchdog{Device: device, Ioctl: ioctl}, log); err != nil {
+		t.Fatal(err)
+	} else if timeout := driver.(gopi.Watchdog).Timeout(); timeout != time.Minute {
+		t.Error("Unexpected timeout", timeout)
+	} else if err := driver.Close(); err != nil {
+		t.Error(err)
+	}
+
+	// Timeouts are in seconds
+	if _, err := gopi.Open(linux.Watchdog{Device: device, Timeout: time.Millisecond, Ioctl: ioctl}, log); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+
+	// A file which is not a watchdog device cannot have its timeout set,
+	// but is still closed with the magic character
+	if err := ioutil.WriteFile(device, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gopi.Open(linux.Watchdog{Device: device}, log); err == nil {
+		t.Error("Expected error for file which is not a watchdog")
+	}
+	if data, err := ioutil.ReadFile(device); err != nil {
+		t.Error(err)
+	} else if string(data) != "V" {
+		t.Errorf("Unexpected data: %q", data)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// IOCTL
+
+func (this *ioctl) Ioctl(fd uintptr, request uintptr, data unsafe.Pointer) error {
+	this.requests = append(this.requests, request)
+	switch request {
+	case linux.WDIOC_SETTIMEOUT:
+		this.timeout = *(*int32)(data)
+	case linux.WDIOC_GETTIMEOUT:
+		*(*int32)(data) = this.timeout
+	default:
+		return syscall.ENOTTY
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// createDevice returns the path to an empty file which stands
+// in for the watchdog device
+func createDevice(t *testing.T) string {
+	if path, err := ioutil.TempDir("", "watchdog"); err != nil {
+		t.Fatal(err)
+		return ""
+	} else {
+		device := filepath.Join(path, "watchdog")
+		if err := ioutil.WriteFile(device, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return device
+	}
+}
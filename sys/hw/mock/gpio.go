@@ -10,10 +10,12 @@
 package mock
 
 import (
-	// Frameworks
 	"fmt"
+	"sync"
 
+	// Frameworks
 	"github.com/djthorpe/gopi"
+	evt "github.com/djthorpe/gopi/util/event"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -25,6 +27,8 @@ type gpio struct {
 	log    gopi.Logger
 	pins   map[gopi.GPIOPin]*pinstate
 	pinmax uint
+	pubsub *evt.PubSub
+	lock   sync.Mutex
 }
 
 type pinstate struct {
@@ -32,6 +36,13 @@ type pinstate struct {
 	state    gopi.GPIOState
 	mode     gopi.GPIOMode
 	pull     gopi.GPIOPull
+	edge     gopi.GPIOEdge
+}
+
+type gpio_event struct {
+	driver *gpio
+	pin    gopi.GPIOPin
+	edge   gopi.GPIOEdge
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -88,6 +99,7 @@ func (config GPIO) Open(logger gopi.Logger) (gopi.Driver, error) {
 			state:    gopi.GPIO_LOW,
 			mode:     gopi.GPIO_ALT0,
 			pull:     gopi.GPIO_PULL_OFF,
+			edge:     gopi.GPIO_EDGE_NONE,
 		}
 		// set highest pin number
 		if k > this.pinmax {
@@ -95,6 +107,9 @@ func (config GPIO) Open(logger gopi.Logger) (gopi.Driver, error) {
 		}
 	}
 
+	// Event interface
+	this.pubsub = evt.NewPubSub(0)
+
 	// Success
 	return this, nil
 }
@@ -102,6 +117,11 @@ func (config GPIO) Open(logger gopi.Logger) (gopi.Driver, error) {
 // Close
 func (this *gpio) Close() error {
 	this.log.Debug("sys.mock.GPIO.Close{ }")
+
+	// Close subscriber channels
+	this.pubsub.Close()
+	this.pubsub = nil
+
 	return nil
 }
 
@@ -153,6 +173,8 @@ func (this *gpio) PhysicalPinForPin(logical gopi.GPIOPin) uint {
 
 // ReadPin reads pin state or returns LOW otherwise
 func (this *gpio) ReadPin(logical gopi.GPIOPin) gopi.GPIOState {
+	this.lock.Lock()
+	defer this.lock.Unlock()
 	if pin, ok := this.pins[logical]; ok {
 		return pin.state
 	} else {
@@ -161,17 +183,32 @@ func (this *gpio) ReadPin(logical gopi.GPIOPin) gopi.GPIOState {
 	}
 }
 
-// Write pin state
+// Write pin state, emitting an event when a watched edge occurs
 func (this *gpio) WritePin(logical gopi.GPIOPin, state gopi.GPIOState) {
+	edge := gopi.GPIO_EDGE_NONE
+	this.lock.Lock()
 	if pin, ok := this.pins[logical]; ok {
+		if pin.state == gopi.GPIO_LOW && state == gopi.GPIO_HIGH {
+			edge = pin.edge & gopi.GPIO_EDGE_RISING
+		} else if pin.state == gopi.GPIO_HIGH && state == gopi.GPIO_LOW {
+			edge = pin.edge & gopi.GPIO_EDGE_FALLING
+		}
 		pin.state = state
 	} else {
-		this.log.Error("sys.mock.GPIO: ReadPin on invalid logical pin %v", logical)
+		this.log.Error("sys.mock.GPIO: WritePin on invalid logical pin %v", logical)
+	}
+	this.lock.Unlock()
+
+	// Emit outside the lock, as subscribers may read pins
+	if edge != gopi.GPIO_EDGE_NONE {
+		this.Emit(logical, edge)
 	}
 }
 
 // Get pin mode
 func (this *gpio) GetPinMode(logical gopi.GPIOPin) gopi.GPIOMode {
+	this.lock.Lock()
+	defer this.lock.Unlock()
 	if pin, ok := this.pins[logical]; ok {
 		return pin.mode
 	} else {
@@ -182,6 +219,8 @@ func (this *gpio) GetPinMode(logical gopi.GPIOPin) gopi.GPIOMode {
 
 // Set pin mode
 func (this *gpio) SetPinMode(logical gopi.GPIOPin, mode gopi.GPIOMode) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
 	if pin, ok := this.pins[logical]; ok {
 		pin.mode = mode
 	} else {
@@ -190,10 +229,71 @@ func (this *gpio) SetPinMode(logical gopi.GPIOPin, mode gopi.GPIOMode) {
 }
 
 // Set pull mode
-func (this *gpio) SetPullMode(logical gopi.GPIOPin, pull gopi.GPIOPull) {
+func (this *gpio) SetPullMode(logical gopi.GPIOPin, pull gopi.GPIOPull) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
 	if pin, ok := this.pins[logical]; ok {
 		pin.pull = pull
+		return nil
 	} else {
 		this.log.Error("sys.mock.GPIO: SetPullMode on invalid logical pin %v", logical)
+		return gopi.ErrBadParameter
 	}
 }
+
+// Watch for rising and/or falling edges when pin state is written,
+// or stop watching when GPIO_EDGE_NONE is passed
+func (this *gpio) Watch(logical gopi.GPIOPin, edge gopi.GPIOEdge) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if pin, ok := this.pins[logical]; ok == false {
+		this.log.Error("sys.mock.GPIO: Watch on invalid logical pin %v", logical)
+		return gopi.ErrBadParameter
+	} else if edge > gopi.GPIO_EDGE_BOTH {
+		return gopi.ErrBadParameter
+	} else {
+		pin.edge = edge
+		return nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBSUB
+
+// Subscribe to events emitted
+func (this *gpio) Subscribe() <-chan gopi.Event {
+	return this.pubsub.Subscribe()
+}
+
+// Unsubscribe from events emitted
+func (this *gpio) Unsubscribe(subscriber <-chan gopi.Event) {
+	this.pubsub.Unsubscribe(subscriber)
+}
+
+// Emit an event
+func (this *gpio) Emit(pin gopi.GPIOPin, edge gopi.GPIOEdge) {
+	this.pubsub.Emit(&gpio_event{driver: this, pin: pin, edge: edge})
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// INTERFACE - EVENT
+
+func (this *gpio_event) Name() string {
+	return "GPIOEvent"
+}
+
+func (this *gpio_event) Source() gopi.Driver {
+	return this.driver
+}
+
+func (this *gpio_event) Pin() gopi.GPIOPin {
+	return this.pin
+}
+
+func (this *gpio_event) Edge() gopi.GPIOEdge {
+	return this.edge
+}
+
+func (this *gpio_event) String() string {
+	return fmt.Sprintf("sys.mock.GPIO.Event{ pin=%v edge=%v }", this.pin, this.edge)
+}
@@ -55,8 +55,17 @@ func init() {
 	gopi.RegisterModule(gopi.Module{
 		Name: "metrics",
 		Type: gopi.MODULE_TYPE_OTHER,
+		Config: func(config *gopi.AppConfig) {
+			config.AppFlags.FlagString("metrics.store", "", "Folder for storing metrics history")
+			config.AppFlags.FlagDuration("metrics.interval", 0, "Interval between recording metrics history")
+		},
 		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
-			return gopi.Open(Metrics{}, app.Logger)
+			store, _ := app.AppFlags.GetString("metrics.store")
+			interval, _ := app.AppFlags.GetDuration("metrics.interval")
+			return gopi.Open(Metrics{
+				Store:    store,
+				Interval: interval,
+			}, app.Logger)
 		},
 	})
 
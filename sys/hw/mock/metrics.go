@@ -21,20 +21,18 @@ import (
 ////////////////////////////////////////////////////////////////////////////////
 // TYPES
 
-type Metrics struct{}
+type Metrics struct {
+	// Folder for storing metric history, and the interval between
+	// recording metrics
+	Store    string
+	Interval time.Duration
+}
 
 type metrics struct {
 	log      gopi.Logger
-	counters map[chan<- uint]*metric
-}
-
-type metric struct {
-	Counter *counter.Counter
-	Name    string
-	Type    gopi.MetricType
-	Rate    gopi.MetricRate
-	Chan    chan uint
-	Done    chan struct{}
+	registry *counter.Registry
+	store    *counter.Store
+	recorder *counter.Recorder
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -50,12 +48,22 @@ var (
 
 // Open creates a new metrics object, returns error if not possible
 func (config Metrics) Open(log gopi.Logger) (gopi.Driver, error) {
-	log.Debug("<sys.hw.mock.Metrics>Open{}")
+	log.Debug("<sys.hw.mock.Metrics>Open{ store='%v' interval=%v }", config.Store, config.Interval)
 
 	// create new driver
 	this := new(metrics)
 	this.log = log
-	this.counters = make(map[chan<- uint]*metric, 0)
+	this.registry = counter.NewRegistry()
+
+	// Record metrics history
+	if config.Store != "" {
+		if store, err := counter.OpenStore(config.Store); err != nil {
+			return nil, err
+		} else {
+			this.store = store
+			this.recorder = counter.NewRecorder(store, this, config.Interval, log)
+		}
+	}
 
 	// return driver
 	return this, nil
@@ -65,17 +73,24 @@ func (config Metrics) Open(log gopi.Logger) (gopi.Driver, error) {
 func (this *metrics) Close() error {
 	this.log.Debug("<sys.hw.mock.Metrics>Close{}")
 
-	// Close all counter channels
-	for _, metric := range this.counters {
-		metric.Done <- gopi.DONE
-		<-metric.Done
-		close(metric.Chan)
+	// Stop recording and close the store
+	var result error
+	if this.recorder != nil {
+		this.recorder.Close()
+		if err := this.store.Close(); err != nil {
+			result = err
+		}
 	}
 
+	// Close all counter channels
+	this.registry.Close()
+
 	// Release resources
-	this.counters = nil
+	this.registry = nil
+	this.recorder = nil
+	this.store = nil
 
-	return nil
+	return result
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -94,63 +109,92 @@ func (this *metrics) LoadAverage() (float64, float64, float64) {
 	return 0.1, 0.2, 0.3
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// HOST METRICS INTERFACE IMPLEMENTATION
+
+func (this *metrics) CPU() ([]gopi.MetricCPU, error) {
+	// Output some fake numbers
+	return []gopi.MetricCPU{
+		gopi.MetricCPU{Name: "cpu", User: 0.2, System: 0.1, Idle: 0.7, Usage: 0.3},
+		gopi.MetricCPU{Name: "cpu0", User: 0.2, System: 0.1, Idle: 0.7, Usage: 0.3},
+	}, nil
+}
+
+func (this *metrics) Memory() (gopi.MetricMemory, error) {
+	// Output some fake numbers
+	return gopi.MetricMemory{
+		Total:     1 << 30,
+		Free:      1 << 28,
+		Available: 1 << 29,
+		SwapTotal: 1 << 27,
+		SwapFree:  1 << 27,
+	}, nil
+}
+
+func (this *metrics) Filesystems() ([]gopi.MetricFilesystem, error) {
+	// Output some fake numbers
+	return []gopi.MetricFilesystem{
+		gopi.MetricFilesystem{Device: "/dev/mmcblk0p2", Path: "/", Type: "ext4", Total: 1 << 34, Free: 1 << 33, Available: 1 << 33},
+	}, nil
+}
+
+func (this *metrics) Network() ([]gopi.MetricNetwork, error) {
+	// Output some fake numbers
+	return []gopi.MetricNetwork{
+		gopi.MetricNetwork{Name: "eth0", RxBytes: 2000, TxBytes: 1000, RxPackets: 20, TxPackets: 10, RxRate: 200, TxRate: 100},
+	}, nil
+}
+
+func (this *metrics) Thermal() ([]gopi.MetricThermal, error) {
+	// Output some fake numbers
+	return []gopi.MetricThermal{
+		gopi.MetricThermal{Name: "thermal_zone0", Type: "cpu-thermal", Celcius: 45.5},
+	}, nil
+}
+
+func (this *metrics) Frequency() ([]gopi.MetricFrequency, error) {
+	// Output some fake numbers
+	return []gopi.MetricFrequency{
+		gopi.MetricFrequency{Name: "cpu0", Current: 600000000, Minimum: 600000000, Maximum: 1200000000},
+	}, nil
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // RATE METRICS INTERFACE IMPLEMENTATION
 
-func (this *metrics) NewCounter(metric_type gopi.MetricType, metric_rate gopi.MetricRate, name string) (chan<- uint, error) {
-	this.log.Debug2("<sys.hw.mock.Metrics>NewCounter{ type=%v rate=%v name='%v' }", metric_type, metric_rate, name)
-
-	// Create a new counter, append to list of existing counters
-	if c := counter.NewCounter(metric_rate); c == nil {
-		return nil, gopi.ErrBadParameter
-	} else {
-		m := &metric{
-			Counter: c,
-			Name:    name,
-			Type:    metric_type,
-			Rate:    metric_rate,
-			Chan:    make(chan uint),
-			Done:    make(chan struct{}),
-		}
-		this.counters[m.Chan] = m
-		// Consume the value
-		go this.consume(m)
-		// Return the counter
-		return m.Chan, nil
-	}
+func (this *metrics) NewCounter(metric_type gopi.MetricType, metric_rate gopi.MetricRate, name string, labels ...gopi.MetricLabel) (chan<- uint, error) {
+	this.log.Debug2("<sys.hw.mock.Metrics>NewCounter{ type=%v rate=%v name='%v' labels=%v }", metric_type, metric_rate, name, labels)
+	return this.registry.NewCounter(metric_type, metric_rate, name, labels...)
+}
+
+func (this *metrics) NewGauge(metric_rate gopi.MetricRate, name string, labels ...gopi.MetricLabel) (gopi.MetricGauge, error) {
+	this.log.Debug2("<sys.hw.mock.Metrics>NewGauge{ rate=%v name='%v' labels=%v }", metric_rate, name, labels)
+	return this.registry.NewGauge(metric_rate, name, labels...)
+}
+
+func (this *metrics) NewHistogram(metric_rate gopi.MetricRate, name string, bounds []float64, labels ...gopi.MetricLabel) (gopi.MetricHistogram, error) {
+	this.log.Debug2("<sys.hw.mock.Metrics>NewHistogram{ rate=%v name='%v' bounds=%v labels=%v }", metric_rate, name, bounds, labels)
+	return this.registry.NewHistogram(metric_rate, name, bounds, labels...)
 }
 
 func (this *metrics) Metric(counter chan<- uint) *gopi.Metric {
-	if m, exists := this.counters[counter]; exists == false {
-		return nil
-	} else {
-		sum, samples, length := m.Counter.Sum()
-		return &gopi.Metric{
-			Rate:  m.Rate,
-			Type:  m.Type,
-			Name:  m.Name,
-			Mean:  float64(sum) / float64(samples),
-			Total: uint(float64(sum) * float64(length) / float64(samples)),
-		}
-	}
+	return this.registry.Metric(counter)
+}
+
+func (this *metrics) Metrics(metric_type gopi.MetricType) []*gopi.Metric {
+	return this.registry.Metrics(metric_type)
 }
 
 ////////////////////////////////////////////////////////////////////////////////
-// PRIVATE METHODS
-
-func (this *metrics) consume(m *metric) {
-FOR_LOOP:
-	for {
-		select {
-		case value := <-m.Chan:
-			if value > 0 {
-				m.Counter.Increment(time.Now(), value)
-			}
-		case <-m.Done:
-			break FOR_LOOP
-		}
+// METRIC HISTORY INTERFACE IMPLEMENTATION
+
+func (this *metrics) Series(from, to time.Time, interval time.Duration, name string, labels ...gopi.MetricLabel) ([]gopi.MetricSample, error) {
+	this.log.Debug2("<sys.hw.mock.Metrics>Series{ from=%v to=%v interval=%v name='%v' labels=%v }", from, to, interval, name, labels)
+	if this.store == nil {
+		// History is not being recorded
+		return nil, gopi.ErrNotImplemented
 	}
-	close(m.Done)
+	return this.store.Query(counter.Key(name, labels...), from, to, interval)
 }
 
 ////////////////////////////////////////////////////////////////////////////////
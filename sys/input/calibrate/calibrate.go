@@ -0,0 +1,129 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package calibrate
+
+import (
+	"fmt"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Matrix is an affine transformation from raw device coordinates to
+// display coordinates, where x' = m[0]*x + m[1]*y + m[2] and
+// y' = m[3]*x + m[4]*y + m[5]
+type Matrix [6]float32
+
+// Device is an input device which reports absolute positions and
+// which can be calibrated
+type Device interface {
+	gopi.InputDevice
+
+	// Return the minimum and maximum raw positions reported
+	RawRange() (gopi.Point, gopi.Point)
+
+	// Return the calibration
+	Calibration() Matrix
+
+	// Set the calibration applied to positions
+	SetCalibration(Matrix)
+}
+
+// Manager is an input manager which calibrates devices
+// and persists the calibrations
+type Manager interface {
+	gopi.InputManager
+
+	// Set the calibration for a device, and persist it
+	SetCalibration(gopi.InputDevice, Matrix) error
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	// Identity transformation, which passes raw coordinates through
+	Identity = Matrix{1, 0, 0, 0, 1, 0}
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTRUCT MATRICES
+
+// Translate returns a matrix which moves positions by (x,y)
+func Translate(x, y float32) Matrix {
+	return Matrix{1, 0, x, 0, 1, y}
+}
+
+// Scale returns a matrix which scales positions
+func Scale(x, y float32) Matrix {
+	return Matrix{x, 0, 0, 0, y, 0}
+}
+
+// SwapXY returns a matrix which swaps the X and Y axes
+func SwapXY() Matrix {
+	return Matrix{0, 1, 0, 1, 0, 0}
+}
+
+// InvertX returns a matrix which inverts the X axis within a width
+func InvertX(width float32) Matrix {
+	return Matrix{-1, 0, width, 0, 1, 0}
+}
+
+// InvertY returns a matrix which inverts the Y axis within a height
+func InvertY(height float32) Matrix {
+	return Matrix{1, 0, 0, 0, -1, height}
+}
+
+// ScaleToSize returns a matrix which scales a range of raw positions
+// to a size, or the identity matrix if the range is empty
+func ScaleToSize(min, max gopi.Point, size gopi.Size) Matrix {
+	if max.X <= min.X || max.Y <= min.Y || size.W <= 0 || size.H <= 0 {
+		return Identity
+	}
+	return Translate(-min.X, -min.Y).Then(Scale(size.W/(max.X-min.X), size.H/(max.Y-min.Y)))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// METHODS
+
+// Transform returns the transformed position
+func (m Matrix) Transform(pt gopi.Point) gopi.Point {
+	return gopi.Point{
+		m[0]*pt.X + m[1]*pt.Y + m[2],
+		m[3]*pt.X + m[4]*pt.Y + m[5],
+	}
+}
+
+// Then returns a matrix which applies this transformation
+// followed by another
+func (m Matrix) Then(n Matrix) Matrix {
+	return Matrix{
+		n[0]*m[0] + n[1]*m[3], n[0]*m[1] + n[1]*m[4], n[0]*m[2] + n[1]*m[5] + n[2],
+		n[3]*m[0] + n[4]*m[3], n[3]*m[1] + n[4]*m[4], n[3]*m[2] + n[4]*m[5] + n[5],
+	}
+}
+
+// IsIdentity returns true if the matrix is the identity matrix
+func (m Matrix) IsIdentity() bool {
+	return m == Identity
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (m Matrix) String() string {
+	if m.IsIdentity() {
+		return "<sys.input.calibrate.Matrix>{ identity }"
+	}
+	return fmt.Sprintf("<sys.input.calibrate.Matrix>{ [%v %v %v] [%v %v %v] }", m[0], m[1], m[2], m[3], m[4], m[5])
+}
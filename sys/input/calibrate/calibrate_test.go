@@ -0,0 +1,198 @@
+package calibrate_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	calibrate "github.com/djthorpe/gopi/sys/input/calibrate"
+	mock "github.com/djthorpe/gopi/sys/input/mock"
+	logger "github.com/djthorpe/gopi/sys/logger"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// calibratedDevice is a mock device which can be calibrated
+type calibratedDevice struct {
+	gopi.InputDevice
+	calibration calibrate.Matrix
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// MATRIX
+
+func TestMatrix_000(t *testing.T) {
+	pt := gopi.Point{10, 20}
+	if calibrate.Identity.Transform(pt).Equals(pt) == false {
+		t.Error("Unexpected identity transform")
+	}
+	if p := calibrate.SwapXY().Transform(pt); p.Equals(gopi.Point{20, 10}) == false {
+		t.Error("Unexpected swap", p)
+	}
+	if p := calibrate.InvertX(100).Then(calibrate.InvertY(50)).Transform(pt); p.Equals(gopi.Point{90, 30}) == false {
+		t.Error("Unexpected invert", p)
+	}
+	if p := calibrate.ScaleToSize(gopi.Point{0, 0}, gopi.Point{4096, 4096}, gopi.Size{800, 480}).Transform(gopi.Point{2048, 1024}); p.Equals(gopi.Point{400, 120}) == false {
+		t.Error("Unexpected scale", p)
+	}
+	if p := calibrate.Translate(1, 2).Then(calibrate.Scale(2, 2)).Transform(pt); p.Equals(gopi.Point{22, 44}) == false {
+		t.Error("Unexpected translate and scale", p)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// COMPUTE
+
+func TestCompute_000(t *testing.T) {
+	// Raw positions are swapped, inverted and scaled
+	expected := calibrate.SwapXY().Then(calibrate.Scale(0.2, 0.1)).Then(calibrate.InvertX(800))
+	calibrator := calibrate.NewCalibrator(gopi.Size{800, 480})
+	for {
+		if target, ok := calibrator.Target(); ok == false {
+			break
+		} else {
+			// Invert the expected transformation to obtain raw positions
+			raw := gopi.Point{target.Y * 10, (800 - target.X) * 5}
+			calibrator.Add(raw)
+		}
+	}
+	if m, err := calibrator.Matrix(); err != nil {
+		t.Fatal(err)
+	} else {
+		for _, raw := range []gopi.Point{{0, 0}, {4800, 4000}, {1234, 567}} {
+			a, b := m.Transform(raw), expected.Transform(raw)
+			if math.Abs(float64(a.X-b.X)) > 0.1 || math.Abs(float64(a.Y-b.Y)) > 0.1 {
+				t.Error("Unexpected transform", raw, a, b)
+			}
+		}
+	}
+}
+
+func TestCompute_001(t *testing.T) {
+	// Collinear points cannot be used for calibration
+	raw := []gopi.Point{{0, 0}, {1, 1}, {2, 2}}
+	if _, err := calibrate.Compute(raw, raw); err != calibrate.ErrCalibrationPoints {
+		t.Error("Expected ErrCalibrationPoints, got", err)
+	}
+	if _, err := calibrate.Compute(raw[:2], raw[:2]); err != calibrate.ErrCalibrationPoints {
+		t.Error("Expected ErrCalibrationPoints, got", err)
+	}
+}
+
+func TestCalibrator_000(t *testing.T) {
+	calibrator := calibrate.NewCalibratorWithTargets([]gopi.Point{{0, 0}, {100, 0}, {0, 100}})
+	for _, raw := range []gopi.Point{{10, 10}, {110, 10}, {10, 110}} {
+		calibrator.Process(mock.NewPositionEvent(nil, 0, gopi.INPUT_EVENT_ABSPOSITION, raw, gopi.ZeroPoint))
+		calibrator.Process(mock.NewKeyEvent(nil, 0, gopi.INPUT_EVENT_KEYPRESS, gopi.KEYCODE_BTNTOUCH, 0))
+		calibrator.Process(mock.NewKeyEvent(nil, 0, gopi.INPUT_EVENT_KEYRELEASE, gopi.KEYCODE_BTNTOUCH, 0))
+	}
+	if calibrator.Done() == false {
+		t.Fatal("Expected calibration to be done")
+	} else if m, err := calibrator.Matrix(); err != nil {
+		t.Fatal(err)
+	} else if p := m.Transform(gopi.Point{60, 60}); math.Abs(float64(p.X-50)) > 0.01 || math.Abs(float64(p.Y-50)) > 0.01 {
+		t.Error("Unexpected transform", p)
+	}
+}
+
+func TestCalibrator_001(t *testing.T) {
+	log, err := gopi.Open(logger.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	input, err := gopi.Open(mock.Device{Name: "touchscreen", Type: gopi.INPUT_TYPE_TOUCHSCREEN}, log.(gopi.Logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer input.Close()
+	device := &calibratedDevice{input.(gopi.InputDevice), calibrate.SwapXY()}
+	other := &calibratedDevice{input.(gopi.InputDevice), calibrate.Identity}
+
+	// The identity calibration is set whilst calibrating
+	calibrator := calibrate.NewCalibratorWithTargets([]gopi.Point{{0, 0}, {100, 0}, {0, 100}})
+	if err := calibrator.End(); err != gopi.ErrOutOfOrder {
+		t.Error("Expected ErrOutOfOrder, got", err)
+	}
+	if err := calibrator.Begin(device); err != nil {
+		t.Fatal(err)
+	} else if device.Calibration() != calibrate.Identity {
+		t.Error("Expected identity calibration, got", device.Calibration())
+	} else if err := calibrator.Begin(other); err != gopi.ErrOutOfOrder {
+		t.Error("Expected ErrOutOfOrder, got", err)
+	}
+
+	// Events from other devices are ignored
+	for _, source := range []*calibratedDevice{other, device} {
+		for _, raw := range []gopi.Point{{10, 10}, {110, 10}, {10, 110}} {
+			calibrator.Process(mock.NewPositionEvent(source, 0, gopi.INPUT_EVENT_ABSPOSITION, raw, gopi.ZeroPoint))
+			calibrator.Process(mock.NewKeyEvent(source, 0, gopi.INPUT_EVENT_KEYPRESS, gopi.KEYCODE_BTNTOUCH, 0))
+			calibrator.Process(mock.NewKeyEvent(source, 0, gopi.INPUT_EVENT_KEYRELEASE, gopi.KEYCODE_BTNTOUCH, 0))
+		}
+		if done := calibrator.Done(); done != (source == device) {
+			t.Error("Unexpected done state for", source.Name(), done)
+		}
+	}
+
+	// The calibration is restored afterwards
+	if err := calibrator.End(); err != nil {
+		t.Error(err)
+	} else if device.Calibration() != calibrate.SwapXY() {
+		t.Error("Expected calibration to be restored, got", device.Calibration())
+	} else if m, err := calibrator.Matrix(); err != nil {
+		t.Error(err)
+	} else if p := m.Transform(gopi.Point{60, 60}); math.Abs(float64(p.X-50)) > 0.01 || math.Abs(float64(p.Y-50)) > 0.01 {
+		t.Error("Unexpected transform", p)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STORE
+
+func TestStore_000(t *testing.T) {
+	buf := new(bytes.Buffer)
+	store := calibrate.NewStore()
+	store.Set("touchscreen", calibrate.SwapXY())
+	if err := store.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	if other, err := calibrate.ReadStore(buf); err != nil {
+		t.Fatal(err)
+	} else if m, exists := other.Get("touchscreen"); exists == false || m != calibrate.SwapXY() {
+		t.Error("Unexpected calibration", m)
+	} else if _, exists := other.Get("other"); exists {
+		t.Error("Unexpected calibration for other")
+	}
+}
+
+func TestStore_001(t *testing.T) {
+	// A null document is an empty store
+	if store, err := calibrate.ReadStore(bytes.NewBufferString("null")); err != nil {
+		t.Fatal(err)
+	} else if _, exists := store.Get("touchscreen"); exists {
+		t.Error("Unexpected calibration for touchscreen")
+	} else {
+		store.Set("touchscreen", calibrate.SwapXY())
+		if m, exists := store.Get("touchscreen"); exists == false || m != calibrate.SwapXY() {
+			t.Error("Unexpected calibration", m)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CALIBRATED DEVICE
+
+func (this *calibratedDevice) RawRange() (gopi.Point, gopi.Point) {
+	return gopi.ZeroPoint, gopi.Point{4096, 4096}
+}
+
+func (this *calibratedDevice) Calibration() calibrate.Matrix {
+	return this.calibration
+}
+
+func (this *calibratedDevice) SetCalibration(m calibrate.Matrix) {
+	this.calibration = m
+}
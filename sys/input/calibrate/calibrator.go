@@ -0,0 +1,267 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package calibrate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Calibrator is a helper for a calibration routine, which presents a
+// sequence of targets on the display and records the raw position
+// touched for each target. The raw positions are obtained from a device
+// with the identity calibration, which is set by calling Begin and
+// restored by calling End, after which the computed calibration can be
+// applied to the device
+type Calibrator struct {
+	targets []gopi.Point
+	raw     []gopi.Point
+
+	// Device being calibrated and the calibration before Begin
+	device      Device
+	calibration Matrix
+
+	// Last position reported whilst touching
+	position gopi.Point
+	touching bool
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Inset of the targets from the edge of the display, as a
+	// proportion of the display size
+	CALIBRATE_INSET = 0.1
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	ErrCalibrationPoints = errors.New("Insufficient or collinear calibration points")
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// COMPUTE
+
+// Compute returns the matrix which best maps raw positions onto target
+// positions, using a least-squares fit. At least three points which are
+// not collinear are required
+func Compute(raw, target []gopi.Point) (Matrix, error) {
+	if len(raw) < 3 || len(raw) != len(target) {
+		return Identity, ErrCalibrationPoints
+	}
+
+	// Normalize the raw positions around their mean, so that the
+	// determinant is independent of the range of raw positions
+	var mx, my, scale float64
+	for _, pt := range raw {
+		mx += float64(pt.X) / float64(len(raw))
+		my += float64(pt.Y) / float64(len(raw))
+	}
+	for _, pt := range raw {
+		scale = math.Max(scale, math.Max(math.Abs(float64(pt.X)-mx), math.Abs(float64(pt.Y)-my)))
+	}
+	if scale == 0 {
+		return Identity, ErrCalibrationPoints
+	}
+
+	// Sums for the normal equations
+	var n, sx, sy, sxx, sxy, syy float64
+	var tx, ty [3]float64
+	for i, pt := range raw {
+		x, y := (float64(pt.X)-mx)/scale, (float64(pt.Y)-my)/scale
+		n += 1
+		sx += x
+		sy += y
+		sxx += x * x
+		sxy += x * y
+		syy += y * y
+		for j, v := range []float64{x, y, 1} {
+			tx[j] += v * float64(target[i].X)
+			ty[j] += v * float64(target[i].Y)
+		}
+	}
+
+	// Solve using Cramer's rule
+	a := [3][3]float64{
+		{sxx, sxy, sx},
+		{sxy, syy, sy},
+		{sx, sy, n},
+	}
+	det := determinant(a)
+	if math.Abs(det) < 1e-9 {
+		return Identity, ErrCalibrationPoints
+	}
+	px, py := solve(a, tx, det), solve(a, ty, det)
+	normalize := Translate(float32(-mx), float32(-my)).Then(Scale(float32(1/scale), float32(1/scale)))
+	return normalize.Then(Matrix{
+		float32(px[0]), float32(px[1]), float32(px[2]),
+		float32(py[0]), float32(py[1]), float32(py[2]),
+	}), nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CALIBRATOR
+
+// NewCalibrator returns a calibration routine for a display size, with
+// targets near each corner and in the centre of the display
+func NewCalibrator(size gopi.Size) *Calibrator {
+	if size.W <= 0 || size.H <= 0 {
+		return nil
+	}
+	left, top := size.W*CALIBRATE_INSET, size.H*CALIBRATE_INSET
+	right, bottom := size.W-left, size.H-top
+	return NewCalibratorWithTargets([]gopi.Point{
+		gopi.Point{left, top},
+		gopi.Point{right, top},
+		gopi.Point{right, bottom},
+		gopi.Point{left, bottom},
+		gopi.Point{size.W / 2, size.H / 2},
+	})
+}
+
+// NewCalibratorWithTargets returns a calibration routine for a set of
+// targets, which must contain at least three points
+func NewCalibratorWithTargets(targets []gopi.Point) *Calibrator {
+	if len(targets) < 3 {
+		return nil
+	}
+	this := new(Calibrator)
+	this.targets = targets
+	this.raw = make([]gopi.Point, 0, len(targets))
+	return this
+}
+
+// Target returns the next target which should be touched, and false
+// when all targets have been touched
+func (this *Calibrator) Target() (gopi.Point, bool) {
+	if this.Done() {
+		return gopi.ZeroPoint, false
+	}
+	return this.targets[len(this.raw)], true
+}
+
+// Done returns true when all targets have been touched
+func (this *Calibrator) Done() bool {
+	return len(this.raw) >= len(this.targets)
+}
+
+// Begin calibrating a device, which sets the identity calibration on
+// the device so that raw positions are reported, and discards any
+// touched positions. Only events from the device are then processed
+func (this *Calibrator) Begin(device Device) error {
+	if device == nil {
+		return gopi.ErrBadParameter
+	} else if this.device != nil {
+		return gopi.ErrOutOfOrder
+	}
+	this.device = device
+	this.calibration = device.Calibration()
+	device.SetCalibration(Identity)
+	this.Reset()
+	return nil
+}
+
+// End calibrating a device, which restores the calibration the device
+// had before Begin was called
+func (this *Calibrator) End() error {
+	if this.device == nil {
+		return gopi.ErrOutOfOrder
+	}
+	this.device.SetCalibration(this.calibration)
+	this.device = nil
+	return nil
+}
+
+// Add records the raw position touched for the current target and
+// returns true when all targets have been touched
+func (this *Calibrator) Add(raw gopi.Point) bool {
+	if this.Done() == false {
+		this.raw = append(this.raw, raw)
+	}
+	return this.Done()
+}
+
+// Process records the raw position for the current target from input
+// events, when the touch is released, and returns true when all targets
+// have been touched
+func (this *Calibrator) Process(evt gopi.InputEvent) bool {
+	if this.device != nil && evt.Source() != gopi.Driver(this.device) {
+		return this.Done()
+	}
+	switch evt.EventType() {
+	case gopi.INPUT_EVENT_ABSPOSITION, gopi.INPUT_EVENT_TOUCHPOSITION:
+		this.position = evt.Position()
+	case gopi.INPUT_EVENT_TOUCHPRESS:
+		this.touching = true
+	case gopi.INPUT_EVENT_KEYPRESS:
+		if evt.Keycode() == gopi.KEYCODE_BTNTOUCH {
+			this.touching = true
+		}
+	case gopi.INPUT_EVENT_TOUCHRELEASE, gopi.INPUT_EVENT_KEYRELEASE:
+		if this.touching && (evt.EventType() == gopi.INPUT_EVENT_TOUCHRELEASE || evt.Keycode() == gopi.KEYCODE_BTNTOUCH) {
+			this.touching = false
+			this.Add(this.position)
+		}
+	}
+	return this.Done()
+}
+
+// Matrix returns the calibration computed from the touched targets
+func (this *Calibrator) Matrix() (Matrix, error) {
+	if this.Done() == false {
+		return Identity, gopi.ErrOutOfOrder
+	}
+	return Compute(this.raw, this.targets)
+}
+
+// Reset discards the touched positions, to restart the routine
+func (this *Calibrator) Reset() {
+	this.raw = this.raw[:0]
+	this.touching = false
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Calibrator) String() string {
+	return fmt.Sprintf("<sys.input.calibrate.Calibrator>{ targets=%v raw=%v device=%v }", this.targets, this.raw, this.device)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func determinant(a [3][3]float64) float64 {
+	return a[0][0]*(a[1][1]*a[2][2]-a[1][2]*a[2][1]) -
+		a[0][1]*(a[1][0]*a[2][2]-a[1][2]*a[2][0]) +
+		a[0][2]*(a[1][0]*a[2][1]-a[1][1]*a[2][0])
+}
+
+// solve returns the solution to a.p = b using Cramer's rule
+func solve(a [3][3]float64, b [3]float64, det float64) [3]float64 {
+	var p [3]float64
+	for col := 0; col < 3; col++ {
+		m := a
+		for row := 0; row < 3; row++ {
+			m[row][col] = b[row]
+		}
+		p[col] = determinant(m) / det
+	}
+	return p
+}
@@ -0,0 +1,131 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package calibrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Store persists calibrations for devices, by device name
+type Store struct {
+	calibrations map[string]Matrix
+	lock         sync.Mutex
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+// NewStore returns an empty store
+func NewStore() *Store {
+	this := new(Store)
+	this.calibrations = make(map[string]Matrix)
+	return this
+}
+
+// ReadStore reads calibrations in JSON format
+func ReadStore(r io.Reader) (*Store, error) {
+	this := NewStore()
+	if err := json.NewDecoder(r).Decode(&this.calibrations); err != nil {
+		return nil, err
+	}
+	// A null document decodes as a nil map
+	if this.calibrations == nil {
+		this.calibrations = make(map[string]Matrix)
+	}
+	return this, nil
+}
+
+// LoadStore reads calibrations from a file, and returns an empty
+// store if the file does not exist
+func LoadStore(path string) (*Store, error) {
+	if file, err := os.Open(path); os.IsNotExist(err) {
+		return NewStore(), nil
+	} else if err != nil {
+		return nil, err
+	} else {
+		defer file.Close()
+		return ReadStore(file)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GET AND SET
+
+// Get returns the calibration for a device name, and false if
+// there is no calibration
+func (this *Store) Get(name string) (Matrix, bool) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	m, exists := this.calibrations[name]
+	return m, exists
+}
+
+// Set the calibration for a device name
+func (this *Store) Set(name string, m Matrix) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.calibrations[name] = m
+}
+
+// Remove the calibration for a device name
+func (this *Store) Remove(name string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	delete(this.calibrations, name)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// WRITE AND SAVE
+
+// Write calibrations in JSON format
+func (this *Store) Write(w io.Writer) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(this.calibrations)
+}
+
+// Save writes calibrations to a file, replacing the existing file
+// once the calibrations have been written
+func (this *Store) Save(path string) error {
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if err := this.Write(file); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Store) String() string {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return fmt.Sprintf("<sys.input.calibrate.Store>{ calibrations=%v }", this.calibrations)
+}
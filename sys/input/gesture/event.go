@@ -0,0 +1,181 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package gesture
+
+import (
+	"fmt"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Type of gesture
+type GestureType uint
+
+// Direction of a swipe
+type Direction uint
+
+// Event is emitted when a gesture is recognized
+type Event interface {
+	gopi.Event
+
+	// Type of gesture
+	Type() GestureType
+
+	// Timestamp of the input event which completed the gesture
+	Timestamp() time.Duration
+
+	// Position of the gesture, which is the centre of the contacts
+	// for pinch and rotate
+	Position() gopi.Point
+
+	// Direction of a swipe
+	Direction() Direction
+
+	// Velocity of a swipe, in units per second
+	Velocity() float32
+
+	// Scale of a pinch, relative to the distance between contacts
+	// when the pinch started
+	Scale() float32
+
+	// Rotation in degrees clockwise, relative to the angle between
+	// contacts when the rotation started
+	Rotation() float32
+}
+
+type gesture_event struct {
+	source       gopi.Driver
+	timestamp    time.Duration
+	gesture_type GestureType
+	position     gopi.Point
+	direction    Direction
+	velocity     float32
+	scale        float32
+	rotation     float32
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	GESTURE_NONE GestureType = iota
+	GESTURE_TAP
+	GESTURE_DOUBLETAP
+	GESTURE_LONGPRESS
+	GESTURE_SWIPE
+	GESTURE_PINCH
+	GESTURE_ROTATE
+)
+
+const (
+	DIRECTION_NONE Direction = iota
+	DIRECTION_LEFT
+	DIRECTION_RIGHT
+	DIRECTION_UP
+	DIRECTION_DOWN
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// gesture.Event INTERFACE
+
+func (this *gesture_event) Name() string {
+	return "GestureEvent"
+}
+
+func (this *gesture_event) Source() gopi.Driver {
+	return this.source
+}
+
+func (this *gesture_event) Type() GestureType {
+	return this.gesture_type
+}
+
+func (this *gesture_event) Timestamp() time.Duration {
+	return this.timestamp
+}
+
+func (this *gesture_event) Position() gopi.Point {
+	return this.position
+}
+
+func (this *gesture_event) Direction() Direction {
+	return this.direction
+}
+
+func (this *gesture_event) Velocity() float32 {
+	return this.velocity
+}
+
+func (this *gesture_event) Scale() float32 {
+	return this.scale
+}
+
+func (this *gesture_event) Rotation() float32 {
+	return this.rotation
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *gesture_event) String() string {
+	switch this.gesture_type {
+	case GESTURE_SWIPE:
+		return fmt.Sprintf("<sys.input.gesture.Event>{ type=%v position=%v direction=%v velocity=%v ts=%v }", this.gesture_type, this.position, this.direction, this.velocity, this.timestamp)
+	case GESTURE_PINCH:
+		return fmt.Sprintf("<sys.input.gesture.Event>{ type=%v position=%v scale=%v ts=%v }", this.gesture_type, this.position, this.scale, this.timestamp)
+	case GESTURE_ROTATE:
+		return fmt.Sprintf("<sys.input.gesture.Event>{ type=%v position=%v rotation=%v ts=%v }", this.gesture_type, this.position, this.rotation, this.timestamp)
+	default:
+		return fmt.Sprintf("<sys.input.gesture.Event>{ type=%v position=%v ts=%v }", this.gesture_type, this.position, this.timestamp)
+	}
+}
+
+func (t GestureType) String() string {
+	switch t {
+	case GESTURE_NONE:
+		return "GESTURE_NONE"
+	case GESTURE_TAP:
+		return "GESTURE_TAP"
+	case GESTURE_DOUBLETAP:
+		return "GESTURE_DOUBLETAP"
+	case GESTURE_LONGPRESS:
+		return "GESTURE_LONGPRESS"
+	case GESTURE_SWIPE:
+		return "GESTURE_SWIPE"
+	case GESTURE_PINCH:
+		return "GESTURE_PINCH"
+	case GESTURE_ROTATE:
+		return "GESTURE_ROTATE"
+	default:
+		return "[?? Invalid GestureType value]"
+	}
+}
+
+func (d Direction) String() string {
+	switch d {
+	case DIRECTION_NONE:
+		return "DIRECTION_NONE"
+	case DIRECTION_LEFT:
+		return "DIRECTION_LEFT"
+	case DIRECTION_RIGHT:
+		return "DIRECTION_RIGHT"
+	case DIRECTION_UP:
+		return "DIRECTION_UP"
+	case DIRECTION_DOWN:
+		return "DIRECTION_DOWN"
+	default:
+		return "[?? Invalid Direction value]"
+	}
+}
@@ -0,0 +1,299 @@
+package gesture_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	gesture "github.com/djthorpe/gopi/sys/input/gesture"
+	mock "github.com/djthorpe/gopi/sys/input/mock"
+	logger "github.com/djthorpe/gopi/sys/logger"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type touch struct {
+	ts         time.Duration
+	event_type gopi.InputEventType
+	slot       uint
+	position   gopi.Point
+}
+
+// device_touch is a touch on one of several touchscreens
+type device_touch struct {
+	device int
+	touch
+}
+
+const (
+	PRESS   = gopi.INPUT_EVENT_TOUCHPRESS
+	MOVE    = gopi.INPUT_EVENT_TOUCHPOSITION
+	RELEASE = gopi.INPUT_EVENT_TOUCHRELEASE
+	MS      = time.Millisecond
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TAP
+
+func TestGesture_000(t *testing.T) {
+	gestures := recognize(t, gesture.Recognizer{}, []touch{
+		{0, PRESS, 0, gopi.Point{100, 100}},
+		{50 * MS, MOVE, 0, gopi.Point{102, 101}},
+		{100 * MS, RELEASE, 0, gopi.Point{102, 101}},
+	}, 0)
+	if len(gestures) != 1 {
+		t.Fatal("Expected one gesture, got", gestures)
+	} else if gestures[0].Type() != gesture.GESTURE_TAP {
+		t.Error("Expected tap, got", gestures[0])
+	} else if gestures[0].Position().Equals(gopi.Point{102, 101}) == false {
+		t.Error("Unexpected position", gestures[0])
+	}
+}
+
+func TestGesture_001(t *testing.T) {
+	// Double tap, followed by a tap which is too late for a double tap
+	gestures := recognize(t, gesture.Recognizer{}, []touch{
+		{0, PRESS, 0, gopi.Point{100, 100}},
+		{100 * MS, RELEASE, 0, gopi.Point{100, 100}},
+		{200 * MS, PRESS, 0, gopi.Point{104, 100}},
+		{300 * MS, RELEASE, 0, gopi.Point{104, 100}},
+		{1000 * MS, PRESS, 0, gopi.Point{104, 100}},
+		{1100 * MS, RELEASE, 0, gopi.Point{104, 100}},
+	}, 0)
+	expectTypes(t, gestures, gesture.GESTURE_TAP, gesture.GESTURE_TAP, gesture.GESTURE_DOUBLETAP, gesture.GESTURE_TAP)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// LONG PRESS
+
+func TestGesture_002(t *testing.T) {
+	// Long press recognized from the timestamps, and no tap on release
+	gestures := recognize(t, gesture.Recognizer{LongPressDuration: time.Hour}, []touch{
+		{0, PRESS, 0, gopi.Point{100, 100}},
+		{30 * time.Minute, MOVE, 0, gopi.Point{101, 100}},
+		{time.Hour, MOVE, 0, gopi.Point{101, 101}},
+		{2 * time.Hour, RELEASE, 0, gopi.Point{101, 101}},
+	}, 0)
+	expectTypes(t, gestures, gesture.GESTURE_LONGPRESS)
+}
+
+func TestGesture_003(t *testing.T) {
+	// Long press recognized whilst the contact is held
+	gestures := recognize(t, gesture.Recognizer{LongPressDuration: 20 * MS}, []touch{
+		{0, PRESS, 0, gopi.Point{100, 100}},
+	}, 200*MS)
+	expectTypes(t, gestures, gesture.GESTURE_LONGPRESS)
+}
+
+func TestGesture_004(t *testing.T) {
+	// Movement cancels a long press
+	gestures := recognize(t, gesture.Recognizer{LongPressDuration: 50 * MS}, []touch{
+		{0, PRESS, 0, gopi.Point{100, 100}},
+		{10 * MS, MOVE, 0, gopi.Point{120, 100}},
+	}, 200*MS)
+	expectTypes(t, gestures)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// SWIPE
+
+func TestGesture_005(t *testing.T) {
+	for _, test := range []struct {
+		end       gopi.Point
+		direction gesture.Direction
+	}{
+		{gopi.Point{0, 110}, gesture.DIRECTION_LEFT},
+		{gopi.Point{200, 90}, gesture.DIRECTION_RIGHT},
+		{gopi.Point{110, 0}, gesture.DIRECTION_UP},
+		{gopi.Point{90, 200}, gesture.DIRECTION_DOWN},
+	} {
+		gestures := recognize(t, gesture.Recognizer{}, []touch{
+			{0, PRESS, 0, gopi.Point{100, 100}},
+			{50 * MS, MOVE, 0, gopi.Point{(100 + test.end.X) / 2, (100 + test.end.Y) / 2}},
+			{100 * MS, RELEASE, 0, test.end},
+		}, 0)
+		if len(gestures) != 1 || gestures[0].Type() != gesture.GESTURE_SWIPE {
+			t.Error("Expected swipe, got", gestures)
+		} else if gestures[0].Direction() != test.direction {
+			t.Error("Expected", test.direction, "got", gestures[0])
+		} else if v := gestures[0].Velocity(); v < 1000 {
+			t.Error("Unexpected velocity", gestures[0])
+		}
+	}
+}
+
+func TestGesture_006(t *testing.T) {
+	// Too slow for a swipe
+	gestures := recognize(t, gesture.Recognizer{}, []touch{
+		{0, PRESS, 0, gopi.Point{100, 100}},
+		{2 * time.Second, RELEASE, 0, gopi.Point{200, 100}},
+	}, 0)
+	expectTypes(t, gestures)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PINCH AND ROTATE
+
+func TestGesture_007(t *testing.T) {
+	// Contacts move apart, which is a pinch but not a rotation, and the
+	// contacts are not recognized as taps
+	gestures := recognize(t, gesture.Recognizer{}, []touch{
+		{0, PRESS, 0, gopi.Point{100, 100}},
+		{10 * MS, PRESS, 1, gopi.Point{200, 100}},
+		{20 * MS, MOVE, 1, gopi.Point{205, 100}},
+		{30 * MS, MOVE, 1, gopi.Point{300, 100}},
+		{40 * MS, RELEASE, 0, gopi.Point{100, 100}},
+		{50 * MS, RELEASE, 1, gopi.Point{300, 100}},
+	}, 0)
+	expectTypes(t, gestures, gesture.GESTURE_PINCH)
+	if len(gestures) == 1 {
+		if gestures[0].Scale() != 2 {
+			t.Error("Expected scale of 2, got", gestures[0])
+		} else if gestures[0].Position().Equals(gopi.Point{200, 100}) == false {
+			t.Error("Expected centre position, got", gestures[0])
+		}
+	}
+}
+
+func TestGesture_008(t *testing.T) {
+	// Second contact rotates clockwise by 90 degrees around the first
+	gestures := recognize(t, gesture.Recognizer{}, []touch{
+		{0, PRESS, 0, gopi.Point{100, 100}},
+		{10 * MS, PRESS, 1, gopi.Point{200, 100}},
+		{20 * MS, MOVE, 1, gopi.Point{100, 200}},
+		{30 * MS, RELEASE, 1, gopi.Point{100, 200}},
+		{40 * MS, RELEASE, 0, gopi.Point{100, 100}},
+	}, 0)
+	expectTypes(t, gestures, gesture.GESTURE_ROTATE)
+	if len(gestures) == 1 && math.Abs(float64(gestures[0].Rotation()-90)) > 0.01 {
+		t.Error("Expected rotation of 90 degrees, got", gestures[0])
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// MULTIPLE DEVICES
+
+func TestGesture_009(t *testing.T) {
+	// The same slot on two touchscreens is two taps, not a pinch
+	gestures := recognizeDevices(t, gesture.Recognizer{}, 2, []device_touch{
+		{0, touch{0, PRESS, 0, gopi.Point{100, 100}}},
+		{1, touch{10 * MS, PRESS, 0, gopi.Point{400, 400}}},
+		{0, touch{20 * MS, MOVE, 0, gopi.Point{101, 100}}},
+		{1, touch{30 * MS, MOVE, 0, gopi.Point{401, 400}}},
+		{0, touch{40 * MS, RELEASE, 0, gopi.Point{101, 100}}},
+		{1, touch{50 * MS, RELEASE, 0, gopi.Point{401, 400}}},
+	}, 0)
+	expectTypes(t, gestures, gesture.GESTURE_TAP, gesture.GESTURE_TAP)
+	if len(gestures) == 2 {
+		if gestures[0].Position().Equals(gopi.Point{101, 100}) == false {
+			t.Error("Unexpected position", gestures[0])
+		} else if gestures[1].Position().Equals(gopi.Point{401, 400}) == false {
+			t.Error("Unexpected position", gestures[1])
+		}
+	}
+}
+
+func TestGesture_010(t *testing.T) {
+	// A contact on a second touchscreen does not interrupt a pinch
+	gestures := recognizeDevices(t, gesture.Recognizer{}, 2, []device_touch{
+		{0, touch{0, PRESS, 0, gopi.Point{100, 100}}},
+		{0, touch{10 * MS, PRESS, 1, gopi.Point{200, 100}}},
+		{1, touch{20 * MS, PRESS, 0, gopi.Point{400, 400}}},
+		{1, touch{25 * MS, RELEASE, 0, gopi.Point{400, 400}}},
+		{0, touch{30 * MS, MOVE, 1, gopi.Point{300, 100}}},
+		{0, touch{40 * MS, RELEASE, 0, gopi.Point{100, 100}}},
+		{0, touch{50 * MS, RELEASE, 1, gopi.Point{300, 100}}},
+	}, 0)
+	expectTypes(t, gestures, gesture.GESTURE_TAP, gesture.GESTURE_PINCH)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// recognize emits touch events on a single touchscreen
+func recognize(t *testing.T, config gesture.Recognizer, touches []touch, wait time.Duration) []gesture.Event {
+	device_touches := make([]device_touch, len(touches))
+	for i, touch := range touches {
+		device_touches[i] = device_touch{0, touch}
+	}
+	return recognizeDevices(t, config, 1, device_touches, wait)
+}
+
+// recognizeDevices emits touch events through a mock input manager, waits
+// and then returns the gestures recognized
+func recognizeDevices(t *testing.T, config gesture.Recognizer, devices int, touches []device_touch, wait time.Duration) []gesture.Event {
+	log := openLogger(t)
+	defer log.Close()
+
+	input := openDriver(t, mock.Input{}, log).(gopi.InputManager)
+	defer input.Close()
+	touchscreens := make([]gopi.InputDevice, devices)
+	for i := range touchscreens {
+		touchscreens[i] = openDriver(t, mock.Device{Name: fmt.Sprint("touchscreen", i), Type: gopi.INPUT_TYPE_TOUCHSCREEN, Bus: gopi.INPUT_BUS_USB}, log).(gopi.InputDevice)
+		defer touchscreens[i].Close()
+	}
+
+	config.Input = input
+	recognizer := openDriver(t, config, log).(gopi.Publisher)
+
+	// Collect gestures until the recognizer is closed
+	gestures := make([]gesture.Event, 0)
+	done := make(chan struct{})
+	events := recognizer.Subscribe()
+	go func() {
+		for evt := range events {
+			gestures = append(gestures, evt.(gesture.Event))
+		}
+		close(done)
+	}()
+
+	emitter := input.(interface {
+		Emit(gopi.Event)
+	})
+	for _, touch := range touches {
+		emitter.Emit(mock.NewTouchEvent(touchscreens[touch.device], touch.ts, touch.event_type, touch.slot, touch.position))
+	}
+	time.Sleep(wait)
+
+	if err := recognizer.(gopi.Driver).Close(); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	return gestures
+}
+
+func expectTypes(t *testing.T, gestures []gesture.Event, types ...gesture.GestureType) {
+	t.Helper()
+	if len(gestures) != len(types) {
+		t.Error("Expected", types, "got", gestures)
+		return
+	}
+	for i, gesture_type := range types {
+		if gestures[i].Type() != gesture_type {
+			t.Error("Expected", types, "got", gestures)
+			return
+		}
+	}
+}
+
+func openLogger(t *testing.T) gopi.Logger {
+	if log, err := gopi.Open(logger.Config{}, nil); err != nil {
+		t.Fatal("Unable to create logger driver")
+		return nil
+	} else {
+		return log.(gopi.Logger)
+	}
+}
+
+func openDriver(t *testing.T, config gopi.Config, log gopi.Logger) gopi.Driver {
+	if driver, err := gopi.Open(config, log); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return driver
+	}
+}
@@ -0,0 +1,57 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package gesture
+
+import (
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INIT
+
+func init() {
+	// Register gesture recognizer
+	gopi.RegisterModule(gopi.Module{
+		Name:     "input/gesture",
+		Type:     gopi.MODULE_TYPE_OTHER,
+		Requires: []string{"input"},
+		Config: func(config *gopi.AppConfig) {
+			config.AppFlags.FlagFloat64("gesture.tap.distance", DEFAULT_TAP_DISTANCE, "Maximum movement of a tap")
+			config.AppFlags.FlagDuration("gesture.tap.duration", DEFAULT_TAP_DURATION, "Maximum duration of a tap")
+			config.AppFlags.FlagDuration("gesture.doubletap", DEFAULT_DOUBLE_TAP_INTERVAL, "Maximum interval between taps of a double tap")
+			config.AppFlags.FlagDuration("gesture.longpress", DEFAULT_LONGPRESS_DURATION, "Minimum duration of a long press")
+			config.AppFlags.FlagFloat64("gesture.swipe.distance", DEFAULT_SWIPE_DISTANCE, "Minimum distance of a swipe")
+			config.AppFlags.FlagFloat64("gesture.swipe.velocity", DEFAULT_SWIPE_VELOCITY, "Minimum velocity of a swipe")
+			config.AppFlags.FlagFloat64("gesture.pinch", DEFAULT_PINCH_SCALE, "Minimum change in scale of a pinch")
+			config.AppFlags.FlagFloat64("gesture.rotate", DEFAULT_ROTATE_ANGLE, "Minimum rotation in degrees")
+		},
+		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
+			tap_distance, _ := app.AppFlags.GetFloat64("gesture.tap.distance")
+			tap_duration, _ := app.AppFlags.GetDuration("gesture.tap.duration")
+			double_tap, _ := app.AppFlags.GetDuration("gesture.doubletap")
+			longpress, _ := app.AppFlags.GetDuration("gesture.longpress")
+			swipe_distance, _ := app.AppFlags.GetFloat64("gesture.swipe.distance")
+			swipe_velocity, _ := app.AppFlags.GetFloat64("gesture.swipe.velocity")
+			pinch, _ := app.AppFlags.GetFloat64("gesture.pinch")
+			rotate, _ := app.AppFlags.GetFloat64("gesture.rotate")
+			return gopi.Open(Recognizer{
+				Input:             app.Input,
+				TapDistance:       float32(tap_distance),
+				TapDuration:       tap_duration,
+				DoubleTapInterval: double_tap,
+				LongPressDuration: longpress,
+				SwipeDistance:     float32(swipe_distance),
+				SwipeVelocity:     float32(swipe_velocity),
+				PinchScale:        float32(pinch),
+				RotateAngle:       float32(rotate),
+			}, app.Logger)
+		},
+	})
+}
@@ -0,0 +1,520 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package gesture
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+	"github.com/djthorpe/gopi/util/event"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Recognizer subscribes to multi-touch events from an input manager and
+// emits gesture events. Durations are measured using the timestamps of
+// the input events, except that a long press is also emitted whilst the
+// contact is held. Zero thresholds are replaced with defaults
+type Recognizer struct {
+	Input gopi.InputManager
+
+	// Maximum distance a contact can move for a tap or long press
+	TapDistance float32
+
+	// Maximum duration of a tap
+	TapDuration time.Duration
+
+	// Maximum interval between the end of one tap and the end of the next
+	// for a double tap
+	DoubleTapInterval time.Duration
+
+	// Minimum duration of a long press
+	LongPressDuration time.Duration
+
+	// Minimum distance and velocity, in units per second, of a swipe
+	SwipeDistance float32
+	SwipeVelocity float32
+
+	// Minimum change in scale before a pinch is recognized
+	PinchScale float32
+
+	// Minimum change in angle, in degrees, before a rotation is recognized
+	RotateAngle float32
+}
+
+type recognizer struct {
+	log    gopi.Logger
+	input  gopi.InputManager
+	events <-chan gopi.Event
+	pubsub *event.PubSub
+	done   chan struct{}
+	lock   sync.Mutex
+	wg     sync.WaitGroup
+	closed bool
+
+	// Thresholds
+	tap_distance        float32
+	tap_duration        time.Duration
+	double_tap_interval time.Duration
+	longpress_duration  time.Duration
+	swipe_distance      float32
+	swipe_velocity      float32
+	pinch_scale         float32
+	rotate_angle        float32
+
+	// Current contacts by device and slot, and the state of a pinch or
+	// rotation for each device
+	contacts   map[contactKey]*contact
+	pinches    map[gopi.Driver]*pinch
+	generation uint
+
+	// The last tap, for recognizing double taps
+	last_tap *contact
+}
+
+// contactKey identifies a contact by the device and slot, so that
+// contacts on different devices are recognized separately
+type contactKey struct {
+	source gopi.Driver
+	slot   uint
+}
+
+// contact is a single touch
+type contact struct {
+	source     gopi.Driver
+	generation uint
+	start      gopi.Point
+	position   gopi.Point
+	start_ts   time.Duration
+	ts         time.Duration
+	moved      bool
+	longpress  bool
+	multi      bool
+	timer      *time.Timer
+}
+
+// pinch is the state of two contacts on the same device
+type pinch struct {
+	contacts [2]contactKey
+	distance float32
+	angle    float32
+	pinching bool
+	rotating bool
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	DEFAULT_TAP_DISTANCE        = 10
+	DEFAULT_TAP_DURATION        = 250 * time.Millisecond
+	DEFAULT_DOUBLE_TAP_INTERVAL = 300 * time.Millisecond
+	DEFAULT_LONGPRESS_DURATION  = 500 * time.Millisecond
+	DEFAULT_SWIPE_DISTANCE      = 50
+	DEFAULT_SWIPE_VELOCITY      = 200
+	DEFAULT_PINCH_SCALE         = 0.1
+	DEFAULT_ROTATE_ANGLE        = 10
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+// Open the recognizer and subscribe to input events
+func (config Recognizer) Open(log gopi.Logger) (gopi.Driver, error) {
+	log.Debug("<sys.input.gesture.Recognizer.Open>{ }")
+
+	if config.Input == nil {
+		return nil, gopi.ErrBadParameter
+	}
+
+	this := new(recognizer)
+	this.log = log
+	this.input = config.Input
+	this.tap_distance = float32OrDefault(config.TapDistance, DEFAULT_TAP_DISTANCE)
+	this.tap_duration = durationOrDefault(config.TapDuration, DEFAULT_TAP_DURATION)
+	this.double_tap_interval = durationOrDefault(config.DoubleTapInterval, DEFAULT_DOUBLE_TAP_INTERVAL)
+	this.longpress_duration = durationOrDefault(config.LongPressDuration, DEFAULT_LONGPRESS_DURATION)
+	this.swipe_distance = float32OrDefault(config.SwipeDistance, DEFAULT_SWIPE_DISTANCE)
+	this.swipe_velocity = float32OrDefault(config.SwipeVelocity, DEFAULT_SWIPE_VELOCITY)
+	this.pinch_scale = float32OrDefault(config.PinchScale, DEFAULT_PINCH_SCALE)
+	this.rotate_angle = float32OrDefault(config.RotateAngle, DEFAULT_ROTATE_ANGLE)
+	this.contacts = make(map[contactKey]*contact)
+	this.pinches = make(map[gopi.Driver]*pinch)
+	this.pubsub = event.NewPubSub(0)
+
+	// Subscribe to events and recognize gestures in the background
+	this.events = this.input.Subscribe()
+	this.done = make(chan struct{})
+	go this.run()
+
+	// Success
+	return this, nil
+}
+
+// Close stops recognizing gestures
+func (this *recognizer) Close() error {
+	this.log.Debug("<sys.input.gesture.Recognizer.Close>{ }")
+
+	// Unsubscribe closes the channel, which ends the background task
+	this.input.Unsubscribe(this.events)
+	<-this.done
+
+	// Stop long press timers, and wait for any which are emitting
+	this.lock.Lock()
+	this.closed = true
+	for _, c := range this.contacts {
+		c.stop()
+	}
+	this.lock.Unlock()
+	this.wg.Wait()
+
+	// Close subscriber channels
+	this.pubsub.Close()
+
+	// Release resources
+	this.input = nil
+	this.events = nil
+	this.pubsub = nil
+	this.contacts = nil
+	this.pinches = nil
+
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLISH AND SUBSCRIBE
+
+// Subscribe to gesture events
+func (this *recognizer) Subscribe() <-chan gopi.Event {
+	return this.pubsub.Subscribe()
+}
+
+// Unsubscribe from gesture events
+func (this *recognizer) Unsubscribe(subscriber <-chan gopi.Event) {
+	this.pubsub.Unsubscribe(subscriber)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *recognizer) String() string {
+	return fmt.Sprintf("<sys.input.gesture.Recognizer>{ tap_distance=%v tap_duration=%v double_tap_interval=%v longpress_duration=%v swipe_distance=%v swipe_velocity=%v pinch_scale=%v rotate_angle=%v }", this.tap_distance, this.tap_duration, this.double_tap_interval, this.longpress_duration, this.swipe_distance, this.swipe_velocity, this.pinch_scale, this.rotate_angle)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// BACKGROUND TASK
+
+func (this *recognizer) run() {
+	for evt := range this.events {
+		if input_event, ok := evt.(gopi.InputEvent); ok && input_event != nil {
+			for _, gesture := range this.process(input_event) {
+				this.pubsub.Emit(gesture)
+			}
+		}
+	}
+	close(this.done)
+}
+
+// process an input event and return any gestures recognized
+func (this *recognizer) process(evt gopi.InputEvent) []gopi.Event {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	switch evt.EventType() {
+	case gopi.INPUT_EVENT_TOUCHPRESS:
+		return this.press(evt)
+	case gopi.INPUT_EVENT_TOUCHPOSITION:
+		return this.move(evt)
+	case gopi.INPUT_EVENT_TOUCHRELEASE:
+		return this.release(evt)
+	default:
+		return nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRESS, MOVE AND RELEASE
+
+func (this *recognizer) press(evt gopi.InputEvent) []gopi.Event {
+	// Replace any existing contact for the slot
+	key := contactKey{evt.Source(), evt.Slot()}
+	if c, exists := this.contacts[key]; exists {
+		c.stop()
+	}
+
+	this.generation++
+	c := &contact{
+		source:     evt.Source(),
+		generation: this.generation,
+		start:      evt.Position(),
+		position:   evt.Position(),
+		start_ts:   evt.Timestamp(),
+		ts:         evt.Timestamp(),
+	}
+	this.contacts[key] = c
+
+	// With two contacts on the device, start a pinch and cancel single
+	// contact gestures, otherwise start the long press timer
+	if keys := this.deviceContacts(key.source); len(keys) > 1 {
+		for _, other := range keys {
+			this.contacts[other].multi = true
+			this.contacts[other].stop()
+		}
+		if len(keys) == 2 && this.pinches[key.source] == nil {
+			this.pinches[key.source] = this.newPinch(keys)
+		}
+	} else {
+		generation := c.generation
+		c.timer = time.AfterFunc(this.longpress_duration, func() {
+			this.longpressTimer(key, generation)
+		})
+	}
+
+	return nil
+}
+
+func (this *recognizer) move(evt gopi.InputEvent) []gopi.Event {
+	key := contactKey{evt.Source(), evt.Slot()}
+	c, exists := this.contacts[key]
+	if exists == false {
+		return nil
+	}
+	c.position = evt.Position()
+	c.ts = evt.Timestamp()
+	c.source = evt.Source()
+
+	// Pinch and rotate
+	if pinch := this.pinches[key.source]; pinch != nil {
+		return this.pinchMove(pinch, c)
+	} else if c.multi {
+		return nil
+	}
+
+	// Cancel tap and long press when the contact moves
+	if c.moved == false && distance(c.start, c.position) > this.tap_distance {
+		c.moved = true
+		c.stop()
+	}
+
+	// Recognize a long press from the timestamps
+	if c.moved == false && c.longpress == false && c.ts-c.start_ts >= this.longpress_duration {
+		c.longpress = true
+		c.stop()
+		return []gopi.Event{this.newEvent(c, GESTURE_LONGPRESS)}
+	}
+
+	return nil
+}
+
+func (this *recognizer) release(evt gopi.InputEvent) []gopi.Event {
+	key := contactKey{evt.Source(), evt.Slot()}
+	c, exists := this.contacts[key]
+	if exists == false {
+		return nil
+	}
+	c.stop()
+	delete(this.contacts, key)
+	c.position = evt.Position()
+	c.ts = evt.Timestamp()
+	c.source = evt.Source()
+
+	// End any pinch when either contact is released
+	if pinch := this.pinches[key.source]; pinch != nil && (pinch.contacts[0] == key || pinch.contacts[1] == key) {
+		delete(this.pinches, key.source)
+		if keys := this.deviceContacts(key.source); len(keys) == 2 {
+			this.pinches[key.source] = this.newPinch(keys)
+		}
+	}
+
+	// Contacts which were part of a multi-touch gesture or which have
+	// already been recognized as a long press are not recognized again
+	if c.multi || c.longpress {
+		return nil
+	}
+	if c.moved == false && distance(c.start, c.position) > this.tap_distance {
+		c.moved = true
+	}
+
+	duration := c.ts - c.start_ts
+	switch {
+	case c.moved == false && duration >= this.longpress_duration:
+		return []gopi.Event{this.newEvent(c, GESTURE_LONGPRESS)}
+	case c.moved == false && duration <= this.tap_duration:
+		events := []gopi.Event{this.newEvent(c, GESTURE_TAP)}
+		if this.last_tap != nil && this.last_tap.source == c.source && c.ts-this.last_tap.ts <= this.double_tap_interval && distance(c.position, this.last_tap.position) <= this.tap_distance {
+			events = append(events, this.newEvent(c, GESTURE_DOUBLETAP))
+			this.last_tap = nil
+		} else {
+			this.last_tap = c
+		}
+		return events
+	case c.moved:
+		return this.swipe(c, duration)
+	default:
+		return nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GESTURES
+
+func (this *recognizer) swipe(c *contact, duration time.Duration) []gopi.Event {
+	dx, dy := c.position.X-c.start.X, c.position.Y-c.start.Y
+	dist := distance(c.start, c.position)
+	if dist < this.swipe_distance || duration <= 0 {
+		return nil
+	}
+	velocity := dist / float32(duration.Seconds())
+	if velocity < this.swipe_velocity {
+		return nil
+	}
+	evt := this.newEvent(c, GESTURE_SWIPE)
+	evt.velocity = velocity
+	switch {
+	case math.Abs(float64(dx)) >= math.Abs(float64(dy)) && dx < 0:
+		evt.direction = DIRECTION_LEFT
+	case math.Abs(float64(dx)) >= math.Abs(float64(dy)):
+		evt.direction = DIRECTION_RIGHT
+	case dy < 0:
+		evt.direction = DIRECTION_UP
+	default:
+		evt.direction = DIRECTION_DOWN
+	}
+	return []gopi.Event{evt}
+}
+
+// newPinch returns the pinch state for two contacts on a device
+func (this *recognizer) newPinch(keys []contactKey) *pinch {
+	p := new(pinch)
+	copy(p.contacts[:], keys)
+	a, b := this.contacts[p.contacts[0]], this.contacts[p.contacts[1]]
+	p.distance = distance(a.position, b.position)
+	p.angle = angle(a.position, b.position)
+	return p
+}
+
+func (this *recognizer) pinchMove(pinch *pinch, c *contact) []gopi.Event {
+	a, b := this.contacts[pinch.contacts[0]], this.contacts[pinch.contacts[1]]
+	centre := gopi.Point{(a.position.X + b.position.X) / 2, (a.position.Y + b.position.Y) / 2}
+	events := make([]gopi.Event, 0, 2)
+
+	// Pinch, when the contacts move apart or together. The rotation is
+	// measured from the first contact to the second contact when the
+	// pinch started
+	if pinch.distance > 0 {
+		scale := distance(a.position, b.position) / pinch.distance
+		if pinch.pinching == false && float32(math.Abs(float64(scale-1))) >= this.pinch_scale {
+			pinch.pinching = true
+		}
+		if pinch.pinching {
+			evt := this.newEvent(c, GESTURE_PINCH)
+			evt.position = centre
+			evt.scale = scale
+			events = append(events, evt)
+		}
+	}
+
+	// Rotate, normalized to the range -180 to 180 degrees
+	rotation := angle(a.position, b.position) - pinch.angle
+	for rotation > 180 {
+		rotation -= 360
+	}
+	for rotation <= -180 {
+		rotation += 360
+	}
+	if pinch.rotating == false && float32(math.Abs(float64(rotation))) >= this.rotate_angle {
+		pinch.rotating = true
+	}
+	if pinch.rotating {
+		evt := this.newEvent(c, GESTURE_ROTATE)
+		evt.position = centre
+		evt.rotation = rotation
+		events = append(events, evt)
+	}
+
+	return events
+}
+
+// longpressTimer is called when a contact has been held for the long
+// press duration without moving
+func (this *recognizer) longpressTimer(key contactKey, generation uint) {
+	this.lock.Lock()
+	c, exists := this.contacts[key]
+	if this.closed || exists == false || c.generation != generation || c.moved || c.longpress || c.multi {
+		this.lock.Unlock()
+		return
+	}
+	c.longpress = true
+	evt := this.newEvent(c, GESTURE_LONGPRESS)
+	evt.timestamp = c.start_ts + this.longpress_duration
+	this.wg.Add(1)
+	this.lock.Unlock()
+
+	defer this.wg.Done()
+	this.pubsub.Emit(evt)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// deviceContacts returns the current contacts for a device
+func (this *recognizer) deviceContacts(source gopi.Driver) []contactKey {
+	keys := make([]contactKey, 0, 2)
+	for key := range this.contacts {
+		if key.source == source {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (this *recognizer) newEvent(c *contact, gesture_type GestureType) *gesture_event {
+	return &gesture_event{
+		source:       c.source,
+		timestamp:    c.ts,
+		gesture_type: gesture_type,
+		position:     c.position,
+	}
+}
+
+func (c *contact) stop() {
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+}
+
+func distance(a, b gopi.Point) float32 {
+	return float32(math.Hypot(float64(b.X-a.X), float64(b.Y-a.Y)))
+}
+
+// angle returns the angle in degrees from a to b
+func angle(a, b gopi.Point) float32 {
+	return float32(math.Atan2(float64(b.Y-a.Y), float64(b.X-a.X)) * 180 / math.Pi)
+}
+
+func float32OrDefault(value, def float32) float32 {
+	if value <= 0 {
+		return def
+	}
+	return value
+}
+
+func durationOrDefault(value, def time.Duration) time.Duration {
+	if value <= 0 {
+		return def
+	}
+	return value
+}
@@ -0,0 +1,138 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+// Normalization of joystick and gamepad axes, and mapping of
+// joystick buttons onto standard gamepad buttons
+package joystick
+
+import (
+	"fmt"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Axis normalizes raw values for an absolute axis. Sticks and hats are
+// normalized between -1.0 and 1.0, and triggers, which rest at the
+// minimum value, are normalized between 0.0 and 1.0
+type Axis struct {
+	Axis     gopi.InputAxis
+	Minimum  int32
+	Maximum  int32
+	Trigger  bool
+	DeadZone float32
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+// NewAxis returns an axis from the range reported by a device, and all
+// the axes reported by the device, which determine whether the axis is a
+// trigger. The flat value is the range of raw values reported as zero by
+// the device, which is used as the dead zone
+func NewAxis(axis gopi.InputAxis, minimum, maximum, flat int32, axes []gopi.InputAxis) *Axis {
+	if maximum <= minimum {
+		return nil
+	}
+	this := &Axis{
+		Axis:    axis,
+		Minimum: minimum,
+		Maximum: maximum,
+		Trigger: isTrigger(axis, minimum, axes),
+	}
+	if flat > 0 {
+		if this.Trigger {
+			this.DeadZone = float32(flat) / float32(maximum-minimum)
+		} else {
+			this.DeadZone = 2 * float32(flat) / float32(maximum-minimum)
+		}
+	}
+	return this
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// NORMALIZE
+
+// Normalize returns the value for a raw value. Values within the dead zone
+// are returned as zero, and values outside the dead zone are scaled so that
+// the full range of values is still reported
+func (this *Axis) Normalize(raw int32) float32 {
+	if raw < this.Minimum {
+		raw = this.Minimum
+	} else if raw > this.Maximum {
+		raw = this.Maximum
+	}
+	value := float32(raw-this.Minimum) / float32(this.Maximum-this.Minimum)
+	if this.Trigger == false {
+		value = value*2 - 1
+	}
+	return deadZone(value, this.DeadZone)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Axis) String() string {
+	return fmt.Sprintf("<sys.input.joystick.Axis>{ axis=%v min=%v max=%v trigger=%v dead_zone=%v }", this.Axis, this.Minimum, this.Maximum, this.Trigger, this.DeadZone)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// isTrigger returns true if an axis is a trigger, which rests at the
+// minimum value. Throttle, gas and brake axes are triggers. Gamepads with
+// analog triggers report them on the Z and RZ axes, with the right stick
+// on the RX and RY axes, whereas generic gamepads report the right stick
+// and flight sticks report the twist on the Z and RZ axes
+func isTrigger(axis gopi.InputAxis, minimum int32, axes []gopi.InputAxis) bool {
+	switch axis {
+	case gopi.INPUT_AXIS_THROTTLE, gopi.INPUT_AXIS_GAS, gopi.INPUT_AXIS_BRAKE:
+		return true
+	case gopi.INPUT_AXIS_Z, gopi.INPUT_AXIS_RZ:
+		return minimum >= 0 && hasAxes(axes, gopi.INPUT_AXIS_RX, gopi.INPUT_AXIS_RY)
+	default:
+		return false
+	}
+}
+
+// hasAxes returns true if all the axes are in a list of axes
+func hasAxes(axes []gopi.InputAxis, which ...gopi.InputAxis) bool {
+	for _, axis := range which {
+		found := false
+		for _, other := range axes {
+			if other == axis {
+				found = true
+				break
+			}
+		}
+		if found == false {
+			return false
+		}
+	}
+	return true
+}
+
+func deadZone(value, dead_zone float32) float32 {
+	if dead_zone <= 0 {
+		return value
+	} else if dead_zone >= 1 {
+		return 0
+	}
+	switch {
+	case value > dead_zone:
+		return (value - dead_zone) / (1 - dead_zone)
+	case value < -dead_zone:
+		return (value + dead_zone) / (1 - dead_zone)
+	default:
+		return 0
+	}
+}
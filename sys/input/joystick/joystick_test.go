@@ -0,0 +1,147 @@
+package joystick_test
+
+import (
+	"math"
+	"testing"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	joystick "github.com/djthorpe/gopi/sys/input/joystick"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// AXIS
+
+func TestAxis_000(t *testing.T) {
+	// Stick with a range of 0 to 255 at rest in the centre
+	axis := joystick.NewAxis(gopi.INPUT_AXIS_X, 0, 255, 0, nil)
+	if axis == nil {
+		t.Fatal("Expected axis")
+	} else if axis.Trigger {
+		t.Error("Unexpected trigger", axis)
+	}
+	for _, test := range []struct {
+		raw   int32
+		value float32
+	}{
+		{0, -1}, {255, 1}, {-10, -1}, {300, 1}, {51, -0.6},
+	} {
+		if value := axis.Normalize(test.raw); equals(value, test.value) == false {
+			t.Error("Normalize", test.raw, "expected", test.value, "got", value)
+		}
+	}
+}
+
+func TestAxis_001(t *testing.T) {
+	// Trigger on a gamepad with the right stick on the RX and RY axes
+	gamepad := []gopi.InputAxis{gopi.INPUT_AXIS_X, gopi.INPUT_AXIS_Y, gopi.INPUT_AXIS_Z, gopi.INPUT_AXIS_RX, gopi.INPUT_AXIS_RY, gopi.INPUT_AXIS_RZ}
+	if axis := joystick.NewAxis(gopi.INPUT_AXIS_RZ, 0, 1023, 0, gamepad); axis == nil {
+		t.Fatal("Expected axis")
+	} else if axis.Trigger == false {
+		t.Error("Expected trigger", axis)
+	} else if value := axis.Normalize(0); value != 0 {
+		t.Error("Expected 0, got", value)
+	} else if value := axis.Normalize(1023); value != 1 {
+		t.Error("Expected 1, got", value)
+	}
+
+	// Right stick reported on the same axis by a generic gamepad
+	generic := []gopi.InputAxis{gopi.INPUT_AXIS_X, gopi.INPUT_AXIS_Y, gopi.INPUT_AXIS_Z, gopi.INPUT_AXIS_RZ}
+	if axis := joystick.NewAxis(gopi.INPUT_AXIS_RZ, 0, 255, 0, generic); axis == nil {
+		t.Fatal("Expected axis")
+	} else if axis.Trigger {
+		t.Error("Unexpected trigger", axis)
+	}
+
+	// Twist on a flight stick is not a trigger, but the throttle is
+	flightstick := []gopi.InputAxis{gopi.INPUT_AXIS_X, gopi.INPUT_AXIS_Y, gopi.INPUT_AXIS_RZ, gopi.INPUT_AXIS_THROTTLE}
+	if axis := joystick.NewAxis(gopi.INPUT_AXIS_RZ, 0, 255, 0, flightstick); axis == nil || axis.Trigger {
+		t.Error("Unexpected trigger", axis)
+	} else if axis := joystick.NewAxis(gopi.INPUT_AXIS_THROTTLE, 0, 255, 0, flightstick); axis == nil || axis.Trigger == false {
+		t.Error("Expected trigger", axis)
+	}
+
+	// Invalid range
+	if axis := joystick.NewAxis(gopi.INPUT_AXIS_X, 10, 10, 0, nil); axis != nil {
+		t.Error("Expected nil axis, got", axis)
+	}
+}
+
+func TestAxis_002(t *testing.T) {
+	// Dead zone from the flat value reported by the device
+	axis := joystick.NewAxis(gopi.INPUT_AXIS_X, -32768, 32767, 4096, nil)
+	if equals(axis.DeadZone, 8192.0/65535.0) == false {
+		t.Error("Unexpected dead zone", axis)
+	}
+	axis.DeadZone = 0.2
+	for _, test := range []struct {
+		raw   int32
+		value float32
+	}{
+		{0, 0}, {3000, 0}, {-3000, 0}, {32767, 1}, {-32768, -1}, {19660, 0.5},
+	} {
+		if value := axis.Normalize(test.raw); equals(value, test.value) == false {
+			t.Error("Normalize", test.raw, "expected", test.value, "got", value)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// MAPPING
+
+func TestMapping_000(t *testing.T) {
+	if key := joystick.StandardMapping.Map(gopi.KEYCODE_BTNTHUMB2); key != gopi.KEYCODE_BTNSOUTH {
+		t.Error("Expected KEYCODE_BTNSOUTH, got", key)
+	} else if key := joystick.StandardMapping.Map(gopi.KEYCODE_BTNSTART); key != gopi.KEYCODE_BTNSTART {
+		t.Error("Expected KEYCODE_BTNSTART, got", key)
+	}
+	for _, test := range []struct {
+		axis  gopi.InputAxis
+		value float32
+		key   gopi.KeyCode
+	}{
+		{gopi.INPUT_AXIS_HAT0X, -1, gopi.KEYCODE_BTNDPADLEFT},
+		{gopi.INPUT_AXIS_HAT0X, 1, gopi.KEYCODE_BTNDPADRIGHT},
+		{gopi.INPUT_AXIS_HAT0Y, -1, gopi.KEYCODE_BTNDPADUP},
+		{gopi.INPUT_AXIS_HAT1Y, 1, gopi.KEYCODE_BTNDPADDOWN},
+		{gopi.INPUT_AXIS_HAT0Y, 0, gopi.KEYCODE_NONE},
+		{gopi.INPUT_AXIS_X, 1, gopi.KEYCODE_NONE},
+	} {
+		if key := joystick.Hat(test.axis, test.value); key != test.key {
+			t.Error("Hat", test.axis, test.value, "expected", test.key, "got", key)
+		}
+	}
+}
+
+func TestMapping_001(t *testing.T) {
+	buttons := []gopi.KeyCode{
+		gopi.KEYCODE_BTNTRIGGER, gopi.KEYCODE_BTNTHUMB, gopi.KEYCODE_BTNTHUMB2, gopi.KEYCODE_BTNTOP,
+		gopi.KEYCODE_BTNTOP2, gopi.KEYCODE_BTNPINKIE, gopi.KEYCODE_BTNBASE, gopi.KEYCODE_BTNBASE2,
+		gopi.KEYCODE_BTNBASE3, gopi.KEYCODE_BTNBASE4, gopi.KEYCODE_BTNBASE5, gopi.KEYCODE_BTNBASE6,
+	}
+	axes := []gopi.InputAxis{gopi.INPUT_AXIS_X, gopi.INPUT_AXIS_Y, gopi.INPUT_AXIS_Z, gopi.INPUT_AXIS_RZ, gopi.INPUT_AXIS_HAT0X, gopi.INPUT_AXIS_HAT0Y}
+
+	// Generic gamepad
+	if joystick.IsStandardLayout(buttons, axes) == false {
+		t.Error("Expected standard layout")
+	}
+	// Flight stick with a throttle
+	if joystick.IsStandardLayout(buttons, append(axes, gopi.INPUT_AXIS_THROTTLE)) {
+		t.Error("Unexpected standard layout for flight stick")
+	}
+	// Joystick with fewer buttons
+	if joystick.IsStandardLayout(buttons[:6], axes) {
+		t.Error("Unexpected standard layout for joystick")
+	}
+	// Gamepad which reports gamepad buttons
+	if joystick.IsStandardLayout(append(buttons, gopi.KEYCODE_BTNSOUTH), axes) {
+		t.Error("Unexpected standard layout for gamepad with gamepad buttons")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func equals(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 0.01
+}
@@ -0,0 +1,115 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package joystick
+
+import (
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Mapping translates the buttons reported by a device onto
+// standard gamepad buttons
+type Mapping map[gopi.KeyCode]gopi.KeyCode
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	// StandardMapping maps the numbered buttons reported by generic
+	// USB gamepads onto the standard gamepad layout, and should only be
+	// applied to devices with the standard layout
+	StandardMapping = Mapping{
+		gopi.KEYCODE_BTNTRIGGER: gopi.KEYCODE_BTNNORTH,
+		gopi.KEYCODE_BTNTHUMB:   gopi.KEYCODE_BTNEAST,
+		gopi.KEYCODE_BTNTHUMB2:  gopi.KEYCODE_BTNSOUTH,
+		gopi.KEYCODE_BTNTOP:     gopi.KEYCODE_BTNWEST,
+		gopi.KEYCODE_BTNTOP2:    gopi.KEYCODE_BTNTL,
+		gopi.KEYCODE_BTNPINKIE:  gopi.KEYCODE_BTNTR,
+		gopi.KEYCODE_BTNBASE:    gopi.KEYCODE_BTNTL2,
+		gopi.KEYCODE_BTNBASE2:   gopi.KEYCODE_BTNTR2,
+		gopi.KEYCODE_BTNBASE3:   gopi.KEYCODE_BTNSELECT,
+		gopi.KEYCODE_BTNBASE4:   gopi.KEYCODE_BTNSTART,
+		gopi.KEYCODE_BTNBASE5:   gopi.KEYCODE_BTNTHUMBL,
+		gopi.KEYCODE_BTNBASE6:   gopi.KEYCODE_BTNTHUMBR,
+	}
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// LAYOUT
+
+// IsStandardLayout returns true if the buttons and axes reported by a
+// device are those of a generic USB gamepad, to which StandardMapping
+// applies. These gamepads report the twelve numbered buttons, two sticks
+// and a hat, whereas flight sticks report throttle or rudder axes and
+// other gamepads report gamepad buttons
+func IsStandardLayout(buttons []gopi.KeyCode, axes []gopi.InputAxis) bool {
+	for button := range StandardMapping {
+		if hasButton(buttons, button) == false {
+			return false
+		}
+	}
+	for _, button := range buttons {
+		if button >= gopi.KEYCODE_BTNSOUTH && button <= gopi.KEYCODE_BTNTHUMBR {
+			return false
+		}
+	}
+	for _, axis := range axes {
+		switch axis {
+		case gopi.INPUT_AXIS_THROTTLE, gopi.INPUT_AXIS_RUDDER, gopi.INPUT_AXIS_WHEEL, gopi.INPUT_AXIS_GAS, gopi.INPUT_AXIS_BRAKE:
+			return false
+		}
+	}
+	return hasAxes(axes, gopi.INPUT_AXIS_X, gopi.INPUT_AXIS_Y, gopi.INPUT_AXIS_HAT0X, gopi.INPUT_AXIS_HAT0Y)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// MAP
+
+// Map returns the gamepad button for a button reported by a device, or
+// the button itself if there is no mapping
+func (this Mapping) Map(key gopi.KeyCode) gopi.KeyCode {
+	if mapped, exists := this[key]; exists {
+		return mapped
+	}
+	return key
+}
+
+// Hat returns the directional pad buttons for the value of a hat axis,
+// which is KEYCODE_NONE when the hat is centred
+func Hat(axis gopi.InputAxis, value float32) gopi.KeyCode {
+	switch {
+	case (axis == gopi.INPUT_AXIS_HAT0X || axis == gopi.INPUT_AXIS_HAT1X) && value < 0:
+		return gopi.KEYCODE_BTNDPADLEFT
+	case (axis == gopi.INPUT_AXIS_HAT0X || axis == gopi.INPUT_AXIS_HAT1X) && value > 0:
+		return gopi.KEYCODE_BTNDPADRIGHT
+	case (axis == gopi.INPUT_AXIS_HAT0Y || axis == gopi.INPUT_AXIS_HAT1Y) && value < 0:
+		return gopi.KEYCODE_BTNDPADUP
+	case (axis == gopi.INPUT_AXIS_HAT0Y || axis == gopi.INPUT_AXIS_HAT1Y) && value > 0:
+		return gopi.KEYCODE_BTNDPADDOWN
+	default:
+		return gopi.KEYCODE_NONE
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// hasButton returns true if a button is in a list of buttons
+func hasButton(buttons []gopi.KeyCode, button gopi.KeyCode) bool {
+	for _, other := range buttons {
+		if other == button {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package keymap
+
+import (
+	"sync"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+// Dead keys are represented by the combining diacritical mark
+const (
+	DEAD_GRAVE      rune = 0x0300
+	DEAD_ACUTE      rune = 0x0301
+	DEAD_CIRCUMFLEX rune = 0x0302
+	DEAD_TILDE      rune = 0x0303
+	DEAD_DIAERESIS  rune = 0x0308
+	DEAD_CEDILLA    rune = 0x0327
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	// Spacing form of each dead key, emitted when the dead key is
+	// followed by space or by a rune which cannot be composed
+	dead_spacing = map[rune]rune{
+		DEAD_GRAVE:      '`',
+		DEAD_ACUTE:      '´',
+		DEAD_CIRCUMFLEX: '^',
+		DEAD_TILDE:      '~',
+		DEAD_DIAERESIS:  '¨',
+		DEAD_CEDILLA:    '¸',
+	}
+
+	// Pairs of base and composed runes for each dead key
+	dead_pairs = map[rune]string{
+		DEAD_GRAVE:      "aàeèiìoòuùAÀEÈIÌOÒUÙ",
+		DEAD_ACUTE:      "aáeéiíoóuúyýcćnńsśzźAÁEÉIÍOÓUÚYÝCĆNŃSŚZŹ",
+		DEAD_CIRCUMFLEX: "aâeêiîoôuûAÂEÊIÎOÔUÛ",
+		DEAD_TILDE:      "aãnñoõAÃNÑOÕ",
+		DEAD_DIAERESIS:  "aäeëiïoöuüyÿAÄEËIÏOÖUÜYŸ",
+		DEAD_CEDILLA:    "cçCÇ",
+	}
+
+	// Compose sequences of two runes, which can be typed in either order
+	compose_sequences = map[string]rune{
+		"ae": 'æ', "AE": 'Æ', "oe": 'œ', "OE": 'Œ', "ss": 'ß',
+		"o/": 'ø', "O/": 'Ø', "ao": 'å', "AO": 'Å',
+		"e=": '€', "L-": '£', "Y=": '¥', "c|": '¢',
+		"oc": '©', "or": '®', "TM": '™', "so": '§', "P!": '¶',
+		"12": '½', "14": '¼', "34": '¾', "^1": '¹', "^2": '²', "^3": '³',
+		"!!": '¡', "??": '¿', "<<": '«', ">>": '»',
+		"+-": '±', "xx": '×', ":-": '÷', "oo": '°', "mu": 'µ', "..": '·',
+	}
+
+	// Composed runes by dead key and base rune, and by compose sequence
+	compose_table map[[2]rune]rune
+	compose_once  sync.Once
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// IsDead returns true if the rune represents a dead key
+func IsDead(r rune) bool {
+	_, exists := dead_spacing[r]
+	return exists
+}
+
+// Compose returns the rune composed from two runes, which are either a dead
+// key followed by a base rune, or a compose sequence. Returns zero if the
+// runes cannot be composed
+func Compose(a, b rune) rune {
+	compose_once.Do(composeInit)
+
+	// Dead keys followed by space return the spacing form
+	if IsDead(a) && b == ' ' {
+		return dead_spacing[a]
+	}
+	if r, exists := compose_table[[2]rune{a, b}]; exists {
+		return r
+	}
+	// Use the spacing forms of dead keys in compose sequences
+	if IsDead(a) {
+		a = dead_spacing[a]
+	}
+	if IsDead(b) {
+		b = dead_spacing[b]
+	}
+	if r, exists := compose_table[[2]rune{a, b}]; exists {
+		return r
+	} else if r, exists := compose_table[[2]rune{b, a}]; exists {
+		return r
+	}
+	return 0
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func composeInit() {
+	compose_table = make(map[[2]rune]rune)
+
+	// Dead keys, and compose sequences using the spacing form of
+	// the accent or an apostrophe, quote or comma
+	accents := map[rune][]rune{
+		DEAD_GRAVE:      {'`'},
+		DEAD_ACUTE:      {'´', '\''},
+		DEAD_CIRCUMFLEX: {'^'},
+		DEAD_TILDE:      {'~'},
+		DEAD_DIAERESIS:  {'¨', '"'},
+		DEAD_CEDILLA:    {'¸', ','},
+	}
+	for dead, pairs := range dead_pairs {
+		runes := []rune(pairs)
+		for i := 0; i+1 < len(runes); i += 2 {
+			compose_table[[2]rune{dead, runes[i]}] = runes[i+1]
+			for _, accent := range accents[dead] {
+				compose_table[[2]rune{accent, runes[i]}] = runes[i+1]
+			}
+		}
+	}
+
+	// Compose sequences
+	for sequence, r := range compose_sequences {
+		runes := []rune(sequence)
+		compose_table[[2]rune{runes[0], runes[1]}] = r
+	}
+}
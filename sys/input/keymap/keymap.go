@@ -0,0 +1,242 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package keymap
+
+import (
+	"fmt"
+	"sync"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Keymap translates key events into runes using a layout. It tracks
+// the modifier and lock state from the key events, and handles dead
+// keys and compose sequences started with the compose key
+type Keymap struct {
+	layout    *Layout
+	state     gopi.KeyState
+	dead      rune
+	compose   []rune
+	composing bool
+	lock      sync.Mutex
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	// Modifier keys, which set the state whilst pressed
+	modifiers = map[gopi.KeyCode]gopi.KeyState{
+		gopi.KEYCODE_LEFTSHIFT:  gopi.KEYSTATE_LEFTSHIFT,
+		gopi.KEYCODE_RIGHTSHIFT: gopi.KEYSTATE_RIGHTSHIFT,
+		gopi.KEYCODE_LEFTALT:    gopi.KEYSTATE_LEFTALT,
+		gopi.KEYCODE_RIGHTALT:   gopi.KEYSTATE_RIGHTALT,
+		gopi.KEYCODE_LEFTCTRL:   gopi.KEYSTATE_LEFTCTRL,
+		gopi.KEYCODE_RIGHTCTRL:  gopi.KEYSTATE_RIGHTCTRL,
+		gopi.KEYCODE_LEFTMETA:   gopi.KEYSTATE_LEFTMETA,
+		gopi.KEYCODE_RIGHTMETA:  gopi.KEYSTATE_RIGHTMETA,
+	}
+
+	// Lock keys, which toggle the state when pressed
+	locks = map[gopi.KeyCode]gopi.KeyState{
+		gopi.KEYCODE_CAPSLOCK:   gopi.KEYSTATE_CAPSLOCK,
+		gopi.KEYCODE_NUMLOCK:    gopi.KEYSTATE_NUMLOCK,
+		gopi.KEYCODE_SCROLLLOCK: gopi.KEYSTATE_SCROLLLOCK,
+	}
+)
+
+const (
+	// Modifiers which are used for shortcuts rather than text, the
+	// right alt key is AltGr
+	KEYSTATE_SHORTCUT = gopi.KEYSTATE_LEFTALT | gopi.KEYSTATE_CTRL | gopi.KEYSTATE_META
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+// NewKeymap returns a keymap for a layout, with num lock on
+func NewKeymap(layout *Layout) *Keymap {
+	if layout == nil {
+		return nil
+	}
+	this := new(Keymap)
+	this.layout = layout
+	this.state = gopi.KEYSTATE_NUMLOCK
+	return this
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PROPERTIES
+
+// Layout returns the current layout
+func (this *Keymap) Layout() *Layout {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.layout
+}
+
+// SetLayout changes the layout and cancels any pending dead key or
+// compose sequence
+func (this *Keymap) SetLayout(layout *Layout) error {
+	if layout == nil {
+		return gopi.ErrBadParameter
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.layout = layout
+	this.reset()
+	return nil
+}
+
+// KeyState returns the current modifier and lock state
+func (this *Keymap) KeyState() gopi.KeyState {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.state
+}
+
+// SetKeyState sets the modifier and lock state, for example from the
+// state reported by an input device
+func (this *Keymap) SetKeyState(state gopi.KeyState) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.state = state & gopi.KEYSTATE_MASK
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PROCESS EVENTS
+
+// Process updates the modifier state from a key event and returns the runes
+// produced by the event. No runes are returned for key releases, modifier
+// keys, shortcuts and whilst a dead key or compose sequence is pending. More
+// than one rune is returned when a dead key cannot be composed, and nothing
+// is returned when backspace cancels a dead key
+func (this *Keymap) Process(evt gopi.InputEvent) []rune {
+	if evt == nil {
+		return nil
+	}
+	switch evt.EventType() {
+	case gopi.INPUT_EVENT_KEYPRESS, gopi.INPUT_EVENT_KEYREPEAT, gopi.INPUT_EVENT_KEYRELEASE:
+		return this.ProcessKey(evt.Keycode(), evt.EventType())
+	default:
+		return nil
+	}
+}
+
+// ProcessKey updates the modifier state from a key press, repeat or release
+// and returns the runes produced
+func (this *Keymap) ProcessKey(key gopi.KeyCode, event_type gopi.InputEventType) []rune {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	press := event_type == gopi.INPUT_EVENT_KEYPRESS
+	release := event_type == gopi.INPUT_EVENT_KEYRELEASE
+
+	// Modifier and lock keys
+	if flag, exists := modifiers[key]; exists {
+		if press {
+			this.state |= flag
+		} else if release {
+			this.state &^= flag
+		}
+		return nil
+	} else if flag, exists := locks[key]; exists {
+		if press {
+			this.state ^= flag
+		}
+		return nil
+	} else if release {
+		return nil
+	}
+
+	// Compose key starts a compose sequence
+	if key == gopi.KEYCODE_COMPOSE {
+		if press {
+			this.reset()
+			this.composing = true
+		}
+		return nil
+	}
+
+	// Ignore shortcuts
+	if this.state&KEYSTATE_SHORTCUT != 0 {
+		return nil
+	}
+
+	if r := this.layout.Rune(key, this.state); r == 0 {
+		return nil
+	} else {
+		return this.combine(r)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Keymap) String() string {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return fmt.Sprintf("<sys.input.keymap.Keymap>{ layout=%v state=%v composing=%v }", this.layout.Name(), this.state, this.composing)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// reset cancels any pending dead key or compose sequence
+func (this *Keymap) reset() {
+	this.dead = 0
+	this.compose = nil
+	this.composing = false
+}
+
+// combine returns the runes produced by a rune, taking into account
+// any pending dead key or compose sequence
+func (this *Keymap) combine(r rune) []rune {
+	switch {
+	case this.composing:
+		// Control characters cancel the sequence
+		if r < ' ' {
+			this.reset()
+			return nil
+		}
+		this.compose = append(this.compose, r)
+		if len(this.compose) < 2 {
+			return nil
+		}
+		composed := Compose(this.compose[0], this.compose[1])
+		this.reset()
+		if composed == 0 {
+			return nil
+		}
+		return []rune{composed}
+	case this.dead != 0:
+		dead := this.dead
+		this.dead = 0
+		if r == '\b' {
+			// Backspace cancels the dead key
+			return nil
+		} else if composed := Compose(dead, r); composed != 0 {
+			return []rune{composed}
+		} else if r == dead {
+			return []rune{dead_spacing[dead]}
+		} else {
+			return append([]rune{dead_spacing[dead]}, this.combine(r)...)
+		}
+	case IsDead(r):
+		this.dead = r
+		return nil
+	default:
+		return []rune{r}
+	}
+}
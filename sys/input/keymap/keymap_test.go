@@ -0,0 +1,155 @@
+package keymap_test
+
+import (
+	"strings"
+	"testing"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	keymap "github.com/djthorpe/gopi/sys/input/keymap"
+	mock "github.com/djthorpe/gopi/sys/input/mock"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// LAYOUTS
+
+func TestLayout_000(t *testing.T) {
+	for _, name := range []string{"us", "uk", "de", "fr"} {
+		if layout, err := keymap.LayoutByName(name); err != nil {
+			t.Error(name, err)
+		} else if layout.Name() != name {
+			t.Error("Unexpected name", layout)
+		}
+	}
+	if _, err := keymap.LayoutByName("xx"); err != gopi.ErrNotFound {
+		t.Error("Expected ErrNotFound, got", err)
+	}
+}
+
+func TestLayout_001(t *testing.T) {
+	tests := []struct {
+		layout string
+		key    gopi.KeyCode
+		state  gopi.KeyState
+		r      rune
+	}{
+		{"us", gopi.KEYCODE_A, gopi.KEYSTATE_NONE, 'a'},
+		{"us", gopi.KEYCODE_A, gopi.KEYSTATE_LEFTSHIFT, 'A'},
+		{"us", gopi.KEYCODE_A, gopi.KEYSTATE_CAPSLOCK, 'A'},
+		{"us", gopi.KEYCODE_A, gopi.KEYSTATE_CAPSLOCK | gopi.KEYSTATE_RIGHTSHIFT, 'a'},
+		{"us", gopi.KEYCODE_1, gopi.KEYSTATE_CAPSLOCK, '1'},
+		{"us", gopi.KEYCODE_2, gopi.KEYSTATE_LEFTSHIFT, '@'},
+		{"us", gopi.KEYCODE_KP1, gopi.KEYSTATE_NONE, 0},
+		{"us", gopi.KEYCODE_KP1, gopi.KEYSTATE_NUMLOCK, '1'},
+		{"uk", gopi.KEYCODE_2, gopi.KEYSTATE_LEFTSHIFT, '"'},
+		{"uk", gopi.KEYCODE_3, gopi.KEYSTATE_LEFTSHIFT, '£'},
+		{"uk", gopi.KEYCODE_4, gopi.KEYSTATE_RIGHTALT, '€'},
+		{"de", gopi.KEYCODE_Y, gopi.KEYSTATE_NONE, 'z'},
+		{"de", gopi.KEYCODE_SEMICOLON, gopi.KEYSTATE_CAPSLOCK, 'Ö'},
+		{"de", gopi.KEYCODE_Q, gopi.KEYSTATE_RIGHTALT, '@'},
+		{"de", gopi.KEYCODE_EQUAL, gopi.KEYSTATE_NONE, keymap.DEAD_ACUTE},
+		{"fr", gopi.KEYCODE_Q, gopi.KEYSTATE_NONE, 'a'},
+		{"fr", gopi.KEYCODE_2, gopi.KEYSTATE_NONE, 'é'},
+		{"fr", gopi.KEYCODE_2, gopi.KEYSTATE_LEFTSHIFT, '2'},
+		{"fr", gopi.KEYCODE_2, gopi.KEYSTATE_CAPSLOCK, 'é'},
+	}
+	for _, test := range tests {
+		layout, _ := keymap.LayoutByName(test.layout)
+		if r := layout.Rune(test.key, test.state); r != test.r {
+			t.Errorf("%v %v %v: expected %q, got %q", test.layout, test.key, test.state, test.r, r)
+		}
+	}
+}
+
+func TestLayout_002(t *testing.T) {
+	table := `
+# Test layout
+name test
+include us
+KEYCODE_A U+03B1 U+0391
+0x0030 - b
+`
+	if layout, err := keymap.ReadLayout(strings.NewReader(table)); err != nil {
+		t.Fatal(err)
+	} else if r := layout.Rune(gopi.KEYCODE_A, gopi.KEYSTATE_LEFTSHIFT); r != 'Α' {
+		t.Errorf("Expected alpha, got %q", r)
+	} else if r := layout.Rune(gopi.KEYCODE_B, gopi.KEYSTATE_NONE); r != 0 {
+		t.Errorf("Expected no rune, got %q", r)
+	} else if r := layout.Rune(gopi.KEYCODE_Z, gopi.KEYSTATE_NONE); r != 'z' {
+		t.Errorf("Expected z, got %q", r)
+	}
+	if _, err := keymap.ReadLayout(strings.NewReader("name bad\nKEYCODE_XX a")); err == nil {
+		t.Error("Expected error for bad key code")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// DEAD KEYS AND COMPOSE
+
+func TestKeymap_000(t *testing.T) {
+	layout, _ := keymap.LayoutByName("de")
+	k := keymap.NewKeymap(layout)
+	tests := []struct {
+		keys []gopi.KeyCode
+		text string
+	}{
+		{[]gopi.KeyCode{gopi.KEYCODE_EQUAL, gopi.KEYCODE_E}, "é"},
+		{[]gopi.KeyCode{gopi.KEYCODE_GRAVE, gopi.KEYCODE_O}, "ô"},
+		{[]gopi.KeyCode{gopi.KEYCODE_GRAVE, gopi.KEYCODE_SPACE}, "^"},
+		{[]gopi.KeyCode{gopi.KEYCODE_GRAVE, gopi.KEYCODE_X}, "^x"},
+		{[]gopi.KeyCode{gopi.KEYCODE_GRAVE, gopi.KEYCODE_BACKSPACE, gopi.KEYCODE_O}, "o"},
+		{[]gopi.KeyCode{gopi.KEYCODE_COMPOSE, gopi.KEYCODE_S, gopi.KEYCODE_S}, "ß"},
+		{[]gopi.KeyCode{gopi.KEYCODE_COMPOSE, gopi.KEYCODE_Q, gopi.KEYCODE_Q}, ""},
+	}
+	for _, test := range tests {
+		text := ""
+		for _, key := range test.keys {
+			text += string(k.ProcessKey(key, gopi.INPUT_EVENT_KEYPRESS))
+			text += string(k.ProcessKey(key, gopi.INPUT_EVENT_KEYRELEASE))
+		}
+		if text != test.text {
+			t.Errorf("%v: expected %q, got %q", test.keys, test.text, text)
+		}
+	}
+}
+
+func TestKeymap_001(t *testing.T) {
+	layout, _ := keymap.LayoutByName("us")
+	k := keymap.NewKeymap(layout)
+	k.ProcessKey(gopi.KEYCODE_LEFTSHIFT, gopi.INPUT_EVENT_KEYPRESS)
+	if r := k.ProcessKey(gopi.KEYCODE_A, gopi.INPUT_EVENT_KEYPRESS); string(r) != "A" {
+		t.Errorf("Expected A, got %q", string(r))
+	}
+	k.ProcessKey(gopi.KEYCODE_LEFTSHIFT, gopi.INPUT_EVENT_KEYRELEASE)
+	if r := k.ProcessKey(gopi.KEYCODE_A, gopi.INPUT_EVENT_KEYREPEAT); string(r) != "a" {
+		t.Errorf("Expected a, got %q", string(r))
+	}
+	k.ProcessKey(gopi.KEYCODE_LEFTCTRL, gopi.INPUT_EVENT_KEYPRESS)
+	if r := k.ProcessKey(gopi.KEYCODE_C, gopi.INPUT_EVENT_KEYPRESS); len(r) != 0 {
+		t.Errorf("Expected no runes for shortcut, got %q", string(r))
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// TEXT ENTRY
+
+func TestTextEntry_000(t *testing.T) {
+	layout, _ := keymap.LayoutByName("us")
+	entry := keymap.NewTextEntry(layout, false)
+	for _, key := range []gopi.KeyCode{gopi.KEYCODE_H, gopi.KEYCODE_I, gopi.KEYCODE_X, gopi.KEYCODE_BACKSPACE, gopi.KEYCODE_1, gopi.KEYCODE_ENTER} {
+		entry.Process(mock.NewKeyEvent(nil, 0, gopi.INPUT_EVENT_KEYPRESS, key, 0))
+		entry.Process(mock.NewKeyEvent(nil, 0, gopi.INPUT_EVENT_KEYRELEASE, key, 0))
+	}
+	if entry.Text() != "hi1" {
+		t.Errorf("Expected \"hi1\", got %q", entry.Text())
+	}
+	if entry.Process(mock.NewKeyEvent(nil, 0, gopi.INPUT_EVENT_KEYPRESS, gopi.KEYCODE_BACKSPACE, 0)) == false {
+		t.Error("Expected text to change")
+	} else if entry.Text() != "hi" {
+		t.Errorf("Expected \"hi\", got %q", entry.Text())
+	}
+	entry.Reset()
+	if entry.Process(mock.NewKeyEvent(nil, 0, gopi.INPUT_EVENT_KEYPRESS, gopi.KEYCODE_BACKSPACE, 0)) {
+		t.Error("Expected text not to change")
+	}
+}
@@ -0,0 +1,315 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package keymap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Layout maps key codes to runes. Each key has up to four levels:
+// unmodified, shift, AltGr (right alt) and shift with AltGr. Dead
+// keys are represented by the combining diacritical mark
+type Layout struct {
+	name string
+	keys map[gopi.KeyCode][LAYOUT_LEVELS]rune
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Number of levels for each key
+	LAYOUT_LEVELS = 4
+)
+
+const (
+	LAYOUT_LEVEL_NONE = iota
+	LAYOUT_LEVEL_SHIFT
+	LAYOUT_LEVEL_ALTGR
+	LAYOUT_LEVEL_SHIFT_ALTGR
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	// Registered layouts, by name
+	layouts     = make(map[string]*Layout)
+	layout_lock sync.Mutex
+
+	// Key codes by name, for parsing layout tables
+	keycodes = make(map[string]gopi.KeyCode)
+
+	// Named runes, for parsing layout tables
+	named_runes = map[string]rune{
+		"space":           ' ',
+		"tab":             '\t',
+		"enter":           '\n',
+		"backspace":       '\b',
+		"escape":          0x1B,
+		"dead_grave":      DEAD_GRAVE,
+		"dead_acute":      DEAD_ACUTE,
+		"dead_circumflex": DEAD_CIRCUMFLEX,
+		"dead_tilde":      DEAD_TILDE,
+		"dead_diaeresis":  DEAD_DIAERESIS,
+		"dead_cedilla":    DEAD_CEDILLA,
+	}
+
+	// Keypad keys which only produce runes when num lock is on
+	keypad = map[gopi.KeyCode]bool{
+		gopi.KEYCODE_KP0: true, gopi.KEYCODE_KP1: true, gopi.KEYCODE_KP2: true,
+		gopi.KEYCODE_KP3: true, gopi.KEYCODE_KP4: true, gopi.KEYCODE_KP5: true,
+		gopi.KEYCODE_KP6: true, gopi.KEYCODE_KP7: true, gopi.KEYCODE_KP8: true,
+		gopi.KEYCODE_KP9: true, gopi.KEYCODE_KPDOT: true, gopi.KEYCODE_KPCOMMA: true,
+	}
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+// NewLayout returns an empty layout
+func NewLayout(name string) *Layout {
+	this := new(Layout)
+	this.name = name
+	this.keys = make(map[gopi.KeyCode][LAYOUT_LEVELS]rune)
+	return this
+}
+
+// ReadLayout parses a layout table. Each line of the table is either
+// blank, a comment starting with '#', "name <name>", "include <name>"
+// to copy the keys from a registered layout, or a key code followed
+// by up to four runes for each level. Key codes are names such as
+// KEYCODE_A or numbers. Runes are single characters, U+XXXX code
+// points, names such as space or dead_acute, or '-' for no rune
+func ReadLayout(r io.Reader) (*Layout, error) {
+	this := NewLayout("")
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch fields[0] {
+		case "name":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("Line %v: %v", line, gopi.ErrBadParameter)
+			}
+			this.name = fields[1]
+		case "include":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("Line %v: %v", line, gopi.ErrBadParameter)
+			} else if other, err := LayoutByName(fields[1]); err != nil {
+				return nil, fmt.Errorf("Line %v: %v: %v", line, fields[1], err)
+			} else {
+				for key, levels := range other.keys {
+					this.keys[key] = levels
+				}
+			}
+		default:
+			if len(fields) < 2 || len(fields) > LAYOUT_LEVELS+1 {
+				return nil, fmt.Errorf("Line %v: %v", line, gopi.ErrBadParameter)
+			}
+			key, err := parseKeyCode(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("Line %v: %v: %v", line, fields[0], err)
+			}
+			runes := make([]rune, 0, LAYOUT_LEVELS)
+			for _, field := range fields[1:] {
+				if r, err := parseRune(field); err != nil {
+					return nil, fmt.Errorf("Line %v: %v: %v", line, field, err)
+				} else {
+					runes = append(runes, r)
+				}
+			}
+			this.SetKey(key, runes...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if this.name == "" {
+		return nil, fmt.Errorf("Missing layout name: %v", gopi.ErrBadParameter)
+	}
+	return this, nil
+}
+
+// LoadLayout reads a layout table from a file
+func LoadLayout(path string) (*Layout, error) {
+	if file, err := os.Open(path); err != nil {
+		return nil, err
+	} else {
+		defer file.Close()
+		return ReadLayout(file)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// REGISTER LAYOUTS
+
+// RegisterLayout makes a layout available through LayoutByName,
+// replacing any existing layout with the same name
+func RegisterLayout(layout *Layout) error {
+	if layout == nil || layout.name == "" {
+		return gopi.ErrBadParameter
+	}
+	layout_lock.Lock()
+	defer layout_lock.Unlock()
+	layouts[layout.name] = layout
+	return nil
+}
+
+// LayoutByName returns a registered layout, or ErrNotFound
+func LayoutByName(name string) (*Layout, error) {
+	layout_lock.Lock()
+	defer layout_lock.Unlock()
+	if layout, exists := layouts[name]; exists {
+		return layout, nil
+	} else {
+		return nil, gopi.ErrNotFound
+	}
+}
+
+// Layouts returns the names of registered layouts
+func Layouts() []string {
+	layout_lock.Lock()
+	defer layout_lock.Unlock()
+	names := make([]string, 0, len(layouts))
+	for name := range layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PROPERTIES
+
+// Name returns the name of the layout
+func (this *Layout) Name() string {
+	return this.name
+}
+
+// SetKey sets the runes for each level of a key. Missing levels
+// produce no rune
+func (this *Layout) SetKey(key gopi.KeyCode, runes ...rune) {
+	var levels [LAYOUT_LEVELS]rune
+	copy(levels[:], runes)
+	this.keys[key] = levels
+}
+
+// Rune returns the rune for a key with the modifier state, or zero
+// if the key produces no rune. Caps lock inverts shift for letters,
+// and keypad digits require num lock. A dead key returns the combining
+// diacritical mark, which is composed with the next rune by Keymap
+func (this *Layout) Rune(key gopi.KeyCode, state gopi.KeyState) rune {
+	levels, exists := this.keys[key]
+	if exists == false {
+		return 0
+	}
+	if keypad[key] && state&gopi.KEYSTATE_NUMLOCK == 0 {
+		return 0
+	}
+
+	// Determine the shift state
+	shift := state&gopi.KEYSTATE_SHIFT != 0
+	if state&gopi.KEYSTATE_CAPSLOCK != 0 && unicode.IsLetter(levels[LAYOUT_LEVEL_NONE]) && unicode.ToUpper(levels[LAYOUT_LEVEL_NONE]) == levels[LAYOUT_LEVEL_SHIFT] {
+		shift = !shift
+	}
+
+	// Return the rune for the level
+	switch {
+	case state&gopi.KEYSTATE_RIGHTALT != 0 && shift:
+		if levels[LAYOUT_LEVEL_SHIFT_ALTGR] != 0 {
+			return levels[LAYOUT_LEVEL_SHIFT_ALTGR]
+		}
+		return levels[LAYOUT_LEVEL_ALTGR]
+	case state&gopi.KEYSTATE_RIGHTALT != 0:
+		return levels[LAYOUT_LEVEL_ALTGR]
+	case shift:
+		return levels[LAYOUT_LEVEL_SHIFT]
+	default:
+		return levels[LAYOUT_LEVEL_NONE]
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Layout) String() string {
+	return fmt.Sprintf("<sys.input.keymap.Layout>{ name=%v keys=%v }", this.name, len(this.keys))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func parseKeyCode(value string) (gopi.KeyCode, error) {
+	if key, exists := keycodes[value]; exists {
+		return key, nil
+	} else if key, err := strconv.ParseUint(value, 0, 16); err != nil {
+		return gopi.KEYCODE_NONE, gopi.ErrBadParameter
+	} else if gopi.KeyCode(key) == gopi.KEYCODE_NONE || gopi.KeyCode(key) > gopi.KEYCODE_MAX {
+		return gopi.KEYCODE_NONE, gopi.ErrBadParameter
+	} else {
+		return gopi.KeyCode(key), nil
+	}
+}
+
+func parseRune(value string) (rune, error) {
+	if value == "-" {
+		return 0, nil
+	} else if r, exists := named_runes[value]; exists {
+		return r, nil
+	} else if strings.HasPrefix(value, "U+") {
+		if r, err := strconv.ParseUint(value[2:], 16, 32); err != nil || utf8.ValidRune(rune(r)) == false {
+			return 0, gopi.ErrBadParameter
+		} else {
+			return rune(r), nil
+		}
+	} else if r, size := utf8.DecodeRuneInString(value); r == utf8.RuneError || size != len(value) {
+		return 0, gopi.ErrBadParameter
+	} else {
+		return r, nil
+	}
+}
+
+func init() {
+	// Index key codes by name
+	for key := gopi.KEYCODE_NONE + 1; key <= gopi.KEYCODE_MAX; key++ {
+		if name := key.String(); strings.HasPrefix(name, "KEYCODE_0x") == false {
+			keycodes[name] = key
+		}
+	}
+
+	// Register built-in layouts, in order of inclusion
+	for _, table := range builtin_layouts {
+		if layout, err := ReadLayout(strings.NewReader(table)); err != nil {
+			panic(err)
+		} else if err := RegisterLayout(layout); err != nil {
+			panic(err)
+		}
+	}
+}
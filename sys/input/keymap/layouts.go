@@ -0,0 +1,178 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package keymap
+
+////////////////////////////////////////////////////////////////////////////////
+// BUILT-IN LAYOUTS
+
+// Built-in layout tables, which are registered in order so that
+// later tables can include earlier ones
+var builtin_layouts = []string{
+	layout_us, layout_uk, layout_de, layout_fr,
+}
+
+const layout_us = `
+# US English
+name us
+KEYCODE_GRAVE      ` + "`" + ` ~
+KEYCODE_1          1 !
+KEYCODE_2          2 @
+KEYCODE_3          3 #
+KEYCODE_4          4 $
+KEYCODE_5          5 %
+KEYCODE_6          6 ^
+KEYCODE_7          7 &
+KEYCODE_8          8 *
+KEYCODE_9          9 (
+KEYCODE_0          0 )
+KEYCODE_MINUS      - _
+KEYCODE_EQUAL      = +
+KEYCODE_Q          q Q
+KEYCODE_W          w W
+KEYCODE_E          e E
+KEYCODE_R          r R
+KEYCODE_T          t T
+KEYCODE_Y          y Y
+KEYCODE_U          u U
+KEYCODE_I          i I
+KEYCODE_O          o O
+KEYCODE_P          p P
+KEYCODE_LEFTBRACE  [ {
+KEYCODE_RIGHTBRACE ] }
+KEYCODE_A          a A
+KEYCODE_S          s S
+KEYCODE_D          d D
+KEYCODE_F          f F
+KEYCODE_G          g G
+KEYCODE_H          h H
+KEYCODE_J          j J
+KEYCODE_K          k K
+KEYCODE_L          l L
+KEYCODE_SEMICOLON  ; :
+KEYCODE_APOSTROPHE ' "
+KEYCODE_BACKSLASH  \ |
+KEYCODE_102ND      \ |
+KEYCODE_Z          z Z
+KEYCODE_X          x X
+KEYCODE_C          c C
+KEYCODE_V          v V
+KEYCODE_B          b B
+KEYCODE_N          n N
+KEYCODE_M          m M
+KEYCODE_COMMA      , <
+KEYCODE_DOT        . >
+KEYCODE_SLASH      / ?
+KEYCODE_SPACE      space space space space
+KEYCODE_TAB        tab tab
+KEYCODE_ENTER      enter enter
+KEYCODE_BACKSPACE  backspace backspace
+KEYCODE_ESC        escape escape
+KEYCODE_KP0        0
+KEYCODE_KP1        1
+KEYCODE_KP2        2
+KEYCODE_KP3        3
+KEYCODE_KP4        4
+KEYCODE_KP5        5
+KEYCODE_KP6        6
+KEYCODE_KP7        7
+KEYCODE_KP8        8
+KEYCODE_KP9        9
+KEYCODE_KPDOT      .
+KEYCODE_KPCOMMA    ,
+KEYCODE_KPSLASH    /
+KEYCODE_KPASTERISK *
+KEYCODE_KPMINUS    -
+KEYCODE_KPPLUS     +
+KEYCODE_KPEQUAL    =
+KEYCODE_KPENTER    enter
+`
+
+const layout_uk = `
+# UK English
+name uk
+include us
+KEYCODE_GRAVE      ` + "`" + ` ¬ ¦
+KEYCODE_2          2 "
+KEYCODE_3          3 £
+KEYCODE_4          4 $ €
+KEYCODE_APOSTROPHE ' @
+KEYCODE_BACKSLASH  # ~
+KEYCODE_102ND      \ |
+KEYCODE_A          a A á Á
+KEYCODE_E          e E é É
+KEYCODE_I          i I í Í
+KEYCODE_O          o O ó Ó
+KEYCODE_U          u U ú Ú
+`
+
+const layout_de = `
+# German
+name de
+include us
+KEYCODE_GRAVE      dead_circumflex °
+KEYCODE_2          2 " ²
+KEYCODE_3          3 § ³
+KEYCODE_6          6 &
+KEYCODE_7          7 / {
+KEYCODE_8          8 ( [
+KEYCODE_9          9 ) ]
+KEYCODE_0          0 = }
+KEYCODE_MINUS      ß ? \
+KEYCODE_EQUAL      dead_acute dead_grave
+KEYCODE_Q          q Q @
+KEYCODE_E          e E €
+KEYCODE_Y          z Z
+KEYCODE_LEFTBRACE  ü Ü
+KEYCODE_RIGHTBRACE + * ~
+KEYCODE_SEMICOLON  ö Ö
+KEYCODE_APOSTROPHE ä Ä
+KEYCODE_BACKSLASH  # '
+KEYCODE_102ND      < > |
+KEYCODE_Z          y Y
+KEYCODE_M          m M µ
+KEYCODE_COMMA      , ;
+KEYCODE_DOT        . :
+KEYCODE_SLASH      - _
+KEYCODE_KPDOT      ,
+`
+
+const layout_fr = `
+# French (AZERTY)
+name fr
+include us
+KEYCODE_GRAVE      ²
+KEYCODE_1          & 1
+KEYCODE_2          é 2 dead_tilde
+KEYCODE_3          " 3 #
+KEYCODE_4          ' 4 {
+KEYCODE_5          ( 5 [
+KEYCODE_6          - 6 |
+KEYCODE_7          è 7 dead_grave
+KEYCODE_8          _ 8 \
+KEYCODE_9          ç 9 ^
+KEYCODE_0          à 0 @
+KEYCODE_MINUS      ) ° ]
+KEYCODE_EQUAL      = + }
+KEYCODE_Q          a A
+KEYCODE_W          z Z
+KEYCODE_E          e E €
+KEYCODE_LEFTBRACE  dead_circumflex dead_diaeresis
+KEYCODE_RIGHTBRACE $ £ ¤
+KEYCODE_A          q Q
+KEYCODE_SEMICOLON  m M
+KEYCODE_APOSTROPHE ù %
+KEYCODE_BACKSLASH  * µ
+KEYCODE_102ND      < >
+KEYCODE_Z          w W
+KEYCODE_M          , ?
+KEYCODE_COMMA      ; .
+KEYCODE_DOT        : /
+KEYCODE_SLASH      ! §
+`
@@ -0,0 +1,114 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package keymap
+
+import (
+	"fmt"
+	"sync"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// TextEntry builds a string from key events. Backspace removes the last
+// rune, and enter is only added to the text when multiline is true
+type TextEntry struct {
+	keymap    *Keymap
+	text      []rune
+	multiline bool
+	lock      sync.Mutex
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+// NewTextEntry returns a text entry helper for a layout
+func NewTextEntry(layout *Layout, multiline bool) *TextEntry {
+	if keymap := NewKeymap(layout); keymap == nil {
+		return nil
+	} else {
+		this := new(TextEntry)
+		this.keymap = keymap
+		this.text = make([]rune, 0)
+		this.multiline = multiline
+		return this
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PROPERTIES
+
+// Keymap returns the keymap used to translate key events
+func (this *TextEntry) Keymap() *Keymap {
+	return this.keymap
+}
+
+// Text returns the text entered
+func (this *TextEntry) Text() string {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return string(this.text)
+}
+
+// SetText replaces the text entered
+func (this *TextEntry) SetText(text string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.text = []rune(text)
+}
+
+// Reset clears the text entered
+func (this *TextEntry) Reset() {
+	this.SetText("")
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PROCESS EVENTS
+
+// Process a key event and return true if the text was changed
+func (this *TextEntry) Process(evt gopi.InputEvent) bool {
+	runes := this.keymap.Process(evt)
+	if len(runes) == 0 {
+		return false
+	}
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	changed := false
+	for _, r := range runes {
+		switch {
+		case r == '\b':
+			if len(this.text) > 0 {
+				this.text = this.text[:len(this.text)-1]
+				changed = true
+			}
+		case r == '\n':
+			if this.multiline {
+				this.text = append(this.text, r)
+				changed = true
+			}
+		case r == '\t' || r >= ' ':
+			this.text = append(this.text, r)
+			changed = true
+		}
+	}
+	return changed
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *TextEntry) String() string {
+	return fmt.Sprintf("<sys.input.keymap.TextEntry>{ text=%q multiline=%v keymap=%v }", this.Text(), this.multiline, this.keymap)
+}
@@ -14,10 +14,13 @@ package linux
 import (
 	"fmt"
 	"os"
+	"sync"
 
 	// Frameworks
 	"github.com/djthorpe/gopi"
 	"github.com/djthorpe/gopi/sys/hw/linux"
+	"github.com/djthorpe/gopi/sys/input/calibrate"
+	"github.com/djthorpe/gopi/sys/input/joystick"
 	"github.com/djthorpe/gopi/util/event"
 )
 
@@ -50,6 +53,9 @@ type device struct {
 	// Handle to the device
 	handle *os.File
 
+	// Device number, from the event device path
+	number uint32
+
 	// The Name of the input device
 	name string
 
@@ -87,6 +93,19 @@ type device struct {
 	slot  uint32
 	slots []slot
 
+	// Range of raw absolute positions, and the calibration which
+	// transforms raw positions
+	abs_min     gopi.Point
+	abs_max     gopi.Point
+	calibration calibrate.Matrix
+	lock        sync.Mutex
+
+	// Joystick and gamepad axes, the mapping of buttons onto gamepad
+	// buttons, and the force feedback effect
+	axes      []*axis
+	mapping   joystick.Mapping
+	ff_effect int16
+
 	/*
 		// the current key state, which is a set of OR'd flags
 		state gopi.KeyState
@@ -96,9 +115,11 @@ type device struct {
 
 // Represents multi-touch slot information
 type slot struct {
-	id       int16
+	id       int32
 	position gopi.Point
 	active   bool
+	moved    bool
+	pending  gopi.InputEventType
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -121,6 +142,7 @@ func (config InputDevice) Open(log gopi.Logger) (gopi.Driver, error) {
 	this.path = config.Path
 	this.exclusive = config.Exclusive
 	this.filepoll = config.FilePoll
+	this.number = evDeviceNumber(config.Path)
 
 	// Open the event stream for reading and writing
 	if handle, err := os.OpenFile(config.Path, os.O_RDWR, 0); err != nil {
@@ -161,9 +183,18 @@ func (config InputDevice) Open(log gopi.Logger) (gopi.Driver, error) {
 		this.capabilities = capabilities
 	}
 
-	// Determine device type. We don't know if joysticks are
-	// currently supported, however, so will need to find a
-	// joystick tester later
+	// Get range of absolute positions, from the single touch
+	// or multi-touch axes
+	if evSupportsEventType(this.capabilities, EV_ABS) {
+		this.abs_min, this.abs_max = evGetAbsRange(this.handle, EV_CODE_X, EV_CODE_Y)
+		if this.abs_max.Equals(this.abs_min) {
+			this.abs_min, this.abs_max = evGetAbsRange(this.handle, EV_CODE_SLOT_X, EV_CODE_SLOT_Y)
+		}
+	}
+	this.calibration = calibrate.Identity
+
+	// Determine device type. Devices with absolute axes are joysticks
+	// if they report joystick or gamepad buttons
 	switch {
 	case evSupportsEventType(this.capabilities, EV_KEY, EV_LED, EV_REP):
 		this.device_type = gopi.INPUT_TYPE_KEYBOARD
@@ -171,10 +202,25 @@ func (config InputDevice) Open(log gopi.Logger) (gopi.Driver, error) {
 		this.device_type = gopi.INPUT_TYPE_MOUSE
 	case evSupportsEventType(this.capabilities, EV_KEY, EV_ABS, EV_MSC):
 		this.device_type = gopi.INPUT_TYPE_JOYSTICK
+	case evSupportsEventType(this.capabilities, EV_KEY, EV_ABS) && evSupportsJoystickButtons(this.handle):
+		this.device_type = gopi.INPUT_TYPE_JOYSTICK
 	case evSupportsEventType(this.capabilities, EV_KEY, EV_ABS):
 		this.device_type = gopi.INPUT_TYPE_TOUCHSCREEN
 	}
 
+	// Get joystick axes, and map buttons onto gamepad buttons for
+	// gamepads with the standard layout
+	this.ff_effect = -1
+	if this.device_type == gopi.INPUT_TYPE_JOYSTICK {
+		if axes, err := evGetAxes(this.handle); err != nil {
+			this.handle.Close()
+			return nil, err
+		} else {
+			this.axes = axes
+			this.mapping = evGetMapping(this.handle, axes)
+		}
+	}
+
 	// Start watching
 	if err := this.filepoll.Watch(this.handle, linux.FILEPOLL_MODE_READ, this.evReceive); err != nil {
 		this.handle.Close()
@@ -212,6 +258,14 @@ func (this *device) Close() error {
 		this.exclusive = false
 	}
 
+	// Remove force feedback effect
+	if this.ff_effect != -1 {
+		if err := evRemoveEffect(this.handle, this.ff_effect); err != nil {
+			this.log.Warn("<linux.InputDevice>Close Error: %v", err)
+		}
+		this.ff_effect = -1
+	}
+
 	// Unwatch device
 	if err := this.filepoll.Unwatch(this.handle); err != nil {
 		this.log.Warn("Unwatch: %v", err)
@@ -301,7 +355,40 @@ func (this *device) Bus() gopi.InputDeviceBus {
 
 // Return absolute cursor position
 func (this *device) Position() gopi.Point {
-	return this.position
+	if this.device_type == gopi.INPUT_TYPE_MOUSE {
+		return this.position
+	}
+	return this.transform(this.position)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CALIBRATION
+
+// Return the range of raw absolute positions
+func (this *device) RawRange() (gopi.Point, gopi.Point) {
+	return this.abs_min, this.abs_max
+}
+
+// Return the calibration
+func (this *device) Calibration() calibrate.Matrix {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.calibration
+}
+
+// Set the calibration applied to absolute positions
+func (this *device) SetCalibration(m calibrate.Matrix) {
+	this.log.Debug2("<sys.input.linux.InputDevice.SetCalibration>{ name=\"%v\" calibration=%v }", this.name, m)
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.calibration = m
+}
+
+// transform applies the calibration to a raw absolute position
+func (this *device) transform(pt gopi.Point) gopi.Point {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.calibration.Transform(pt)
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -320,12 +407,15 @@ func (this *device) Unsubscribe(subscriber <-chan gopi.Event) {
 
 // Emit events
 func (this *device) Emit(evt gopi.Event) {
-	this.log.Info("Emit: %v", evt)
+	this.log.Debug2("<sys.input.linux.InputDevice.Emit>{ %v }", evt)
+	if this.pubsub != nil {
+		this.pubsub.Emit(evt)
+	}
 }
 
 ////////////////////////////////////////////////////////////////////////////////
 // STRINGIFY
 
 func (this *device) String() string {
-	return fmt.Sprintf("<sys.input.linux.InputDevice>{ name=\"%s\" phys=\"%v\" uniq=\"%v\" type=%v bus=%v position=%v product=0x%04X vendor=0x%04X version=0x%04X capabilities=%v exclusive=%v fd=%v path=%v }", this.name, this.phys, this.uniq, this.device_type, this.bus, this.position, this.product, this.vendor, this.version, this.capabilities, this.exclusive, this.handle.Fd(), this.path)
+	return fmt.Sprintf("<sys.input.linux.InputDevice>{ name=\"%s\" phys=\"%v\" uniq=\"%v\" type=%v bus=%v position=%v product=0x%04X vendor=0x%04X version=0x%04X capabilities=%v calibration=%v exclusive=%v fd=%v path=%v }", this.name, this.phys, this.uniq, this.device_type, this.bus, this.Position(), this.product, this.vendor, this.version, this.capabilities, this.Calibration(), this.exclusive, this.handle.Fd(), this.path)
 }
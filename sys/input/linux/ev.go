@@ -17,10 +17,13 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/djthorpe/gopi"
 	"github.com/djthorpe/gopi/sys/hw/linux"
+	"github.com/djthorpe/gopi/sys/input/joystick"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -30,6 +33,15 @@ type evType uint16
 type evKeyCode uint16
 type evKeyAction uint32
 
+type evAbsInfo struct {
+	Value      int32
+	Minimum    int32
+	Maximum    int32
+	Fuzz       int32
+	Flat       int32
+	Resolution int32
+}
+
 type evEvent struct {
 	Second      uint32
 	Microsecond uint32
@@ -65,6 +77,28 @@ func evFind(callback func(string)) error {
 	return nil
 }
 
+// evDeviceNumber returns the number of the event device from the
+// device path, or zero if it cannot be determined
+func evDeviceNumber(device_path string) uint32 {
+	if number, err := strconv.ParseUint(strings.TrimPrefix(path.Base(device_path), "event"), 10, 32); err != nil {
+		return 0
+	} else {
+		return uint32(number)
+	}
+}
+
+// evGetAbsRange returns the minimum and maximum values for two
+// absolute axes, or zero values if the range cannot be determined
+func evGetAbsRange(handle *os.File, x, y evKeyCode) (gopi.Point, gopi.Point) {
+	if info_x, err := evGetAbsInfo(handle, x); err != nil {
+		return gopi.ZeroPoint, gopi.ZeroPoint
+	} else if info_y, err := evGetAbsInfo(handle, y); err != nil {
+		return gopi.ZeroPoint, gopi.ZeroPoint
+	} else {
+		return gopi.Point{float32(info_x.Minimum), float32(info_y.Minimum)}, gopi.Point{float32(info_x.Maximum), float32(info_y.Maximum)}
+	}
+}
+
 // evSupportsEventType returns true if all event types are supported
 // else returns false
 func evSupportsEventType(capabilities []evType, types ...evType) bool {
@@ -110,6 +144,24 @@ const (
 	EV_CODE_SLOT_ID  evKeyCode = 0x0039 // Unique ID for multi touch position
 )
 
+// Joystick and gamepad axes
+const (
+	EV_CODE_Z        evKeyCode = 0x0002
+	EV_CODE_RX       evKeyCode = 0x0003
+	EV_CODE_RY       evKeyCode = 0x0004
+	EV_CODE_RZ       evKeyCode = 0x0005
+	EV_CODE_THROTTLE evKeyCode = 0x0006
+	EV_CODE_RUDDER   evKeyCode = 0x0007
+	EV_CODE_WHEEL    evKeyCode = 0x0008
+	EV_CODE_GAS      evKeyCode = 0x0009
+	EV_CODE_BRAKE    evKeyCode = 0x000A
+	EV_CODE_HAT0X    evKeyCode = 0x0010
+	EV_CODE_HAT0Y    evKeyCode = 0x0011
+	EV_CODE_HAT1X    evKeyCode = 0x0012
+	EV_CODE_HAT1Y    evKeyCode = 0x0013
+	EV_CODE_ABS_MAX  evKeyCode = 0x003F
+)
+
 const (
 	EV_VALUE_KEY_NONE   evKeyAction = 0x00000000
 	EV_VALUE_KEY_UP     evKeyAction = 0x00000000
@@ -170,16 +222,22 @@ func (this *device) evReceive(dev *os.File, mode linux.FilePollMode) {
 		if evt := this.evDecodeSyn(&raw_event); evt != nil {
 			this.Emit(evt)
 		}
+		for _, evt := range this.evDecodeSlots(&raw_event) {
+			this.Emit(evt)
+		}
+		for _, evt := range this.evDecodeAxes(&raw_event) {
+			this.Emit(evt)
+		}
 	case EV_KEY:
 		this.evDecodeKey(&raw_event)
 	case EV_ABS:
-		if evt := this.evDecodeAbs(&raw_event); evt != nil {
-			this.Emit(evt)
-		}
+		this.evDecodeAbs(&raw_event)
 	case EV_REL:
 		this.evDecodeRel(&raw_event)
 	case EV_MSC:
 		this.evDecodeMsc(&raw_event)
+	case EV_FF, EV_FF_STATUS:
+		// Ignore force feedback status
 	default:
 		this.log.Warn("sys.input.linux.InputDevice.Receive: Ignoring event with type %v", raw_event.Type)
 	}
@@ -200,24 +258,26 @@ func (this *device) evDecodeSyn(raw_event *evEvent) gopi.InputEvent {
 	if this.rel_position.Equals(gopi.ZeroPoint) == false {
 		evt.event_type = gopi.INPUT_EVENT_RELPOSITION
 		evt.rel_position = this.rel_position
+		evt.position = this.position
 		this.rel_position = gopi.ZeroPoint
 		this.last_position = this.position
 	} else if this.position.Equals(this.last_position) == false {
 		evt.event_type = gopi.INPUT_EVENT_ABSPOSITION
+		evt.position = this.transform(this.position)
 		this.last_position = this.position
 	} else if this.key_action == EV_VALUE_KEY_UP {
 		evt.event_type = gopi.INPUT_EVENT_KEYRELEASE
-		evt.key_code = gopi.KeyCode(this.key_code)
+		evt.key_code = this.keycode()
 		evt.scan_code = this.scan_code
 		this.key_action = EV_VALUE_KEY_NONE
 	} else if this.key_action == EV_VALUE_KEY_DOWN {
 		evt.event_type = gopi.INPUT_EVENT_KEYPRESS
-		evt.key_code = gopi.KeyCode(this.key_code)
+		evt.key_code = this.keycode()
 		evt.scan_code = this.scan_code
 		this.key_action = EV_VALUE_KEY_NONE
 	} else if this.key_action == EV_VALUE_KEY_REPEAT {
 		evt.event_type = gopi.INPUT_EVENT_KEYREPEAT
-		evt.key_code = gopi.KeyCode(this.key_code)
+		evt.key_code = this.keycode()
 		evt.scan_code = this.scan_code
 		this.key_action = EV_VALUE_KEY_NONE
 	} else {
@@ -289,11 +349,15 @@ func (this *device) evDecodeKey(raw_event *evEvent) {
 
 }
 
-func (this *device) evDecodeAbs(raw_event *evEvent) gopi.InputEvent {
+func (this *device) evDecodeAbs(raw_event *evEvent) {
 	if raw_event.Code == EV_CODE_X {
 		this.position.X = float32(int32(raw_event.Value))
+		this.evDecodeAxis(raw_event)
 	} else if raw_event.Code == EV_CODE_Y {
 		this.position.Y = float32(int32(raw_event.Value))
+		this.evDecodeAxis(raw_event)
+	} else if _, exists := evAxes[raw_event.Code]; exists {
+		this.evDecodeAxis(raw_event)
 	} else if raw_event.Code == EV_CODE_SLOT {
 		this.slot = raw_event.Value
 	} else if raw_event.Code == EV_CODE_SLOT_ID || raw_event.Code == EV_CODE_SLOT_X || raw_event.Code == EV_CODE_SLOT_Y {
@@ -301,45 +365,128 @@ func (this *device) evDecodeAbs(raw_event *evEvent) gopi.InputEvent {
 		case this.slot < uint32(0) || this.slot >= INPUT_MAX_MULTITOUCH_SLOTS:
 			this.log.Warn("evDecodeAbs: Ignoring out-of-range slot %v", this.slot)
 		case raw_event.Code == EV_CODE_SLOT_ID:
-			return this.evDecodeAbsTouch(raw_event)
+			this.evDecodeAbsTouch(raw_event)
 		case raw_event.Code == EV_CODE_SLOT_X:
 			this.slots[this.slot].position.X = float32(int32(raw_event.Value))
-			this.slots[this.slot].active = true
+			this.slots[this.slot].moved = true
 		case raw_event.Code == EV_CODE_SLOT_Y:
 			this.slots[this.slot].position.Y = float32(int32(raw_event.Value))
-			this.slots[this.slot].active = true
+			this.slots[this.slot].moved = true
 		}
 	} else {
 		this.log.Warn("evDecodeAbs: %v Ignoring code %v", raw_event.Type, raw_event.Code)
 	}
-	return nil
 }
 
-func (this *device) evDecodeAbsTouch(raw_event *evEvent) gopi.InputEvent {
-	evt := &input_event{
-		device:      this,
-		timestamp:   time.Duration(time.Duration(raw_event.Second)*time.Second + time.Duration(raw_event.Microsecond)*time.Microsecond),
-		device_type: this.device_type,
-	}
-
-	// Decode the slot_id, if -1 then this is the release for a slot
-	if slot_id := int16(raw_event.Value); slot_id == -1 {
-		this.slots[this.slot].active = false
-		evt.event_type = gopi.INPUT_EVENT_TOUCHRELEASE
-	} else if slot_id < INPUT_MAX_MULTITOUCH_SLOTS {
+// Decode the tracking identifier for a slot. The press or release
+// is emitted on the next EV_SYN, once the position has been decoded
+func (this *device) evDecodeAbsTouch(raw_event *evEvent) {
+	// If the tracking identifier is -1 then this is the release for a slot
+	if slot_id := int32(raw_event.Value); slot_id == -1 {
+		if this.slots[this.slot].active {
+			this.slots[this.slot].active = false
+			this.slots[this.slot].pending = gopi.INPUT_EVENT_TOUCHRELEASE
+		}
+	} else {
 		this.slots[this.slot].active = true
 		this.slots[this.slot].id = slot_id
-		evt.event_type = gopi.INPUT_EVENT_TOUCHPRESS
-	} else {
-		this.log.Warn("evDecodeAbsTouch: %v Ignoring slot %v", raw_event.Type, slot_id)
+		this.slots[this.slot].pending = gopi.INPUT_EVENT_TOUCHPRESS
+	}
+}
+
+// Decode the multi-touch slots on EV_SYN, returning press and release
+// events, and position events for slots which have moved
+func (this *device) evDecodeSlots(raw_event *evEvent) []gopi.InputEvent {
+	var events []gopi.InputEvent
+	for i := range this.slots {
+		slot := &this.slots[i]
+		event_type := slot.pending
+		if event_type == gopi.INPUT_EVENT_NONE && slot.moved && slot.active {
+			event_type = gopi.INPUT_EVENT_TOUCHPOSITION
+		}
+		slot.pending = gopi.INPUT_EVENT_NONE
+		slot.moved = false
+		if event_type == gopi.INPUT_EVENT_NONE {
+			continue
+		}
+		events = append(events, &input_event{
+			device:      this,
+			timestamp:   time.Duration(time.Duration(raw_event.Second)*time.Second + time.Duration(raw_event.Microsecond)*time.Microsecond),
+			device_type: this.device_type,
+			event_type:  event_type,
+			slot:        uint(i),
+			key_code:    gopi.KEYCODE_BTNTOUCH,
+			position:    this.transform(slot.position),
+		})
 	}
+	return events
+}
 
-	// Populate the slot and keycode
-	evt.slot = uint(this.slot)
-	evt.key_code = gopi.KEYCODE_BTNTOUCH
+// Decode the raw value for a joystick axis. The axis event is
+// emitted on the next EV_SYN
+func (this *device) evDecodeAxis(raw_event *evEvent) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for _, a := range this.axes {
+		if a.code == raw_event.Code {
+			a.raw = int32(raw_event.Value)
+			a.changed = true
+		}
+	}
+}
 
-	// Return the event to emit
-	return evt
+// Decode the joystick axes on EV_SYN, returning axis events for axes
+// which have changed value. Hats also return directional pad key
+// press and release events
+func (this *device) evDecodeAxes(raw_event *evEvent) []gopi.InputEvent {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	var events []gopi.InputEvent
+	timestamp := time.Duration(time.Duration(raw_event.Second)*time.Second + time.Duration(raw_event.Microsecond)*time.Microsecond)
+	for _, a := range this.axes {
+		if a.changed == false {
+			continue
+		}
+		a.changed = false
+		value := a.Normalize(a.raw)
+		if value == a.value {
+			continue
+		}
+		a.value = value
+		events = append(events, &input_event{
+			device:      this,
+			timestamp:   timestamp,
+			device_type: this.device_type,
+			event_type:  gopi.INPUT_EVENT_AXIS,
+			axis:        a.Axis.Axis,
+			axis_value:  value,
+		})
+
+		// Directional pad
+		if hat := joystick.Hat(a.Axis.Axis, value); hat != a.hat {
+			if a.hat != gopi.KEYCODE_NONE {
+				events = append(events, &input_event{
+					device:      this,
+					timestamp:   timestamp,
+					device_type: this.device_type,
+					event_type:  gopi.INPUT_EVENT_KEYRELEASE,
+					key_code:    a.hat,
+				})
+			}
+			if hat != gopi.KEYCODE_NONE {
+				events = append(events, &input_event{
+					device:      this,
+					timestamp:   timestamp,
+					device_type: this.device_type,
+					event_type:  gopi.INPUT_EVENT_KEYPRESS,
+					key_code:    hat,
+				})
+			}
+			a.hat = hat
+		}
+	}
+	return events
 }
 
 func (this *device) evDecodeRel(raw_event *evEvent) {
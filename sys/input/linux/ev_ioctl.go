@@ -14,6 +14,7 @@ package linux
 import (
 	"os"
 	"syscall"
+	"time"
 	"unsafe"
 )
 
@@ -122,6 +123,68 @@ func evGetSupportedEventTypes(handle *os.File) ([]evType, error) {
 	return capabilities, nil
 }
 
+// Get the range of an absolute axis
+func evGetAbsInfo(handle *os.File, code evKeyCode) (evAbsInfo, error) {
+	var info evAbsInfo
+	err := evIoctl(handle.Fd(), uintptr(C._EVIOCGABS(C.int(code))), unsafe.Pointer(&info))
+	if err != 0 {
+		return info, err
+	}
+	return info, nil
+}
+
+// Get the codes supported for an event type, up to a maximum code
+func evGetSupportedCodes(handle *os.File, typ evType, max evKeyCode) ([]evKeyCode, error) {
+	bits := make([]byte, int(max>>3)+1)
+	err := evIoctl(handle.Fd(), uintptr(C._EVIOCGBIT(C.int(typ), C.int(len(bits)))), unsafe.Pointer(&bits[0]))
+	if err != 0 {
+		return nil, err
+	}
+	codes := make([]evKeyCode, 0)
+	for code := evKeyCode(0); code <= max; code++ {
+		if bits[code>>3]&(1<<(code&0x07)) != 0x00 {
+			codes = append(codes, code)
+		}
+	}
+	return codes, nil
+}
+
+// Upload a force feedback rumble effect and return the effect identifier.
+// An existing effect is replaced when id is not -1
+func evUploadRumble(handle *os.File, id int16, strong, weak uint16, length time.Duration) (int16, error) {
+	var effect C.struct_ff_effect
+	effect._type = C.FF_RUMBLE
+	effect.id = C.__s16(id)
+	effect.replay.length = C.__u16(length / time.Millisecond)
+	rumble := (*C.struct_ff_rumble_effect)(unsafe.Pointer(&effect.u[0]))
+	rumble.strong_magnitude = C.__u16(strong)
+	rumble.weak_magnitude = C.__u16(weak)
+	if err := evIoctl(handle.Fd(), C.EVIOCSFF, unsafe.Pointer(&effect)); err != 0 {
+		return -1, err
+	}
+	return int16(effect.id), nil
+}
+
+// Remove a force feedback effect
+func evRemoveEffect(handle *os.File, id int16) error {
+	if err := evIoctl(handle.Fd(), C.EVIOCRMFF, unsafe.Pointer(uintptr(id))); err != 0 {
+		return err
+	}
+	return nil
+}
+
+// Write an event to the device, which is used to play force
+// feedback effects
+func evWriteEvent(handle *os.File, typ evType, code evKeyCode, value int32) error {
+	var evt C.struct_input_event
+	evt._type = C.__u16(typ)
+	evt.code = C.__u16(code)
+	evt.value = C.__s32(value)
+	buf := (*[C.sizeof_struct_input_event]byte)(unsafe.Pointer(&evt))[:]
+	_, err := handle.Write(buf)
+	return err
+}
+
 // Obtain and release exclusive device usage ("grab")
 func evSetGrabState(handle *os.File, state bool) error {
 	if state {
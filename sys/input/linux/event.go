@@ -25,6 +25,7 @@ import (
 // Input event
 type input_event struct {
 	device       *device
+	virtual      *virtual
 	timestamp    time.Duration
 	device_type  gopi.InputDeviceType
 	event_type   gopi.InputEventType
@@ -33,6 +34,8 @@ type input_event struct {
 	key_code     gopi.KeyCode
 	scan_code    uint32
 	slot         uint
+	axis         gopi.InputAxis
+	axis_value   float32
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -43,6 +46,9 @@ func (this *input_event) Name() string {
 }
 
 func (this *input_event) Source() gopi.Driver {
+	if this.virtual != nil {
+		return this.virtual
+	}
 	return this.device
 }
 
@@ -58,6 +64,13 @@ func (this *input_event) EventType() gopi.InputEventType {
 	return this.event_type
 }
 
+func (this *input_event) Device() uint32 {
+	if this.device == nil {
+		return 0
+	}
+	return this.device.number
+}
+
 func (this *input_event) Keycode() gopi.KeyCode {
 	return this.key_code
 }
@@ -78,6 +91,14 @@ func (this *input_event) Slot() uint {
 	return this.slot
 }
 
+func (this *input_event) Axis() gopi.InputAxis {
+	return this.axis
+}
+
+func (this *input_event) AxisValue() float32 {
+	return this.axis_value
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // STRINGIFY
 
@@ -90,9 +111,11 @@ func (this *input_event) String() string {
 	case gopi.INPUT_EVENT_KEYPRESS, gopi.INPUT_EVENT_KEYRELEASE, gopi.INPUT_EVENT_KEYREPEAT:
 		return fmt.Sprintf("<sys.input.linux.InputEvent>{ type=%v device=%v key_code=%v scan_code=%v ts=%v }", this.event_type, this.device_type, this.key_code, this.scan_code, this.timestamp)
 	case gopi.INPUT_EVENT_TOUCHPRESS, gopi.INPUT_EVENT_TOUCHRELEASE:
-		return fmt.Sprintf("<sys.input.linux.InputEvent>{ type=%v device=%v key_code=%v slot=%v position=%v ts=%v }", this.event_type, this.device_type, this.key_code, this.position, this.slot, this.timestamp)
+		return fmt.Sprintf("<sys.input.linux.InputEvent>{ type=%v device=%v key_code=%v slot=%v position=%v ts=%v }", this.event_type, this.device_type, this.key_code, this.slot, this.position, this.timestamp)
 	case gopi.INPUT_EVENT_TOUCHPOSITION:
-		return fmt.Sprintf("<sys.input.linux.InputEvent>{ type=%v device=%v slot=%v position=%v ts=%v }", this.event_type, this.device_type, this.position, this.slot, this.timestamp)
+		return fmt.Sprintf("<sys.input.linux.InputEvent>{ type=%v device=%v slot=%v position=%v ts=%v }", this.event_type, this.device_type, this.slot, this.position, this.timestamp)
+	case gopi.INPUT_EVENT_AXIS:
+		return fmt.Sprintf("<sys.input.linux.InputEvent>{ type=%v device=%v axis=%v value=%v ts=%v }", this.event_type, this.device_type, this.axis, this.axis_value, this.timestamp)
 	default:
 		return fmt.Sprintf("<sys.input.linux.InputEvent>{ type=%v device=%v ts=%v }", this.event_type, this.device_type, this.timestamp)
 	}
@@ -0,0 +1,52 @@
+// +build linux
+
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package linux
+
+import (
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	"github.com/djthorpe/gopi/sys/input/calibrate"
+)
+
+// Export parsing functions for testing
+var (
+	HotplugParseUevent  = hotplugParseUevent
+	HotplugParseInotify = hotplugParseInotify
+)
+
+type HotplugAction = hotplugAction
+
+type (
+	EvEvent   = evEvent
+	EvKeyCode = evKeyCode
+)
+
+// DecodeSlots decodes raw events for a multi-touch device and returns
+// the slot events which would be emitted on each EV_SYN
+func DecodeSlots(log gopi.Logger, raw_events []EvEvent) []gopi.InputEvent {
+	this := &device{
+		log:         log,
+		device_type: gopi.INPUT_TYPE_TOUCHSCREEN,
+		calibration: calibrate.Identity,
+		slots:       make([]slot, INPUT_MAX_MULTITOUCH_SLOTS),
+	}
+	events := make([]gopi.InputEvent, 0)
+	for i := range raw_events {
+		switch raw_events[i].Type {
+		case EV_SYN:
+			events = append(events, this.evDecodeSlots(&raw_events[i])...)
+		case EV_ABS:
+			this.evDecodeAbs(&raw_events[i])
+		}
+	}
+	return events
+}
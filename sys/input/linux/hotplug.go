@@ -0,0 +1,238 @@
+// +build linux
+
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package linux
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+	"github.com/djthorpe/gopi/sys/hw/linux"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type hotplugAction uint
+
+// Callback which is called with the action and the device path
+type hotplugCallback func(hotplugAction, string)
+
+// Watches for input devices being plugged in or removed, using a
+// netlink uevent socket or inotify on /dev/input as a fallback
+type hotplug struct {
+	log      gopi.Logger
+	filepoll linux.FilePollInterface
+	handle   *os.File
+	inotify  bool
+	callback hotplugCallback
+	buf      []byte
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	HOTPLUG_ACTION_NONE hotplugAction = iota
+	HOTPLUG_ACTION_ADD
+	HOTPLUG_ACTION_REMOVE
+)
+
+const (
+	// Path for input device nodes
+	INPUT_PATH_DEVNODES = "/dev/input"
+
+	// Kernel uevent multicast group
+	HOTPLUG_NETLINK_GROUP = 1
+
+	// Size of the buffer used for reading uevents
+	HOTPLUG_BUFFER_SIZE = 4096
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW AND CLOSE
+
+func newHotplug(log gopi.Logger, filepoll linux.FilePollInterface, callback hotplugCallback) (*hotplug, error) {
+	this := new(hotplug)
+	this.log = log
+	this.filepoll = filepoll
+	this.callback = callback
+	this.buf = make([]byte, HOTPLUG_BUFFER_SIZE)
+
+	// Use netlink, or fall back to inotify
+	if handle, err := hotplugNetlink(); err == nil {
+		this.handle = handle
+	} else if handle, err2 := hotplugInotify(INPUT_PATH_DEVNODES); err2 == nil {
+		log.Debug("<sys.input.linux.hotplug>: netlink: %v (falling back to inotify)", err)
+		this.handle = handle
+		this.inotify = true
+	} else {
+		return nil, err2
+	}
+
+	// Watch for events
+	if err := this.filepoll.Watch(this.handle, linux.FILEPOLL_MODE_READ, this.receive); err != nil {
+		this.handle.Close()
+		return nil, err
+	}
+
+	// Success
+	return this, nil
+}
+
+func (this *hotplug) Close() error {
+	if err := this.filepoll.Unwatch(this.handle); err != nil {
+		this.log.Warn("<sys.input.linux.hotplug>Close: %v", err)
+	}
+	err := this.handle.Close()
+	this.handle = nil
+	this.filepoll = nil
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// RECEIVE
+
+func (this *hotplug) receive(handle *os.File, mode linux.FilePollMode) {
+	n, err := syscall.Read(int(handle.Fd()), this.buf)
+	if err != nil {
+		this.log.Error("<sys.input.linux.hotplug>receive: %v", err)
+		return
+	} else if n <= 0 {
+		return
+	}
+	if this.inotify {
+		hotplugParseInotify(this.buf[:n], this.callback)
+	} else if action, device_path := hotplugParseUevent(this.buf[:n]); action != HOTPLUG_ACTION_NONE {
+		this.callback(action, device_path)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// hotplugNetlink opens a netlink socket to receive kernel uevents
+func hotplugNetlink() (*os.File, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, syscall.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Pid:    0,
+		Groups: HOTPLUG_NETLINK_GROUP,
+	}); err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("bind", err)
+	}
+	return os.NewFile(uintptr(fd), "netlink"), nil
+}
+
+// hotplugInotify watches a directory for files being created or removed
+func hotplugInotify(dir string) (*os.File, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	if _, err := syscall.InotifyAddWatch(fd, dir, syscall.IN_CREATE|syscall.IN_DELETE|syscall.IN_ATTRIB); err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("inotify_add_watch", err)
+	}
+	return os.NewFile(uintptr(fd), "inotify"), nil
+}
+
+// hotplugParseUevent returns the action and device path for an input event
+// device from a kernel uevent message, which is a header followed by
+// null-separated KEY=VALUE pairs
+func hotplugParseUevent(buf []byte) (hotplugAction, string) {
+	var action hotplugAction
+	var subsystem, devname string
+	for _, field := range bytes.Split(buf, []byte{0}) {
+		if kv := strings.SplitN(string(field), "=", 2); len(kv) != 2 {
+			continue
+		} else {
+			switch kv[0] {
+			case "ACTION":
+				switch kv[1] {
+				case "add":
+					action = HOTPLUG_ACTION_ADD
+				case "remove":
+					action = HOTPLUG_ACTION_REMOVE
+				}
+			case "SUBSYSTEM":
+				subsystem = kv[1]
+			case "DEVNAME":
+				devname = kv[1]
+			}
+		}
+	}
+	if subsystem != "input" || action == HOTPLUG_ACTION_NONE {
+		return HOTPLUG_ACTION_NONE, ""
+	} else if strings.HasPrefix(path.Base(devname), "event") == false {
+		return HOTPLUG_ACTION_NONE, ""
+	} else if path.IsAbs(devname) {
+		return action, path.Clean(devname)
+	} else {
+		return action, path.Join("/", "dev", devname)
+	}
+}
+
+// hotplugParseInotify calls the callback for each input event device
+// which is created or removed
+func hotplugParseInotify(buf []byte, callback hotplugCallback) {
+	// The size of the struct includes padding after the name, so
+	// the size of the header is used instead
+	var evt syscall.InotifyEvent
+	header := syscall.SizeofInotifyEvent
+	for len(buf) >= header {
+		if err := binary.Read(bytes.NewReader(buf[:header]), binary.LittleEndian, &evt); err != nil {
+			return
+		}
+		end := header + int(evt.Len)
+		if end > len(buf) {
+			return
+		}
+		name := string(bytes.TrimRight(buf[header:end], "\x00"))
+		buf = buf[end:]
+		if strings.HasPrefix(name, "event") == false {
+			continue
+		}
+		device_path := path.Join(INPUT_PATH_DEVNODES, name)
+		switch {
+		case evt.Mask&syscall.IN_DELETE != 0:
+			callback(HOTPLUG_ACTION_REMOVE, device_path)
+		case evt.Mask&(syscall.IN_CREATE|syscall.IN_ATTRIB) != 0:
+			callback(HOTPLUG_ACTION_ADD, device_path)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (a hotplugAction) String() string {
+	switch a {
+	case HOTPLUG_ACTION_NONE:
+		return "HOTPLUG_ACTION_NONE"
+	case HOTPLUG_ACTION_ADD:
+		return "HOTPLUG_ACTION_ADD"
+	case HOTPLUG_ACTION_REMOVE:
+		return "HOTPLUG_ACTION_REMOVE"
+	default:
+		return "[?? Invalid hotplugAction value]"
+	}
+}
@@ -0,0 +1,95 @@
+// +build linux
+
+package linux_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"syscall"
+	"testing"
+
+	// Frameworks
+	linux "github.com/djthorpe/gopi/sys/input/linux"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// UEVENT
+
+func TestHotplugUevent_000(t *testing.T) {
+	for _, test := range []struct {
+		fields []string
+		action string
+		path   string
+	}{
+		{[]string{"add@/devices/input5/event3", "ACTION=add", "SUBSYSTEM=input", "DEVNAME=input/event3", "SEQNUM=1"}, "HOTPLUG_ACTION_ADD", "/dev/input/event3"},
+		{[]string{"remove@/devices/input5/event3", "ACTION=remove", "SUBSYSTEM=input", "DEVNAME=/dev/input/event3"}, "HOTPLUG_ACTION_REMOVE", "/dev/input/event3"},
+		{[]string{"add@/devices/input5/mouse0", "ACTION=add", "SUBSYSTEM=input", "DEVNAME=input/mouse0"}, "HOTPLUG_ACTION_NONE", ""},
+		{[]string{"add@/devices/input5", "ACTION=add", "SUBSYSTEM=input"}, "HOTPLUG_ACTION_NONE", ""},
+		{[]string{"add@/devices/sda", "ACTION=add", "SUBSYSTEM=block", "DEVNAME=sda"}, "HOTPLUG_ACTION_NONE", ""},
+		{[]string{"change@/devices/input5/event3", "ACTION=change", "SUBSYSTEM=input", "DEVNAME=input/event3"}, "HOTPLUG_ACTION_NONE", ""},
+		{[]string{"libudev", "ACTION", "SUBSYSTEM=input=x"}, "HOTPLUG_ACTION_NONE", ""},
+		{nil, "HOTPLUG_ACTION_NONE", ""},
+	} {
+		buf := []byte(strings.Join(test.fields, "\x00") + "\x00")
+		if action, path := linux.HotplugParseUevent(buf); action.String() != test.action || path != test.path {
+			t.Errorf("%q: Expected %v %q, got %v %q", test.fields, test.action, test.path, action, path)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// INOTIFY
+
+func TestHotplugInotify_000(t *testing.T) {
+	buf := new(bytes.Buffer)
+	inotifyEvent(buf, syscall.IN_CREATE, "event1")
+	inotifyEvent(buf, syscall.IN_CREATE, "mouse0")
+	inotifyEvent(buf, syscall.IN_ATTRIB, "event2")
+	inotifyEvent(buf, syscall.IN_DELETE, "event1")
+	inotifyEvent(buf, syscall.IN_CREATE, "")
+
+	events := make([]string, 0)
+	linux.HotplugParseInotify(buf.Bytes(), func(action linux.HotplugAction, path string) {
+		events = append(events, action.String()+" "+path)
+	})
+	expected := []string{
+		"HOTPLUG_ACTION_ADD /dev/input/event1",
+		"HOTPLUG_ACTION_ADD /dev/input/event2",
+		"HOTPLUG_ACTION_REMOVE /dev/input/event1",
+	}
+	if strings.Join(events, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, events)
+	}
+}
+
+func TestHotplugInotify_001(t *testing.T) {
+	// Truncated events are ignored
+	buf := new(bytes.Buffer)
+	inotifyEvent(buf, syscall.IN_CREATE, "event1")
+	inotifyEvent(buf, syscall.IN_CREATE, "event2")
+	truncated := buf.Bytes()[:buf.Len()-4]
+
+	events := make([]string, 0)
+	linux.HotplugParseInotify(truncated, func(action linux.HotplugAction, path string) {
+		events = append(events, path)
+	})
+	if len(events) != 1 || events[0] != "/dev/input/event1" {
+		t.Error("Unexpected events", events)
+	}
+	linux.HotplugParseInotify(truncated[:4], func(action linux.HotplugAction, path string) {
+		t.Error("Unexpected event", path)
+	})
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// UTILITY METHODS
+
+// inotifyEvent appends an event with the name padded with null bytes,
+// as the kernel does
+func inotifyEvent(buf *bytes.Buffer, mask uint32, name string) {
+	padded := make([]byte, (len(name)/16+1)*16)
+	copy(padded, name)
+	binary.Write(buf, binary.LittleEndian, syscall.InotifyEvent{Mask: mask, Len: uint32(len(padded))})
+	buf.Write(padded)
+}
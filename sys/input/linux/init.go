@@ -27,12 +27,28 @@ func init() {
 		Type:     gopi.MODULE_TYPE_INPUT,
 		Config: func(config *gopi.AppConfig) {
 			config.AppFlags.FlagBool("input.exclusive", true, "Input device exclusivity")
+			config.AppFlags.FlagBool("input.hotplug", true, "Open input devices when plugged in")
+			config.AppFlags.FlagString("input.calibration", "", "File for touchscreen calibrations")
+			config.AppFlags.FlagBool("input.swapxy", false, "Swap touchscreen axes")
+			config.AppFlags.FlagBool("input.invertx", false, "Invert touchscreen X axis")
+			config.AppFlags.FlagBool("input.inverty", false, "Invert touchscreen Y axis")
 		},
 		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
 			exclusive, _ := app.AppFlags.GetBool("input.exclusive")
+			hotplug, _ := app.AppFlags.GetBool("input.hotplug")
+			calibration, _ := app.AppFlags.GetString("input.calibration")
+			swap_xy, _ := app.AppFlags.GetBool("input.swapxy")
+			invert_x, _ := app.AppFlags.GetBool("input.invertx")
+			invert_y, _ := app.AppFlags.GetBool("input.inverty")
 			return gopi.Open(InputManager{
-				FilePoll:  app.ModuleInstance("linux/filepoll").(linux.FilePollInterface),
-				Exclusive: exclusive,
+				FilePoll:    app.ModuleInstance("linux/filepoll").(linux.FilePollInterface),
+				Exclusive:   exclusive,
+				Hotplug:     hotplug,
+				Display:     app.Display,
+				Calibration: calibration,
+				SwapXY:      swap_xy,
+				InvertX:     invert_x,
+				InvertY:     invert_y,
 			}, app.Logger)
 		},
 	})
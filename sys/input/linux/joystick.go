@@ -0,0 +1,235 @@
+// +build linux
+
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package linux
+
+import (
+	"os"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+	"github.com/djthorpe/gopi/sys/input/joystick"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Represents a joystick or gamepad axis
+type axis struct {
+	*joystick.Axis
+	code    evKeyCode
+	raw     int32
+	value   float32
+	changed bool
+	hat     gopi.KeyCode
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Maximum duration of a force feedback effect
+	EV_FF_MAX_DURATION = 0xFFFF * time.Millisecond
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	// Absolute axis codes for joystick and gamepad axes
+	evAxes = map[evKeyCode]gopi.InputAxis{
+		EV_CODE_X:        gopi.INPUT_AXIS_X,
+		EV_CODE_Y:        gopi.INPUT_AXIS_Y,
+		EV_CODE_Z:        gopi.INPUT_AXIS_Z,
+		EV_CODE_RX:       gopi.INPUT_AXIS_RX,
+		EV_CODE_RY:       gopi.INPUT_AXIS_RY,
+		EV_CODE_RZ:       gopi.INPUT_AXIS_RZ,
+		EV_CODE_THROTTLE: gopi.INPUT_AXIS_THROTTLE,
+		EV_CODE_RUDDER:   gopi.INPUT_AXIS_RUDDER,
+		EV_CODE_WHEEL:    gopi.INPUT_AXIS_WHEEL,
+		EV_CODE_GAS:      gopi.INPUT_AXIS_GAS,
+		EV_CODE_BRAKE:    gopi.INPUT_AXIS_BRAKE,
+		EV_CODE_HAT0X:    gopi.INPUT_AXIS_HAT0X,
+		EV_CODE_HAT0Y:    gopi.INPUT_AXIS_HAT0Y,
+		EV_CODE_HAT1X:    gopi.INPUT_AXIS_HAT1X,
+		EV_CODE_HAT1Y:    gopi.INPUT_AXIS_HAT1Y,
+	}
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// gopi.InputJoystick INTERFACE
+
+// Return the axes reported by the device
+func (this *device) Axes() []gopi.InputAxis {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	axes := make([]gopi.InputAxis, len(this.axes))
+	for i, a := range this.axes {
+		axes[i] = a.Axis.Axis
+	}
+	return axes
+}
+
+// Return the current value for an axis, or zero if the axis
+// is not reported by the device
+func (this *device) AxisValue(which gopi.InputAxis) float32 {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if a := this.axis(which); a == nil {
+		return 0
+	} else {
+		return a.Normalize(a.raw)
+	}
+}
+
+// Return the dead zone for an axis
+func (this *device) DeadZone(which gopi.InputAxis) float32 {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if a := this.axis(which); a == nil {
+		return 0
+	} else {
+		return a.DeadZone
+	}
+}
+
+// Set the dead zone for an axis, as a proportion of the range
+func (this *device) SetDeadZone(which gopi.InputAxis, dead_zone float32) error {
+	this.log.Debug2("<sys.input.linux.InputDevice.SetDeadZone>{ name=\"%v\" axis=%v dead_zone=%v }", this.name, which, dead_zone)
+	if dead_zone < 0 || dead_zone >= 1 {
+		return gopi.ErrBadParameter
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if a := this.axis(which); a == nil {
+		return gopi.ErrNotFound
+	} else {
+		a.DeadZone = dead_zone
+		return nil
+	}
+}
+
+// Play a force feedback rumble effect. A duration of zero plays the
+// effect until it is replaced
+func (this *device) Rumble(strong, weak float32, duration time.Duration) error {
+	this.log.Debug2("<sys.input.linux.InputDevice.Rumble>{ name=\"%v\" strong=%v weak=%v duration=%v }", this.name, strong, weak, duration)
+	if evSupportsEventType(this.capabilities, EV_FF) == false {
+		return gopi.ErrNotImplemented
+	}
+	if strong < 0 || strong > 1 || weak < 0 || weak > 1 || duration < 0 {
+		return gopi.ErrBadParameter
+	}
+	if duration > EV_FF_MAX_DURATION {
+		duration = EV_FF_MAX_DURATION
+	}
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.handle == nil {
+		return gopi.ErrOutOfOrder
+	}
+
+	// Upload the effect, replacing any existing effect, and play it once
+	if id, err := evUploadRumble(this.handle, this.ff_effect, uint16(strong*0xFFFF), uint16(weak*0xFFFF), duration); err != nil {
+		return err
+	} else {
+		this.ff_effect = id
+	}
+	return evWriteEvent(this.handle, EV_FF, evKeyCode(this.ff_effect), 1)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// evGetAxes returns the joystick axes supported by a device, ordered by
+// axis code
+func evGetAxes(handle *os.File) ([]*axis, error) {
+	codes, err := evGetSupportedCodes(handle, EV_ABS, EV_CODE_ABS_MAX)
+	if err != nil {
+		return nil, err
+	}
+
+	// Whether an axis is a trigger depends on the other axes
+	supported := make([]gopi.InputAxis, 0, len(codes))
+	for _, code := range codes {
+		if value, exists := evAxes[code]; exists {
+			supported = append(supported, value)
+		}
+	}
+
+	axes := make([]*axis, 0, len(supported))
+	for _, code := range codes {
+		if value, exists := evAxes[code]; exists == false {
+			continue
+		} else if info, err := evGetAbsInfo(handle, code); err != nil {
+			return nil, err
+		} else if a := joystick.NewAxis(value, info.Minimum, info.Maximum, info.Flat, supported); a != nil {
+			axes = append(axes, &axis{Axis: a, code: code, raw: info.Value, value: a.Normalize(info.Value)})
+		}
+	}
+	return axes, nil
+}
+
+// evGetMapping returns the mapping of buttons onto gamepad buttons for a
+// device with axes, or nil if the device does not have the standard layout
+func evGetMapping(handle *os.File, axes []*axis) joystick.Mapping {
+	codes, err := evGetSupportedCodes(handle, EV_KEY, EV_CODE_KEY_MAX)
+	if err != nil {
+		return nil
+	}
+	buttons := make([]gopi.KeyCode, len(codes))
+	for i, code := range codes {
+		buttons[i] = gopi.KeyCode(code)
+	}
+	which := make([]gopi.InputAxis, len(axes))
+	for i, a := range axes {
+		which[i] = a.Axis.Axis
+	}
+	if joystick.IsStandardLayout(buttons, which) {
+		return joystick.StandardMapping
+	}
+	return nil
+}
+
+// evSupportsJoystickButtons returns true if the device reports
+// joystick or gamepad buttons
+func evSupportsJoystickButtons(handle *os.File) bool {
+	if codes, err := evGetSupportedCodes(handle, EV_KEY, EV_CODE_KEY_MAX); err != nil {
+		return false
+	} else {
+		for _, code := range codes {
+			if code >= evKeyCode(gopi.KEYCODE_BTNTRIGGER) && code <= evKeyCode(gopi.KEYCODE_BTNTHUMBR) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// axis returns an axis, or nil if the axis is not reported by the device
+func (this *device) axis(which gopi.InputAxis) *axis {
+	for _, a := range this.axes {
+		if a.Axis.Axis == which {
+			return a
+		}
+	}
+	return nil
+}
+
+// keycode returns the key code for the last key event, which is mapped
+// onto a gamepad button for joysticks
+func (this *device) keycode() gopi.KeyCode {
+	if this.mapping != nil {
+		return this.mapping.Map(gopi.KeyCode(this.key_code))
+	}
+	return gopi.KeyCode(this.key_code)
+}
@@ -13,10 +13,14 @@ package linux
 
 import (
 	"fmt"
+	"os"
+	"sync"
+	"time"
 
 	// Frameworks
 	"github.com/djthorpe/gopi"
 	"github.com/djthorpe/gopi/sys/hw/linux"
+	"github.com/djthorpe/gopi/sys/input/calibrate"
 	"github.com/djthorpe/gopi/util/event"
 )
 
@@ -30,6 +34,23 @@ type InputManager struct {
 
 	// Whether to try and get exclusivity when opening devices
 	Exclusive bool
+
+	// Whether to open devices which are plugged in after
+	// OpenDevicesByName has been called
+	Hotplug bool
+
+	// Display used to scale touchscreen positions when a device
+	// has not been calibrated, or nil
+	Display gopi.Display
+
+	// Path to the file of stored calibrations, or empty
+	Calibration string
+
+	// Swap and invert axes for touchscreens which have not been
+	// calibrated, for rotated displays
+	SwapXY  bool
+	InvertX bool
+	InvertY bool
 }
 
 // Driver of multiple input devices
@@ -43,13 +64,52 @@ type manager struct {
 
 	// List of open devices
 	devices []gopi.InputDevice
+
+	// Forwarding of device events and opening of hot-plugged devices,
+	// which end when the manager is closed
+	wg     sync.WaitGroup
+	lock   sync.Mutex
+	closed bool
+	done   chan struct{}
+
+	// Hot-plug detection and the filters used to open devices
+	hotplug *hotplug
+	filters []filter
+
+	// Virtual devices created by the manager
+	virtual []gopi.InputVirtualDevice
+
+	// Touchscreen calibration
+	display          gopi.Display
+	calibration_path string
+	calibrations     *calibrate.Store
+	swap_xy          bool
+	invert_x         bool
+	invert_y         bool
+}
+
+// filter is the set of arguments to OpenDevicesByName
+type filter struct {
+	alias string
+	flags gopi.InputDeviceType
+	bus   gopi.InputDeviceBus
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Number of attempts and the delay between each attempt to open
+	// a hot-plugged device, whilst the device node permissions are set
+	HOTPLUG_OPEN_ATTEMPTS = 5
+	HOTPLUG_OPEN_DELAY    = 200 * time.Millisecond
+)
+
 ////////////////////////////////////////////////////////////////////////////////
 // OPEN AND CLOSE
 
 func (config InputManager) Open(log gopi.Logger) (gopi.Driver, error) {
-	log.Debug("<sys.input.linux.InputManager.Open>{ exclusive=%v }", config.Exclusive)
+	log.Debug("<sys.input.linux.InputManager.Open>{ exclusive=%v hotplug=%v calibration=\"%v\" swap_xy=%v invert_x=%v invert_y=%v }", config.Exclusive, config.Hotplug, config.Calibration, config.SwapXY, config.InvertX, config.InvertY)
 
 	// create new input device manager
 	this := new(manager)
@@ -63,6 +123,32 @@ func (config InputManager) Open(log gopi.Logger) (gopi.Driver, error) {
 	this.filepoll = config.FilePoll
 	this.pubsub = event.NewPubSub(0)
 	this.devices = make([]gopi.InputDevice, 0)
+	this.filters = make([]filter, 0)
+	this.virtual = make([]gopi.InputVirtualDevice, 0)
+	this.done = make(chan struct{})
+	this.display = config.Display
+	this.calibration_path = config.Calibration
+	this.swap_xy = config.SwapXY
+	this.invert_x = config.InvertX
+	this.invert_y = config.InvertY
+
+	// Read stored calibrations
+	if this.calibration_path == "" {
+		this.calibrations = calibrate.NewStore()
+	} else if calibrations, err := calibrate.LoadStore(this.calibration_path); err != nil {
+		return nil, err
+	} else {
+		this.calibrations = calibrations
+	}
+
+	// Watch for devices being plugged in and removed
+	if config.Hotplug {
+		if hotplug, err := newHotplug(log, this.filepoll, this.hotplugEvent); err != nil {
+			log.Warn("<sys.input.linux.InputManager.Open>: Hotplug disabled: %v", err)
+		} else {
+			this.hotplug = hotplug
+		}
+	}
 
 	// success
 	return this, nil
@@ -72,12 +158,48 @@ func (config InputManager) Open(log gopi.Logger) (gopi.Driver, error) {
 func (this *manager) Close() error {
 	this.log.Debug("<sys.input.linux.InputManager.Close>{ }")
 
+	// Stop watching for hot-plug events
+	if this.hotplug != nil {
+		if err := this.hotplug.Close(); err != nil {
+			this.log.Warn("<sys.input.linux.InputManager.Close>: %v", err)
+		}
+		this.hotplug = nil
+	}
+
+	// Prevent devices from being added, and stop opening hot-plugged
+	// devices
+	this.lock.Lock()
+	this.closed = true
+	close(this.done)
+	this.lock.Unlock()
+
+	// Close all open devices
+	for _, device := range this.openDevices() {
+		if err := this.CloseDevice(device); err != nil {
+			this.log.Warn("<sys.input.linux.InputManager.Close>: %v", err)
+		}
+	}
+
+	// Destroy virtual devices
+	for _, device := range this.virtualDevices() {
+		if err := this.CloseDevice(device); err != nil {
+			this.log.Warn("<sys.input.linux.InputManager.Close>: %v", err)
+		}
+	}
+
+	// Wait for event forwarding and opening hot-plugged devices to end
+	this.wg.Wait()
+
 	this.pubsub.Close()
 
 	// Empty
 	this.filepoll = nil
 	this.pubsub = nil
 	this.devices = nil
+	this.filters = nil
+	this.virtual = nil
+	this.display = nil
+	this.calibrations = nil
 
 	return nil
 }
@@ -86,7 +208,7 @@ func (this *manager) Close() error {
 // STRINGIFY
 
 func (this *manager) String() string {
-	return fmt.Sprintf("<sys.input.linux.InputManager>{ exclusive=%v }", this.exclusive)
+	return fmt.Sprintf("<sys.input.linux.InputManager>{ exclusive=%v hotplug=%v virtual=%v }", this.exclusive, this.hotplug != nil, this.virtualDevices())
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -100,6 +222,9 @@ func (this *manager) OpenDevicesByName(alias string, flags gopi.InputDeviceType,
 	new_devices := make([]gopi.InputDevice, 0)
 	opened_devices := make([]gopi.InputDevice, 0)
 
+	// Register the filter for hot-plugged devices
+	this.addFilter(filter{alias, flags, bus})
+
 	// Discover devices using evFind and add any new ones to the new_devices
 	// array, they are left in an opened state
 	evFind(func(path string) {
@@ -123,42 +248,114 @@ func (this *manager) OpenDevicesByName(alias string, flags gopi.InputDeviceType,
 		}
 	}
 
-	// For opened devices, subscribe to receive events
-	// TODO
+	// For opened devices, subscribe to receive events. Devices which
+	// were opened in the meantime are closed
+	added_devices := make([]gopi.InputDevice, 0, len(opened_devices))
+	for i, device := range opened_devices {
+		if added, err := this.addDevice(device); err != nil {
+			for _, device := range opened_devices[i:] {
+				device.Close()
+			}
+			return nil, err
+		} else if added == false {
+			device.Close()
+		} else {
+			added_devices = append(added_devices, device)
+		}
+	}
 
 	// Return newly opened devices
-	return opened_devices, nil
+	return added_devices, nil
 }
 
 func (this *manager) CloseDevice(device gopi.InputDevice) error {
 	this.log.Debug2("<sys.input.linux.InputManager.CloseDevice>{ device=%v }", device)
 
-	// TODO
-	// Unsubscribe from events
-	// Remove from devices array
-	// Close the device
+	// Remove from devices or virtual devices array
+	if this.removeDevice(device) == false && this.removeVirtualDevice(device) == false {
+		return gopi.ErrNotFound
+	}
 
-	return gopi.ErrNotImplemented
+	// Close the device, which also closes the subscriber channel
+	// and ends the forwarding of events
+	return device.Close()
 }
 
-/*
-	// Remove device from poll
-	if linux_device, ok := device.(*device); ok {
-		if err := this.filepoll.Unwatch(linux_device.GetFd()); err != nil {
-			return err
-		}
+// CreateVirtualDevice creates a device through uinput which injects events
+// into the system. The events are received by opening the device with
+// OpenDevicesByName, or automatically when hot-plugging is enabled and the
+// device matches a previous call to OpenDevicesByName
+func (this *manager) CreateVirtualDevice(name string, device_type gopi.InputDeviceType, size gopi.Size) (gopi.InputVirtualDevice, error) {
+	this.log.Debug2("<sys.input.linux.InputManager.CreateVirtualDevice>{ name=\"%v\" device_type=%v size=%v }", name, device_type, size)
+
+	if device, err := gopi.Open(VirtualDevice{Name: name, Type: device_type, Size: size}, this.log); err != nil {
+		return nil, err
+	} else {
+		this.lock.Lock()
+		defer this.lock.Unlock()
+		this.virtual = append(this.virtual, device.(gopi.InputVirtualDevice))
+		return device.(gopi.InputVirtualDevice), nil
 	}
-		// Remove device from list of devices
-		delete(this.devices, int(linux_device.GetFd()))
-	// Close device
-	if err := device.Close(); err != nil {
-		return err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CALIBRATION
+
+// SetCalibration sets the calibration for a device, and persists the
+// calibration when a calibration file has been set
+func (this *manager) SetCalibration(device gopi.InputDevice, m calibrate.Matrix) error {
+	this.log.Debug2("<sys.input.linux.InputManager.SetCalibration>{ device=%v calibration=%v }", device, m)
+
+	if calibrate_device, ok := device.(calibrate.Device); ok == false || calibrate_device == nil {
+		return gopi.ErrBadParameter
+	} else {
+		calibrate_device.SetCalibration(m)
+		this.calibrations.Set(device.Name(), m)
+	}
+
+	// Persist calibrations
+	if this.calibration_path != "" {
+		return this.calibrations.Save(this.calibration_path)
 	}
 
 	// Success
 	return nil
 }
-*/
+
+// calibrate sets the stored calibration for a touchscreen, or else
+// scales raw positions to the display and swaps or inverts axes
+func (this *manager) calibrate(device calibrate.Device) {
+	if device.Type() != gopi.INPUT_TYPE_TOUCHSCREEN {
+		return
+	} else if m, exists := this.calibrations.Get(device.Name()); exists {
+		device.SetCalibration(m)
+		return
+	}
+
+	// Determine the size of the display, or the range of raw positions
+	min, max := device.RawRange()
+	size := gopi.Size{max.X - min.X, max.Y - min.Y}
+	if this.display != nil {
+		if w, h := this.display.Size(); w > 0 && h > 0 {
+			size = gopi.Size{float32(w), float32(h)}
+		}
+	}
+
+	// Swap axes, then scale to the size and invert axes
+	m := calibrate.Identity
+	if this.swap_xy {
+		m = calibrate.SwapXY()
+		min, max = gopi.Point{min.Y, min.X}, gopi.Point{max.Y, max.X}
+	}
+	m = m.Then(calibrate.ScaleToSize(min, max, size))
+	if this.invert_x {
+		m = m.Then(calibrate.InvertX(size.W))
+	}
+	if this.invert_y {
+		m = m.Then(calibrate.InvertY(size.H))
+	}
+	device.SetCalibration(m)
+}
 
 ////////////////////////////////////////////////////////////////////////////////
 // PUBLISH AND SUBSCRIBE TO INPUT EVENTS
@@ -178,6 +375,14 @@ func (this *manager) Unsubscribe(subscriber <-chan gopi.Event) {
 // PRIVATE METHODS
 
 func (this *manager) deviceByPath(path string) gopi.InputDevice {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.deviceByPathLocked(path)
+}
+
+// deviceByPathLocked returns an open device for a path, and should be
+// called with the lock held
+func (this *manager) deviceByPathLocked(path string) gopi.InputDevice {
 	for _, d := range this.devices {
 		if linux_device, is_linux := d.(*device); is_linux {
 			if linux_device.path == path {
@@ -187,3 +392,212 @@ func (this *manager) deviceByPath(path string) gopi.InputDevice {
 	}
 	return nil
 }
+
+// isOpenLocked returns true if a device with the same path is open,
+// and should be called with the lock held
+func (this *manager) isOpenLocked(d gopi.InputDevice) bool {
+	if linux_device, is_linux := d.(*device); is_linux {
+		return this.deviceByPathLocked(linux_device.path) != nil
+	} else {
+		return false
+	}
+}
+
+func (this *manager) openDevices() []gopi.InputDevice {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	devices := make([]gopi.InputDevice, len(this.devices))
+	copy(devices, this.devices)
+	return devices
+}
+
+// addDevice appends a device to the list of open devices and
+// forwards events from the device to subscribers of the manager. It
+// returns false if a device with the same path is already open, or
+// ErrOutOfOrder if the manager has been closed
+func (this *manager) addDevice(device gopi.InputDevice) (bool, error) {
+	if calibrate_device, ok := device.(calibrate.Device); ok {
+		this.calibrate(calibrate_device)
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.closed {
+		return false, gopi.ErrOutOfOrder
+	}
+	if this.isOpenLocked(device) {
+		return false, nil
+	}
+	this.devices = append(this.devices, device)
+	this.wg.Add(1)
+	go func(events <-chan gopi.Event) {
+		defer this.wg.Done()
+		for evt := range events {
+			this.pubsub.Emit(evt)
+		}
+	}(device.Subscribe())
+	return true, nil
+}
+
+// removeDevice removes a device from the list of open devices and
+// returns false if the device was not found
+func (this *manager) removeDevice(device gopi.InputDevice) bool {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for i, d := range this.devices {
+		if d == device {
+			this.devices = append(this.devices[:i], this.devices[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (this *manager) virtualDevices() []gopi.InputVirtualDevice {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	devices := make([]gopi.InputVirtualDevice, len(this.virtual))
+	copy(devices, this.virtual)
+	return devices
+}
+
+// removeVirtualDevice removes a device from the list of virtual devices
+// and returns false if the device was not found
+func (this *manager) removeVirtualDevice(device gopi.InputDevice) bool {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for i, d := range this.virtual {
+		if d == device {
+			this.virtual = append(this.virtual[:i], this.virtual[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// addFilter registers the arguments to OpenDevicesByName, ignoring
+// duplicates
+func (this *manager) addFilter(f filter) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for _, other := range this.filters {
+		if other == f {
+			return
+		}
+	}
+	this.filters = append(this.filters, f)
+}
+
+// matchesFilter returns true if a device matches any registered filter
+func (this *manager) matchesFilter(device gopi.InputDevice) bool {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for _, f := range this.filters {
+		if device.Matches(f.alias, f.flags, f.bus) {
+			return true
+		}
+	}
+	return false
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// HOTPLUG
+
+func (this *manager) hotplugEvent(action hotplugAction, path string) {
+	this.log.Debug2("<sys.input.linux.InputManager.hotplugEvent>{ action=%v path=%v }", action, path)
+	switch action {
+	case HOTPLUG_ACTION_ADD:
+		if this.deviceByPath(path) == nil {
+			// Open in the background, since the device node may
+			// not be accessible immediately
+			this.lock.Lock()
+			defer this.lock.Unlock()
+			if this.closed == false {
+				this.wg.Add(1)
+				go this.hotplugAdd(path)
+			}
+		}
+	case HOTPLUG_ACTION_REMOVE:
+		if this.deviceByPath(path) != nil {
+			// Emit and close in the background, so that subscribers
+			// do not hold up hot-plug events
+			this.lock.Lock()
+			defer this.lock.Unlock()
+			if this.closed == false {
+				this.wg.Add(1)
+				go this.hotplugRemove(path)
+			}
+		}
+	}
+}
+
+// hotplugRemove emits an event for a removed device and closes it,
+// unless it was closed in the meantime
+func (this *manager) hotplugRemove(path string) {
+	defer this.wg.Done()
+
+	device := this.deviceByPath(path)
+	if device == nil || this.removeDevice(device) == false {
+		return
+	}
+	this.pubsub.Emit(this.deviceEvent(device, gopi.INPUT_EVENT_DEVICEREMOVED))
+	if err := device.Close(); err != nil {
+		this.log.Warn("Hotplug: %v: %v", path, err)
+	}
+}
+
+// hotplugAdd opens a hot-plugged device, retrying until the device
+// node is accessible or the manager is closed
+func (this *manager) hotplugAdd(path string) {
+	defer this.wg.Done()
+
+	var input_device gopi.Driver
+	var err error
+	for attempt := 0; attempt < HOTPLUG_OPEN_ATTEMPTS; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(HOTPLUG_OPEN_DELAY):
+			case <-this.done:
+				return
+			}
+		}
+		if input_device, err = gopi.Open(InputDevice{Path: path, Exclusive: this.exclusive, FilePoll: this.filepoll}, this.log); err == nil {
+			break
+		} else if os.IsNotExist(err) {
+			return
+		}
+	}
+	if err != nil {
+		this.log.Warn("Hotplug: %v: %v", path, err)
+		return
+	}
+
+	// Close the device if it doesn't match any filter
+	device := input_device.(gopi.InputDevice)
+	if this.matchesFilter(device) == false {
+		if err := device.Close(); err != nil {
+			this.log.Warn("Hotplug: %v: %v", path, err)
+		}
+		return
+	}
+
+	// Add the device and emit an event, unless it was opened or the
+	// manager has been closed in the meantime. The manager waits for
+	// this method to return before closing the publisher
+	if added, err := this.addDevice(device); err != nil || added == false {
+		device.Close()
+		return
+	}
+	this.pubsub.Emit(this.deviceEvent(device, gopi.INPUT_EVENT_DEVICEADDED))
+}
+
+func (this *manager) deviceEvent(d gopi.InputDevice, event_type gopi.InputEventType) gopi.InputEvent {
+	evt := &input_event{
+		event_type:  event_type,
+		device_type: d.Type(),
+	}
+	if linux_device, ok := d.(*device); ok {
+		evt.device = linux_device
+		evt.position = linux_device.position
+	}
+	return evt
+}
@@ -0,0 +1,95 @@
+// +build linux
+
+package linux_test
+
+import (
+	"testing"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	linux "github.com/djthorpe/gopi/sys/input/linux"
+	logger "github.com/djthorpe/gopi/sys/logger"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// MULTI-TOUCH SLOTS
+
+func TestSlots_000(t *testing.T) {
+	// Press in slot 0, move and release
+	events := linux.DecodeSlots(openLogger(t), []linux.EvEvent{
+		abs(linux.EV_CODE_SLOT, 0), abs(linux.EV_CODE_SLOT_ID, 10), abs(linux.EV_CODE_SLOT_X, 100), abs(linux.EV_CODE_SLOT_Y, 200), syn(),
+		abs(linux.EV_CODE_SLOT_X, 110), syn(),
+		syn(),
+		abs(linux.EV_CODE_SLOT_ID, 0xFFFFFFFF), syn(),
+	})
+	expectSlots(t, events, []slotEvent{
+		{gopi.INPUT_EVENT_TOUCHPRESS, 0, gopi.Point{X: 100, Y: 200}},
+		{gopi.INPUT_EVENT_TOUCHPOSITION, 0, gopi.Point{X: 110, Y: 200}},
+		{gopi.INPUT_EVENT_TOUCHRELEASE, 0, gopi.Point{X: 110, Y: 200}},
+	})
+}
+
+func TestSlots_001(t *testing.T) {
+	// Two slots pressed in the same frame, then the second released
+	events := linux.DecodeSlots(openLogger(t), []linux.EvEvent{
+		abs(linux.EV_CODE_SLOT, 0), abs(linux.EV_CODE_SLOT_ID, 1), abs(linux.EV_CODE_SLOT_X, 10), abs(linux.EV_CODE_SLOT_Y, 20),
+		abs(linux.EV_CODE_SLOT, 1), abs(linux.EV_CODE_SLOT_ID, 2), abs(linux.EV_CODE_SLOT_X, 30), abs(linux.EV_CODE_SLOT_Y, 40), syn(),
+		abs(linux.EV_CODE_SLOT_ID, 0xFFFFFFFF), abs(linux.EV_CODE_SLOT, 0), abs(linux.EV_CODE_SLOT_Y, 25), syn(),
+	})
+	expectSlots(t, events, []slotEvent{
+		{gopi.INPUT_EVENT_TOUCHPRESS, 0, gopi.Point{X: 10, Y: 20}},
+		{gopi.INPUT_EVENT_TOUCHPRESS, 1, gopi.Point{X: 30, Y: 40}},
+		{gopi.INPUT_EVENT_TOUCHPOSITION, 0, gopi.Point{X: 10, Y: 25}},
+		{gopi.INPUT_EVENT_TOUCHRELEASE, 1, gopi.Point{X: 30, Y: 40}},
+	})
+}
+
+func TestSlots_002(t *testing.T) {
+	// Release of an inactive slot, and out-of-range slots, are ignored
+	events := linux.DecodeSlots(openLogger(t), []linux.EvEvent{
+		abs(linux.EV_CODE_SLOT, 2), abs(linux.EV_CODE_SLOT_ID, 0xFFFFFFFF), syn(),
+		abs(linux.EV_CODE_SLOT, linux.INPUT_MAX_MULTITOUCH_SLOTS), abs(linux.EV_CODE_SLOT_ID, 5), abs(linux.EV_CODE_SLOT_X, 1), syn(),
+	})
+	expectSlots(t, events, []slotEvent{})
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+type slotEvent struct {
+	event_type gopi.InputEventType
+	slot       uint
+	position   gopi.Point
+}
+
+func abs(code linux.EvKeyCode, value uint32) linux.EvEvent {
+	return linux.EvEvent{Type: linux.EV_ABS, Code: code, Value: value}
+}
+
+func syn() linux.EvEvent {
+	return linux.EvEvent{Type: linux.EV_SYN}
+}
+
+func expectSlots(t *testing.T, events []gopi.InputEvent, expected []slotEvent) {
+	t.Helper()
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %v events, got %v: %v", len(expected), len(events), events)
+	}
+	for i, evt := range events {
+		if evt.EventType() != expected[i].event_type || evt.Slot() != expected[i].slot || evt.Position().Equals(expected[i].position) == false {
+			t.Errorf("Event %v: Expected %v, got %v", i, expected[i], evt)
+		}
+		if evt.Keycode() != gopi.KEYCODE_BTNTOUCH {
+			t.Errorf("Event %v: Unexpected keycode %v", i, evt.Keycode())
+		}
+	}
+}
+
+func openLogger(t *testing.T) gopi.Logger {
+	if log, err := gopi.Open(logger.Config{}, nil); err != nil {
+		t.Fatal("Unable to create logger driver")
+		return nil
+	} else {
+		return log.(gopi.Logger)
+	}
+}
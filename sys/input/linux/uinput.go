@@ -0,0 +1,526 @@
+// +build linux
+
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package linux
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+	"github.com/djthorpe/gopi/util/event"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// CGO INTERFACE
+
+/*
+ #include <linux/uinput.h>
+*/
+import "C"
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Virtual input device, which is created through the uinput
+// kernel module
+type VirtualDevice struct {
+	// Name of the device
+	Name string
+
+	// Type of device, which determines the events which can be injected
+	Type gopi.InputDeviceType
+
+	// Range of absolute positions for touchscreen and joystick devices
+	Size gopi.Size
+}
+
+type virtual struct {
+	log         gopi.Logger
+	pubsub      *event.PubSub
+	handle      *os.File
+	name        string
+	device_type gopi.InputDeviceType
+	size        gopi.Size
+	position    gopi.Point
+	touches     uint
+	lock        sync.Mutex
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Path to the uinput device
+	UINPUT_PATH_DEVICE = "/dev/uinput"
+
+	// Product and vendor reported for virtual devices
+	UINPUT_VENDOR  = 0x0001
+	UINPUT_PRODUCT = 0x0001
+	UINPUT_VERSION = 0x0001
+
+	// Property set for touchscreens
+	UINPUT_PROP_DIRECT = 0x0001
+)
+
+const (
+	EV_CODE_SYN_REPORT evKeyCode = 0x0000
+	EV_CODE_LED_NUML   evKeyCode = 0x0000
+	EV_CODE_LED_CAPSL  evKeyCode = 0x0001
+	EV_CODE_LED_SCROLL evKeyCode = 0x0002
+	EV_CODE_MSC_SCAN   evKeyCode = 0x0004
+	EV_CODE_KEY_MAX    evKeyCode = evKeyCode(gopi.KEYCODE_MAX)
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+// Create a virtual device or return error
+func (config VirtualDevice) Open(log gopi.Logger) (gopi.Driver, error) {
+	log.Debug("<sys.input.linux.VirtualDevice.Open>{ name=\"%v\" type=%v size=%v }", config.Name, config.Type, config.Size)
+
+	// Check incoming configuration parameters
+	if config.Name == "" {
+		return nil, gopi.ErrBadParameter
+	}
+	switch config.Type {
+	case gopi.INPUT_TYPE_KEYBOARD, gopi.INPUT_TYPE_REMOTE, gopi.INPUT_TYPE_MOUSE:
+		break
+	case gopi.INPUT_TYPE_TOUCHSCREEN, gopi.INPUT_TYPE_JOYSTICK:
+		if config.Size.W <= 0 || config.Size.H <= 0 {
+			return nil, gopi.ErrBadParameter
+		}
+	default:
+		return nil, gopi.ErrBadParameter
+	}
+
+	this := new(virtual)
+	this.log = log
+	this.name = config.Name
+	this.device_type = config.Type
+	this.size = config.Size
+
+	// Open the uinput device
+	if handle, err := os.OpenFile(UINPUT_PATH_DEVICE, os.O_WRONLY|syscall.O_NONBLOCK, 0); err != nil {
+		return nil, err
+	} else {
+		this.handle = handle
+	}
+
+	// Set the capabilities and create the device
+	if err := this.setCapabilities(); err != nil {
+		this.handle.Close()
+		return nil, err
+	} else if err := this.create(); err != nil {
+		this.handle.Close()
+		return nil, err
+	}
+
+	// PubSub
+	this.pubsub = event.NewPubSub(0)
+
+	// Success
+	return this, nil
+}
+
+// Close destroys the virtual device
+func (this *virtual) Close() error {
+	this.log.Debug("<sys.input.linux.VirtualDevice.Close>{ name=\"%v\" }", this.name)
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if this.handle == nil {
+		return nil
+	}
+
+	// Destroy the device
+	if err := uinputIoctl(this.handle, C.UI_DEV_DESTROY, 0); err != nil {
+		this.log.Warn("<sys.input.linux.VirtualDevice.Close>: %v", err)
+	}
+
+	// Close file handle
+	err := this.handle.Close()
+
+	// Close subscriber channels
+	this.pubsub.Close()
+
+	// Blank out
+	this.handle = nil
+	this.pubsub = nil
+
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// INTERFACE IMPLEMENTATION
+
+// Return name of the device
+func (this *virtual) Name() string {
+	return this.name
+}
+
+// Return the type of device
+func (this *virtual) Type() gopi.InputDeviceType {
+	return this.device_type
+}
+
+// Virtual devices are always on the virtual bus
+func (this *virtual) Bus() gopi.InputDeviceBus {
+	return gopi.INPUT_BUS_VIRTUAL
+}
+
+// Return absolute cursor position
+func (this *virtual) Position() gopi.Point {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.position
+}
+
+// Return true if the device matches an alias, type and bus
+func (this *virtual) Matches(alias string, flags gopi.InputDeviceType, bus gopi.InputDeviceBus) bool {
+	if flags != gopi.INPUT_TYPE_NONE && flags != gopi.INPUT_TYPE_ANY && this.device_type&flags == 0 {
+		return false
+	}
+	if bus != gopi.INPUT_BUS_NONE && bus != gopi.INPUT_BUS_ANY && bus != gopi.INPUT_BUS_VIRTUAL {
+		return false
+	}
+	if alias != "" && alias != this.name {
+		return false
+	}
+	return true
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// INJECT EVENTS
+
+// Inject a key press, release or repeat
+func (this *virtual) InjectKey(key gopi.KeyCode, event_type gopi.InputEventType) error {
+	this.log.Debug2("<sys.input.linux.VirtualDevice.InjectKey>{ key=%v event_type=%v }", key, event_type)
+
+	var value evKeyAction
+	switch event_type {
+	case gopi.INPUT_EVENT_KEYPRESS:
+		value = EV_VALUE_KEY_DOWN
+	case gopi.INPUT_EVENT_KEYRELEASE:
+		value = EV_VALUE_KEY_UP
+	case gopi.INPUT_EVENT_KEYREPEAT:
+		value = EV_VALUE_KEY_REPEAT
+	default:
+		return gopi.ErrBadParameter
+	}
+	if key == gopi.KEYCODE_NONE || key > gopi.KEYCODE_MAX {
+		return gopi.ErrBadParameter
+	}
+
+	evt := &input_event{
+		event_type: event_type,
+		key_code:   key,
+	}
+	return this.inject(evt, func() error {
+		return this.write(EV_KEY, evKeyCode(key), uint32(value))
+	})
+}
+
+// Inject relative motion, for mouse devices
+func (this *virtual) InjectRelPosition(rel gopi.Point) error {
+	this.log.Debug2("<sys.input.linux.VirtualDevice.InjectRelPosition>{ rel=%v }", rel)
+
+	if this.device_type != gopi.INPUT_TYPE_MOUSE {
+		return gopi.ErrNotImplemented
+	}
+
+	evt := &input_event{
+		event_type:   gopi.INPUT_EVENT_RELPOSITION,
+		rel_position: rel,
+	}
+	return this.inject(evt, func() error {
+		if err := this.write(EV_REL, EV_CODE_X, uint32(int32(rel.X))); err != nil {
+			return err
+		} else if err := this.write(EV_REL, EV_CODE_Y, uint32(int32(rel.Y))); err != nil {
+			return err
+		}
+		this.position = gopi.Point{this.position.X + rel.X, this.position.Y + rel.Y}
+		evt.position = this.position
+		return nil
+	})
+}
+
+// Inject absolute position, for touchscreen and joystick devices
+func (this *virtual) InjectAbsPosition(position gopi.Point) error {
+	this.log.Debug2("<sys.input.linux.VirtualDevice.InjectAbsPosition>{ position=%v }", position)
+
+	if this.device_type != gopi.INPUT_TYPE_TOUCHSCREEN && this.device_type != gopi.INPUT_TYPE_JOYSTICK {
+		return gopi.ErrNotImplemented
+	}
+
+	evt := &input_event{
+		event_type: gopi.INPUT_EVENT_ABSPOSITION,
+		position:   position,
+	}
+	return this.inject(evt, func() error {
+		if err := this.write(EV_ABS, EV_CODE_X, uint32(int32(position.X))); err != nil {
+			return err
+		} else if err := this.write(EV_ABS, EV_CODE_Y, uint32(int32(position.Y))); err != nil {
+			return err
+		}
+		this.position = position
+		return nil
+	})
+}
+
+// Inject a multi-touch press, release or position for a slot, for
+// touchscreen devices. The tracking identifier for a touch is the slot
+func (this *virtual) InjectTouch(slot uint, event_type gopi.InputEventType, position gopi.Point) error {
+	this.log.Debug2("<sys.input.linux.VirtualDevice.InjectTouch>{ slot=%v event_type=%v position=%v }", slot, event_type, position)
+
+	if this.device_type != gopi.INPUT_TYPE_TOUCHSCREEN {
+		return gopi.ErrNotImplemented
+	} else if slot >= INPUT_MAX_MULTITOUCH_SLOTS {
+		return gopi.ErrBadParameter
+	} else if event_type != gopi.INPUT_EVENT_TOUCHPRESS && event_type != gopi.INPUT_EVENT_TOUCHRELEASE && event_type != gopi.INPUT_EVENT_TOUCHPOSITION {
+		return gopi.ErrBadParameter
+	}
+
+	evt := &input_event{
+		event_type: event_type,
+		key_code:   gopi.KEYCODE_BTNTOUCH,
+		slot:       slot,
+		position:   position,
+	}
+	return this.inject(evt, func() error {
+		// Select the slot
+		if err := this.write(EV_ABS, EV_CODE_SLOT, uint32(slot)); err != nil {
+			return err
+		}
+		switch event_type {
+		case gopi.INPUT_EVENT_TOUCHPRESS:
+			if err := this.write(EV_ABS, EV_CODE_SLOT_ID, uint32(slot)); err != nil {
+				return err
+			} else if err := this.writeTouchPosition(position); err != nil {
+				return err
+			}
+			if this.touches == 0 {
+				if err := this.write(EV_KEY, evKeyCode(gopi.KEYCODE_BTNTOUCH), uint32(EV_VALUE_KEY_DOWN)); err != nil {
+					return err
+				}
+			}
+			this.touches++
+		case gopi.INPUT_EVENT_TOUCHRELEASE:
+			// A tracking identifier of -1 releases the slot
+			if err := this.write(EV_ABS, EV_CODE_SLOT_ID, 0xFFFFFFFF); err != nil {
+				return err
+			}
+			if this.touches == 1 {
+				if err := this.write(EV_KEY, evKeyCode(gopi.KEYCODE_BTNTOUCH), uint32(EV_VALUE_KEY_UP)); err != nil {
+					return err
+				}
+			}
+			if this.touches > 0 {
+				this.touches--
+			}
+		case gopi.INPUT_EVENT_TOUCHPOSITION:
+			if err := this.writeTouchPosition(position); err != nil {
+				return err
+			}
+		}
+		this.position = position
+		return nil
+	})
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLISH AND SUBSCRIBE INTERFACE IMPLEMTATION
+
+// Subscribe to injected events
+func (this *virtual) Subscribe() <-chan gopi.Event {
+	return this.pubsub.Subscribe()
+}
+
+// Unsubscribe from injected events
+func (this *virtual) Unsubscribe(subscriber <-chan gopi.Event) {
+	this.pubsub.Unsubscribe(subscriber)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *virtual) String() string {
+	return fmt.Sprintf("<sys.input.linux.VirtualDevice>{ name=\"%s\" type=%v size=%v position=%v }", this.name, this.device_type, this.size, this.Position())
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// setCapabilities sets the event types and codes which can be
+// injected, depending on the type of device. The capabilities
+// are chosen so that the device type is recognized when the
+// device is opened through InputManager
+func (this *virtual) setCapabilities() error {
+	types := []evType{EV_SYN, EV_KEY}
+	keys := []evKeyCode{}
+	leds := []evKeyCode{}
+	rels := []evKeyCode{}
+	abs := []evKeyCode{}
+	msc := []evKeyCode{}
+
+	switch this.device_type {
+	case gopi.INPUT_TYPE_KEYBOARD, gopi.INPUT_TYPE_REMOTE:
+		types = append(types, EV_LED, EV_REP)
+		leds = append(leds, EV_CODE_LED_NUML, EV_CODE_LED_CAPSL, EV_CODE_LED_SCROLL)
+		for key := evKeyCode(1); key <= EV_CODE_KEY_MAX; key++ {
+			keys = append(keys, key)
+		}
+	case gopi.INPUT_TYPE_MOUSE:
+		types = append(types, EV_REL)
+		rels = append(rels, EV_CODE_X, EV_CODE_Y)
+		keys = append(keys, evKeyCode(gopi.KEYCODE_BTNLEFT), evKeyCode(gopi.KEYCODE_BTNRIGHT), evKeyCode(gopi.KEYCODE_BTNMIDDLE), evKeyCode(gopi.KEYCODE_BTNSIDE), evKeyCode(gopi.KEYCODE_BTNEXTRA))
+	case gopi.INPUT_TYPE_TOUCHSCREEN:
+		types = append(types, EV_ABS)
+		abs = append(abs, EV_CODE_X, EV_CODE_Y, EV_CODE_SLOT, EV_CODE_SLOT_X, EV_CODE_SLOT_Y, EV_CODE_SLOT_ID)
+		keys = append(keys, evKeyCode(gopi.KEYCODE_BTNTOUCH))
+	case gopi.INPUT_TYPE_JOYSTICK:
+		types = append(types, EV_ABS, EV_MSC)
+		abs = append(abs, EV_CODE_X, EV_CODE_Y)
+		msc = append(msc, EV_CODE_MSC_SCAN)
+		for key := gopi.KEYCODE_BTN0; key <= gopi.KEYCODE_BTN9; key++ {
+			keys = append(keys, evKeyCode(key))
+		}
+	}
+
+	// Set the bits
+	for _, typ := range types {
+		if err := uinputIoctl(this.handle, C.UI_SET_EVBIT, uintptr(typ)); err != nil {
+			return err
+		}
+	}
+	for _, bits := range []struct {
+		request uintptr
+		codes   []evKeyCode
+	}{
+		{C.UI_SET_KEYBIT, keys},
+		{C.UI_SET_LEDBIT, leds},
+		{C.UI_SET_RELBIT, rels},
+		{C.UI_SET_ABSBIT, abs},
+		{C.UI_SET_MSCBIT, msc},
+	} {
+		for _, code := range bits.codes {
+			if err := uinputIoctl(this.handle, bits.request, uintptr(code)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Touchscreens report direct input
+	if this.device_type == gopi.INPUT_TYPE_TOUCHSCREEN {
+		if err := uinputIoctl(this.handle, C.UI_SET_PROPBIT, UINPUT_PROP_DIRECT); err != nil {
+			return err
+		}
+	}
+
+	// Success
+	return nil
+}
+
+// create writes the device description and creates the device
+func (this *virtual) create() error {
+	var dev C.struct_uinput_user_dev
+	for i := 0; i < len(this.name) && i < C.UINPUT_MAX_NAME_SIZE-1; i++ {
+		dev.name[i] = C.char(this.name[i])
+	}
+	dev.id.bustype = C.__u16(gopi.INPUT_BUS_VIRTUAL)
+	dev.id.vendor = UINPUT_VENDOR
+	dev.id.product = UINPUT_PRODUCT
+	dev.id.version = UINPUT_VERSION
+
+	// Set absolute ranges
+	if this.device_type == gopi.INPUT_TYPE_TOUCHSCREEN || this.device_type == gopi.INPUT_TYPE_JOYSTICK {
+		dev.absmax[EV_CODE_X] = C.__s32(this.size.W - 1)
+		dev.absmax[EV_CODE_Y] = C.__s32(this.size.H - 1)
+	}
+	if this.device_type == gopi.INPUT_TYPE_TOUCHSCREEN {
+		dev.absmax[EV_CODE_SLOT] = C.__s32(INPUT_MAX_MULTITOUCH_SLOTS - 1)
+		dev.absmax[EV_CODE_SLOT_ID] = C.__s32(INPUT_MAX_MULTITOUCH_SLOTS - 1)
+		dev.absmax[EV_CODE_SLOT_X] = C.__s32(this.size.W - 1)
+		dev.absmax[EV_CODE_SLOT_Y] = C.__s32(this.size.H - 1)
+	}
+
+	// Write the description and create the device
+	buf := (*[C.sizeof_struct_uinput_user_dev]byte)(unsafe.Pointer(&dev))[:]
+	if _, err := this.handle.Write(buf); err != nil {
+		return err
+	}
+	return uinputIoctl(this.handle, C.UI_DEV_CREATE, 0)
+}
+
+// write an event to the device
+func (this *virtual) write(typ evType, code evKeyCode, value uint32) error {
+	if this.handle == nil {
+		return gopi.ErrOutOfOrder
+	}
+	var evt C.struct_input_event
+	evt._type = C.__u16(typ)
+	evt.code = C.__u16(code)
+	evt.value = C.__s32(int32(value))
+	buf := (*[C.sizeof_struct_input_event]byte)(unsafe.Pointer(&evt))[:]
+	_, err := this.handle.Write(buf)
+	return err
+}
+
+// writeTouchPosition writes the position of the current slot
+func (this *virtual) writeTouchPosition(position gopi.Point) error {
+	if err := this.write(EV_ABS, EV_CODE_SLOT_X, uint32(int32(position.X))); err != nil {
+		return err
+	} else if err := this.write(EV_ABS, EV_CODE_SLOT_Y, uint32(int32(position.Y))); err != nil {
+		return err
+	}
+	return nil
+}
+
+// inject calls the writes function and then writes the report event,
+// and emits the injected event to subscribers of the virtual device
+func (this *virtual) inject(evt *input_event, writes func() error) error {
+	this.lock.Lock()
+	err := writes()
+	if err == nil {
+		err = this.write(EV_SYN, EV_CODE_SYN_REPORT, 0)
+	}
+	pubsub := this.pubsub
+	this.lock.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	// Emit the event
+	evt.virtual = this
+	evt.device_type = this.device_type
+	evt.timestamp = time.Duration(time.Now().UnixNano())
+	if pubsub != nil {
+		pubsub.Emit(evt)
+	}
+
+	// Success
+	return nil
+}
+
+// uinputIoctl calls ioctl on the uinput device with an integer argument
+func uinputIoctl(handle *os.File, request uintptr, value uintptr) error {
+	if _, _, err := syscall.Syscall(syscall.SYS_IOCTL, handle.Fd(), request, value); err != 0 {
+		return os.NewSyscallError("ioctl", err)
+	}
+	return nil
+}
@@ -1,6 +1,6 @@
 /*
   Go Language Raspberry Pi Interface
-  (c) Copyright David Thorpe 2016-2017
+  (c) Copyright David Thorpe 2016-2018
   All Rights Reserved
 
   Documentation http://djthorpe.github.io/gopi/
@@ -15,6 +15,7 @@ import (
 
 	// Frameworks
 	"github.com/djthorpe/gopi"
+	"github.com/djthorpe/gopi/util/event"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -23,12 +24,9 @@ import (
 type Input struct{}
 
 type input struct {
-	log         gopi.Logger
-	subscribers []chan gopi.Event
-	devices     []gopi.InputDevice
-}
-
-type event struct {
+	log     gopi.Logger
+	pubsub  *event.PubSub
+	devices []gopi.InputDevice
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -40,7 +38,7 @@ func (config Input) Open(logger gopi.Logger) (gopi.Driver, error) {
 
 	this := new(input)
 	this.log = logger
-	this.subscribers = make([]chan gopi.Event, 0)
+	this.pubsub = event.NewPubSub(0)
 	this.devices = make([]gopi.InputDevice, 0)
 
 	// Success
@@ -60,7 +58,8 @@ func (this *input) Close() error {
 		}
 	}
 
-	this.subscribers = nil
+	this.pubsub.Close()
+	this.pubsub = nil
 	this.devices = nil
 
 	return nil
@@ -71,7 +70,13 @@ func (this *input) Close() error {
 
 // Open Devices by name, type and bus
 func (this *input) OpenDevicesByName(name string, flags gopi.InputDeviceType, bus gopi.InputDeviceBus) ([]gopi.InputDevice, error) {
-	return nil, nil
+	matched := make([]gopi.InputDevice, 0, len(this.devices))
+	for _, device := range this.devices {
+		if device != nil && device.Matches(name, flags, bus) {
+			matched = append(matched, device)
+		}
+	}
+	return matched, nil
 }
 
 // Add Device
@@ -80,8 +85,13 @@ func (this *input) AddDevice(device gopi.InputDevice) error {
 	if device == nil {
 		return gopi.ErrBadParameter
 	}
-	// TODO
-	return gopi.ErrNotImplemented
+	for _, other := range this.devices {
+		if other == device {
+			return gopi.ErrBadParameter
+		}
+	}
+	this.devices = append(this.devices, device)
+	return nil
 }
 
 // Close Device
@@ -121,32 +131,19 @@ func (this *input) Watch(ctx context.Context) error {
 ////////////////////////////////////////////////////////////////////////////////
 // PUBLISHER INTERFACE
 
-func (this *input) Subscribe() chan gopi.Event {
-	subscriber := make(chan gopi.Event)
-	this.subscribers = append(this.subscribers, subscriber)
-	return subscriber
+// Subscribe to events emitted
+func (this *input) Subscribe() <-chan gopi.Event {
+	return this.pubsub.Subscribe()
 }
 
 // Unsubscribe from events emitted
-func (this *input) Unsubscribe(subscriber chan gopi.Event) {
-	for i, s := range this.subscribers {
-		if subscriber == s {
-			this.subscribers[i] = nil
-		}
-	}
-}
-
-////////////////////////////////////////////////////////////////////////////////
-// EVENT INTERFACE
-
-// Source of the event
-func (this *event) Source() gopi.Driver {
-	return nil
+func (this *input) Unsubscribe(subscriber <-chan gopi.Event) {
+	this.pubsub.Unsubscribe(subscriber)
 }
 
-// Name of the event
-func (this *event) Name() string {
-	return "sys.mock.Input.Event"
+// Emit an event to subscribers
+func (this *input) Emit(evt gopi.Event) {
+	this.pubsub.Emit(evt)
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -10,10 +10,11 @@
 package mock
 
 import (
-	// Frameworks
 	"fmt"
 
+	// Frameworks
 	"github.com/djthorpe/gopi"
+	"github.com/djthorpe/gopi/util/event"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -27,13 +28,13 @@ type Device struct {
 }
 
 type device struct {
-	log         gopi.Logger
-	subscribers []chan gopi.Event
-	name        string
-	typ         gopi.InputDeviceType
-	bus         gopi.InputDeviceBus
-	position    gopi.Point
-	keystate    gopi.KeyState
+	log      gopi.Logger
+	pubsub   *event.PubSub
+	name     string
+	typ      gopi.InputDeviceType
+	bus      gopi.InputDeviceBus
+	position gopi.Point
+	keystate gopi.KeyState
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -60,7 +61,7 @@ func (config Device) Open(logger gopi.Logger) (gopi.Driver, error) {
 	this.bus = config.Bus
 	this.position = config.Position
 	this.keystate = gopi.KEYSTATE_NONE
-	this.subscribers = make([]chan gopi.Event, 0)
+	this.pubsub = event.NewPubSub(0)
 
 	// Success
 	return this, nil
@@ -69,11 +70,12 @@ func (config Device) Open(logger gopi.Logger) (gopi.Driver, error) {
 // Close
 func (this *device) Close() error {
 	this.log.Debug("sys.mock.InputDevice.Close{ }")
-	if this.subscribers == nil {
+	if this.pubsub == nil {
 		this.log.Warn("sys.mock.InputDevice.Close: Called Close() more than once")
 		return nil
 	}
-	this.subscribers = nil
+	this.pubsub.Close()
+	this.pubsub = nil
 	return nil
 }
 
@@ -141,18 +143,20 @@ func (this *device) Matches(alias string, device_type gopi.InputDeviceType, devi
 ////////////////////////////////////////////////////////////////////////////////
 // PUBLISHER INTERFACE
 
-func (this *device) Subscribe() chan gopi.Event {
-	subscriber := make(chan gopi.Event)
-	this.subscribers = append(this.subscribers, subscriber)
-	return subscriber
+// Subscribe to events emitted
+func (this *device) Subscribe() <-chan gopi.Event {
+	return this.pubsub.Subscribe()
 }
 
 // Unsubscribe from events emitted
-func (this *device) Unsubscribe(subscriber chan gopi.Event) {
-	for i, s := range this.subscribers {
-		if subscriber == s {
-			this.subscribers[i] = nil
-		}
+func (this *device) Unsubscribe(subscriber <-chan gopi.Event) {
+	this.pubsub.Unsubscribe(subscriber)
+}
+
+// Emit an event to subscribers
+func (this *device) Emit(evt gopi.Event) {
+	if this.pubsub != nil {
+		this.pubsub.Emit(evt)
 	}
 }
 
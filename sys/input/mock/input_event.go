@@ -0,0 +1,176 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package mock
+
+import (
+	"fmt"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Synthetic input event
+type input_event struct {
+	source       gopi.Driver
+	timestamp    time.Duration
+	device_type  gopi.InputDeviceType
+	event_type   gopi.InputEventType
+	device       uint32
+	position     gopi.Point
+	rel_position gopi.Point
+	key_code     gopi.KeyCode
+	scan_code    uint32
+	slot         uint
+	axis         gopi.InputAxis
+	axis_value   float32
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// NewKeyEvent returns a key press, release or repeat event
+func NewKeyEvent(source gopi.InputDevice, ts time.Duration, event_type gopi.InputEventType, key_code gopi.KeyCode, scan_code uint32) gopi.InputEvent {
+	return &input_event{
+		source:      source,
+		timestamp:   ts,
+		device_type: deviceType(source),
+		event_type:  event_type,
+		key_code:    key_code,
+		scan_code:   scan_code,
+	}
+}
+
+// NewPositionEvent returns an absolute or relative position event
+func NewPositionEvent(source gopi.InputDevice, ts time.Duration, event_type gopi.InputEventType, position, relative gopi.Point) gopi.InputEvent {
+	return &input_event{
+		source:       source,
+		timestamp:    ts,
+		device_type:  deviceType(source),
+		event_type:   event_type,
+		position:     position,
+		rel_position: relative,
+	}
+}
+
+// NewTouchEvent returns a multi-touch press, release or position event
+// for a slot
+func NewTouchEvent(source gopi.InputDevice, ts time.Duration, event_type gopi.InputEventType, slot uint, position gopi.Point) gopi.InputEvent {
+	return &input_event{
+		source:      source,
+		timestamp:   ts,
+		device_type: deviceType(source),
+		event_type:  event_type,
+		key_code:    gopi.KEYCODE_BTNTOUCH,
+		slot:        slot,
+		position:    position,
+	}
+}
+
+// NewAxisEvent returns a joystick or gamepad axis event
+func NewAxisEvent(source gopi.InputDevice, ts time.Duration, axis gopi.InputAxis, value float32) gopi.InputEvent {
+	return &input_event{
+		source:      source,
+		timestamp:   ts,
+		device_type: deviceType(source),
+		event_type:  gopi.INPUT_EVENT_AXIS,
+		axis:        axis,
+		axis_value:  value,
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// gopi.InputEvent INTERFACE
+
+func (this *input_event) Name() string {
+	return "InputEvent"
+}
+
+func (this *input_event) Source() gopi.Driver {
+	return this.source
+}
+
+func (this *input_event) Timestamp() time.Duration {
+	return this.timestamp
+}
+
+func (this *input_event) DeviceType() gopi.InputDeviceType {
+	return this.device_type
+}
+
+func (this *input_event) EventType() gopi.InputEventType {
+	return this.event_type
+}
+
+func (this *input_event) Device() uint32 {
+	return this.device
+}
+
+func (this *input_event) Keycode() gopi.KeyCode {
+	return this.key_code
+}
+
+func (this *input_event) Scancode() uint32 {
+	return this.scan_code
+}
+
+func (this *input_event) Position() gopi.Point {
+	return this.position
+}
+
+func (this *input_event) Relative() gopi.Point {
+	return this.rel_position
+}
+
+func (this *input_event) Slot() uint {
+	return this.slot
+}
+
+func (this *input_event) Axis() gopi.InputAxis {
+	return this.axis
+}
+
+func (this *input_event) AxisValue() float32 {
+	return this.axis_value
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *input_event) String() string {
+	switch this.event_type {
+	case gopi.INPUT_EVENT_RELPOSITION:
+		return fmt.Sprintf("<sys.mock.InputEvent>{ type=%v device=%v relative=%v position=%v ts=%v }", this.event_type, this.device_type, this.rel_position, this.position, this.timestamp)
+	case gopi.INPUT_EVENT_ABSPOSITION:
+		return fmt.Sprintf("<sys.mock.InputEvent>{ type=%v device=%v position=%v ts=%v }", this.event_type, this.device_type, this.position, this.timestamp)
+	case gopi.INPUT_EVENT_KEYPRESS, gopi.INPUT_EVENT_KEYRELEASE, gopi.INPUT_EVENT_KEYREPEAT:
+		return fmt.Sprintf("<sys.mock.InputEvent>{ type=%v device=%v key_code=%v scan_code=%v ts=%v }", this.event_type, this.device_type, this.key_code, this.scan_code, this.timestamp)
+	case gopi.INPUT_EVENT_TOUCHPRESS, gopi.INPUT_EVENT_TOUCHRELEASE, gopi.INPUT_EVENT_TOUCHPOSITION:
+		return fmt.Sprintf("<sys.mock.InputEvent>{ type=%v device=%v slot=%v position=%v ts=%v }", this.event_type, this.device_type, this.slot, this.position, this.timestamp)
+	case gopi.INPUT_EVENT_AXIS:
+		return fmt.Sprintf("<sys.mock.InputEvent>{ type=%v device=%v axis=%v value=%v ts=%v }", this.event_type, this.device_type, this.axis, this.axis_value, this.timestamp)
+	default:
+		return fmt.Sprintf("<sys.mock.InputEvent>{ type=%v device=%v ts=%v }", this.event_type, this.device_type, this.timestamp)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func deviceType(source gopi.InputDevice) gopi.InputDeviceType {
+	if source == nil {
+		return gopi.INPUT_TYPE_NONE
+	} else {
+		return source.Type()
+	}
+}
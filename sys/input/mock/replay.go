@@ -0,0 +1,176 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package mock
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+	"github.com/djthorpe/gopi/sys/input/record"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Replay is an input manager which re-emits events from a recording
+// made with sys/input/record. The recording is read from Path, or
+// from Reader when it is not nil. When Realtime is true, the events
+// are emitted with the same timing as they were recorded, otherwise
+// they are emitted as fast as possible
+type Replay struct {
+	Path     string
+	Reader   io.Reader
+	Realtime bool
+}
+
+// Replayer is an input manager which emits recorded events
+// when Replay is called
+type Replayer interface {
+	gopi.InputManager
+
+	// Replay emits all recorded events and blocks until all events
+	// have been emitted or the context is cancelled
+	Replay(ctx context.Context) error
+}
+
+type replay struct {
+	*input
+	records  []*record.Record
+	realtime bool
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+// Open the recording, and create a mock device for each recorded
+// device
+func (config Replay) Open(logger gopi.Logger) (gopi.Driver, error) {
+	logger.Debug("sys.mock.Replay.Open{ path=\"%v\" realtime=%v }", config.Path, config.Realtime)
+
+	// Read the records
+	reader := config.Reader
+	if reader == nil {
+		if config.Path == "" {
+			return nil, gopi.ErrBadParameter
+		} else if file, err := os.Open(config.Path); err != nil {
+			return nil, err
+		} else {
+			defer file.Close()
+			reader = file
+		}
+	}
+	records, err := record.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the input manager
+	this := new(replay)
+	if driver, err := (Input{}).Open(logger); err != nil {
+		return nil, err
+	} else {
+		this.input = driver.(*input)
+	}
+	this.records = records
+	this.realtime = config.Realtime
+
+	// Create the devices. Events from devices which cannot be
+	// created are ignored on replay
+	for _, r := range records {
+		if this.deviceForRecord(r) != nil {
+			continue
+		} else if device, err := gopi.Open(Device{Name: r.Name, Type: r.DeviceType, Bus: r.DeviceBus}, logger); err != nil {
+			logger.Warn("sys.mock.Replay.Open: Ignoring device \"%v\": %v", r.Name, err)
+		} else if err := this.input.AddDevice(device.(gopi.InputDevice)); err != nil {
+			this.input.Close()
+			return nil, err
+		}
+	}
+
+	// Success
+	return this, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// REPLAY
+
+func (this *replay) Replay(ctx context.Context) error {
+	this.log.Debug("sys.mock.Replay.Replay{ records=%v }", len(this.records))
+
+	var prev time.Duration
+	for _, r := range this.records {
+		// Wait for the event when replaying in real time
+		if this.realtime && r.Offset > prev {
+			timer := time.NewTimer(r.Offset - prev)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+				break
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+		prev = r.Offset
+
+		// Update device state and emit the event
+		device := this.deviceForRecord(r)
+		if device == nil {
+			continue
+		}
+		if r.EventType == gopi.INPUT_EVENT_ABSPOSITION || r.EventType == gopi.INPUT_EVENT_RELPOSITION {
+			device.SetPosition(r.Position)
+		}
+		evt := &input_event{
+			source:       device,
+			timestamp:    r.Timestamp,
+			device_type:  r.DeviceType,
+			event_type:   r.EventType,
+			device:       r.Device,
+			position:     r.Position,
+			rel_position: r.Relative,
+			key_code:     r.Keycode,
+			scan_code:    r.Scancode,
+			slot:         r.Slot,
+			axis:         r.Axis,
+			axis_value:   r.AxisValue,
+		}
+		device.Emit(evt)
+		this.Emit(evt)
+	}
+
+	// Success
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *replay) String() string {
+	return fmt.Sprintf("<sys.mock.Replay>{ records=%v realtime=%v devices=%v }", len(this.records), this.realtime, this.devices)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *replay) deviceForRecord(r *record.Record) *device {
+	for _, d := range this.devices {
+		if mock_device, ok := d.(*device); ok && mock_device.name == r.Name && mock_device.typ == r.DeviceType && mock_device.bus == r.DeviceBus {
+			return mock_device
+		}
+	}
+	return nil
+}
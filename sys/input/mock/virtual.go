@@ -0,0 +1,120 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package mock
+
+import (
+	"fmt"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Virtual device which emits injected events on the device and
+// on the input manager which created it
+type virtual struct {
+	*device
+	input *input
+	size  gopi.Size
+	start time.Time
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// INPUT INTERFACE
+
+// CreateVirtualDevice creates a mock device, which is added to the
+// managed input devices
+func (this *input) CreateVirtualDevice(name string, device_type gopi.InputDeviceType, size gopi.Size) (gopi.InputVirtualDevice, error) {
+	switch device_type {
+	case gopi.INPUT_TYPE_TOUCHSCREEN, gopi.INPUT_TYPE_JOYSTICK:
+		if size.W <= 0 || size.H <= 0 {
+			return nil, gopi.ErrBadParameter
+		}
+	}
+
+	if driver, err := gopi.Open(Device{Name: name, Type: device_type, Bus: gopi.INPUT_BUS_VIRTUAL}, this.log); err != nil {
+		return nil, err
+	} else {
+		v := &virtual{driver.(*device), this, size, time.Now()}
+		if err := this.AddDevice(v); err != nil {
+			v.Close()
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// INJECT EVENTS
+
+// Inject a key press, release or repeat
+func (this *virtual) InjectKey(key gopi.KeyCode, event_type gopi.InputEventType) error {
+	switch event_type {
+	case gopi.INPUT_EVENT_KEYPRESS, gopi.INPUT_EVENT_KEYRELEASE, gopi.INPUT_EVENT_KEYREPEAT:
+		this.inject(NewKeyEvent(this, time.Since(this.start), event_type, key, 0))
+		return nil
+	default:
+		return gopi.ErrBadParameter
+	}
+}
+
+// Inject relative motion, for mouse devices
+func (this *virtual) InjectRelPosition(rel gopi.Point) error {
+	if this.typ != gopi.INPUT_TYPE_MOUSE {
+		return gopi.ErrNotImplemented
+	}
+	this.SetPosition(gopi.Point{this.position.X + rel.X, this.position.Y + rel.Y})
+	this.inject(NewPositionEvent(this, time.Since(this.start), gopi.INPUT_EVENT_RELPOSITION, this.position, rel))
+	return nil
+}
+
+// Inject absolute position, for touchscreen and joystick devices
+func (this *virtual) InjectAbsPosition(position gopi.Point) error {
+	if this.typ != gopi.INPUT_TYPE_TOUCHSCREEN && this.typ != gopi.INPUT_TYPE_JOYSTICK {
+		return gopi.ErrNotImplemented
+	}
+	this.SetPosition(position)
+	this.inject(NewPositionEvent(this, time.Since(this.start), gopi.INPUT_EVENT_ABSPOSITION, position, gopi.ZeroPoint))
+	return nil
+}
+
+// Inject a multi-touch press, release or position for a slot, for
+// touchscreen devices
+func (this *virtual) InjectTouch(slot uint, event_type gopi.InputEventType, position gopi.Point) error {
+	if this.typ != gopi.INPUT_TYPE_TOUCHSCREEN {
+		return gopi.ErrNotImplemented
+	}
+	switch event_type {
+	case gopi.INPUT_EVENT_TOUCHPRESS, gopi.INPUT_EVENT_TOUCHRELEASE, gopi.INPUT_EVENT_TOUCHPOSITION:
+		this.SetPosition(position)
+		this.inject(NewTouchEvent(this, time.Since(this.start), event_type, slot, position))
+		return nil
+	default:
+		return gopi.ErrBadParameter
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *virtual) String() string {
+	return fmt.Sprintf("<sys.mock.VirtualDevice>{ name=%v type=%v size=%v position=%v }", this.name, this.typ, this.size, this.position)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *virtual) inject(evt gopi.InputEvent) {
+	this.Emit(evt)
+	this.input.Emit(evt)
+}
@@ -0,0 +1,39 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package record
+
+import (
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INIT
+
+func init() {
+	// Register input recorder
+	gopi.RegisterModule(gopi.Module{
+		Name:     "input/record",
+		Type:     gopi.MODULE_TYPE_OTHER,
+		Requires: []string{"input"},
+		Config: func(config *gopi.AppConfig) {
+			config.AppFlags.FlagString("input.record", "", "File for recording input events")
+			config.AppFlags.FlagBool("input.record.append", false, "Append recorded events to end of file")
+		},
+		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
+			path, _ := app.AppFlags.GetString("input.record")
+			append, _ := app.AppFlags.GetBool("input.record.append")
+			return gopi.Open(Recorder{
+				Input:  app.Input,
+				Path:   path,
+				Append: append,
+			}, app.Logger)
+		},
+	})
+}
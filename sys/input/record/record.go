@@ -0,0 +1,134 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+// Record input events to a file, and read them back again for replay
+package record
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Record is a single serialized input event. Offset is the time since
+// the start of the recording, and Timestamp is the timestamp reported
+// by the device
+type Record struct {
+	Offset     time.Duration        `json:"offset"`
+	Timestamp  time.Duration        `json:"ts"`
+	Name       string               `json:"name"`
+	DeviceType gopi.InputDeviceType `json:"device_type"`
+	DeviceBus  gopi.InputDeviceBus  `json:"device_bus"`
+	Device     uint32               `json:"device"`
+	EventType  gopi.InputEventType  `json:"event_type"`
+	Keycode    gopi.KeyCode         `json:"key_code,omitempty"`
+	Scancode   uint32               `json:"scan_code,omitempty"`
+	Position   gopi.Point           `json:"position"`
+	Relative   gopi.Point           `json:"relative"`
+	Slot       uint                 `json:"slot,omitempty"`
+	Axis       gopi.InputAxis       `json:"axis,omitempty"`
+	AxisValue  float32              `json:"axis_value,omitempty"`
+}
+
+// Encoder writes records as newline-delimited JSON
+type Encoder struct {
+	encoder *json.Encoder
+}
+
+// Decoder reads records written by an Encoder
+type Decoder struct {
+	decoder *json.Decoder
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+// NewRecord returns a record for an input event which occurred at
+// offset from the start of the recording
+func NewRecord(evt gopi.InputEvent, offset time.Duration) *Record {
+	record := &Record{
+		Offset:     offset,
+		Timestamp:  evt.Timestamp(),
+		DeviceType: evt.DeviceType(),
+		Device:     evt.Device(),
+		EventType:  evt.EventType(),
+		Keycode:    evt.Keycode(),
+		Scancode:   evt.Scancode(),
+		Position:   evt.Position(),
+		Relative:   evt.Relative(),
+		Slot:       evt.Slot(),
+		Axis:       evt.Axis(),
+		AxisValue:  evt.AxisValue(),
+	}
+	if device, ok := evt.Source().(gopi.InputDevice); ok && device != nil {
+		record.Name = device.Name()
+		record.DeviceBus = device.Bus()
+	}
+	return record
+}
+
+// NewEncoder returns an encoder which writes to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{json.NewEncoder(w)}
+}
+
+// NewDecoder returns a decoder which reads from r
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{json.NewDecoder(r)}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// ENCODE AND DECODE
+
+// Encode writes a single record
+func (this *Encoder) Encode(record *Record) error {
+	if record == nil {
+		return gopi.ErrBadParameter
+	}
+	return this.encoder.Encode(record)
+}
+
+// Decode reads the next record, and returns io.EOF when there
+// are no more records
+func (this *Decoder) Decode() (*Record, error) {
+	record := new(Record)
+	if err := this.decoder.Decode(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ReadAll reads records until the end of the stream
+func ReadAll(r io.Reader) ([]*Record, error) {
+	decoder := NewDecoder(r)
+	records := make([]*Record, 0)
+	for {
+		if record, err := decoder.Decode(); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, err
+		} else {
+			records = append(records, record)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Record) String() string {
+	return fmt.Sprintf("<sys.input.record.Record>{ offset=%v name=\"%v\" device_type=%v event_type=%v key_code=%v position=%v relative=%v slot=%v axis=%v axis_value=%v }", this.Offset, this.Name, this.DeviceType, this.EventType, this.Keycode, this.Position, this.Relative, this.Slot, this.Axis, this.AxisValue)
+}
@@ -0,0 +1,145 @@
+package record_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	mock "github.com/djthorpe/gopi/sys/input/mock"
+	record "github.com/djthorpe/gopi/sys/input/record"
+	logger "github.com/djthorpe/gopi/sys/logger"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// ENCODE AND DECODE
+
+func TestRecord_000(t *testing.T) {
+	buf := new(bytes.Buffer)
+	encoder := record.NewEncoder(buf)
+	if err := encoder.Encode(&record.Record{Name: "keyboard", EventType: gopi.INPUT_EVENT_KEYPRESS, Keycode: gopi.KEYCODE_A}); err != nil {
+		t.Fatal(err)
+	}
+	if err := encoder.Encode(&record.Record{Name: "mouse", EventType: gopi.INPUT_EVENT_ABSPOSITION, Position: gopi.Point{10, 20}}); err != nil {
+		t.Fatal(err)
+	}
+	if records, err := record.ReadAll(buf); err != nil {
+		t.Fatal(err)
+	} else if len(records) != 2 {
+		t.Fatal("Expected two records, got", len(records))
+	} else if records[0].Keycode != gopi.KEYCODE_A {
+		t.Error("Unexpected keycode", records[0])
+	} else if records[1].Position.Equals(gopi.Point{10, 20}) == false {
+		t.Error("Unexpected position", records[1])
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// RECORD AND REPLAY
+
+func TestRecord_001(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+
+	// Record events emitted by a mock input manager
+	buf := new(bytes.Buffer)
+	input := openDriver(t, mock.Input{}, log).(gopi.InputManager)
+	keyboard := openDriver(t, mock.Device{Name: "keyboard", Type: gopi.INPUT_TYPE_KEYBOARD, Bus: gopi.INPUT_BUS_USB}, log).(gopi.InputDevice)
+	recorder := openDriver(t, record.Recorder{Input: input, Writer: buf}, log)
+
+	emitter := input.(interface {
+		Emit(gopi.Event)
+	})
+	emitter.Emit(mock.NewKeyEvent(keyboard, time.Second, gopi.INPUT_EVENT_KEYPRESS, gopi.KEYCODE_A, 30))
+	emitter.Emit(mock.NewKeyEvent(keyboard, 2*time.Second, gopi.INPUT_EVENT_KEYRELEASE, gopi.KEYCODE_A, 30))
+
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	keyboard.Close()
+	input.Close()
+
+	// Replay the events
+	replay := openDriver(t, mock.Replay{Reader: buf}, log).(mock.Replayer)
+	defer replay.Close()
+	if devices, err := replay.OpenDevicesByName("keyboard", gopi.INPUT_TYPE_KEYBOARD, gopi.INPUT_BUS_USB); err != nil {
+		t.Fatal(err)
+	} else if len(devices) != 1 {
+		t.Fatal("Expected one device, got", devices)
+	}
+
+	events := replay.Subscribe()
+	go func() {
+		if err := replay.Replay(context.Background()); err != nil {
+			t.Error(err)
+		}
+	}()
+	for _, event_type := range []gopi.InputEventType{gopi.INPUT_EVENT_KEYPRESS, gopi.INPUT_EVENT_KEYRELEASE} {
+		evt := (<-events).(gopi.InputEvent)
+		if evt.EventType() != event_type {
+			t.Error("Unexpected event type", evt)
+		} else if evt.Keycode() != gopi.KEYCODE_A || evt.Scancode() != 30 {
+			t.Error("Unexpected key", evt)
+		} else if device, ok := evt.Source().(gopi.InputDevice); ok == false || device.Name() != "keyboard" {
+			t.Error("Unexpected source", evt.Source())
+		}
+	}
+}
+
+func TestRecord_002(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+
+	// Record events injected through a virtual device
+	buf := new(bytes.Buffer)
+	input := openDriver(t, mock.Input{}, log).(gopi.InputManager)
+	mouse, err := input.CreateVirtualDevice("mouse", gopi.INPUT_TYPE_MOUSE, gopi.ZeroSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := openDriver(t, record.Recorder{Input: input, Writer: buf}, log)
+	if err := mouse.InjectRelPosition(gopi.Point{5, -5}); err != nil {
+		t.Fatal(err)
+	} else if err := mouse.InjectKey(gopi.KEYCODE_BTNLEFT, gopi.INPUT_EVENT_KEYPRESS); err != nil {
+		t.Fatal(err)
+	} else if err := mouse.InjectAbsPosition(gopi.Point{10, 10}); err != gopi.ErrNotImplemented {
+		t.Error("Expected ErrNotImplemented, got", err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	input.Close()
+
+	if records, err := record.ReadAll(buf); err != nil {
+		t.Fatal(err)
+	} else if len(records) != 2 {
+		t.Fatal("Expected two records, got", len(records))
+	} else if records[0].Relative.Equals(gopi.Point{5, -5}) == false || records[0].DeviceBus != gopi.INPUT_BUS_VIRTUAL {
+		t.Error("Unexpected record", records[0])
+	} else if records[1].Keycode != gopi.KEYCODE_BTNLEFT {
+		t.Error("Unexpected record", records[1])
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func openLogger(t *testing.T) gopi.Logger {
+	if log, err := gopi.Open(logger.Config{}, nil); err != nil {
+		t.Fatal("Unable to create logger driver")
+		return nil
+	} else {
+		return log.(gopi.Logger)
+	}
+}
+
+func openDriver(t *testing.T, config gopi.Config, log gopi.Logger) gopi.Driver {
+	if driver, err := gopi.Open(config, log); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return driver
+	}
+}
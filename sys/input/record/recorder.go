@@ -0,0 +1,150 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package record
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Recorder subscribes to input events from an input manager and
+// writes them to a file, or to Writer when it is not nil
+type Recorder struct {
+	Input  gopi.InputManager
+	Path   string
+	Append bool
+	Writer io.Writer
+}
+
+type recorder struct {
+	log     gopi.Logger
+	input   gopi.InputManager
+	path    string
+	file    *os.File
+	encoder *Encoder
+	events  <-chan gopi.Event
+	start   time.Time
+	count   uint
+	done    chan struct{}
+	lock    sync.Mutex
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+// Open the recorder and start recording events
+func (config Recorder) Open(log gopi.Logger) (gopi.Driver, error) {
+	log.Debug("<sys.input.Recorder.Open>{ path=\"%v\" append=%v }", config.Path, config.Append)
+
+	if config.Input == nil {
+		return nil, gopi.ErrBadParameter
+	}
+
+	this := new(recorder)
+	this.log = log
+	this.input = config.Input
+	this.path = config.Path
+
+	// Open the file for writing or use the writer
+	if config.Writer != nil {
+		this.encoder = NewEncoder(config.Writer)
+	} else if config.Path == "" {
+		return nil, gopi.ErrBadParameter
+	} else {
+		flags := os.O_WRONLY | os.O_CREATE
+		if config.Append {
+			flags |= os.O_APPEND
+		} else {
+			flags |= os.O_TRUNC
+		}
+		if file, err := os.OpenFile(config.Path, flags, 0644); err != nil {
+			return nil, err
+		} else {
+			this.file = file
+			this.encoder = NewEncoder(file)
+		}
+	}
+
+	// Subscribe to events and record in the background
+	this.start = time.Now()
+	this.events = this.input.Subscribe()
+	this.done = make(chan struct{})
+	go this.record()
+
+	// Success
+	return this, nil
+}
+
+// Close stops recording and closes the file
+func (this *recorder) Close() error {
+	this.log.Debug("<sys.input.Recorder.Close>{ path=\"%v\" count=%v }", this.path, this.Count())
+
+	// Unsubscribe closes the channel, which ends the background task
+	this.input.Unsubscribe(this.events)
+	<-this.done
+
+	// Close the file
+	var err error
+	if this.file != nil {
+		err = this.file.Close()
+	}
+
+	// Release resources
+	this.input = nil
+	this.events = nil
+	this.encoder = nil
+	this.file = nil
+
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PROPERTIES
+
+// Count returns the number of events recorded
+func (this *recorder) Count() uint {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.count
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *recorder) String() string {
+	return fmt.Sprintf("<sys.input.Recorder>{ path=\"%v\" count=%v }", this.path, this.Count())
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *recorder) record() {
+	for evt := range this.events {
+		if input_event, ok := evt.(gopi.InputEvent); ok && input_event != nil {
+			this.lock.Lock()
+			if err := this.encoder.Encode(NewRecord(input_event, time.Since(this.start))); err != nil {
+				this.log.Error("<sys.input.Recorder>record: %v", err)
+			} else {
+				this.count++
+			}
+			this.lock.Unlock()
+		}
+	}
+	close(this.done)
+}
@@ -0,0 +1,291 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+// Raise alerts when metrics cross thresholds
+package alert
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+	"github.com/djthorpe/gopi/util/event"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Alerts evaluates rules at an interval scheduled on a timer, and emits
+// events when rules fire and resolve. Rules are only evaluated by
+// calling Evaluate when there is no timer
+type Alerts struct {
+	Timer    gopi.Timer
+	Interval time.Duration
+	Rules    []Rule
+}
+
+// AlertManager is the driver for alerts
+type AlertManager interface {
+	gopi.Driver
+	gopi.Publisher
+
+	// Add a rule, which should have a unique name
+	AddRule(Rule) error
+
+	// Remove a rule by name
+	RemoveRule(string) error
+
+	// Return all rules
+	Rules() []Rule
+
+	// Return the state of a rule by name
+	State(string) State
+
+	// Evaluate all rules at a time
+	Evaluate(time.Time)
+}
+
+type alerts struct {
+	log      gopi.Logger
+	timer    gopi.Timer
+	interval time.Duration
+	events   <-chan gopi.Event
+	pubsub   *event.PubSub
+	lock     sync.Mutex
+	wg       sync.WaitGroup
+	closed   bool
+	rules    []*rule
+}
+
+type rule struct {
+	Rule
+	state State
+	since time.Time
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	DEFAULT_INTERVAL = 10 * time.Second
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+// Open the driver
+func (config Alerts) Open(log gopi.Logger) (gopi.Driver, error) {
+	log.Debug("<sys.metrics.alert.Alerts>Open{ timer=%v interval=%v rules=%v }", config.Timer, config.Interval, config.Rules)
+
+	this := new(alerts)
+	this.log = log
+	this.timer = config.Timer
+	this.interval = config.Interval
+	this.pubsub = event.NewPubSub(0)
+	this.rules = make([]*rule, 0, len(config.Rules))
+	if this.interval == 0 {
+		this.interval = DEFAULT_INTERVAL
+	} else if this.interval < 0 {
+		return nil, gopi.ErrBadParameter
+	}
+	for _, r := range config.Rules {
+		if err := this.AddRule(r); err != nil {
+			return nil, err
+		}
+	}
+
+	// Evaluate rules on each timer event
+	if this.timer != nil {
+		this.events = this.timer.Subscribe()
+		this.wg.Add(1)
+		go this.run()
+		this.timer.NewInterval(this.interval, this, false)
+	}
+
+	// Success
+	return this, nil
+}
+
+// Close the driver
+func (this *alerts) Close() error {
+	this.log.Debug("<sys.metrics.alert.Alerts>Close{}")
+
+	this.lock.Lock()
+	this.closed = true
+	this.lock.Unlock()
+
+	// Stop receiving timer events, and close subscriber channels so that
+	// the background task is not blocked emitting alerts
+	if this.events != nil {
+		this.timer.Unsubscribe(this.events)
+	}
+	this.pubsub.Close()
+
+	// Cancel the interval and wait for the background task to end
+	if this.events != nil {
+		this.timer.Cancel(this)
+		this.wg.Wait()
+	}
+
+	// Release resources
+	this.rules = nil
+
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// RULES
+
+func (this *alerts) AddRule(r Rule) error {
+	this.log.Debug2("<sys.metrics.alert.Alerts>AddRule{ rule=%v }", r)
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if r.Name == "" || r.Source == nil || r.Hysteresis < 0 || r.For < 0 {
+		return gopi.ErrBadParameter
+	} else if r.Condition != CONDITION_ABOVE && r.Condition != CONDITION_BELOW {
+		return gopi.ErrBadParameter
+	} else if this.rule(r.Name) != nil {
+		return gopi.ErrBadParameter
+	} else {
+		this.rules = append(this.rules, &rule{Rule: r})
+		return nil
+	}
+}
+
+func (this *alerts) RemoveRule(name string) error {
+	this.log.Debug2("<sys.metrics.alert.Alerts>RemoveRule{ name='%v' }", name)
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for i, r := range this.rules {
+		if r.Name == name {
+			this.rules = append(this.rules[:i], this.rules[i+1:]...)
+			return nil
+		}
+	}
+	return gopi.ErrNotFound
+}
+
+func (this *alerts) Rules() []Rule {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	rules := make([]Rule, len(this.rules))
+	for i, r := range this.rules {
+		rules[i] = r.Rule
+	}
+	return rules
+}
+
+func (this *alerts) State(name string) State {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if r := this.rule(name); r == nil {
+		return STATE_OK
+	} else {
+		return r.state
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// EVALUATE
+
+// Evaluate all rules at a time, and emit events for rules which
+// fire or resolve
+func (this *alerts) Evaluate(ts time.Time) {
+	this.lock.Lock()
+	if this.closed {
+		this.lock.Unlock()
+		return
+	}
+	events := make([]gopi.Event, 0)
+	for _, r := range this.rules {
+		if value, err := r.Source(); err != nil {
+			this.log.Debug2("<sys.metrics.alert.Alerts>Evaluate: %v: %v", r.Name, err)
+		} else if evt := this.evaluate(r, ts, value); evt != nil {
+			events = append(events, evt)
+		}
+	}
+	this.lock.Unlock()
+
+	// Emit events after releasing the lock
+	for _, evt := range events {
+		this.log.Debug("<sys.metrics.alert.Alerts>Emit{ %v }", evt)
+		this.pubsub.Emit(evt)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLISHER INTERFACE
+
+func (this *alerts) Subscribe() <-chan gopi.Event {
+	return this.pubsub.Subscribe()
+}
+
+func (this *alerts) Unsubscribe(subscriber <-chan gopi.Event) {
+	this.pubsub.Unsubscribe(subscriber)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *alerts) String() string {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return fmt.Sprintf("<sys.metrics.alert.Alerts>{ interval=%v rules=%v }", this.interval, len(this.rules))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *alerts) run() {
+	defer this.wg.Done()
+	for evt := range this.events {
+		// Only evaluate on events for the interval scheduled by this driver
+		if timer_event, ok := evt.(gopi.TimerEvent); ok && timer_event.UserInfo() == this {
+			this.Evaluate(timer_event.Timestamp())
+		}
+	}
+}
+
+// evaluate a rule with a value, and return an event when the rule
+// fires or resolves
+func (this *alerts) evaluate(r *rule, ts time.Time, value float64) gopi.Event {
+	switch r.state {
+	case STATE_OK, STATE_PENDING:
+		if r.fires(value) == false {
+			r.state = STATE_OK
+			return nil
+		}
+		if r.state == STATE_OK {
+			r.state, r.since = STATE_PENDING, ts
+		}
+		if ts.Sub(r.since) >= r.For {
+			r.state = STATE_FIRING
+			return &alert_event{this, r.Name, STATE_FIRING, value, r.Threshold, ts}
+		}
+	case STATE_FIRING:
+		if r.resolves(value) {
+			r.state = STATE_OK
+			return &alert_event{this, r.Name, STATE_RESOLVED, value, r.Threshold, ts}
+		}
+	}
+	return nil
+}
+
+func (this *alerts) rule(name string) *rule {
+	for _, r := range this.rules {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
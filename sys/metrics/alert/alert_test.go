@@ -0,0 +1,315 @@
+package alert_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	mock "github.com/djthorpe/gopi/sys/hw/mock"
+	logger "github.com/djthorpe/gopi/sys/logger"
+	alert "github.com/djthorpe/gopi/sys/metrics/alert"
+	timer "github.com/djthorpe/gopi/sys/timer"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// RULES
+
+func TestAlert_000(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	alerts := openDriver(t, alert.Alerts{}, log).(alert.AlertManager)
+	defer alerts.Close()
+
+	source := func() (float64, error) { return 0, nil }
+	if err := alerts.AddRule(alert.Rule{Name: "test", Source: source}); err != nil {
+		t.Error(err)
+	}
+	if err := alerts.AddRule(alert.Rule{Name: "test", Source: source}); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter for duplicate rule, got", err)
+	}
+	if err := alerts.AddRule(alert.Rule{Name: "nosource"}); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter for rule without source, got", err)
+	}
+	if rules := alerts.Rules(); len(rules) != 1 || rules[0].Name != "test" {
+		t.Error("Unexpected rules", rules)
+	}
+	if err := alerts.RemoveRule("other"); err != gopi.ErrNotFound {
+		t.Error("Expected ErrNotFound, got", err)
+	}
+	if err := alerts.RemoveRule("test"); err != nil {
+		t.Error(err)
+	}
+	if rules := alerts.Rules(); len(rules) != 0 {
+		t.Error("Unexpected rules", rules)
+	}
+}
+
+func TestAlert_001(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	metrics := openDriver(t, mock.Metrics{}, log).(gopi.Metrics)
+	defer metrics.Close()
+
+	load1, load5, load15 := metrics.LoadAverage()
+	for minutes, expected := range map[uint]float64{1: load1, 5: load5, 15: load15} {
+		if value, err := alert.LoadAverage(metrics, minutes)(); err != nil {
+			t.Error(err)
+		} else if value != expected {
+			t.Error("Unexpected load average", minutes, value)
+		}
+	}
+	if _, err := alert.LoadAverage(metrics, 2)(); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	if gauge, err := metrics.NewGauge(gopi.METRIC_RATE_MINUTE, "gauge", gopi.MetricLabel{Name: "a", Value: "b"}); err != nil {
+		t.Fatal(err)
+	} else {
+		gauge.Set(42)
+	}
+	if value, err := alert.MetricValue(metrics, "gauge", gopi.MetricLabel{Name: "a", Value: "b"})(); err != nil {
+		t.Error(err)
+	} else if value != 42 {
+		t.Error("Unexpected value", value)
+	}
+	if _, err := alert.MetricValue(metrics, "gauge", gopi.MetricLabel{Name: "a", Value: "c"})(); err != gopi.ErrNotFound {
+		t.Error("Expected ErrNotFound, got", err)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// EVALUATE
+
+func TestAlert_002(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	alerts := openDriver(t, alert.Alerts{}, log).(alert.AlertManager)
+	defer alerts.Close()
+
+	value := float64(0)
+	if err := alerts.AddRule(alert.Rule{
+		Name:       "load",
+		Source:     func() (float64, error) { return value, nil },
+		Condition:  alert.CONDITION_ABOVE,
+		Threshold:  4,
+		Hysteresis: 1,
+		For:        5 * time.Minute,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	events := collect(alerts)
+
+	// Fires after the condition holds for five minutes, and resolves
+	// when the value drops below the threshold less the hysteresis
+	ts := time.Now()
+	steps := []struct {
+		value float64
+		after time.Duration
+		state alert.State
+	}{
+		{3, 0, alert.STATE_OK},
+		{5, time.Minute, alert.STATE_PENDING},
+		{5, 5 * time.Minute, alert.STATE_PENDING},
+		{5, 6 * time.Minute, alert.STATE_FIRING},
+		{3.5, 7 * time.Minute, alert.STATE_FIRING},
+		{5, 8 * time.Minute, alert.STATE_FIRING},
+		{3, 9 * time.Minute, alert.STATE_OK},
+		{5, 10 * time.Minute, alert.STATE_PENDING},
+		{3.5, 11 * time.Minute, alert.STATE_OK},
+	}
+	for i, step := range steps {
+		value = step.value
+		alerts.Evaluate(ts.Add(step.after))
+		if state := alerts.State("load"); state != step.state {
+			t.Errorf("Step %v: expected %v, got %v", i, step.state, state)
+		}
+	}
+
+	alerts.Close()
+	received := <-events
+	if len(received) != 2 {
+		t.Fatal("Expected two events, got", received)
+	}
+	if received[0].Rule() != "load" || received[0].State() != alert.STATE_FIRING || received[0].Value() != 5 {
+		t.Error("Unexpected event", received[0])
+	}
+	if received[1].State() != alert.STATE_RESOLVED || received[1].Value() != 3 || received[1].Threshold() != 4 {
+		t.Error("Unexpected event", received[1])
+	}
+}
+
+func TestAlert_003(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	alerts := openDriver(t, alert.Alerts{}, log).(alert.AlertManager)
+	defer alerts.Close()
+
+	// Counter rate drops to zero, and fires immediately
+	value := float64(10)
+	if err := alerts.AddRule(alert.Rule{
+		Name:       "rate",
+		Source:     func() (float64, error) { return value, nil },
+		Condition:  alert.CONDITION_BELOW,
+		Threshold:  0.5,
+		Hysteresis: 0.5,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	ts := time.Now()
+	alerts.Evaluate(ts)
+	if state := alerts.State("rate"); state != alert.STATE_OK {
+		t.Error("Unexpected state", state)
+	}
+	value = 0
+	alerts.Evaluate(ts.Add(time.Second))
+	if state := alerts.State("rate"); state != alert.STATE_FIRING {
+		t.Error("Unexpected state", state)
+	}
+	value = 0.8
+	alerts.Evaluate(ts.Add(2 * time.Second))
+	if state := alerts.State("rate"); state != alert.STATE_FIRING {
+		t.Error("Unexpected state", state)
+	}
+	value = 1
+	alerts.Evaluate(ts.Add(3 * time.Second))
+	if state := alerts.State("rate"); state != alert.STATE_OK {
+		t.Error("Unexpected state", state)
+	}
+}
+
+func TestAlert_004(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	timer := openDriver(t, timer.Timer{}, log).(gopi.Timer)
+	defer timer.Close()
+	alerts := openDriver(t, alert.Alerts{
+		Timer:    timer,
+		Interval: 50 * time.Millisecond,
+		Rules: []alert.Rule{
+			alert.Rule{
+				Name:      "always",
+				Source:    func() (float64, error) { return 1, nil },
+				Condition: alert.CONDITION_ABOVE,
+			},
+		},
+	}, log).(alert.AlertManager)
+
+	// Rule is evaluated on the timer
+	events := alerts.Subscribe()
+	select {
+	case evt := <-events:
+		if evt.(alert.Event).State() != alert.STATE_FIRING {
+			t.Error("Unexpected event", evt)
+		}
+	case <-time.After(time.Second):
+		t.Error("Timeout waiting for event")
+	}
+	alerts.Unsubscribe(events)
+	if err := alerts.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAlert_005(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	timer := openDriver(t, timer.Timer{}, log).(gopi.Timer)
+	defer timer.Close()
+
+	// Close while the timer is emitting, which cancels the interval
+	for i := 0; i < 10; i++ {
+		source, evaluated := evaluatedSource(0)
+		alerts := openDriver(t, alert.Alerts{
+			Timer:    timer,
+			Interval: time.Millisecond,
+			Rules:    []alert.Rule{{Name: "test", Source: source, Condition: alert.CONDITION_ABOVE, Threshold: 1}},
+		}, log).(alert.AlertManager)
+		<-evaluated
+		if err := alerts.Close(); err != nil {
+			t.Error(err)
+		}
+	}
+	if timer_str := fmt.Sprint(timer); timer_str != "<sys.timer>{ timers=map[] }" {
+		t.Error("Expected intervals to be cancelled:", timer_str)
+	}
+}
+
+func TestAlert_006(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	timer := openDriver(t, timer.Timer{}, log).(gopi.Timer)
+	defer timer.Close()
+
+	// Close when a subscriber has stopped receiving alerts
+	source, evaluated := evaluatedSource(2)
+	alerts := openDriver(t, alert.Alerts{
+		Timer:    timer,
+		Interval: time.Millisecond,
+		Rules:    []alert.Rule{{Name: "test", Source: source, Condition: alert.CONDITION_ABOVE, Threshold: 1}},
+	}, log).(alert.AlertManager)
+	events := alerts.Subscribe()
+	<-evaluated
+	closed := make(chan error)
+	go func() {
+		closed <- alerts.Close()
+	}()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout closing alerts")
+	}
+	for range events {
+		// Drain until the channel is closed
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN
+
+// evaluatedSource returns a source which returns a value, and a channel
+// which receives a value when the source is first evaluated
+func evaluatedSource(value float64) (alert.Source, <-chan struct{}) {
+	evaluated := make(chan struct{})
+	var once sync.Once
+	return func() (float64, error) {
+		once.Do(func() { close(evaluated) })
+		return value, nil
+	}, evaluated
+}
+
+// collect returns events emitted until the publisher is closed
+func collect(publisher gopi.Publisher) <-chan []alert.Event {
+	events := publisher.Subscribe()
+	result := make(chan []alert.Event, 1)
+	go func() {
+		received := make([]alert.Event, 0)
+		for evt := range events {
+			received = append(received, evt.(alert.Event))
+		}
+		result <- received
+	}()
+	return result
+}
+
+func openLogger(t *testing.T) gopi.Logger {
+	if log, err := gopi.Open(logger.Config{}, nil); err != nil {
+		t.Fatal("Unable to create logger driver")
+		return nil
+	} else {
+		return log.(gopi.Logger)
+	}
+}
+
+func openDriver(t *testing.T, config gopi.Config, log gopi.Logger) gopi.Driver {
+	if driver, err := gopi.Open(config, log); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return driver
+	}
+}
@@ -0,0 +1,86 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package alert
+
+import (
+	"fmt"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Event is emitted when a rule fires or resolves
+type Event interface {
+	gopi.Event
+
+	// Name of the rule
+	Rule() string
+
+	// STATE_FIRING or STATE_RESOLVED
+	State() State
+
+	// Value which caused the change and the rule threshold
+	Value() float64
+	Threshold() float64
+
+	// Time of the change
+	Timestamp() time.Time
+}
+
+type alert_event struct {
+	source    gopi.Driver
+	rule      string
+	state     State
+	value     float64
+	threshold float64
+	timestamp time.Time
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// alert.Event INTERFACE
+
+func (this *alert_event) Name() string {
+	return "AlertEvent"
+}
+
+func (this *alert_event) Source() gopi.Driver {
+	return this.source
+}
+
+func (this *alert_event) Rule() string {
+	return this.rule
+}
+
+func (this *alert_event) State() State {
+	return this.state
+}
+
+func (this *alert_event) Value() float64 {
+	return this.value
+}
+
+func (this *alert_event) Threshold() float64 {
+	return this.threshold
+}
+
+func (this *alert_event) Timestamp() time.Time {
+	return this.timestamp
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *alert_event) String() string {
+	return fmt.Sprintf("<sys.metrics.alert.Event>{ rule='%v' state=%v value=%v threshold=%v ts=%v }", this.rule, this.state, this.value, this.threshold, this.timestamp)
+}
@@ -0,0 +1,72 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package alert
+
+import (
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INIT
+
+func init() {
+	// Register alerts, with rules for load average and core
+	// temperature which are enabled by flags
+	gopi.RegisterModule(gopi.Module{
+		Name:     "metrics/alert",
+		Type:     gopi.MODULE_TYPE_OTHER,
+		Requires: []string{"metrics", "timer"},
+		Config: func(config *gopi.AppConfig) {
+			config.AppFlags.FlagDuration("alert.interval", DEFAULT_INTERVAL, "Interval between evaluating alert rules")
+			config.AppFlags.FlagFloat64("alert.load", 0, "Alert when the 5 minute load average is above this value")
+			config.AppFlags.FlagFloat64("alert.temperature", 0, "Alert when the core temperature in celcius is above this value")
+			config.AppFlags.FlagDuration("alert.for", 5*time.Minute, "Duration a condition holds before alerting")
+		},
+		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
+			interval, _ := app.AppFlags.GetDuration("alert.interval")
+			load, _ := app.AppFlags.GetFloat64("alert.load")
+			temperature, _ := app.AppFlags.GetFloat64("alert.temperature")
+			duration, _ := app.AppFlags.GetDuration("alert.for")
+			metrics, ok := app.ModuleInstance("metrics").(gopi.Metrics)
+			if ok == false {
+				return nil, gopi.ErrBadParameter
+			}
+			rules := make([]Rule, 0, 2)
+			if load > 0 {
+				rules = append(rules, Rule{
+					Name:       "load_average",
+					Source:     LoadAverage(metrics, 5),
+					Condition:  CONDITION_ABOVE,
+					Threshold:  load,
+					Hysteresis: load * 0.1,
+					For:        duration,
+				})
+			}
+			if temperature > 0 {
+				rules = append(rules, Rule{
+					Name:       "core_temperature",
+					Source:     CoreTemperatureCelcius(app.Hardware),
+					Condition:  CONDITION_ABOVE,
+					Threshold:  temperature,
+					Hysteresis: 5,
+					For:        duration,
+				})
+			}
+			return gopi.Open(Alerts{
+				Timer:    app.Timer,
+				Interval: interval,
+				Rules:    rules,
+			}, app.Logger)
+		},
+	})
+}
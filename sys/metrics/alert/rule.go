@@ -0,0 +1,215 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package alert
+
+import (
+	"fmt"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Rule fires when a value crosses a threshold for a duration, and
+// resolves when the value crosses back beyond the hysteresis. For
+// example, a rule which fires when a counter rate drops to zero is
+// CONDITION_BELOW with a threshold of 0.5 and hysteresis of 0.5
+type Rule struct {
+	// Unique name of the rule
+	Name string
+
+	// Source of values
+	Source Source
+
+	// Condition and threshold for firing
+	Condition Condition
+	Threshold float64
+
+	// Amount the value must cross back beyond the threshold to resolve
+	Hysteresis float64
+
+	// Duration the condition must hold before firing, or zero to fire
+	// as soon as the condition holds
+	For time.Duration
+}
+
+// Source returns the current value for a rule
+type Source func() (float64, error)
+
+// Condition for a rule to fire
+type Condition uint
+
+// State of a rule
+type State uint
+
+// CoreTemperature is implemented by hardware which can report
+// the core temperature
+type CoreTemperature interface {
+	GetCoreTemperatureCelcius() (float64, error)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	CONDITION_ABOVE Condition = iota // Fires when value > threshold
+	CONDITION_BELOW                  // Fires when value < threshold
+)
+
+const (
+	STATE_OK       State = iota // Condition does not hold
+	STATE_PENDING               // Condition holds but not yet for the duration
+	STATE_FIRING                // Rule has fired and not resolved
+	STATE_RESOLVED              // Rule has resolved, which is only used in events
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// SOURCES
+
+// LoadAverage returns the 1, 5 or 15 minute load average
+func LoadAverage(metrics gopi.Metrics, minutes uint) Source {
+	return func() (float64, error) {
+		load1, load5, load15 := metrics.LoadAverage()
+		switch minutes {
+		case 1:
+			return load1, nil
+		case 5:
+			return load5, nil
+		case 15:
+			return load15, nil
+		default:
+			return 0, gopi.ErrBadParameter
+		}
+	}
+}
+
+// CoreTemperatureCelcius returns the core temperature for hardware
+// which implements CoreTemperature, such as the Raspberry Pi
+func CoreTemperatureCelcius(hardware gopi.Hardware) Source {
+	return func() (float64, error) {
+		if hw, ok := hardware.(CoreTemperature); ok == false || hw == nil {
+			return 0, gopi.ErrNotImplemented
+		} else {
+			return hw.GetCoreTemperatureCelcius()
+		}
+	}
+}
+
+// MetricMean returns the mean of a custom metric per rate interval,
+// which is zero when a counter has not been incremented
+func MetricMean(metrics gopi.Metrics, name string, labels ...gopi.MetricLabel) Source {
+	return func() (float64, error) {
+		if metric := findMetric(metrics, name, labels); metric == nil {
+			return 0, gopi.ErrNotFound
+		} else {
+			return metric.Mean, nil
+		}
+	}
+}
+
+// MetricValue returns the current value of a custom gauge
+func MetricValue(metrics gopi.Metrics, name string, labels ...gopi.MetricLabel) Source {
+	return func() (float64, error) {
+		if metric := findMetric(metrics, name, labels); metric == nil {
+			return 0, gopi.ErrNotFound
+		} else {
+			return metric.Value, nil
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONDITIONS
+
+// fires returns true when the value meets the firing condition
+func (this *Rule) fires(value float64) bool {
+	switch this.Condition {
+	case CONDITION_ABOVE:
+		return value > this.Threshold
+	case CONDITION_BELOW:
+		return value < this.Threshold
+	default:
+		return false
+	}
+}
+
+// resolves returns true when the value has crossed back
+// beyond the hysteresis
+func (this *Rule) resolves(value float64) bool {
+	switch this.Condition {
+	case CONDITION_ABOVE:
+		return value <= this.Threshold-this.Hysteresis
+	case CONDITION_BELOW:
+		return value >= this.Threshold+this.Hysteresis
+	default:
+		return true
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this Rule) String() string {
+	return fmt.Sprintf("<sys.metrics.alert.Rule>{ name='%v' condition=%v threshold=%v hysteresis=%v for=%v }", this.Name, this.Condition, this.Threshold, this.Hysteresis, this.For)
+}
+
+func (c Condition) String() string {
+	switch c {
+	case CONDITION_ABOVE:
+		return "CONDITION_ABOVE"
+	case CONDITION_BELOW:
+		return "CONDITION_BELOW"
+	default:
+		return "[?? Invalid Condition value]"
+	}
+}
+
+func (s State) String() string {
+	switch s {
+	case STATE_OK:
+		return "STATE_OK"
+	case STATE_PENDING:
+		return "STATE_PENDING"
+	case STATE_FIRING:
+		return "STATE_FIRING"
+	case STATE_RESOLVED:
+		return "STATE_RESOLVED"
+	default:
+		return "[?? Invalid State value]"
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func findMetric(metrics gopi.Metrics, name string, labels []gopi.MetricLabel) *gopi.Metric {
+FOR_LOOP:
+	for _, metric := range metrics.Metrics(gopi.METRIC_TYPE_NONE) {
+		if metric.Name != name {
+			continue
+		}
+		for _, label := range labels {
+			found := false
+			for _, other := range metric.Labels {
+				if label == other {
+					found = true
+				}
+			}
+			if found == false {
+				continue FOR_LOOP
+			}
+		}
+		return metric
+	}
+	return nil
+}
@@ -0,0 +1,362 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+// Expose metrics in Prometheus text and OpenMetrics formats
+package prometheus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Format of the exposition
+type Format uint
+
+// Family is a set of samples with the same name
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Sample is a single value with labels. The suffix is appended to the
+// family name, for histogram buckets, sums and counts
+type Sample struct {
+	Suffix string
+	Labels []Label
+	Value  float64
+}
+
+// Label is a name and value pair
+type Label struct {
+	Name  string
+	Value string
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	FORMAT_TEXT Format = iota
+	FORMAT_OPENMETRICS
+)
+
+const (
+	CONTENT_TYPE_TEXT        = "text/plain; version=0.0.4; charset=utf-8"
+	CONTENT_TYPE_OPENMETRICS = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+const (
+	// Prefix for all metric names
+	METRIC_PREFIX = "gopi_"
+
+	// Family types
+	TYPE_GAUGE     = "gauge"
+	TYPE_COUNTER   = "counter"
+	TYPE_HISTOGRAM = "histogram"
+	TYPE_SUMMARY   = "summary"
+
+	// Suffix for counter samples
+	COUNTER_SUFFIX = "_total"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// COLLECT
+
+// Collect returns the families for host metrics and custom metrics,
+// ordered by name
+func Collect(metrics gopi.Metrics) []*Family {
+	families := make(map[string]*Family)
+
+	// Host metrics
+	add(families, "host_uptime_seconds", "Host uptime", TYPE_GAUGE, Sample{Value: metrics.UptimeHost().Seconds()})
+	add(families, "app_uptime_seconds", "Application uptime", TYPE_GAUGE, Sample{Value: metrics.UptimeApp().Seconds()})
+	load1, load5, load15 := metrics.LoadAverage()
+	add(families, "load_average", "Load average", TYPE_GAUGE,
+		Sample{Labels: []Label{{"period", "1m"}}, Value: load1},
+		Sample{Labels: []Label{{"period", "5m"}}, Value: load5},
+		Sample{Labels: []Label{{"period", "15m"}}, Value: load15},
+	)
+
+	// Processor, memory, filesystem, network and thermal metrics
+	if host, ok := metrics.(gopi.HostMetrics); ok {
+		collectHost(families, host)
+	}
+
+	// Custom metrics, where the totals, means and summaries are over
+	// the rate window
+	for _, metric := range metrics.Metrics(gopi.METRIC_TYPE_NONE) {
+		labels := []Label{{"type", typeLabel(metric.Type)}, {"rate", rateLabel(metric.Rate)}}
+		for _, label := range metric.Labels {
+			labels = append(labels, Label{sanitizeLabel(label.Name), label.Value})
+		}
+		name := SanitizeName(metric.Name)
+		switch metric.Type {
+		case gopi.METRIC_TYPE_GAUGE:
+			add(families, name, "Value of "+metric.Name, TYPE_GAUGE, Sample{Labels: labels, Value: metric.Value})
+			addSummary(families, name, metric, labels)
+		case gopi.METRIC_TYPE_HISTOGRAM:
+			samples := make([]Sample, 0, len(metric.Buckets)+2)
+			for _, bucket := range metric.Buckets {
+				samples = append(samples, Sample{"_bucket", withLabel(labels, "le", formatValue(bucket.UpperBound)), float64(bucket.Count)})
+			}
+			samples = append(samples, Sample{"_sum", labels, metric.Sum}, Sample{"_count", labels, float64(metric.Count)})
+			add(families, name, "Observations of "+metric.Name, TYPE_HISTOGRAM, samples...)
+			addSummary(families, name, metric, labels)
+		default:
+			add(families, name, "Total of "+metric.Name+" over the rate window", TYPE_GAUGE, Sample{Labels: labels, Value: float64(metric.Total)})
+			add(families, name+"_mean", "Mean of "+metric.Name+" per rate interval", TYPE_GAUGE, Sample{Labels: labels, Value: metric.Mean})
+		}
+	}
+
+	// Order by name
+	result := make([]*Family, 0, len(families))
+	for _, family := range families {
+		result = append(result, family)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// WRITE
+
+// Write the host metrics and custom metrics
+func Write(w io.Writer, metrics gopi.Metrics, format Format) error {
+	return WriteFamilies(w, Collect(metrics), format)
+}
+
+// WriteFamilies writes families of samples
+func WriteFamilies(w io.Writer, families []*Family, format Format) error {
+	buf := bufio.NewWriter(w)
+	for _, family := range families {
+		name := family.metadataName(format)
+		fmt.Fprintf(buf, "# HELP %v %v\n", name, escape(family.Help, false))
+		fmt.Fprintf(buf, "# TYPE %v %v\n", name, family.Type)
+		for _, sample := range family.Samples {
+			buf.WriteString(family.Name + sample.Suffix)
+			if len(sample.Labels) > 0 {
+				labels := make([]string, len(sample.Labels))
+				for i, label := range sample.Labels {
+					labels[i] = label.Name + "=\"" + escape(label.Value, true) + "\""
+				}
+				buf.WriteString("{" + strings.Join(labels, ",") + "}")
+			}
+			buf.WriteString(" " + formatValue(sample.Value) + "\n")
+		}
+	}
+	if format == FORMAT_OPENMETRICS {
+		buf.WriteString("# EOF\n")
+	}
+	return buf.Flush()
+}
+
+// ContentType returns the content type for a format
+func (f Format) ContentType() string {
+	switch f {
+	case FORMAT_OPENMETRICS:
+		return CONTENT_TYPE_OPENMETRICS
+	default:
+		return CONTENT_TYPE_TEXT
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// NAMES
+
+// metadataName returns the name used in HELP and TYPE lines. In the
+// text format the name of a counter is the same as its samples, which
+// end in _total, whereas in OpenMetrics the suffix is not included
+func (family *Family) metadataName(format Format) string {
+	if family.Type == TYPE_COUNTER && format == FORMAT_TEXT && strings.HasSuffix(family.Name, COUNTER_SUFFIX) == false {
+		return family.Name + COUNTER_SUFFIX
+	} else {
+		return family.Name
+	}
+}
+
+// SanitizeName returns a metric name with the prefix, where characters
+// which are not allowed in metric names are replaced with underscores
+func SanitizeName(name string) string {
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r == ':':
+			continue
+		case r >= '0' && r <= '9':
+			continue
+		default:
+			runes[i] = '_'
+		}
+	}
+	return METRIC_PREFIX + string(runes)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (f Format) String() string {
+	switch f {
+	case FORMAT_TEXT:
+		return "FORMAT_TEXT"
+	case FORMAT_OPENMETRICS:
+		return "FORMAT_OPENMETRICS"
+	default:
+		return "[?? Invalid Format value]"
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// add samples to a family, creating the family if it doesn't exist
+func add(families map[string]*Family, name, help, family_type string, samples ...Sample) {
+	if strings.HasPrefix(name, METRIC_PREFIX) == false {
+		name = METRIC_PREFIX + name
+	}
+	if family, exists := families[name]; exists {
+		family.Samples = append(family.Samples, samples...)
+	} else {
+		families[name] = &Family{name, help, family_type, samples}
+	}
+}
+
+// collectHost adds families for host metrics, ignoring any which
+// cannot be read
+func collectHost(families map[string]*Family, host gopi.HostMetrics) {
+	if cpus, err := host.CPU(); err == nil {
+		for _, cpu := range cpus {
+			for _, mode := range []struct {
+				name  string
+				value float64
+			}{
+				{"user", cpu.User}, {"nice", cpu.Nice}, {"system", cpu.System}, {"idle", cpu.Idle},
+				{"iowait", cpu.IOWait}, {"irq", cpu.IRQ}, {"steal", cpu.Steal},
+			} {
+				add(families, "cpu_mode_ratio", "Fraction of processor time in each mode", TYPE_GAUGE, Sample{Labels: []Label{{"cpu", cpu.Name}, {"mode", mode.name}}, Value: mode.value})
+			}
+			add(families, "cpu_usage_ratio", "Fraction of processor time not idle", TYPE_GAUGE, Sample{Labels: []Label{{"cpu", cpu.Name}}, Value: cpu.Usage})
+		}
+	}
+	if memory, err := host.Memory(); err == nil {
+		add(families, "memory_bytes", "Memory usage", TYPE_GAUGE,
+			Sample{Labels: []Label{{"type", "total"}}, Value: float64(memory.Total)},
+			Sample{Labels: []Label{{"type", "free"}}, Value: float64(memory.Free)},
+			Sample{Labels: []Label{{"type", "available"}}, Value: float64(memory.Available)},
+			Sample{Labels: []Label{{"type", "buffers"}}, Value: float64(memory.Buffers)},
+			Sample{Labels: []Label{{"type", "cached"}}, Value: float64(memory.Cached)},
+		)
+		add(families, "swap_bytes", "Swap usage", TYPE_GAUGE,
+			Sample{Labels: []Label{{"type", "total"}}, Value: float64(memory.SwapTotal)},
+			Sample{Labels: []Label{{"type", "free"}}, Value: float64(memory.SwapFree)},
+		)
+	}
+	if filesystems, err := host.Filesystems(); err == nil {
+		for _, fs := range filesystems {
+			labels := []Label{{"device", fs.Device}, {"mountpoint", fs.Path}, {"fstype", fs.Type}}
+			add(families, "filesystem_size_bytes", "Filesystem size", TYPE_GAUGE, Sample{Labels: labels, Value: float64(fs.Total)})
+			add(families, "filesystem_free_bytes", "Filesystem free space", TYPE_GAUGE, Sample{Labels: labels, Value: float64(fs.Free)})
+			add(families, "filesystem_avail_bytes", "Filesystem space available to users", TYPE_GAUGE, Sample{Labels: labels, Value: float64(fs.Available)})
+		}
+	}
+	if interfaces, err := host.Network(); err == nil {
+		for _, iface := range interfaces {
+			labels := []Label{{"interface", iface.Name}}
+			add(families, "network_receive_bytes", "Bytes received", TYPE_COUNTER, Sample{COUNTER_SUFFIX, labels, float64(iface.RxBytes)})
+			add(families, "network_transmit_bytes", "Bytes transmitted", TYPE_COUNTER, Sample{COUNTER_SUFFIX, labels, float64(iface.TxBytes)})
+			add(families, "network_receive_errors", "Receive errors", TYPE_COUNTER, Sample{COUNTER_SUFFIX, labels, float64(iface.RxErrors)})
+			add(families, "network_transmit_errors", "Transmit errors", TYPE_COUNTER, Sample{COUNTER_SUFFIX, labels, float64(iface.TxErrors)})
+			add(families, "network_receive_bytes_per_second", "Bytes received per second", TYPE_GAUGE, Sample{Labels: labels, Value: iface.RxRate})
+			add(families, "network_transmit_bytes_per_second", "Bytes transmitted per second", TYPE_GAUGE, Sample{Labels: labels, Value: iface.TxRate})
+		}
+	}
+	if zones, err := host.Thermal(); err == nil {
+		for _, zone := range zones {
+			add(families, "thermal_zone_celsius", "Thermal zone temperature", TYPE_GAUGE, Sample{Labels: []Label{{"zone", zone.Name}, {"type", zone.Type}}, Value: zone.Celcius})
+		}
+	}
+	if cpus, err := host.Frequency(); err == nil {
+		for _, cpu := range cpus {
+			labels := []Label{{"cpu", cpu.Name}}
+			add(families, "cpu_frequency_hertz", "Processor frequency", TYPE_GAUGE, Sample{Labels: labels, Value: float64(cpu.Current)})
+			add(families, "cpu_frequency_min_hertz", "Minimum processor frequency", TYPE_GAUGE, Sample{Labels: labels, Value: float64(cpu.Minimum)})
+			add(families, "cpu_frequency_max_hertz", "Maximum processor frequency", TYPE_GAUGE, Sample{Labels: labels, Value: float64(cpu.Maximum)})
+		}
+	}
+}
+
+// addSummary adds the minimum, maximum and quantiles of a metric over
+// the rate window
+func addSummary(families map[string]*Family, name string, metric *gopi.Metric, labels []Label) {
+	summary := metric.Summary
+	samples := make([]Sample, 0, len(summary.Quantiles)+2)
+	for _, q := range summary.Quantiles {
+		samples = append(samples, Sample{Labels: withLabel(labels, "quantile", formatValue(q.Quantile)), Value: q.Value})
+	}
+	samples = append(samples, Sample{"_sum", labels, metric.Mean * float64(summary.Count)}, Sample{"_count", labels, float64(summary.Count)})
+	add(families, name+"_window", "Summary of "+metric.Name+" over the rate window", TYPE_SUMMARY, samples...)
+	add(families, name+"_min", "Minimum of "+metric.Name+" over the rate window", TYPE_GAUGE, Sample{Labels: labels, Value: summary.Min})
+	add(families, name+"_max", "Maximum of "+metric.Name+" over the rate window", TYPE_GAUGE, Sample{Labels: labels, Value: summary.Max})
+}
+
+// withLabel returns a copy of labels with an additional label
+func withLabel(labels []Label, name, value string) []Label {
+	return append(append(make([]Label, 0, len(labels)+1), labels...), Label{name, value})
+}
+
+// sanitizeLabel returns a label name where characters which are not
+// allowed are replaced with underscores
+func sanitizeLabel(name string) string {
+	return strings.Replace(strings.TrimPrefix(SanitizeName(name), METRIC_PREFIX), ":", "_", -1)
+}
+
+func typeLabel(metric_type gopi.MetricType) string {
+	return strings.ToLower(strings.TrimPrefix(metric_type.String(), "METRIC_TYPE_"))
+}
+
+func rateLabel(metric_rate gopi.MetricRate) string {
+	return strings.ToLower(strings.TrimPrefix(metric_rate.String(), "METRIC_RATE_"))
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, +1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+// escape backslashes and newlines, and double quotes in label values
+func escape(value string, quotes bool) string {
+	value = strings.Replace(value, "\\", "\\\\", -1)
+	value = strings.Replace(value, "\n", "\\n", -1)
+	if quotes {
+		value = strings.Replace(value, "\"", "\\\"", -1)
+	}
+	return value
+}
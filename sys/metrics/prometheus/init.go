@@ -0,0 +1,43 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package prometheus
+
+import (
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INIT
+
+func init() {
+	// Register metrics/prometheus
+	gopi.RegisterModule(gopi.Module{
+		Name:     "metrics/prometheus",
+		Type:     gopi.MODULE_TYPE_OTHER,
+		Requires: []string{"metrics"},
+		Config: func(config *gopi.AppConfig) {
+			config.AppFlags.FlagUint("metrics.port", DEFAULT_PORT, "Port for serving metrics over HTTP")
+			config.AppFlags.FlagString("metrics.path", DEFAULT_PATH, "Path for serving metrics over HTTP")
+		},
+		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
+			port, _ := app.AppFlags.GetUint("metrics.port")
+			path, _ := app.AppFlags.GetString("metrics.path")
+			if metrics, ok := app.ModuleInstance("metrics").(gopi.Metrics); ok == false {
+				return nil, gopi.ErrBadParameter
+			} else {
+				return gopi.Open(Server{
+					Metrics: metrics,
+					Port:    port,
+					Path:    path,
+				}, app.Logger)
+			}
+		},
+	})
+}
@@ -0,0 +1,217 @@
+package prometheus_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	mock "github.com/djthorpe/gopi/sys/hw/mock"
+	logger "github.com/djthorpe/gopi/sys/logger"
+	prometheus "github.com/djthorpe/gopi/sys/metrics/prometheus"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// EXPOSITION
+
+func TestExposition_000(t *testing.T) {
+	if name := prometheus.SanitizeName("requests.per-second"); name != "gopi_requests_per_second" {
+		t.Error("Unexpected name", name)
+	}
+}
+
+func TestExposition_001(t *testing.T) {
+	buf := new(bytes.Buffer)
+	families := []*prometheus.Family{
+		&prometheus.Family{
+			Name: "gopi_test",
+			Help: "Test\nfamily",
+			Type: prometheus.TYPE_GAUGE,
+			Samples: []prometheus.Sample{
+				{Labels: []prometheus.Label{{"name", "a \"quoted\" value"}}, Value: 1.5},
+				{Value: 2},
+			},
+		},
+	}
+	if err := prometheus.WriteFamilies(buf, families, prometheus.FORMAT_OPENMETRICS); err != nil {
+		t.Fatal(err)
+	}
+	expected := "# HELP gopi_test Test\\nfamily\n" +
+		"# TYPE gopi_test gauge\n" +
+		"gopi_test{name=\"a \\\"quoted\\\" value\"} 1.5\n" +
+		"gopi_test 2\n" +
+		"# EOF\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%v", buf.String())
+	}
+}
+
+func TestExposition_002(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	metrics := openDriver(t, mock.Metrics{}, log).(gopi.Metrics)
+	defer metrics.Close()
+
+	// Create a counter and increment it
+	if counter, err := metrics.NewCounter(gopi.METRIC_TYPE_NONE, gopi.METRIC_RATE_MINUTE, "requests"); err != nil {
+		t.Fatal(err)
+	} else {
+		counter <- 3
+		counter <- 4
+		counter <- 0
+	}
+
+	buf := new(bytes.Buffer)
+	if err := prometheus.Write(buf, metrics, prometheus.FORMAT_TEXT); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"# TYPE gopi_host_uptime_seconds gauge\n",
+		"gopi_load_average{period=\"5m\"} 0.2\n",
+		"gopi_cpu_usage_ratio{cpu=\"cpu0\"} 0.3\n",
+		"gopi_memory_bytes{type=\"total\"} 1.073741824e+09\n",
+		"gopi_network_receive_bytes_total{interface=\"eth0\"} 2000\n",
+		"# TYPE gopi_network_receive_bytes_total counter\n",
+		"gopi_thermal_zone_celsius{zone=\"thermal_zone0\",type=\"cpu-thermal\"} 45.5\n",
+		"gopi_requests{type=\"none\",rate=\"minute\"} 420\n",
+		"gopi_requests_mean{type=\"none\",rate=\"minute\"} 7\n",
+	} {
+		if strings.Contains(buf.String(), line) == false {
+			t.Errorf("Expected %q in output:\n%v", line, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "# EOF") {
+		t.Error("Unexpected EOF in text format")
+	}
+
+	// In OpenMetrics the counter family does not include the suffix
+	buf.Reset()
+	if err := prometheus.Write(buf, metrics, prometheus.FORMAT_OPENMETRICS); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"# TYPE gopi_network_receive_bytes counter\n",
+		"gopi_network_receive_bytes_total{interface=\"eth0\"} 2000\n",
+	} {
+		if strings.Contains(buf.String(), line) == false {
+			t.Errorf("Expected %q in output:\n%v", line, buf.String())
+		}
+	}
+}
+
+func TestExposition_003(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	metrics := openDriver(t, mock.Metrics{}, log).(gopi.Metrics)
+	defer metrics.Close()
+
+	// Create a gauge and a histogram
+	if gauge, err := metrics.NewGauge(gopi.METRIC_RATE_MINUTE, "temperature", gopi.MetricLabel{Name: "zone", Value: "cpu"}); err != nil {
+		t.Fatal(err)
+	} else {
+		gauge.Set(40)
+		gauge.Set(50)
+	}
+	if histogram, err := metrics.NewHistogram(gopi.METRIC_RATE_MINUTE, "latency", []float64{1, 5}); err != nil {
+		t.Fatal(err)
+	} else {
+		histogram.Observe(0.5)
+		histogram.Observe(3)
+		histogram.Observe(10)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := prometheus.Write(buf, metrics, prometheus.FORMAT_TEXT); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"# TYPE gopi_temperature gauge\n",
+		"gopi_temperature{type=\"gauge\",rate=\"minute\",zone=\"cpu\"} 50\n",
+		"gopi_temperature_max{type=\"gauge\",rate=\"minute\",zone=\"cpu\"} 50\n",
+		"gopi_temperature_min{type=\"gauge\",rate=\"minute\",zone=\"cpu\"} 40\n",
+		"# TYPE gopi_temperature_window summary\n",
+		"gopi_temperature_window{type=\"gauge\",rate=\"minute\",zone=\"cpu\",quantile=\"0.5\"} 45\n",
+		"gopi_temperature_window_count{type=\"gauge\",rate=\"minute\",zone=\"cpu\"} 2\n",
+		"# TYPE gopi_latency histogram\n",
+		"gopi_latency_bucket{type=\"histogram\",rate=\"minute\",le=\"1\"} 1\n",
+		"gopi_latency_bucket{type=\"histogram\",rate=\"minute\",le=\"5\"} 2\n",
+		"gopi_latency_bucket{type=\"histogram\",rate=\"minute\",le=\"+Inf\"} 3\n",
+		"gopi_latency_sum{type=\"histogram\",rate=\"minute\"} 13.5\n",
+		"gopi_latency_count{type=\"histogram\",rate=\"minute\"} 3\n",
+	} {
+		if strings.Contains(buf.String(), line) == false {
+			t.Errorf("Expected %q in output:\n%v", line, buf.String())
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// SERVER
+
+func TestServer_000(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	metrics := openDriver(t, mock.Metrics{}, log).(gopi.Metrics)
+	defer metrics.Close()
+	server := openDriver(t, prometheus.Server{Metrics: metrics}, log)
+	defer server.Close()
+
+	addr := server.(interface {
+		Addr() net.Addr
+	}).Addr().(*net.TCPAddr)
+	url := fmt.Sprintf("http://localhost:%v/metrics", addr.Port)
+
+	// Prometheus text format
+	if response, err := http.Get(url); err != nil {
+		t.Fatal(err)
+	} else if body, err := ioutil.ReadAll(response.Body); err != nil {
+		t.Fatal(err)
+	} else if response.Header.Get("Content-Type") != prometheus.CONTENT_TYPE_TEXT {
+		t.Error("Unexpected content type", response.Header.Get("Content-Type"))
+	} else if strings.Contains(string(body), "gopi_app_uptime_seconds ") == false {
+		t.Error("Unexpected body", string(body))
+	} else {
+		response.Body.Close()
+	}
+
+	// OpenMetrics format
+	request, _ := http.NewRequest("GET", url, nil)
+	request.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	if response, err := http.DefaultClient.Do(request); err != nil {
+		t.Fatal(err)
+	} else if body, err := ioutil.ReadAll(response.Body); err != nil {
+		t.Fatal(err)
+	} else if response.Header.Get("Content-Type") != prometheus.CONTENT_TYPE_OPENMETRICS {
+		t.Error("Unexpected content type", response.Header.Get("Content-Type"))
+	} else if strings.HasSuffix(string(body), "# EOF\n") == false {
+		t.Error("Unexpected body", string(body))
+	} else {
+		response.Body.Close()
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func openLogger(t *testing.T) gopi.Logger {
+	if log, err := gopi.Open(logger.Config{}, nil); err != nil {
+		t.Fatal("Unable to create logger driver")
+		return nil
+	} else {
+		return log.(gopi.Logger)
+	}
+}
+
+func openDriver(t *testing.T, config gopi.Config, log gopi.Logger) gopi.Driver {
+	if driver, err := gopi.Open(config, log); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return driver
+	}
+}
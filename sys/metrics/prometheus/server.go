@@ -0,0 +1,157 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package prometheus
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Server serves metrics over HTTP, for scraping by Prometheus
+type Server struct {
+	Metrics gopi.Metrics
+
+	// Port to listen on, or zero to choose any free port
+	Port uint
+
+	// Path for metrics, which defaults to /metrics
+	Path string
+}
+
+type server struct {
+	log      gopi.Logger
+	path     string
+	listener net.Listener
+	server   *http.Server
+	done     chan error
+}
+
+type handler struct {
+	metrics gopi.Metrics
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	DEFAULT_PORT = 9190
+	DEFAULT_PATH = "/metrics"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+// Open the server and start serving metrics in the background
+func (config Server) Open(log gopi.Logger) (gopi.Driver, error) {
+	log.Debug("<sys.metrics.prometheus.Server.Open>{ port=%v path=%v }", config.Port, config.Path)
+
+	if config.Metrics == nil {
+		return nil, gopi.ErrBadParameter
+	}
+
+	this := new(server)
+	this.log = log
+	this.path = config.Path
+	if this.path == "" {
+		this.path = DEFAULT_PATH
+	} else if strings.HasPrefix(this.path, "/") == false {
+		this.path = "/" + this.path
+	}
+
+	// Listen for connections
+	if listener, err := net.Listen("tcp", fmt.Sprintf(":%v", config.Port)); err != nil {
+		return nil, err
+	} else {
+		this.listener = listener
+	}
+
+	// Serve metrics in the background
+	mux := http.NewServeMux()
+	mux.Handle(this.path, Handler(config.Metrics))
+	this.server = &http.Server{Handler: mux}
+	this.done = make(chan error, 1)
+	go func() {
+		this.done <- this.server.Serve(this.listener)
+	}()
+
+	// Success
+	return this, nil
+}
+
+// Close the server
+func (this *server) Close() error {
+	this.log.Debug("<sys.metrics.prometheus.Server.Close>{ addr=%v }", this.listener.Addr())
+
+	// Stop serving and wait for the background task to end
+	err := this.server.Close()
+	if serve_err := <-this.done; serve_err != nil && serve_err != http.ErrServerClosed && err == nil {
+		err = serve_err
+	}
+
+	// Release resources
+	this.server = nil
+	this.done = nil
+
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PROPERTIES
+
+// Addr returns the address the server is listening on
+func (this *server) Addr() net.Addr {
+	return this.listener.Addr()
+}
+
+// Path returns the path for metrics
+func (this *server) Path() string {
+	return this.path
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// HANDLER
+
+// Handler returns an HTTP handler which serves metrics, in OpenMetrics
+// format when the client accepts it or else in Prometheus text format
+func Handler(metrics gopi.Metrics) http.Handler {
+	return &handler{metrics}
+}
+
+func (this *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	format := FORMAT_TEXT
+	if strings.Contains(req.Header.Get("Accept"), "application/openmetrics-text") {
+		format = FORMAT_OPENMETRICS
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	if req.Method == http.MethodHead {
+		return
+	}
+	Write(w, this.metrics, format)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *server) String() string {
+	return fmt.Sprintf("<sys.metrics.prometheus.Server>{ addr=%v path=%v }", this.listener.Addr(), this.path)
+}
@@ -0,0 +1,161 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	// Frameworks
+	gogrpc "google.golang.org/grpc"
+	reflection_pb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	proto "google.golang.org/protobuf/proto"
+	protodesc "google.golang.org/protobuf/reflect/protodesc"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Service is a service and its methods, as returned by the gateway
+type Service struct {
+	Name    string    `json:"name"`
+	Methods []*Method `json:"methods"`
+}
+
+// Method is a method of a service, as returned by the gateway
+type Method struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Input     string `json:"input"`
+	Output    string `json:"output"`
+	Streaming bool   `json:"streaming"`
+
+	// Method descriptor
+	desc protoreflect.MethodDescriptor
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Services which are not served by the gateway
+	REFLECTION_PREFIX = "grpc.reflection."
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// DESCRIPTORS
+
+// Services returns the services registered on a server, using the
+// reflection service, ordered by name
+func Services(ctx context.Context, conn *gogrpc.ClientConn) ([]*Service, error) {
+	stream, err := reflection_pb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.CloseSend()
+
+	// List the services
+	names := make([]string, 0)
+	if resp, err := reflect(stream, &reflection_pb.ServerReflectionRequest{
+		MessageRequest: &reflection_pb.ServerReflectionRequest_ListServices{},
+	}); err != nil {
+		return nil, err
+	} else if list := resp.GetListServicesResponse(); list == nil {
+		return nil, fmt.Errorf("Unexpected reflection response")
+	} else {
+		for _, service := range list.Service {
+			if strings.HasPrefix(service.Name, REFLECTION_PREFIX) == false {
+				names = append(names, service.Name)
+			}
+		}
+	}
+
+	// Obtain the file descriptors for each service, which include
+	// the dependencies not already returned
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if resp, err := reflect(stream, &reflection_pb.ServerReflectionRequest{
+			MessageRequest: &reflection_pb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: name},
+		}); err != nil {
+			return nil, err
+		} else if files := resp.GetFileDescriptorResponse(); files == nil {
+			return nil, fmt.Errorf("%v: Unexpected reflection response", name)
+		} else {
+			for _, data := range files.FileDescriptorProto {
+				file := &descriptorpb.FileDescriptorProto{}
+				if err := proto.Unmarshal(data, file); err != nil {
+					return nil, err
+				} else if seen[file.GetName()] == false {
+					seen[file.GetName()] = true
+					set.File = append(set.File, file)
+				}
+			}
+		}
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the services and methods
+	services := make([]*Service, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		if desc, err := files.FindDescriptorByName(protoreflect.FullName(name)); err != nil {
+			return nil, err
+		} else if desc, ok := desc.(protoreflect.ServiceDescriptor); ok == false {
+			return nil, fmt.Errorf("%v: Not a service", name)
+		} else {
+			service := &Service{Name: name, Methods: make([]*Method, 0, desc.Methods().Len())}
+			for i := 0; i < desc.Methods().Len(); i++ {
+				method := desc.Methods().Get(i)
+				service.Methods = append(service.Methods, &Method{
+					Name:      string(method.Name()),
+					Path:      name + "/" + string(method.Name()),
+					Input:     string(method.Input().FullName()),
+					Output:    string(method.Output().FullName()),
+					Streaming: method.IsStreamingServer(),
+					desc:      method,
+				})
+			}
+			services = append(services, service)
+		}
+	}
+	return services, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PROPERTIES
+
+// Descriptor returns the method descriptor, for creating the input and
+// output messages of the method dynamically
+func (this *Method) Descriptor() protoreflect.MethodDescriptor {
+	return this.desc
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func reflect(stream reflection_pb.ServerReflection_ServerReflectionInfoClient, req *reflection_pb.ServerReflectionRequest) (*reflection_pb.ServerReflectionResponse, error) {
+	if err := stream.Send(req); err != nil {
+		return nil, err
+	} else if resp, err := stream.Recv(); err != nil {
+		return nil, err
+	} else if err := resp.GetErrorResponse(); err != nil {
+		return nil, fmt.Errorf("%v", err.ErrorMessage)
+	} else {
+		return resp, nil
+	}
+}
@@ -0,0 +1,289 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+// Serve RPC services over HTTP with JSON encoding
+package gateway
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+	certificate "github.com/djthorpe/gopi/util/certificate"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Gateway serves the unary and server-streaming methods of the services
+// on an RPC server over HTTP, with JSON encoding of messages. Each request
+// requires a bearer token, which is passed to the server, so that calls are
+// authorized for the client rather than the gateway. The gateway does not
+// present a client certificate, so servers which require client
+// certificates cannot be called through the gateway
+type Gateway struct {
+	// Server for the services, which is connected to once started
+	Server gopi.RPCServer
+
+	// Host and port to listen on, where the host defaults to localhost
+	// and a zero port chooses any free port
+	Host string
+	Port uint
+
+	// Path prefix for services, which defaults to /api
+	Prefix string
+
+	// Certificate and key for serving HTTPS, which are required
+	// unless listening on a loopback address
+	SSLCertificate string
+	SSLKey         string
+
+	// Connection to the server, where the server certificate
+	// is not verified when SkipVerify is true
+	SSL        bool
+	SkipVerify bool
+}
+
+type gateway struct {
+	log        gopi.Logger
+	rpcserver  gopi.RPCServer
+	prefix     string
+	ssl        bool
+	skipverify bool
+	tls        bool
+	listener   net.Listener
+	server     *http.Server
+	done       chan error
+
+	// Connection to the server and the services on it
+	conn     grpc.GRPCClientConn
+	services map[string]*Method
+	lock     sync.Mutex
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	DEFAULT_HOST    = "localhost"
+	DEFAULT_PORT    = 8080
+	DEFAULT_PREFIX  = "/api"
+	DEFAULT_TIMEOUT = 10 * time.Second
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+// Open the gateway and start serving in the background
+func (config Gateway) Open(log gopi.Logger) (gopi.Driver, error) {
+	log.Debug("<rpc.gateway.Open>{ host=%v port=%v prefix=%v sslcert=%v ssl=%v }", config.Host, config.Port, config.Prefix, config.SSLCertificate, config.SSL)
+
+	if config.Server == nil {
+		return nil, gopi.ErrBadParameter
+	} else if (config.SSLCertificate == "") != (config.SSLKey == "") {
+		return nil, gopi.ErrBadParameter
+	}
+
+	this := new(gateway)
+	this.log = log
+	this.rpcserver = config.Server
+	this.ssl = config.SSL
+	this.skipverify = config.SkipVerify
+	this.tls = config.SSLCertificate != ""
+	this.prefix = "/" + strings.Trim(config.Prefix, "/")
+	if this.prefix == "/" {
+		this.prefix = DEFAULT_PREFIX
+	}
+
+	if config.Host == "" {
+		config.Host = DEFAULT_HOST
+	}
+
+	// Listen for connections, where tokens are only sent in the
+	// clear on a loopback address
+	if listener, err := net.Listen("tcp", net.JoinHostPort(config.Host, fmt.Sprint(config.Port))); err != nil {
+		return nil, err
+	} else if addr, ok := listener.Addr().(*net.TCPAddr); this.tls == false && (ok == false || addr.IP.IsLoopback() == false) {
+		listener.Close()
+		log.Error("rpc.gateway: A certificate is required to listen on %v", listener.Addr())
+		return nil, gopi.ErrBadParameter
+	} else {
+		this.listener = listener
+	}
+
+	// Serve HTTPS when there is a certificate, which is
+	// reloaded when the files change
+	if this.tls {
+		if store, err := certificate.NewStore(config.SSLCertificate, config.SSLKey, ""); err != nil {
+			this.listener.Close()
+			return nil, err
+		} else {
+			this.listener = tls.NewListener(this.listener, store.ServerConfig("h2", "http/1.1"))
+		}
+	}
+
+	// Serve in the background
+	mux := http.NewServeMux()
+	mux.Handle(this.prefix+"/", this)
+	this.server = &http.Server{Handler: mux}
+	this.done = make(chan error, 1)
+	go func() {
+		this.done <- this.server.Serve(this.listener)
+	}()
+
+	// Success
+	return this, nil
+}
+
+// Close the gateway
+func (this *gateway) Close() error {
+	this.log.Debug("<rpc.gateway.Close>{ addr=%v }", this.listener.Addr())
+
+	// Stop serving and wait for the background task to end
+	err := this.server.Close()
+	if serve_err := <-this.done; serve_err != nil && serve_err != http.ErrServerClosed && err == nil {
+		err = serve_err
+	}
+
+	// Disconnect from the server
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.conn != nil {
+		if conn_err := this.conn.Close(); conn_err != nil && err == nil {
+			err = conn_err
+		}
+	}
+
+	// Release resources
+	this.server = nil
+	this.done = nil
+	this.conn = nil
+	this.services = nil
+
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PROPERTIES
+
+// Addr returns the address the gateway is listening on
+func (this *gateway) Addr() net.Addr {
+	return this.listener.Addr()
+}
+
+// Prefix returns the path prefix for services
+func (this *gateway) Prefix() string {
+	return this.prefix
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *gateway) String() string {
+	return fmt.Sprintf("<rpc.gateway>{ addr=%v prefix=%v tls=%v }", this.listener.Addr(), this.prefix, this.tls)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONNECT
+
+// connect returns the connection to the server, connecting
+// once the server has started. The connection has no client
+// certificate, and calls use the token for each request
+func (this *gateway) connect() (grpc.GRPCClientConn, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.conn != nil {
+		return this.conn, nil
+	}
+	addr := this.rpcserver.Addr()
+	if addr == nil {
+		return nil, gopi.ErrOutOfOrder
+	}
+	if conn, err := gopi.Open(grpc.ClientConn{
+		Name:       "gateway",
+		Addr:       dialAddr(addr),
+		SSL:        this.ssl,
+		SkipVerify: this.skipverify,
+	}, this.log); err != nil {
+		return nil, err
+	} else if err := conn.(interface{ Connect() error }).Connect(); err != nil {
+		conn.Close()
+		return nil, err
+	} else {
+		this.conn = conn.(grpc.GRPCClientConn)
+		return this.conn, nil
+	}
+}
+
+// method returns a method from its path, loading the
+// services when the method is not yet known
+func (this *gateway) method(ctx context.Context, path string) (*Method, error) {
+	this.lock.Lock()
+	method, exists := this.services[path]
+	this.lock.Unlock()
+	if exists {
+		return method, nil
+	} else if services, err := this.Services(ctx); err != nil {
+		return nil, err
+	} else {
+		for _, service := range services {
+			for _, method := range service.Methods {
+				if method.Path == path {
+					return method, nil
+				}
+			}
+		}
+	}
+	return nil, gopi.ErrNotFound
+}
+
+// Services returns the services on the server, and their methods,
+// where the context has the credentials for the call
+func (this *gateway) Services(ctx context.Context) ([]*Service, error) {
+	conn, err := this.connect()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, DEFAULT_TIMEOUT)
+	defer cancel()
+	services, err := Services(ctx, conn.GRPCConn())
+	if err != nil {
+		return nil, err
+	}
+	methods := make(map[string]*Method)
+	for _, service := range services {
+		for _, method := range service.Methods {
+			methods[method.Path] = method
+		}
+	}
+	this.lock.Lock()
+	this.services = methods
+	this.lock.Unlock()
+	return services, nil
+}
+
+// dialAddr returns the address to dial for the server address
+func dialAddr(addr net.Addr) string {
+	switch addr := addr.(type) {
+	case *net.TCPAddr:
+		return net.JoinHostPort("localhost", fmt.Sprint(addr.Port))
+	case *net.UnixAddr:
+		return "unix://" + addr.Name
+	default:
+		return addr.String()
+	}
+}
@@ -0,0 +1,374 @@
+package gateway_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	logger "github.com/djthorpe/gopi/sys/logger"
+	gateway "github.com/djthorpe/gopi/sys/rpc/gateway"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+	certificate "github.com/djthorpe/gopi/util/certificate"
+	gogrpc "google.golang.org/grpc"
+)
+
+type Gateway interface {
+	gopi.Driver
+	Addr() net.Addr
+}
+
+// token sends a bearer token with each call
+type token string
+
+func (this token) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(this)}, nil
+}
+
+func (this token) RequireTransportSecurity() bool {
+	return false
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	TOKEN = "abc"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GATEWAY
+
+func TestGateway_000(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	server := openServer(t, log)
+
+	// Calls fail until the server has started
+	gw := openGateway(t, log, server)
+	defer gw.Close()
+	resp := get(t, url(gw, "/api/grpc.health.v1.Health/Check"), TOKEN)
+	if resp.Body.Close(); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Error("Expected StatusServiceUnavailable, got", resp.Status)
+	}
+
+	stop := startServer(t, server)
+	defer stop()
+
+	// List services, which does not include reflection
+	services := []gateway.Service{}
+	if code := getJSON(t, http.MethodGet, url(gw, "/api"), "", &services); code != http.StatusOK {
+		t.Fatal("Unexpected status", code)
+	} else if len(services) != 1 || services[0].Name != "grpc.health.v1.Health" {
+		t.Fatal("Unexpected services", services)
+	} else if len(services[0].Methods) != 2 {
+		t.Error("Unexpected methods", services[0].Methods)
+	}
+}
+
+func TestGateway_001(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	server := openServer(t, log)
+	stop := startServer(t, server)
+	defer stop()
+	gw := openGateway(t, log, server)
+	defer gw.Close()
+
+	// Call a unary method
+	reply := make(map[string]interface{})
+	if code := getJSON(t, http.MethodPost, url(gw, "/api/grpc.health.v1.Health/Check"), `{ "service": "" }`, &reply); code != http.StatusOK {
+		t.Error("Unexpected status", code)
+	} else if reply["status"] != "SERVING" {
+		t.Error("Unexpected reply", reply)
+	}
+	if code := getJSON(t, http.MethodGet, url(gw, "/api/grpc.health.v1.Health/Check"), "", &reply); code != http.StatusOK {
+		t.Error("Unexpected status", code)
+	}
+
+	// Errors are returned with the status for the code
+	for _, test := range []struct {
+		method, path, body string
+		code               int
+	}{
+		{http.MethodPost, "/api/grpc.health.v1.Health/Check", `{ "service": "unknown.Service" }`, http.StatusNotFound},
+		{http.MethodPost, "/api/grpc.health.v1.Health/Check", `{ "other": "" }`, http.StatusBadRequest},
+		{http.MethodPost, "/api/grpc.health.v1.Health/Check", `{`, http.StatusBadRequest},
+		{http.MethodPost, "/api/grpc.health.v1.Health/Other", ``, http.StatusNotFound},
+		{http.MethodPut, "/api/grpc.health.v1.Health/Check", ``, http.StatusMethodNotAllowed},
+		{http.MethodPost, "/api/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo", ``, http.StatusNotFound},
+	} {
+		e := gateway.Error{}
+		if code := getJSON(t, test.method, url(gw, test.path), test.body, &e); code != test.code {
+			t.Errorf("%v %v: Expected %v, got %v", test.method, test.path, test.code, code)
+		} else if e.Code == "" || e.Message == "" {
+			t.Errorf("%v %v: Unexpected error %v", test.method, test.path, e)
+		}
+	}
+}
+
+func TestGateway_002(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	server := openServer(t, log)
+	stop := startServer(t, server)
+	defer stop()
+	gw := openGateway(t, log, server)
+	defer gw.Close()
+
+	// Server-streaming methods return events
+	resp := get(t, url(gw, "/api/grpc.health.v1.Health/Watch"), TOKEN)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("Unexpected status", resp.Status)
+	} else if resp.Header.Get("Content-Type") != gateway.CONTENT_TYPE_SSE {
+		t.Fatal("Unexpected content type", resp.Header.Get("Content-Type"))
+	}
+	events := readEvents(resp)
+	if event := <-events; event != `data: {"status":"SERVING"}` {
+		t.Error("Unexpected event", event)
+	}
+
+	// Reporting the server is not serving is sent as an event, and
+	// the end of the stream as an error event
+	server.(gopi.RPCHealth).SetServing("", false)
+	if event := <-events; event != `data: {"status":"NOT_SERVING"}` {
+		t.Error("Unexpected event", event)
+	}
+	if event := <-events; strings.HasPrefix(event, "event: error") == false || strings.Contains(event, "Unavailable") == false {
+		t.Error("Unexpected event", event)
+	}
+}
+
+func TestGateway_003(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	server := openServer(t, log)
+	stop := startServer(t, server)
+	defer stop()
+	gw := openGateway(t, log, server)
+	defer gw.Close()
+
+	// Requests require a bearer token, which is checked by the server
+	for _, test := range []struct {
+		path, token string
+		code        int
+	}{
+		{"/api", "", http.StatusUnauthorized},
+		{"/api/grpc.health.v1.Health/Check", "", http.StatusUnauthorized},
+		{"/api", "xyz", http.StatusUnauthorized},
+		{"/api/grpc.health.v1.Health/Check", "xyz", http.StatusUnauthorized},
+		{"/api/grpc.health.v1.Health/Check", TOKEN, http.StatusOK},
+	} {
+		resp := get(t, url(gw, test.path), test.token)
+		if resp.Body.Close(); resp.StatusCode != test.code {
+			t.Errorf("%v %q: Expected %v, got %v", test.path, test.token, test.code, resp.Status)
+		}
+	}
+}
+
+func TestGateway_004(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	server := openServer(t, log)
+	stop := startServer(t, server)
+	defer stop()
+
+	// Gateway listens on localhost by default, and requires a
+	// certificate to listen on other addresses
+	gw := openGateway(t, log, server)
+	if addr := gw.Addr().(*net.TCPAddr); addr.IP.IsLoopback() == false {
+		t.Error("Expected loopback address, got", addr)
+	}
+	gw.Close()
+	if _, err := gopi.Open(gateway.Gateway{Server: server, Host: "0.0.0.0"}, log); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+
+	// Serve HTTPS with a certificate
+	path, err := ioutil.TempDir("", "gateway")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+	certfile, keyfile := filepath.Join(path, "cert.pem"), filepath.Join(path, "key.pem")
+	cert_pem, key_pem, err := certificate.Generate(certificate.Request{CommonName: "gateway", Hosts: []string{"127.0.0.1", "localhost"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := ioutil.WriteFile(certfile, cert_pem, 0644); err != nil {
+		t.Fatal(err)
+	} else if err := ioutil.WriteFile(keyfile, key_pem, 0600); err != nil {
+		t.Fatal(err)
+	}
+	gw = openGatewayWithConfig(t, log, gateway.Gateway{Server: server, SSLCertificate: certfile, SSLKey: keyfile})
+	defer gw.Close()
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(cert_pem)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp := do(t, client, http.MethodGet, "https://"+gw.Addr().String()+"/api/grpc.health.v1.Health/Check", "", TOKEN)
+	if resp.Body.Close(); resp.StatusCode != http.StatusOK {
+		t.Error("Unexpected status", resp.Status)
+	} else if resp.TLS == nil {
+		t.Error("Expected TLS connection")
+	}
+}
+
+func TestServices_000(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	server := openServer(t, log)
+	stop := startServer(t, server)
+	defer stop()
+
+	conn, err := gogrpc.Dial(fmt.Sprintf("127.0.0.1:%v", server.Addr().(*net.TCPAddr).Port), gogrpc.WithInsecure(), gogrpc.WithPerRPCCredentials(token(TOKEN)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Services returns method descriptors for creating messages
+	if services, err := gateway.Services(context.Background(), conn); err != nil {
+		t.Fatal(err)
+	} else if len(services) != 1 {
+		t.Fatal("Unexpected services", services)
+	} else {
+		for _, method := range services[0].Methods {
+			if desc := method.Descriptor(); desc == nil {
+				t.Error("Missing descriptor for", method.Path)
+			} else if string(desc.Input().FullName()) != method.Input || string(desc.Output().FullName()) != method.Output {
+				t.Error("Unexpected descriptor for", method.Path)
+			} else if desc.IsStreamingServer() != method.Streaming {
+				t.Error("Unexpected streaming for", method.Path)
+			}
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN
+
+func openServer(t *testing.T, log gopi.Logger) gopi.RPCServer {
+	if driver, err := gopi.Open(grpc.Server{Authenticator: grpc.StaticTokens{"client": TOKEN}}, log); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return driver.(gopi.RPCServer)
+	}
+}
+
+// startServer starts the server and returns a function
+// which stops and closes it
+func startServer(t *testing.T, server gopi.RPCServer) func() {
+	events := server.Subscribe()
+	defer server.Unsubscribe(events)
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Start()
+	}()
+	select {
+	case <-events:
+	case err := <-stopped:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for server to start")
+	}
+	return func() {
+		server.Stop(true)
+		<-stopped
+		server.Close()
+	}
+}
+
+func openGateway(t *testing.T, log gopi.Logger, server gopi.RPCServer) Gateway {
+	return openGatewayWithConfig(t, log, gateway.Gateway{Server: server})
+}
+
+func openGatewayWithConfig(t *testing.T, log gopi.Logger, config gateway.Gateway) Gateway {
+	if driver, err := gopi.Open(config, log); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return driver.(Gateway)
+	}
+}
+
+func openLogger(t *testing.T) gopi.Logger {
+	if log, err := gopi.Open(logger.Config{}, nil); err != nil {
+		t.Fatal("Unable to create logger driver")
+		return nil
+	} else {
+		return log.(gopi.Logger)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// HTTP
+
+func url(gw Gateway, path string) string {
+	return fmt.Sprintf("http://%v%v", gw.Addr(), path)
+}
+
+// get makes a request with a bearer token, or without one when
+// the token is empty
+func get(t *testing.T, url, token string) *http.Response {
+	return do(t, http.DefaultClient, http.MethodGet, url, "", token)
+}
+
+func do(t *testing.T, client *http.Client, method, url, body, token string) *http.Response {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// getJSON makes a request with a bearer token and decodes the
+// response, returning the status
+func getJSON(t *testing.T, method, url, body string, v interface{}) int {
+	resp := do(t, http.DefaultClient, method, url, body, TOKEN)
+	defer resp.Body.Close()
+	if data, err := ioutil.ReadAll(resp.Body); err != nil {
+		t.Fatal(err)
+	} else if err := json.Unmarshal(data, v); err != nil {
+		t.Fatal(err, string(data))
+	}
+	return resp.StatusCode
+}
+
+// readEvents returns events, where the lines of each event are joined
+func readEvents(resp *http.Response) <-chan string {
+	events := make(chan string, 10)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		lines := []string{}
+		for scanner.Scan() {
+			if line := scanner.Text(); line != "" {
+				lines = append(lines, line)
+			} else if len(lines) > 0 {
+				events <- strings.Join(lines, "\n")
+				lines = lines[:0]
+			}
+		}
+	}()
+	return events
+}
@@ -0,0 +1,254 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+	gogrpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	metadata "google.golang.org/grpc/metadata"
+	status "google.golang.org/grpc/status"
+	protojson "google.golang.org/protobuf/encoding/protojson"
+	dynamicpb "google.golang.org/protobuf/types/dynamicpb"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Error is returned as the body of a response when a call fails
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	CONTENT_TYPE_JSON = "application/json"
+	CONTENT_TYPE_SSE  = "text/event-stream"
+
+	// Maximum size of a request body
+	MAX_REQUEST_SIZE = 4 * 1024 * 1024
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// HANDLER
+
+// ServeHTTP lists the services at the prefix, and calls methods at
+// prefix/package.Service/Method with the request body as the JSON
+// message. Server-streaming methods return Server-Sent Events. Each
+// request requires a bearer token, which is passed to the server
+func (this *gateway) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(req.URL.Path, this.prefix), "/")
+	if bearerToken(req) == "" {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, codes.Unauthenticated, "Missing bearer token")
+	} else if path == "" {
+		this.serveServices(w, req)
+	} else if req.Method != http.MethodGet && req.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, codes.Unimplemented, http.StatusText(http.StatusMethodNotAllowed))
+	} else if method, err := this.method(outgoingContext(req), path); err == gopi.ErrNotFound {
+		writeError(w, http.StatusNotFound, codes.NotFound, "Not found: "+path)
+	} else if err != nil {
+		writeStatus(w, err)
+	} else if method.desc.IsStreamingClient() {
+		writeError(w, http.StatusNotImplemented, codes.Unimplemented, "Client streaming is not supported: "+path)
+	} else if request, err := readRequest(req, method); err != nil {
+		writeError(w, http.StatusBadRequest, codes.InvalidArgument, err.Error())
+	} else if method.Streaming {
+		this.serveStream(w, req, method, request)
+	} else {
+		this.serveUnary(w, req, method, request)
+	}
+}
+
+func (this *gateway) serveServices(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeError(w, http.StatusMethodNotAllowed, codes.Unimplemented, http.StatusText(http.StatusMethodNotAllowed))
+	} else if services, err := this.Services(outgoingContext(req)); err != nil {
+		writeStatus(w, err)
+	} else {
+		w.Header().Set("Content-Type", CONTENT_TYPE_JSON)
+		json.NewEncoder(w).Encode(services)
+	}
+}
+
+func (this *gateway) serveUnary(w http.ResponseWriter, req *http.Request, method *Method, request *dynamicpb.Message) {
+	conn, err := this.connect()
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(outgoingContext(req), DEFAULT_TIMEOUT)
+	defer cancel()
+	reply := dynamicpb.NewMessage(method.desc.Output())
+	if err := conn.GRPCConn().Invoke(ctx, "/"+method.Path, request, reply); err != nil {
+		writeStatus(w, err)
+	} else if data, err := protojson.Marshal(reply); err != nil {
+		writeError(w, http.StatusInternalServerError, codes.Internal, err.Error())
+	} else {
+		w.Header().Set("Content-Type", CONTENT_TYPE_JSON)
+		w.Write(data)
+	}
+}
+
+// serveStream sends each message as an event until the stream ends or
+// the client disconnects. Errors after the first message are sent as
+// an error event
+func (this *gateway) serveStream(w http.ResponseWriter, req *http.Request, method *Method, request *dynamicpb.Message) {
+	flusher, ok := w.(http.Flusher)
+	if ok == false {
+		writeError(w, http.StatusInternalServerError, codes.Internal, "Streaming is not supported")
+		return
+	}
+	conn, err := this.connect()
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+	ctx, cancel := context.WithCancel(outgoingContext(req))
+	defer cancel()
+	stream, err := conn.GRPCConn().NewStream(ctx, &gogrpc.StreamDesc{ServerStreams: true}, "/"+method.Path)
+	if err != nil {
+		writeStatus(w, err)
+		return
+	} else if err := stream.SendMsg(request); err != nil {
+		writeStatus(w, err)
+		return
+	} else if err := stream.CloseSend(); err != nil {
+		writeStatus(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", CONTENT_TYPE_SSE)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		reply := dynamicpb.NewMessage(method.desc.Output())
+		if err := stream.RecvMsg(reply); err == io.EOF {
+			fmt.Fprint(w, "event: end\ndata: {}\n\n")
+			break
+		} else if err != nil {
+			if ctx.Err() == nil {
+				code, message := statusFor(err)
+				data, _ := json.Marshal(Error{code.String(), message})
+				fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+			}
+			break
+		} else if data, err := protojson.Marshal(reply); err != nil {
+			this.log.Warn("rpc.gateway: %v: %v", method.Path, err)
+			break
+		} else {
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+	flusher.Flush()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// readRequest returns the request message from the body, which
+// can be empty
+func readRequest(req *http.Request, method *Method) (*dynamicpb.Message, error) {
+	request := dynamicpb.NewMessage(method.desc.Input())
+	if data, err := ioutil.ReadAll(io.LimitReader(req.Body, MAX_REQUEST_SIZE+1)); err != nil {
+		return nil, err
+	} else if len(data) > MAX_REQUEST_SIZE {
+		return nil, fmt.Errorf("Request too large")
+	} else if len(strings.TrimSpace(string(data))) == 0 {
+		return request, nil
+	} else if err := protojson.Unmarshal(data, request); err != nil {
+		return nil, err
+	} else {
+		return request, nil
+	}
+}
+
+// bearerToken returns the token from the authorization header
+func bearerToken(req *http.Request) string {
+	if value := strings.TrimSpace(req.Header.Get("Authorization")); len(value) > len(grpc.BEARER_PREFIX) && strings.EqualFold(value[:len(grpc.BEARER_PREFIX)], grpc.BEARER_PREFIX) {
+		return strings.TrimSpace(value[len(grpc.BEARER_PREFIX):])
+	} else {
+		return ""
+	}
+}
+
+// outgoingContext passes the bearer token to the server
+func outgoingContext(req *http.Request) context.Context {
+	return metadata.NewOutgoingContext(req.Context(), metadata.Pairs(grpc.METADATA_AUTHORIZATION, grpc.BEARER_PREFIX+bearerToken(req)))
+}
+
+// statusFor returns the gRPC code and message for an error
+func statusFor(err error) (codes.Code, string) {
+	if err == gopi.ErrOutOfOrder {
+		return codes.Unavailable, "Server not started"
+	} else if s, ok := status.FromError(err); ok {
+		return s.Code(), s.Message()
+	} else {
+		return codes.Unknown, err.Error()
+	}
+}
+
+// writeStatus writes an error with the HTTP status for the gRPC code
+func writeStatus(w http.ResponseWriter, err error) {
+	code, message := statusFor(err)
+	writeError(w, httpStatus(code), code, message)
+}
+
+func writeError(w http.ResponseWriter, httpstatus int, code codes.Code, message string) {
+	w.Header().Set("Content-Type", CONTENT_TYPE_JSON)
+	w.WriteHeader(httpstatus)
+	json.NewEncoder(w).Encode(Error{code.String(), message})
+}
+
+// httpStatus returns the HTTP status for a gRPC code
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,62 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package gateway
+
+import (
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INIT
+
+func init() {
+	// Register rpc/gateway
+	gopi.RegisterModule(gopi.Module{
+		Name:     "rpc/gateway",
+		Type:     gopi.MODULE_TYPE_OTHER,
+		Requires: []string{"rpc/server"},
+		Config: func(config *gopi.AppConfig) {
+			config.AppFlags.FlagString("gateway.host", DEFAULT_HOST, "Host for serving RPC services over HTTP")
+			config.AppFlags.FlagUint("gateway.port", DEFAULT_PORT, "Port for serving RPC services over HTTP")
+			config.AppFlags.FlagString("gateway.prefix", DEFAULT_PREFIX, "Path prefix for serving RPC services over HTTP")
+			config.AppFlags.FlagString("gateway.sslcert", "", "SSL Certificate Path for serving HTTPS, or the server certificate when empty")
+			config.AppFlags.FlagString("gateway.sslkey", "", "SSL Key Path for serving HTTPS, or the server key when empty")
+		},
+		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
+			host, _ := app.AppFlags.GetString("gateway.host")
+			port, _ := app.AppFlags.GetUint("gateway.port")
+			prefix, _ := app.AppFlags.GetString("gateway.prefix")
+			cert, _ := app.AppFlags.GetString("gateway.sslcert")
+			key, _ := app.AppFlags.GetString("gateway.sslkey")
+			// The server uses SSL when it has a certificate, which
+			// is not verified since the connection is local
+			servercert, _ := app.AppFlags.GetString("rpc.sslcert")
+			serverkey, _ := app.AppFlags.GetString("rpc.sslkey")
+			if cert == "" && key == "" {
+				cert, key = servercert, serverkey
+			}
+			if server, ok := app.ModuleInstance("rpc/server").(gopi.RPCServer); ok == false {
+				return nil, gopi.ErrBadParameter
+			} else {
+				return gopi.Open(Gateway{
+					Server:         server,
+					Host:           host,
+					Port:           port,
+					Prefix:         prefix,
+					SSLCertificate: cert,
+					SSLKey:         key,
+					SSL:            servercert != "",
+					SkipVerify:     true,
+				}, app.Logger)
+			}
+		},
+	})
+}
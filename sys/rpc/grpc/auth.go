@@ -0,0 +1,245 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	certificate "github.com/djthorpe/gopi/util/certificate"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	credentials "google.golang.org/grpc/credentials"
+	metadata "google.golang.org/grpc/metadata"
+	peer "google.golang.org/grpc/peer"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Authorization maps a service name, such as "mutablelogic.Greeter", or
+// a method, such as "mutablelogic.Greeter/SayHello", to the client
+// identities which can call it. The identity "*" allows any authenticated
+// client. A method takes precedence over its service, and services which
+// are not in the map can be called by any authenticated client
+type Authorization map[string][]string
+
+// authorizer checks client identities from certificates and tokens
+// against the authorization for each call
+type authorizer struct {
+	log           gopi.Logger
+	authenticator Authenticator
+	required      bool
+	authorization Authorization
+	lock          sync.RWMutex
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	AUTHORIZE_ANY = "*"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// ParseAuthorization returns authorization from a comma-separated list
+// of service=identity or service/method=identity pairs
+func ParseAuthorization(value string) (Authorization, error) {
+	authorization := make(Authorization)
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		} else if kv := strings.SplitN(pair, "=", 2); len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, fmt.Errorf("Invalid authorization: %v", pair)
+		} else {
+			service, identity := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+			authorization[service] = append(authorization[service], identity)
+		}
+	}
+	return authorization, nil
+}
+
+// ParseMethodAuthorization returns authorization for the methods of
+// a service from a comma-separated list of method=identity pairs,
+// where the method "*" is all methods of the service
+func ParseMethodAuthorization(service, value string) (Authorization, error) {
+	if service == "" {
+		return nil, gopi.ErrBadParameter
+	} else if methods, err := ParseAuthorization(value); err != nil {
+		return nil, err
+	} else {
+		authorization := make(Authorization, len(methods))
+		for method, identities := range methods {
+			if strings.Contains(method, "/") {
+				return nil, fmt.Errorf("Invalid method: %v", method)
+			} else if method == AUTHORIZE_ANY {
+				authorization[service] = identities
+			} else {
+				authorization[service+"/"+method] = identities
+			}
+		}
+		return authorization, nil
+	}
+}
+
+// PeerIdentities returns the identities of the verified client
+// certificate for a request, or nil if there is no verified certificate
+func PeerIdentities(ctx context.Context) []string {
+	if p, ok := peer.FromContext(ctx); ok == false || p.AuthInfo == nil {
+		return nil
+	} else if info, ok := p.AuthInfo.(credentials.TLSInfo); ok == false {
+		return nil
+	} else if len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil
+	} else {
+		return certificate.Identities(info.State.VerifiedChains[0][0])
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// AUTHORIZER
+
+func newAuthorizer(authorization Authorization, authenticator Authenticator, certificates bool, log gopi.Logger) *authorizer {
+	this := new(authorizer)
+	this.log = log
+	this.authenticator = authenticator
+	this.required = certificates || authenticator != nil
+	this.authorization = make(Authorization)
+	this.add(authorization)
+	return this
+}
+
+// add identities for services and methods
+func (this *authorizer) add(authorization Authorization) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for key, identities := range authorization {
+		this.authorization[key] = append(this.authorization[key], identities...)
+	}
+	if len(authorization) > 0 && this.required == false {
+		this.log.Warn("grpc.Server: No client certificates or tokens, restricted calls will be denied")
+	}
+}
+
+// allowed returns the identities which can call a method,
+// which is of the form /package.Service/Method
+func (this *authorizer) allowed(method string) ([]string, bool) {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	method = strings.TrimPrefix(method, "/")
+	if identities, exists := this.authorization[method]; exists {
+		return identities, true
+	} else if identities, exists := this.authorization[serviceForMethod(method)]; exists {
+		return identities, true
+	} else {
+		return nil, false
+	}
+}
+
+func (this *authorizer) unaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := this.authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (this *authorizer) streamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := this.authorize(stream.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// authorize returns an error if the client cannot call a method,
+// and records the denied call in the log
+func (this *authorizer) authorize(ctx context.Context, method string) error {
+	allowed, restricted := this.allowed(method)
+	if this.required == false && restricted == false {
+		return nil
+	}
+	identities, err := this.identities(ctx)
+	if err != nil {
+		return this.deny(ctx, method, identities, codes.Unauthenticated, err.Error())
+	} else if len(identities) == 0 {
+		return this.deny(ctx, method, identities, codes.Unauthenticated, "Missing client certificate or token")
+	} else if restricted == false {
+		return nil
+	}
+	for _, identity := range allowed {
+		if identity == AUTHORIZE_ANY {
+			return nil
+		}
+		for _, other := range identities {
+			if identity == other {
+				return nil
+			}
+		}
+	}
+	return this.deny(ctx, method, identities, codes.PermissionDenied, identities[0]+": Not authorized")
+}
+
+// identities returns the client certificate identities and the
+// identity for any bearer token
+func (this *authorizer) identities(ctx context.Context) ([]string, error) {
+	identities := PeerIdentities(ctx)
+	if this.authenticator == nil {
+		return identities, nil
+	} else if token := bearerToken(ctx); token == "" {
+		return identities, nil
+	} else if identity, err := this.authenticator.Authenticate(token); err != nil {
+		return identities, err
+	} else {
+		return append(identities, identity), nil
+	}
+}
+
+// deny records a denied call and returns the error for the client
+func (this *authorizer) deny(ctx context.Context, method string, identities []string, code codes.Code, reason string) error {
+	addr := "<unknown>"
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = p.Addr.String()
+	}
+	this.log.Warn("grpc.Server: Denied %v{ addr=%v identities=%v code=%v reason=%v }", method, addr, identities, code, reason)
+	return grpc.Errorf(code, "%v", reason)
+}
+
+// bearerToken returns the token from the authorization metadata
+func bearerToken(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok == false {
+		return ""
+	} else if values := md[METADATA_AUTHORIZATION]; len(values) == 0 {
+		return ""
+	} else if value := strings.TrimSpace(values[0]); len(value) > len(BEARER_PREFIX) && strings.EqualFold(value[:len(BEARER_PREFIX)], BEARER_PREFIX) {
+		return strings.TrimSpace(value[len(BEARER_PREFIX):])
+	} else {
+		return ""
+	}
+}
+
+func serviceForMethod(method string) string {
+	if parts := strings.Split(strings.TrimPrefix(method, "/"), "/"); len(parts) > 0 {
+		return parts[0]
+	} else {
+		return ""
+	}
+}
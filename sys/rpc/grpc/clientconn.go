@@ -11,13 +11,14 @@ package grpc
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
+	"net"
 	"sync"
 	"time"
 
 	// Frameworks
 	gopi "github.com/djthorpe/gopi"
+	certificate "github.com/djthorpe/gopi/util/certificate"
 	grpc "google.golang.org/grpc"
 	credentials "google.golang.org/grpc/credentials"
 	reflection_pb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
@@ -30,6 +31,25 @@ type ClientConn struct {
 	SSL        bool
 	SkipVerify bool
 	Timeout    time.Duration
+
+	// Client certificate and key presented to the server
+	SSLCertificate string
+	SSLKey         string
+
+	// Bundle of certificate authorities for verifying the server,
+	// rather than the system certificate authorities
+	SSLCA string
+
+	// Bearer token sent with each call
+	Token string
+
+	// Maximum delay between attempts to reconnect
+	Backoff time.Duration
+
+	// Dial connections rather than using the network, such as the
+	// Dial method of a MemoryListener. Addresses of the form unix:///path
+	// are dialed on unix sockets
+	Dialer func(addr string, timeout time.Duration) (net.Conn, error)
 }
 
 type clientconn struct {
@@ -39,6 +59,12 @@ type clientconn struct {
 	ssl        bool
 	skipverify bool
 	timeout    time.Duration
+	sslcert    string
+	sslkey     string
+	sslca      string
+	token      string
+	backoff    time.Duration
+	dialer     func(string, time.Duration) (net.Conn, error)
 	conn       *grpc.ClientConn
 	lock       sync.Mutex
 }
@@ -50,6 +76,11 @@ type clientconn struct {
 func (config ClientConn) Open(log gopi.Logger) (gopi.Driver, error) {
 	log.Debug("<grpc.ClientConn>Open(addr=%v,ssl=%v,skipverify=%v,timeout=%v)", config.Addr, config.SSL, config.SkipVerify, config.Timeout)
 
+	// Tokens are not sent to servers which are not verified
+	if config.Token != "" && config.SSL && config.SkipVerify && config.SSLCA == "" {
+		return nil, gopi.ErrBadParameter
+	}
+
 	// Create a client object
 	this := new(clientconn)
 	this.name = config.Name
@@ -57,6 +88,12 @@ func (config ClientConn) Open(log gopi.Logger) (gopi.Driver, error) {
 	this.ssl = config.SSL
 	this.skipverify = config.SkipVerify
 	this.timeout = config.Timeout
+	this.sslcert = config.SSLCertificate
+	this.sslkey = config.SSLKey
+	this.sslca = config.SSLCA
+	this.token = config.Token
+	this.backoff = config.Backoff
+	this.dialer = config.Dialer
 	this.log = log
 	this.conn = nil
 
@@ -90,13 +127,41 @@ func (this *clientconn) Connect() error {
 	// Create connection options
 	opts := make([]grpc.DialOption, 0, 1)
 
-	// SSL options
+	// SSL options, where certificates are loaded on each connection
 	if this.ssl {
-		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{InsecureSkipVerify: this.skipverify})))
+		if store, err := certificate.NewStore(this.sslcert, this.sslkey, this.sslca); err != nil {
+			return err
+		} else {
+			opts = append(opts, grpc.WithTransportCredentials(&certificateCredentials{
+				TransportCredentials: credentials.NewTLS(store.ClientConfig(this.skipverify)),
+				store:                store,
+				skipverify:           this.skipverify,
+			}))
+		}
 	} else {
 		opts = append(opts, grpc.WithInsecure())
 	}
 
+	// Token credentials, which are only sent in the clear when SSL is disabled
+	if this.token != "" {
+		if this.ssl == false {
+			this.log.Warn("grpc.ClientConn: Sending token to %v without SSL", this.addr)
+		}
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials{this.token, this.ssl}))
+	}
+
+	// Dialer options
+	if this.dialer != nil {
+		opts = append(opts, grpc.WithDialer(this.dialer))
+	} else if path := unixPath(this.addr); path != "" {
+		opts = append(opts, grpc.WithDialer(dialUnix(path)))
+	}
+
+	// Reconnection options
+	if this.backoff > 0 {
+		opts = append(opts, grpc.WithBackoffMaxDelay(this.backoff))
+	}
+
 	// Connection timeout options
 	if this.timeout > 0 {
 		opts = append(opts, grpc.WithTimeout(this.timeout))
@@ -224,3 +289,33 @@ func (this *clientconn) listServices(c reflection_pb.ServerReflection_ServerRefl
 		return module_names, nil
 	}
 }
+
+////////////////////////////////////////////////////////////////////////////////
+// CERTIFICATE CREDENTIALS
+
+// certificateCredentials creates the client configuration on each
+// handshake, so that a reloaded certificate or bundle is used when
+// the connection is re-established
+type certificateCredentials struct {
+	credentials.TransportCredentials
+	store      *certificate.Store
+	skipverify bool
+	servername string
+}
+
+func (this *certificateCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	config := this.store.ClientConfig(this.skipverify)
+	config.ServerName = this.servername
+	return credentials.NewTLS(config).ClientHandshake(ctx, authority, conn)
+}
+
+func (this *certificateCredentials) Clone() credentials.TransportCredentials {
+	clone := *this
+	clone.TransportCredentials = this.TransportCredentials.Clone()
+	return &clone
+}
+
+func (this *certificateCredentials) OverrideServerName(servername string) error {
+	this.servername = servername
+	return this.TransportCredentials.OverrideServerName(servername)
+}
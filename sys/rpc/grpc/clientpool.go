@@ -13,6 +13,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -22,6 +23,9 @@ import (
 	gopi "github.com/djthorpe/gopi"
 	rpc "github.com/djthorpe/gopi/sys/rpc"
 	evt "github.com/djthorpe/gopi/util/event"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	health_pb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -33,6 +37,20 @@ type ClientPool struct {
 	Timeout    time.Duration
 	Discovery  gopi.RPCServiceDiscovery
 	Service    string
+
+	// Client certificate and key, and bundle of certificate
+	// authorities for verifying servers
+	SSLCertificate string
+	SSLKey         string
+	SSLCA          string
+
+	// Bearer token sent with each call
+	Token string
+
+	// Interval between health checks of connections, and the maximum
+	// delay between attempts to reconnect unhealthy connections
+	HealthInterval time.Duration
+	Backoff        time.Duration
 }
 
 type clientpool struct {
@@ -40,13 +58,39 @@ type clientpool struct {
 	skipverify bool
 	ssl        bool
 	timeout    time.Duration
+	sslcert    string
+	sslkey     string
+	sslca      string
+	token      string
+	health     time.Duration
+	backoff    time.Duration
 	pubsub     *evt.PubSub
 	discovery  gopi.RPCServiceDiscovery
 	services   map[string]*servicetuple
 	clients    map[string]gopi.RPCNewClientFunc
-	records    map[string]*gopi.RPCServiceRecord
 	done       chan struct{}
 	lock       sync.Mutex
+
+	// Discovered service records keyed by host, port and type, which
+	// are updated in the discovery goroutine
+	records    map[string]*gopi.RPCServiceRecord
+	recordlock sync.Mutex
+
+	// Shared connections keyed by address, and the next service
+	// record for each service type
+	conns    map[string]*pooledconn
+	next     map[string]int
+	connlock sync.Mutex
+	wg       sync.WaitGroup
+}
+
+type pooledconn struct {
+	conn    *clientconn
+	record  *gopi.RPCServiceRecord
+	refs    uint
+	healthy bool
+	done    chan struct{}
+	stopped chan struct{}
 }
 
 type servicetuple struct {
@@ -68,6 +112,10 @@ func (this *clientpool) String() string {
 const (
 	DEFAULT_DISCOVERY_DEADLINE = 5 * time.Second
 	DEFAULT_DISCOVERY_DELTA    = 60 * time.Second
+	DEFAULT_HEALTH_INTERVAL    = 30 * time.Second
+	DEFAULT_HEALTH_TIMEOUT     = 5 * time.Second
+	DEFAULT_BACKOFF_MIN        = time.Second
+	DEFAULT_BACKOFF_MAX        = 60 * time.Second
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -83,15 +131,37 @@ func (config ClientPool) Services() []string {
 
 func (config ClientPool) Open(log gopi.Logger) (gopi.Driver, error) {
 	log.Debug("<grpc.clientpool>Open{ services=%v SSL=%v skipverify=%v timeout=%v mdns=%v }", config.Services(), config.SSL, config.SkipVerify, config.Timeout, config.Discovery)
+
+	// Tokens are not sent to servers which are not verified
+	if config.Token != "" && config.SSL && config.SkipVerify && config.SSLCA == "" {
+		return nil, gopi.ErrBadParameter
+	}
+
 	this := new(clientpool)
 	this.log = log
 	this.skipverify = config.SkipVerify
 	this.ssl = config.SSL
 	this.timeout = config.Timeout
+	this.sslcert = config.SSLCertificate
+	this.sslkey = config.SSLKey
+	this.sslca = config.SSLCA
+	this.token = config.Token
+	this.health = config.HealthInterval
+	this.backoff = config.Backoff
 	this.pubsub = evt.NewPubSub(0)
 	this.clients = make(map[string]gopi.RPCNewClientFunc)
 	this.discovery = config.Discovery
 	this.records = make(map[string]*gopi.RPCServiceRecord)
+	this.conns = make(map[string]*pooledconn)
+	this.next = make(map[string]int)
+
+	// Set default health check interval and backoff
+	if this.health == 0 {
+		this.health = DEFAULT_HEALTH_INTERVAL
+	}
+	if this.backoff == 0 {
+		this.backoff = DEFAULT_BACKOFF_MAX
+	}
 
 	// Start the discovery in the background if it's available
 	if this.discovery != nil {
@@ -125,14 +195,34 @@ func (this *clientpool) Close() error {
 		<-this.done
 	}
 
+	// Stop health checks and close shared connections
+	this.connlock.Lock()
+	conns := this.conns
+	this.conns = nil
+	this.connlock.Unlock()
+	for _, pooled := range conns {
+		close(pooled.done)
+	}
+	this.wg.Wait()
+	for _, pooled := range conns {
+		if err := pooled.conn.Disconnect(); err != nil {
+			this.log.Warn("grpc.clientpool: %v: %v", pooled.conn.Addr(), err)
+		}
+	}
+
 	// Release resources
 	this.pubsub.Close()
 	this.pubsub = nil
 	this.clients = nil
 	this.discovery = nil
 	this.done = nil
-	this.services = nil
+	this.recordlock.Lock()
 	this.records = nil
+	this.recordlock.Unlock()
+	this.next = nil
+	this.lock.Lock()
+	this.services = nil
+	this.lock.Unlock()
 
 	return nil
 }
@@ -143,15 +233,38 @@ func (this *clientpool) Close() error {
 func (this *clientpool) Connect(service *gopi.RPCServiceRecord, flags gopi.RPCFlag) (gopi.RPCClientConn, error) {
 	this.log.Debug2("<grpc.clientpool>Connect{ service=%v flags=%v }", service, flags)
 
+	// Critical section
+	this.connlock.Lock()
+	defer this.connlock.Unlock()
+
 	// Determine the address
-	if addr := addressFor(service, flags); addr == "" {
+	addr := addressFor(service, flags)
+	if addr == "" {
 		return nil, gopi.ErrBadParameter
-	} else if clientconn_, err := gopi.Open(ClientConn{
-		Name:       service.Name,
-		Addr:       addr + ":" + fmt.Sprint(service.Port),
-		SSL:        this.ssl,
-		SkipVerify: this.skipverify,
-		Timeout:    this.timeout,
+	} else if this.conns == nil {
+		return nil, gopi.ErrOutOfOrder
+	} else {
+		addr = net.JoinHostPort(addr, fmt.Sprint(service.Port))
+	}
+
+	// Share an existing connection
+	if pooled, exists := this.conns[addr]; exists {
+		pooled.refs++
+		return pooled.conn, nil
+	}
+
+	// Make a new connection
+	if clientconn_, err := gopi.Open(ClientConn{
+		Name:           service.Name,
+		Addr:           addr,
+		SSL:            this.ssl,
+		SkipVerify:     this.skipverify,
+		Timeout:        this.timeout,
+		SSLCertificate: this.sslcert,
+		SSLKey:         this.sslkey,
+		SSLCA:          this.sslca,
+		Token:          this.token,
+		Backoff:        this.backoff,
 	}, this.log); err != nil {
 		return nil, err
 	} else if clientconn, ok := clientconn_.(*clientconn); ok == false {
@@ -162,6 +275,12 @@ func (this *clientpool) Connect(service *gopi.RPCServiceRecord, flags gopi.RPCFl
 			return nil, err
 		}
 
+		// Share the connection and check health in the background
+		pooled := &pooledconn{clientconn, service, 1, true, make(chan struct{}), make(chan struct{})}
+		this.conns[addr] = pooled
+		this.wg.Add(1)
+		go this.healthLoop(pooled)
+
 		// Emit a connected event
 		this.emit(rpc.NewEvent(clientconn, gopi.RPC_EVENT_CLIENT_CONNECTED, service))
 
@@ -173,12 +292,136 @@ func (this *clientpool) Connect(service *gopi.RPCServiceRecord, flags gopi.RPCFl
 func (this *clientpool) Disconnect(conn gopi.RPCClientConn) error {
 	this.log.Debug2("<grpc.clientpool>Disconnect{ conn=%v }", conn)
 
+	// Release a shared connection, which is only disconnected
+	// when it is no longer used
+	var stopped chan struct{}
+	this.connlock.Lock()
+	if pooled, exists := this.conns[conn.Addr()]; exists && pooled.conn == conn {
+		if pooled.refs--; pooled.refs > 0 {
+			this.connlock.Unlock()
+			return nil
+		}
+		delete(this.conns, conn.Addr())
+		close(pooled.done)
+		stopped = pooled.stopped
+	}
+	this.connlock.Unlock()
+
+	// Wait for any health check on the connection to complete
+	if stopped != nil {
+		<-stopped
+	}
+
 	// Emit a disconnect event - event happens just before disconnect occurs
 	this.emit(rpc.NewEvent(conn, gopi.RPC_EVENT_CLIENT_DISCONNECTED, nil))
 
 	return conn.(*clientconn).Disconnect()
 }
 
+func (this *clientpool) ConnectService(service string, flags gopi.RPCFlag) (gopi.RPCClientConn, error) {
+	this.log.Debug2("<grpc.clientpool>ConnectService{ service=%v flags=%v }", service, flags)
+
+	// Obtain service type and the records for it
+	service_type := service
+	if strings.HasPrefix(service_type, "_") == false {
+		if service_type_, err := gopi.RPCServiceType(service, flags&gopi.RPC_FLAG_INET_UDP); err != nil {
+			return nil, err
+		} else {
+			service_type = service_type_
+		}
+	}
+	records := this.recordsForType(service_type, flags)
+	if len(records) == 0 {
+		return nil, gopi.ErrNotFound
+	}
+
+	// Start after the record used last time, and choose the first record
+	// which isn't known to be unhealthy
+	this.connlock.Lock()
+	next := this.next[service_type]
+	this.next[service_type] = next + 1
+	record := records[next%len(records)]
+	for i := range records {
+		candidate := records[(next+i)%len(records)]
+		addr := net.JoinHostPort(addressFor(candidate, flags), fmt.Sprint(candidate.Port))
+		if pooled, exists := this.conns[addr]; exists == false || pooled.healthy {
+			record = candidate
+			break
+		}
+	}
+	this.connlock.Unlock()
+
+	// Connect to the record
+	return this.Connect(record, flags)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// HEALTH CHECKS
+
+// healthLoop checks a connection at an interval until it is disconnected,
+// checking unhealthy connections with exponential backoff. A check
+// reconnects a connection which has failed
+func (this *clientpool) healthLoop(pooled *pooledconn) {
+	defer this.wg.Done()
+	defer close(pooled.stopped)
+	delay := DEFAULT_BACKOFF_MIN
+	timer := time.NewTimer(this.health)
+	defer timer.Stop()
+	for {
+		select {
+		case <-pooled.done:
+			return
+		case <-timer.C:
+			if err := this.healthCheck(pooled.conn); err != nil {
+				this.log.Debug("<grpc.clientpool>healthLoop{ addr=%v retry=%v }: %v", pooled.conn.Addr(), delay, err)
+				this.setHealthy(pooled, false)
+				timer.Reset(delay)
+				if delay = delay * 2; delay > this.backoff {
+					delay = this.backoff
+				}
+			} else {
+				this.setHealthy(pooled, true)
+				timer.Reset(this.health)
+				delay = DEFAULT_BACKOFF_MIN
+			}
+		}
+	}
+}
+
+// healthCheck calls the standard health check service, and servers
+// which do not implement the service are healthy when they respond
+func (this *clientpool) healthCheck(conn *clientconn) error {
+	timeout := conn.Timeout()
+	if timeout == 0 {
+		timeout = DEFAULT_HEALTH_TIMEOUT
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if reply, err := health_pb.NewHealthClient(conn.GRPCConn()).Check(ctx, &health_pb.HealthCheckRequest{}); grpc.Code(err) == codes.Unimplemented {
+		return nil
+	} else if err != nil {
+		return err
+	} else if reply.Status != health_pb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("%v", reply.Status)
+	} else {
+		return nil
+	}
+}
+
+// setHealthy sets the health of a connection and emits an event
+// when the health changes
+func (this *clientpool) setHealthy(pooled *pooledconn, healthy bool) {
+	this.connlock.Lock()
+	changed := pooled.healthy != healthy
+	pooled.healthy = healthy
+	this.connlock.Unlock()
+	if changed && healthy {
+		this.emit(rpc.NewEvent(pooled.conn, gopi.RPC_EVENT_CLIENT_HEALTHY, pooled.record))
+	} else if changed {
+		this.emit(rpc.NewEvent(pooled.conn, gopi.RPC_EVENT_CLIENT_UNHEALTHY, pooled.record))
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // CLIENTS
 
@@ -230,6 +473,7 @@ func (this *clientpool) Lookup(ctx context.Context, name, addr string, max int)
 	this.log.Debug2("<grpc.clientpool>Lookup{ name='%v' addr='%v' max='%v' }", name, addr, max)
 
 	// Make a buffered channel of all service records and put them in
+	this.recordlock.Lock()
 	records := make(chan *gopi.RPCServiceRecord, len(this.records)+2)
 	matched := make([]*gopi.RPCServiceRecord, 0, max)
 
@@ -237,6 +481,7 @@ func (this *clientpool) Lookup(ctx context.Context, name, addr string, max int)
 	for _, record := range this.records {
 		records <- record
 	}
+	this.recordlock.Unlock()
 
 	// Subscribe to receive events
 	pool_events := this.Subscribe()
@@ -281,6 +526,24 @@ FOR_LOOP:
 	}
 }
 
+// recordsForType returns copies of the discovered records of a service
+// type which can be connected to, in a consistent order
+func (this *clientpool) recordsForType(service_type string, flags gopi.RPCFlag) []*gopi.RPCServiceRecord {
+	this.recordlock.Lock()
+	defer this.recordlock.Unlock()
+	records := make([]*gopi.RPCServiceRecord, 0, len(this.records))
+	for _, record := range this.records {
+		if record.Type == service_type && record.TTL != 0 && addressFor(record, flags) != "" {
+			copy := *record
+			records = append(records, &copy)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return mdnsRecordHash(records[i]) < mdnsRecordHash(records[j])
+	})
+	return records
+}
+
 func lookupMatch(name, addr string, record *gopi.RPCServiceRecord) bool {
 	if name != "" && name == record.Name {
 		return true
@@ -397,9 +660,15 @@ func (this *clientpool) mdnsHandleEvent(evt gopi.RPCEvent) {
 	if evt.Type() != gopi.RPC_EVENT_SERVICE_RECORD {
 		return
 	}
+	// Critical section
+	this.recordlock.Lock()
+	defer this.recordlock.Unlock()
+
 	// Now create a hash for the service record
 	new_sr := evt.ServiceRecord()
-	if hash := mdnsRecordHash(new_sr); hash == "" {
+	if this.records == nil {
+		return
+	} else if hash := mdnsRecordHash(new_sr); hash == "" {
 		return
 	} else if sr, exists := this.records[hash]; exists == false {
 		this.records[hash] = new_sr
@@ -485,6 +754,11 @@ func (this *clientpool) mdnsSetCancel(service string, cancel context.CancelFunc)
 	this.lock.Lock()
 	defer this.lock.Unlock()
 
+	// When the pool has been closed, the tuple is discarded
+	if this.services == nil {
+		return &servicetuple{}
+	}
+
 	// Make a servicetuple if it doesn't exist yet
 	if _, exists := this.services[service]; exists == false {
 		this.services[service] = &servicetuple{
@@ -0,0 +1,252 @@
+package grpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	rpc "github.com/djthorpe/gopi/sys/rpc"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+	evt "github.com/djthorpe/gopi/util/event"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// discovery returns fixed service records when browsing
+type discovery struct {
+	records []*gopi.RPCServiceRecord
+	pubsub  *evt.PubSub
+}
+
+func (this *discovery) Close() error {
+	this.pubsub.Close()
+	return nil
+}
+
+func (this *discovery) Subscribe() <-chan gopi.Event {
+	return this.pubsub.Subscribe()
+}
+
+func (this *discovery) Unsubscribe(c <-chan gopi.Event) {
+	this.pubsub.Unsubscribe(c)
+}
+
+func (this *discovery) Register(service *gopi.RPCServiceRecord) error {
+	return gopi.ErrNotImplemented
+}
+
+// Browse announces the records repeatedly until the context is done
+func (this *discovery) Browse(ctx context.Context, service_type string) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, record := range this.records {
+				if record.Type == service_type {
+					this.pubsub.Emit(rpc.NewEvent(this, gopi.RPC_EVENT_SERVICE_RECORD, record))
+				}
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CLIENT POOL
+
+func TestClientPool_000(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+
+	server := openServer(t, log, grpc.Server{})
+	addr, stopped := startServer(t, server)
+	defer stopServer(server, stopped)
+
+	pool := openPool(t, log, 0, addr)
+	defer pool.Close()
+	records := lookup(t, pool, 1)
+
+	// Connections to the same record are shared
+	conn1, err := pool.Connect(records[0], gopi.RPC_FLAG_INET_V4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn2, err := pool.Connect(records[0], gopi.RPC_FLAG_INET_V4)
+	if err != nil {
+		t.Fatal(err)
+	} else if conn1 != conn2 {
+		t.Error("Expected connection to be shared")
+	}
+
+	// The connection is disconnected when no longer used
+	if err := pool.Disconnect(conn1); err != nil {
+		t.Error(err)
+	} else if conn2.Connected() == false {
+		t.Error("Expected connection to remain connected")
+	} else if err := pool.Disconnect(conn2); err != nil {
+		t.Error(err)
+	} else if conn2.Connected() {
+		t.Error("Expected connection to be disconnected")
+	}
+}
+
+func TestClientPool_001(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+
+	server := openServer(t, log, grpc.Server{})
+	addr, stopped := startServer(t, server)
+	defer stopServer(server, stopped)
+
+	pool := openPool(t, log, 50*time.Millisecond, addr)
+	defer pool.Close()
+	records := lookup(t, pool, 1)
+	conn, err := pool.Connect(records[0], gopi.RPC_FLAG_INET_V4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Disconnect(conn)
+	events := pool.Subscribe()
+	defer pool.Unsubscribe(events)
+
+	// Health changes are emitted as events
+	server.(gopi.RPCHealth).SetServing("", false)
+	waitForEvent(t, events, gopi.RPC_EVENT_CLIENT_UNHEALTHY)
+	server.(gopi.RPCHealth).SetServing("", true)
+	waitForEvent(t, events, gopi.RPC_EVENT_CLIENT_HEALTHY)
+}
+
+func TestClientPool_002(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+
+	server1 := openServer(t, log, grpc.Server{})
+	addr1, stopped1 := startServer(t, server1)
+	defer stopServer(server1, stopped1)
+	server2 := openServer(t, log, grpc.Server{})
+	addr2, stopped2 := startServer(t, server2)
+	defer stopServer(server2, stopped2)
+
+	pool := openPool(t, log, 50*time.Millisecond, addr1, addr2)
+	defer pool.Close()
+	lookup(t, pool, 2)
+
+	// Connections are balanced between records
+	conns := make(map[string]gopi.RPCClientConn)
+	for i := 0; i < 2; i++ {
+		if conn, err := pool.ConnectService("test", gopi.RPC_FLAG_INET_V4); err != nil {
+			t.Fatal(err)
+		} else {
+			conns[conn.Addr()] = conn
+			defer pool.Disconnect(conn)
+		}
+	}
+	if len(conns) != 2 {
+		t.Fatal("Expected two connections, got", conns)
+	}
+
+	// Unhealthy connections are avoided
+	events := pool.Subscribe()
+	server1.(gopi.RPCHealth).SetServing("", false)
+	waitForEvent(t, events, gopi.RPC_EVENT_CLIENT_UNHEALTHY)
+	pool.Unsubscribe(events)
+	for i := 0; i < 2; i++ {
+		if conn, err := pool.ConnectService("test", gopi.RPC_FLAG_INET_V4); err != nil {
+			t.Fatal(err)
+		} else if conn.Addr() != addr2 {
+			t.Error("Expected connection to healthy server, got", conn.Addr())
+		} else {
+			pool.Disconnect(conn)
+		}
+	}
+}
+
+func TestClientPool_003(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+
+	server := openServer(t, log, grpc.Server{})
+	addr, stopped := startServer(t, server)
+	defer stopServer(server, stopped)
+
+	// Disconnect waits for health checks which are running
+	pool := openPool(t, log, time.Millisecond, addr)
+	defer pool.Close()
+	records := lookup(t, pool, 1)
+	for i := 0; i < 20; i++ {
+		if conn, err := pool.Connect(records[0], gopi.RPC_FLAG_INET_V4); err != nil {
+			t.Fatal(err)
+		} else {
+			time.Sleep(time.Duration(i%5) * time.Millisecond)
+			if err := pool.Disconnect(conn); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN
+
+// openPool returns a client pool which discovers a service record
+// for each address
+func openPool(t *testing.T, log gopi.Logger, interval time.Duration, addrs ...string) gopi.RPCClientPool {
+	discovery := &discovery{pubsub: evt.NewPubSub(0)}
+	for _, addr := range addrs {
+		if tcpaddr, err := net.ResolveTCPAddr("tcp", addr); err != nil {
+			t.Fatal(err)
+		} else {
+			discovery.records = append(discovery.records, &gopi.RPCServiceRecord{
+				Name: addr,
+				Type: "_test._tcp",
+				Host: "localhost.",
+				Port: uint(tcpaddr.Port),
+				IP4:  []net.IP{tcpaddr.IP},
+				TTL:  time.Minute,
+			})
+		}
+	}
+	if driver, err := gopi.Open(grpc.ClientPool{
+		Discovery:      discovery,
+		Service:        "test",
+		HealthInterval: interval,
+		Timeout:        time.Second,
+	}, log); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return driver.(gopi.RPCClientPool)
+	}
+}
+
+func lookup(t *testing.T, pool gopi.RPCClientPool, count int) []*gopi.RPCServiceRecord {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if records, err := pool.Lookup(ctx, "", "", count); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return records
+	}
+}
+
+func waitForEvent(t *testing.T, events <-chan gopi.Event, event_type gopi.RPCEventType) {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case evt := <-events:
+			if rpc_event, ok := evt.(gopi.RPCEvent); ok && rpc_event.Type() == event_type {
+				return
+			}
+		case <-timeout:
+			t.Fatal("Timeout waiting for", event_type)
+			return
+		}
+	}
+}
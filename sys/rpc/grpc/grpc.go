@@ -30,6 +30,13 @@ type GRPCServer interface {
 	GRPCServer() *grpc.Server
 }
 
+// GRPCAuthorizer is a GRPCServer which restricts services
+// and methods to some client identities
+type GRPCAuthorizer interface {
+	// Add identities which can call services and methods
+	Authorize(Authorization)
+}
+
 // GRPCClientConn is an RPCClientConn which also
 // returns gRPC-specific properties
 type GRPCClientConn interface {
@@ -61,3 +68,37 @@ func IsErrDeadlineExceeded(err error) bool {
 	}
 	return grpc.Code(err) == codes.DeadlineExceeded
 }
+
+// ErrorToStatus returns errors from drivers with status codes, so
+// that clients can return the same errors
+func ErrorToStatus(err error) error {
+	switch err {
+	case nil:
+		return nil
+	case gopi.ErrNotImplemented:
+		return grpc.Errorf(codes.Unimplemented, "%v", err)
+	case gopi.ErrBadParameter:
+		return grpc.Errorf(codes.InvalidArgument, "%v", err)
+	case gopi.ErrNotFound:
+		return grpc.Errorf(codes.NotFound, "%v", err)
+	default:
+		return err
+	}
+}
+
+// ErrorFromStatus returns the driver errors for status codes
+func ErrorFromStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch grpc.Code(err) {
+	case codes.Unimplemented:
+		return gopi.ErrNotImplemented
+	case codes.InvalidArgument:
+		return gopi.ErrBadParameter
+	case codes.NotFound:
+		return gopi.ErrNotFound
+	default:
+		return err
+	}
+}
@@ -0,0 +1,453 @@
+package grpc_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	logger "github.com/djthorpe/gopi/sys/logger"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+	certificate "github.com/djthorpe/gopi/util/certificate"
+	gogrpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	credentials "google.golang.org/grpc/credentials"
+	peer "google.golang.org/grpc/peer"
+	reflection_pb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// AUTHORIZATION
+
+func TestAuthorization_000(t *testing.T) {
+	if authorization, err := grpc.ParseAuthorization("a.Service=client, a.Service=other,b.Service=*"); err != nil {
+		t.Error(err)
+	} else if len(authorization["a.Service"]) != 2 || authorization["b.Service"][0] != grpc.AUTHORIZE_ANY {
+		t.Error("Unexpected authorization", authorization)
+	}
+	if _, err := grpc.ParseAuthorization("a.Service"); err == nil {
+		t.Error("Expected error for missing identity")
+	}
+}
+
+func TestAuthorization_001(t *testing.T) {
+	if authorization, err := grpc.ParseMethodAuthorization("a.Service", "Get=client,*=admin"); err != nil {
+		t.Error(err)
+	} else if authorization["a.Service/Get"][0] != "client" || authorization["a.Service"][0] != "admin" {
+		t.Error("Unexpected authorization", authorization)
+	}
+	if _, err := grpc.ParseMethodAuthorization("a.Service", "b.Service/Get=client"); err == nil {
+		t.Error("Expected error for method of another service")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// TOKENS
+
+func TestTokens_000(t *testing.T) {
+	tokens := grpc.HMACTokens{Secret: []byte("secret")}
+	if token, err := tokens.Token("client", time.Time{}); err != nil {
+		t.Error(err)
+	} else if identity, err := tokens.Authenticate(token); err != nil {
+		t.Error(err)
+	} else if identity != "client" {
+		t.Error("Unexpected identity", identity)
+	} else if _, err := (grpc.HMACTokens{Secret: []byte("other")}).Authenticate(token); err != grpc.ErrInvalidToken {
+		t.Error("Expected ErrInvalidToken, got", err)
+	} else if _, err := tokens.Authenticate(strings.Replace(token, ".0.", ".1.", 1)); err != grpc.ErrInvalidToken {
+		t.Error("Expected ErrInvalidToken, got", err)
+	}
+	if token, err := tokens.Token("client", time.Now().Add(-time.Second)); err != nil {
+		t.Error(err)
+	} else if _, err := tokens.Authenticate(token); err != grpc.ErrExpiredToken {
+		t.Error("Expected ErrExpiredToken, got", err)
+	}
+	if _, err := (grpc.HMACTokens{}).Token("client", time.Time{}); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+}
+
+func TestTokens_001(t *testing.T) {
+	if tokens, err := grpc.ParseStaticTokens("client=abc, admin=xyz"); err != nil {
+		t.Error(err)
+	} else if identity, err := tokens.Authenticate("xyz"); err != nil || identity != "admin" {
+		t.Error("Unexpected identity", identity, err)
+	} else if _, err := tokens.Authenticate("xy"); err != grpc.ErrInvalidToken {
+		t.Error("Expected ErrInvalidToken, got", err)
+	}
+
+	// Try static tokens then signed tokens
+	hmac := grpc.HMACTokens{Secret: []byte("secret")}
+	authenticator := grpc.Authenticators{grpc.StaticTokens{"client": "abc"}, hmac}
+	if token, err := hmac.Token("signed", time.Time{}); err != nil {
+		t.Error(err)
+	} else if identity, err := authenticator.Authenticate(token); err != nil || identity != "signed" {
+		t.Error("Unexpected identity", identity, err)
+	} else if identity, err := authenticator.Authenticate("abc"); err != nil || identity != "client" {
+		t.Error("Unexpected identity", identity, err)
+	} else if _, err := authenticator.Authenticate("xyz"); err != grpc.ErrInvalidToken {
+		t.Error("Expected ErrInvalidToken, got", err)
+	}
+}
+
+func TestTokens_002(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+
+	// Reflection can only be called by the admin, which is
+	// authorized after the server is opened
+	server := openServer(t, log, grpc.Server{
+		Authenticator: grpc.StaticTokens{"client": "abc", "admin": "xyz"},
+	})
+	server.(grpc.GRPCAuthorizer).Authorize(grpc.Authorization{
+		"grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo": []string{"admin"},
+	})
+	addr, stopped := startServer(t, server)
+	defer stopServer(server, stopped)
+
+	for _, test := range []struct {
+		token string
+		code  codes.Code
+	}{
+		{"xyz", codes.OK},
+		{"abc", codes.PermissionDenied},
+		{"123", codes.Unauthenticated},
+		{"", codes.Unauthenticated},
+	} {
+		conn := openClient(t, log, grpc.ClientConn{Addr: addr, Token: test.token, Timeout: 5 * time.Second})
+		if _, err := conn.Services(); gogrpc.Code(err) != test.code {
+			t.Errorf("%v: Expected %v, got %v", test.token, test.code, err)
+		}
+		conn.Close()
+	}
+}
+
+func TestTokens_003(t *testing.T) {
+	path := tempDir(t)
+	defer os.RemoveAll(path)
+
+	// Secrets are read from a file, or the environment when there is no file
+	file := filepath.Join(path, "tokens")
+	if err := ioutil.WriteFile(file, []byte("client=abc\nadmin=xyz\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("GOPI_TEST_TOKENS", " client=env ")
+	defer os.Unsetenv("GOPI_TEST_TOKENS")
+	if value, err := grpc.ReadSecret(file, "GOPI_TEST_TOKENS"); err != nil {
+		t.Error(err)
+	} else if tokens, err := grpc.ParseStaticTokens(value); err != nil {
+		t.Error(err)
+	} else if identity, err := tokens.Authenticate("xyz"); err != nil || identity != "admin" {
+		t.Error("Unexpected identity", identity, err)
+	}
+	if value, err := grpc.ReadSecret("", "GOPI_TEST_TOKENS"); err != nil || value != "client=env" {
+		t.Error("Unexpected secret", value, err)
+	}
+	if _, err := grpc.ReadSecret(filepath.Join(path, "missing"), "GOPI_TEST_TOKENS"); err == nil {
+		t.Error("Expected error for missing file")
+	}
+
+	// Errors do not include the secret
+	if _, err := grpc.ParseStaticTokens("client=abc,secret"); err == nil || strings.Contains(err.Error(), "secret") {
+		t.Error("Unexpected error", err)
+	}
+}
+
+func TestTokens_004(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+
+	// Tokens are not sent to servers which are not verified
+	if _, err := gopi.Open(grpc.ClientConn{Addr: "localhost:0", SSL: true, SkipVerify: true, Token: "abc"}, log); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	if _, err := gopi.Open(grpc.ClientPool{SSL: true, SkipVerify: true, Token: "abc"}, log); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	for _, config := range []grpc.ClientConn{
+		{Addr: "localhost:0", SSL: true, Token: "abc"},
+		{Addr: "localhost:0", SSL: true, SkipVerify: true, SSLCA: "ca.pem", Token: "abc"},
+		{Addr: "localhost:0", SSL: true, SkipVerify: true},
+	} {
+		if conn, err := gopi.Open(config, log); err != nil {
+			t.Error(err)
+		} else {
+			conn.Close()
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// MUTUAL TLS
+
+func TestMutualTLS_000(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	path := tempDir(t)
+	defer os.RemoveAll(path)
+	certs := newCertificates(t, path)
+
+	// Reflection can only be called by the allowed client
+	server := openServer(t, log, grpc.Server{
+		SSLCertificate: certs["server"].cert,
+		SSLKey:         certs["server"].key,
+		SSLCA:          certs["ca"].cert,
+		Authorization: grpc.Authorization{
+			"grpc.reflection.v1alpha.ServerReflection": []string{"allowed"},
+		},
+	})
+	addr, stopped := startServer(t, server)
+	defer stopServer(server, stopped)
+
+	for _, test := range []struct {
+		client string
+		code   codes.Code
+	}{
+		{"allowed", codes.OK},
+		{"denied", codes.PermissionDenied},
+		{"rogue", codes.Unavailable},
+		{"", codes.Unavailable},
+	} {
+		config := grpc.ClientConn{Addr: addr, SSL: true, SSLCA: certs["ca"].cert, Timeout: 5 * time.Second}
+		if test.client != "" {
+			config.SSLCertificate, config.SSLKey = certs[test.client].cert, certs[test.client].key
+		}
+		conn := openClient(t, log, config)
+		services, err := conn.Services()
+		if code := gogrpc.Code(err); code != test.code {
+			t.Errorf("%v: Expected %v, got %v (%v)", test.client, test.code, code, err)
+		} else if test.code == codes.OK && len(services) == 0 {
+			t.Errorf("%v: Expected services", test.client)
+		}
+		conn.Close()
+	}
+}
+
+func TestMutualTLS_001(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	path := tempDir(t)
+	defer os.RemoveAll(path)
+	certs := newCertificates(t, path)
+
+	server := openServer(t, log, grpc.Server{
+		SSLCertificate: certs["server"].cert,
+		SSLKey:         certs["server"].key,
+		SSLCA:          certs["ca"].cert,
+	})
+	addr, stopped := startServer(t, server)
+	defer stopServer(server, stopped)
+	if name := serverName(t, addr, certs); name != "server" {
+		t.Error("Unexpected server certificate", name)
+	}
+
+	// Replace the server certificate, which is used for new connections
+	ca := loadCertificate(t, certs["ca"])
+	writeCertificate(t, certs["server"], certificate.Request{CommonName: "renewed", Hosts: []string{"127.0.0.1"}}, ca)
+	modtime := time.Now().Add(time.Minute)
+	if err := os.Chtimes(certs["server"].cert, modtime, modtime); err != nil {
+		t.Fatal(err)
+	}
+	if name := serverName(t, addr, certs); name != "renewed" {
+		t.Error("Unexpected server certificate", name)
+	}
+}
+
+func TestMutualTLS_002(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	path := tempDir(t)
+	defer os.RemoveAll(path)
+
+	// Generate a self-signed certificate on first run
+	certfile, keyfile := filepath.Join(path, "ssl", "cert.pem"), filepath.Join(path, "ssl", "key.pem")
+	server := openServer(t, log, grpc.Server{SSLCertificate: certfile, SSLKey: keyfile, SSLGenerate: true})
+	defer server.Close()
+	if _, err := tls.LoadX509KeyPair(certfile, keyfile); err != nil {
+		t.Error(err)
+	}
+
+	// Authorization requires a bundle
+	if _, err := gopi.Open(grpc.Server{SSLCertificate: certfile, SSLKey: keyfile, Authorization: grpc.Authorization{"a": []string{"b"}}}, log); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CERTIFICATES
+
+type keypair struct {
+	cert, key string
+}
+
+// newCertificates writes a certificate authority, and server and client
+// certificates which it signs, and a self-signed client certificate
+func newCertificates(t *testing.T, path string) map[string]keypair {
+	certs := make(map[string]keypair)
+	for _, name := range []string{"ca", "server", "allowed", "denied", "rogue"} {
+		certs[name] = keypair{filepath.Join(path, name+".pem"), filepath.Join(path, name+".key")}
+	}
+	writeCertificate(t, certs["ca"], certificate.Request{CommonName: "ca", CA: true}, nil)
+	ca := loadCertificate(t, certs["ca"])
+	writeCertificate(t, certs["server"], certificate.Request{CommonName: "server", Hosts: []string{"127.0.0.1"}}, ca)
+	writeCertificate(t, certs["allowed"], certificate.Request{CommonName: "allowed"}, ca)
+	writeCertificate(t, certs["denied"], certificate.Request{CommonName: "denied"}, ca)
+	writeCertificate(t, certs["rogue"], certificate.Request{CommonName: "allowed"}, nil)
+	return certs
+}
+
+func writeCertificate(t *testing.T, pair keypair, req certificate.Request, parent *tls.Certificate) {
+	if cert, key, err := certificate.Generate(req, parent); err != nil {
+		t.Fatal(err)
+	} else if err := ioutil.WriteFile(pair.cert, cert, 0644); err != nil {
+		t.Fatal(err)
+	} else if err := ioutil.WriteFile(pair.key, key, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func loadCertificate(t *testing.T, pair keypair) *tls.Certificate {
+	if cert, err := tls.LoadX509KeyPair(pair.cert, pair.key); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return &cert
+	}
+}
+
+// serverName returns the common name of the server certificate
+func serverName(t *testing.T, addr string, certs map[string]keypair) string {
+	data, err := ioutil.ReadFile(certs["ca"].cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(data)
+	ca, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	client := loadCertificate(t, certs["allowed"])
+	conn, err := gogrpc.Dial(addr, gogrpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{*client},
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Call reflection and return the peer certificate
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var p peer.Peer
+	stream, err := reflection_pb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx, gogrpc.Peer(&p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(&reflection_pb.ServerReflectionRequest{MessageRequest: &reflection_pb.ServerReflectionRequest_ListServices{}}); err != nil {
+		t.Fatal(err)
+	} else if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	} else if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The peer is set once the stream has ended
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatal("Expected EOF, got", err)
+	}
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok == false || len(info.State.PeerCertificates) == 0 {
+		t.Fatal("Missing peer certificate")
+		return ""
+	} else {
+		return info.State.PeerCertificates[0].Subject.CommonName
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN
+
+func openServer(t *testing.T, log gopi.Logger, config grpc.Server) gopi.RPCServer {
+	if driver, err := gopi.Open(config, log); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return driver.(gopi.RPCServer)
+	}
+}
+
+// startServer starts the server in the background and returns the
+// loopback or socket address once it is listening, and a channel which
+// receives the result of serving
+func startServer(t *testing.T, server gopi.RPCServer) (string, <-chan error) {
+	events := server.Subscribe()
+	defer server.Unsubscribe(events)
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Start()
+	}()
+	select {
+	case <-events:
+		if addr, ok := server.Addr().(*net.TCPAddr); ok {
+			return fmt.Sprintf("127.0.0.1:%v", addr.Port), stopped
+		} else {
+			return server.Addr().String(), stopped
+		}
+	case err := <-stopped:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for server to start")
+	}
+	return "", nil
+}
+
+// stopServer halts the server and waits for serving to end
+// before closing it
+func stopServer(server gopi.RPCServer, stopped <-chan error) {
+	server.Stop(true)
+	<-stopped
+	server.Close()
+}
+
+func openClient(t *testing.T, log gopi.Logger, config grpc.ClientConn) gopi.RPCClientConn {
+	if driver, err := gopi.Open(config, log); err != nil {
+		t.Fatal(err)
+		return nil
+	} else if err := driver.(interface{ Connect() error }).Connect(); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return driver.(gopi.RPCClientConn)
+	}
+}
+
+func openLogger(t *testing.T) gopi.Logger {
+	if log, err := gopi.Open(logger.Config{}, nil); err != nil {
+		t.Fatal("Unable to create logger driver")
+		return nil
+	} else {
+		return log.(gopi.Logger)
+	}
+}
+
+func tempDir(t *testing.T) string {
+	if path, err := ioutil.TempDir("", "grpc"); err != nil {
+		t.Fatal(err)
+		return ""
+	} else {
+		return path
+	}
+}
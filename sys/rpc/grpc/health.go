@@ -0,0 +1,154 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package grpc
+
+import (
+	"context"
+	"sync"
+
+	// Frameworks
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	health_pb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// healthservice implements grpc.health.v1.Health, where the empty
+// service name is the health of the server. Services are serving unless
+// they report otherwise, and nothing is serving when the server is
+// stopping
+type healthservice struct {
+	server   *grpc.Server
+	serving  map[string]bool
+	stopping bool
+	watchers map[chan struct{}]bool
+	done     chan struct{}
+	lock     sync.Mutex
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	HEALTH_SERVICE = "grpc.health.v1.Health"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+func newHealthService(server *grpc.Server) *healthservice {
+	this := new(healthservice)
+	this.server = server
+	this.serving = make(map[string]bool)
+	this.watchers = make(map[chan struct{}]bool)
+	this.stopping = true
+	health_pb.RegisterHealthServer(server, this)
+	return this
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STATUS
+
+// setServing sets whether a service is serving, or when the service
+// is empty, whether the server is serving
+func (this *healthservice) setServing(service string, serving bool) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if service == "" {
+		this.stopping = (serving == false)
+		if serving && this.done == nil {
+			this.done = make(chan struct{})
+		} else if serving == false && this.done != nil {
+			// End watches so that the server can stop gracefully
+			close(this.done)
+			this.done = nil
+		}
+	} else {
+		this.serving[service] = serving
+	}
+	for watcher := range this.watchers {
+		select {
+		case watcher <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// status returns the status of a service, which is unknown
+// when the service is not registered
+func (this *healthservice) status(service string) health_pb.HealthCheckResponse_ServingStatus {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if service != "" {
+		if _, exists := this.server.GetServiceInfo()[service]; exists == false {
+			return health_pb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+	}
+	if this.stopping {
+		return health_pb.HealthCheckResponse_NOT_SERVING
+	} else if serving, exists := this.serving[service]; exists && serving == false {
+		return health_pb.HealthCheckResponse_NOT_SERVING
+	} else {
+		return health_pb.HealthCheckResponse_SERVING
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// HEALTH SERVICE
+
+func (this *healthservice) Check(ctx context.Context, req *health_pb.HealthCheckRequest) (*health_pb.HealthCheckResponse, error) {
+	if status := this.status(req.Service); status == health_pb.HealthCheckResponse_SERVICE_UNKNOWN {
+		return nil, grpc.Errorf(codes.NotFound, "Unknown service: %v", req.Service)
+	} else {
+		return &health_pb.HealthCheckResponse{Status: status}, nil
+	}
+}
+
+func (this *healthservice) Watch(req *health_pb.HealthCheckRequest, stream health_pb.Health_WatchServer) error {
+	changed := make(chan struct{}, 1)
+	this.lock.Lock()
+	this.watchers[changed] = true
+	done := this.done
+	this.lock.Unlock()
+	defer func() {
+		this.lock.Lock()
+		delete(this.watchers, changed)
+		this.lock.Unlock()
+	}()
+
+	// Send the status, and then send it whenever it changes
+	// until the client or the server ends the watch
+	last := health_pb.HealthCheckResponse_ServingStatus(-1)
+	for {
+		if status := this.status(req.Service); status != last {
+			if err := stream.Send(&health_pb.HealthCheckResponse{Status: status}); err != nil {
+				return err
+			}
+			last = status
+		}
+		if done == nil {
+			return grpc.Errorf(codes.Unavailable, "Server stopped")
+		}
+		select {
+		case <-changed:
+			continue
+		case <-stream.Context().Done():
+			return grpc.Errorf(codes.Canceled, "Watch canceled")
+		case <-done:
+			// Send the final status
+			if status := this.status(req.Service); status != last {
+				stream.Send(&health_pb.HealthCheckResponse{Status: status})
+			}
+			return grpc.Errorf(codes.Unavailable, "Server stopping")
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package grpc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+	gogrpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	health_pb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// HEALTH
+
+func TestHealth_000(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+
+	server := openServer(t, log, grpc.Server{})
+	addr, stopped := startServer(t, server)
+	defer stopServer(server, stopped)
+	client, conn := openHealthClient(t, addr)
+	defer conn.Close()
+
+	// Registered services are serving until they report otherwise
+	reflection := "grpc.reflection.v1alpha.ServerReflection"
+	checkHealth(t, client, "", health_pb.HealthCheckResponse_SERVING)
+	checkHealth(t, client, reflection, health_pb.HealthCheckResponse_SERVING)
+	server.(gopi.RPCHealth).SetServing(reflection, false)
+	checkHealth(t, client, reflection, health_pb.HealthCheckResponse_NOT_SERVING)
+	checkHealth(t, client, "", health_pb.HealthCheckResponse_SERVING)
+	server.(gopi.RPCHealth).SetServing(reflection, true)
+	checkHealth(t, client, reflection, health_pb.HealthCheckResponse_SERVING)
+
+	// Unknown services are not found
+	if _, err := client.Check(context.Background(), &health_pb.HealthCheckRequest{Service: "unknown.Service"}); gogrpc.Code(err) != codes.NotFound {
+		t.Error("Expected NotFound, got", err)
+	}
+}
+
+func TestHealth_001(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+
+	server := openServer(t, log, grpc.Server{})
+	addr, stopped := startServer(t, server)
+	defer server.Close()
+	client, conn := openHealthClient(t, addr)
+	defer conn.Close()
+
+	// Watch the health of the server
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	watch, err := client.Watch(ctx, &health_pb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	} else if reply, err := watch.Recv(); err != nil {
+		t.Fatal(err)
+	} else if reply.Status != health_pb.HealthCheckResponse_SERVING {
+		t.Error("Expected SERVING, got", reply.Status)
+	}
+
+	// Stopping gracefully reports that the server is not serving,
+	// and ends the watch so that the server can stop
+	go server.Stop(false)
+	if reply, err := watch.Recv(); err != nil {
+		t.Fatal(err)
+	} else if reply.Status != health_pb.HealthCheckResponse_NOT_SERVING {
+		t.Error("Expected NOT_SERVING, got", reply.Status)
+	}
+	if _, err := watch.Recv(); gogrpc.Code(err) != codes.Unavailable {
+		t.Error("Expected Unavailable, got", err)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Error("Timeout waiting for server to stop")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CLIENT
+
+func openHealthClient(t *testing.T, addr string) (health_pb.HealthClient, *gogrpc.ClientConn) {
+	if conn, err := gogrpc.Dial(addr, gogrpc.WithInsecure()); err != nil {
+		t.Fatal(err)
+		return nil, nil
+	} else {
+		return health_pb.NewHealthClient(conn), conn
+	}
+}
+
+func checkHealth(t *testing.T, client health_pb.HealthClient, service string, status health_pb.HealthCheckResponse_ServingStatus) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if reply, err := client.Check(ctx, &health_pb.HealthCheckRequest{Service: service}); err != nil {
+		t.Error(err)
+	} else if reply.Status != status {
+		t.Errorf("%v: Expected %v, got %v", service, status, reply.Status)
+	}
+}
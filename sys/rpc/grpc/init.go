@@ -10,11 +10,26 @@
 package grpc
 
 import (
+	"fmt"
+	"os"
+	"strconv"
+
 	// Frameworks
 	gopi "github.com/djthorpe/gopi"
 	grpc "google.golang.org/grpc"
 )
 
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Environment variables for secrets, which are read when the
+	// flags for the secret files are empty
+	ENV_RPC_TOKENS     = "GOPI_RPC_TOKENS"
+	ENV_RPC_HMACSECRET = "GOPI_RPC_HMACSECRET"
+	ENV_RPC_TOKEN      = "GOPI_RPC_TOKEN"
+)
+
 ////////////////////////////////////////////////////////////////////////////////
 // INIT
 
@@ -25,19 +40,51 @@ func init() {
 		Type: gopi.MODULE_TYPE_OTHER,
 		Config: func(config *gopi.AppConfig) {
 			config.AppFlags.FlagUint("rpc.port", 0, "Server Port")
+			config.AppFlags.FlagString("rpc.socket", "", "Server unix socket path")
+			config.AppFlags.FlagString("rpc.socketmode", "0660", "Server unix socket permissions")
 			config.AppFlags.FlagString("rpc.sslcert", "", "SSL Certificate Path")
 			config.AppFlags.FlagString("rpc.sslkey", "", "SSL Key Path")
+			config.AppFlags.FlagString("rpc.sslca", "", "SSL Certificate Authorities Path for client certificates")
+			config.AppFlags.FlagBool("rpc.sslgenerate", false, "Generate self-signed SSL Certificate and Key")
+			config.AppFlags.FlagString("rpc.authorize", "", "Comma-separated list of service=identity or service/method=identity pairs")
+			config.AppFlags.FlagString("rpc.tokens", "", "Path to identity=token pairs, or "+ENV_RPC_TOKENS+" when empty")
+			config.AppFlags.FlagString("rpc.hmacsecret", "", "Path to shared secret for signed tokens, or "+ENV_RPC_HMACSECRET+" when empty")
 		},
 		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
 			port, _ := app.AppFlags.GetUint("rpc.port")
+			path, _ := app.AppFlags.GetString("rpc.socket")
+			mode_, _ := app.AppFlags.GetString("rpc.socketmode")
 			key, _ := app.AppFlags.GetString("rpc.sslkey")
 			cert, _ := app.AppFlags.GetString("rpc.sslcert")
-			return gopi.Open(Server{
-				Port:           port,
-				SSLCertificate: cert,
-				SSLKey:         key,
-				ServerOption:   []grpc.ServerOption{},
-			}, app.Logger)
+			ca, _ := app.AppFlags.GetString("rpc.sslca")
+			generate, _ := app.AppFlags.GetBool("rpc.sslgenerate")
+			authorize, _ := app.AppFlags.GetString("rpc.authorize")
+			tokens_, _ := app.AppFlags.GetString("rpc.tokens")
+			secret_, _ := app.AppFlags.GetString("rpc.hmacsecret")
+			if mode, err := strconv.ParseUint(mode_, 8, 32); err != nil {
+				return nil, fmt.Errorf("Invalid -rpc.socketmode: %v", mode_)
+			} else if tokens, err := ReadSecret(tokens_, ENV_RPC_TOKENS); err != nil {
+				return nil, err
+			} else if secret, err := ReadSecret(secret_, ENV_RPC_HMACSECRET); err != nil {
+				return nil, err
+			} else if authorization, err := ParseAuthorization(authorize); err != nil {
+				return nil, err
+			} else if authenticator, err := newAuthenticator(tokens, secret); err != nil {
+				return nil, err
+			} else {
+				return gopi.Open(Server{
+					Port:           port,
+					Path:           path,
+					Mode:           os.FileMode(mode),
+					SSLCertificate: cert,
+					SSLKey:         key,
+					SSLCA:          ca,
+					SSLGenerate:    generate,
+					Authenticator:  authenticator,
+					Authorization:  authorization,
+					ServerOption:   []grpc.ServerOption{},
+				}, app.Logger)
+			}
 		},
 	})
 
@@ -48,24 +95,51 @@ func init() {
 		Requires: []string{"mdns"},
 		Config: func(config *gopi.AppConfig) {
 			config.AppFlags.FlagBool("rpc.insecure", false, "Disable SSL Connection")
-			config.AppFlags.FlagBool("rpc.skipverify", true, "Skip SSL Verification")
+			config.AppFlags.FlagBool("rpc.skipverify", true, "Skip SSL Verification, except when a token is sent")
 			config.AppFlags.FlagDuration("rpc.timeout", 0, "Connection timeout")
 			config.AppFlags.FlagString("rpc.service", "", "Comma-separated list of service names")
+			config.AppFlags.FlagString("rpc.clientcert", "", "SSL Client Certificate Path")
+			config.AppFlags.FlagString("rpc.clientkey", "", "SSL Client Key Path")
+			config.AppFlags.FlagString("rpc.clientca", "", "SSL Certificate Authorities Path for servers")
+			config.AppFlags.FlagString("rpc.token", "", "Path to token sent with each call, or "+ENV_RPC_TOKEN+" when empty")
+			config.AppFlags.FlagDuration("rpc.health", DEFAULT_HEALTH_INTERVAL, "Interval between connection health checks")
+			config.AppFlags.FlagDuration("rpc.backoff", DEFAULT_BACKOFF_MAX, "Maximum delay between reconnection attempts")
 		},
 		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
 			insecure, _ := app.AppFlags.GetBool("rpc.insecure")
-			skipverify, _ := app.AppFlags.GetBool("rpc.skipverify")
+			skipverify, skipverify_set := app.AppFlags.GetBool("rpc.skipverify")
 			timeout, _ := app.AppFlags.GetDuration("rpc.timeout")
 			service, _ := app.AppFlags.GetString("rpc.service")
+			cert, _ := app.AppFlags.GetString("rpc.clientcert")
+			key, _ := app.AppFlags.GetString("rpc.clientkey")
+			ca, _ := app.AppFlags.GetString("rpc.clientca")
+			token_, _ := app.AppFlags.GetString("rpc.token")
+			health, _ := app.AppFlags.GetDuration("rpc.health")
+			backoff, _ := app.AppFlags.GetDuration("rpc.backoff")
 			if service == "" {
 				service = app.Service()
 			}
+			token, err := ReadSecret(token_, ENV_RPC_TOKEN)
+			if err != nil {
+				return nil, err
+			}
+			// Servers are verified before a token is sent to them,
+			// so verification is only skipped by default without a token
+			if token != "" && skipverify_set == false {
+				skipverify = false
+			}
 			return gopi.Open(ClientPool{
-				Discovery:  app.ModuleInstance("mdns").(gopi.RPCServiceDiscovery),
-				SkipVerify: skipverify,
-				SSL:        (insecure == false),
-				Timeout:    timeout,
-				Service:    service,
+				Discovery:      app.ModuleInstance("mdns").(gopi.RPCServiceDiscovery),
+				SkipVerify:     skipverify,
+				SSL:            (insecure == false),
+				Timeout:        timeout,
+				Service:        service,
+				SSLCertificate: cert,
+				SSLKey:         key,
+				SSLCA:          ca,
+				Token:          token,
+				HealthInterval: health,
+				Backoff:        backoff,
 			}, app.Logger)
 		},
 	})
@@ -95,3 +169,25 @@ func init() {
 			},
 		})*/
 }
+
+// newAuthenticator returns static and signed token authenticators,
+// or nil when neither is configured
+func newAuthenticator(tokens, secret string) (Authenticator, error) {
+	authenticators := Authenticators{}
+	if static, err := ParseStaticTokens(tokens); err != nil {
+		return nil, err
+	} else if len(static) > 0 {
+		authenticators = append(authenticators, static)
+	}
+	if secret != "" {
+		authenticators = append(authenticators, HMACTokens{Secret: []byte(secret)})
+	}
+	switch len(authenticators) {
+	case 0:
+		return nil, nil
+	case 1:
+		return authenticators[0], nil
+	default:
+		return authenticators, nil
+	}
+}
@@ -0,0 +1,64 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package grpc
+
+import (
+	"context"
+
+	// Frameworks
+	grpc "google.golang.org/grpc"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// CHAIN INTERCEPTORS
+
+// chainUnaryServer returns an interceptor which calls the interceptors
+// in order, or nil when there are no interceptors
+func chainUnaryServer(interceptors ...grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	switch len(interceptors) {
+	case 0:
+		return nil
+	case 1:
+		return interceptors[0]
+	default:
+		return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			next := handler
+			for i := len(interceptors) - 1; i > 0; i-- {
+				interceptor, handler := interceptors[i], next
+				next = func(ctx context.Context, req interface{}) (interface{}, error) {
+					return interceptor(ctx, req, info, handler)
+				}
+			}
+			return interceptors[0](ctx, req, info, next)
+		}
+	}
+}
+
+// chainStreamServer returns an interceptor which calls the interceptors
+// in order, or nil when there are no interceptors
+func chainStreamServer(interceptors ...grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	switch len(interceptors) {
+	case 0:
+		return nil
+	case 1:
+		return interceptors[0]
+	default:
+		return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			next := handler
+			for i := len(interceptors) - 1; i > 0; i-- {
+				interceptor, handler := interceptors[i], next
+				next = func(srv interface{}, stream grpc.ServerStream) error {
+					return interceptor(srv, stream, info, handler)
+				}
+			}
+			return interceptors[0](srv, stream, info, next)
+		}
+	}
+}
@@ -19,7 +19,9 @@ import (
 	// Frameworks
 	gopi "github.com/djthorpe/gopi"
 	rpc "github.com/djthorpe/gopi/sys/rpc"
+	certificate "github.com/djthorpe/gopi/util/certificate"
 	evt "github.com/djthorpe/gopi/util/event"
+	systemd "github.com/djthorpe/gopi/util/systemd"
 	grpc "google.golang.org/grpc"
 	credentials "google.golang.org/grpc/credentials"
 	reflection "google.golang.org/grpc/reflection"
@@ -29,16 +31,44 @@ import (
 type Server struct {
 	SSLKey         string
 	SSLCertificate string
-	Port           uint
-	ServerOption   []grpc.ServerOption
+
+	// Bundle of certificate authorities for verifying client
+	// certificates, which are then required
+	SSLCA string
+
+	// Generate a self-signed certificate and key when they do not exist
+	SSLGenerate bool
+
+	// Authenticate clients with bearer tokens
+	Authenticator Authenticator
+
+	// Services and methods which can only be called by some client
+	// identities, which requires SSLCA or an Authenticator
+	Authorization Authorization
+
+	Port         uint
+	ServerOption []grpc.ServerOption
+
+	// Listen on a unix socket with file permissions rather than a port,
+	// or DEFAULT_SOCKET_MODE permissions when zero
+	Path string
+	Mode os.FileMode
+
+	// Serve on a listener rather than a port, such as a MemoryListener
+	Listener net.Listener
 }
 
 type server struct {
-	log    gopi.Logger
-	port   uint
-	server *grpc.Server
-	addr   net.Addr
-	pubsub *evt.PubSub
+	log      gopi.Logger
+	port     uint
+	path     string
+	mode     os.FileMode
+	server   *grpc.Server
+	addr     net.Addr
+	pubsub   *evt.PubSub
+	listener net.Listener
+	auth     *authorizer
+	health   *healthservice
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -46,28 +76,86 @@ type server struct {
 
 // Open the server
 func (config Server) Open(log gopi.Logger) (gopi.Driver, error) {
-	log.Debug("<grpc.Server>Open(port=%v,sslcert=\"%v\",sslkey=\"%v\")", config.Port, config.SSLCertificate, config.SSLKey)
+	log.Debug("<grpc.Server>Open(port=%v,path=\"%v\",sslcert=\"%v\",sslkey=\"%v\",sslca=\"%v\")", config.Port, config.Path, config.SSLCertificate, config.SSLKey, config.SSLCA)
 
 	this := new(server)
 	this.log = log
 	this.port = config.Port
+	this.path = config.Path
+	this.mode = config.Mode
+	if this.mode == 0 {
+		this.mode = DEFAULT_SOCKET_MODE
+	}
 
+	// Check parameters
+	if config.Path != "" && (config.Port != 0 || config.Listener != nil) {
+		return nil, gopi.ErrBadParameter
+	} else if config.Listener != nil && config.Port != 0 {
+		return nil, gopi.ErrBadParameter
+	} else if config.SSLCA != "" && (config.SSLKey == "" || config.SSLCertificate == "") {
+		return nil, gopi.ErrBadParameter
+	} else if len(config.Authorization) > 0 && config.SSLCA == "" && config.Authenticator == nil {
+		return nil, gopi.ErrBadParameter
+	}
+
+	// Generate a self-signed device certificate on first run
+	if config.SSLGenerate {
+		if config.SSLKey == "" || config.SSLCertificate == "" {
+			return nil, gopi.ErrBadParameter
+		} else if req, err := certificate.DeviceRequest(); err != nil {
+			return nil, err
+		} else if generated, err := certificate.GenerateFiles(config.SSLCertificate, config.SSLKey, req); err != nil {
+			return nil, err
+		} else if generated {
+			log.Info("grpc.Server: Generated certificate %v", config.SSLCertificate)
+		}
+	}
+
+	// Credentials, which are reloaded when the files change
+	options := append([]grpc.ServerOption{}, config.ServerOption...)
 	if config.SSLKey != "" || config.SSLCertificate != "" {
-		if creds, err := credentials.NewServerTLSFromFile(config.SSLCertificate, config.SSLKey); err != nil {
+		if store, err := certificate.NewStore(config.SSLCertificate, config.SSLKey, config.SSLCA); err != nil {
 			return nil, err
 		} else {
-			this.server = grpc.NewServer(append(config.ServerOption, grpc.Creds(creds))...)
+			options = append(options, grpc.Creds(credentials.NewTLS(store.ServerConfig("h2"))))
 		}
-	} else {
-		this.server = grpc.NewServer(config.ServerOption...)
 	}
 
+	// Interceptors, where authorization is checked first. Services can
+	// add authorization for their methods after the server is opened
+	this.auth = newAuthorizer(config.Authorization, config.Authenticator, config.SSLCA != "", log)
+	unary := []grpc.UnaryServerInterceptor{this.auth.unaryInterceptor()}
+	stream := []grpc.StreamServerInterceptor{this.auth.streamInterceptor()}
+	if interceptor := chainUnaryServer(unary...); interceptor != nil {
+		options = append(options, grpc.UnaryInterceptor(interceptor))
+	}
+	if interceptor := chainStreamServer(stream...); interceptor != nil {
+		options = append(options, grpc.StreamInterceptor(interceptor))
+	}
+	this.server = grpc.NewServer(options...)
+
 	this.addr = nil
 
+	// Accept connections on a listener, or a socket passed by the
+	// service manager, rather than binding to the port
+	if config.Listener != nil {
+		this.listener = config.Listener
+	} else if listeners, err := systemd.Listeners(); err != nil {
+		return nil, err
+	} else if len(listeners) > 0 {
+		this.listener = listeners[0]
+		this.log.Debug("<grpc.Server>Open: Socket activation, addr=%v", this.listener.Addr())
+		for _, listener := range listeners[1:] {
+			this.log.Warn("grpc.Server: Ignoring socket %v", listener.Addr())
+			listener.Close()
+		}
+	}
+
 	// Fan out events to subscribers
 	this.pubsub = evt.NewPubSub(0)
 
-	// Register reflection service on gRPC server.
+	// Register health and reflection services on gRPC server.
+	this.health = newHealthService(this.server)
 	reflection.Register(this.server)
 
 	// success
@@ -85,6 +173,10 @@ func (this *server) Close() error {
 	}
 
 	// Release resources
+	if this.listener != nil {
+		this.listener.Close()
+		this.listener = nil
+	}
 	this.pubsub.Close()
 	this.pubsub = nil
 	this.addr = nil
@@ -103,14 +195,16 @@ func (this *server) Start() error {
 	// Check for serving
 	if this.addr != nil {
 		return errors.New("Cannot call Start() when server already started")
-	} else if lis, err := net.Listen("tcp", portString(this.port)); err != nil {
+	} else if lis, err := this.listen(); err != nil {
 		return err
 	} else {
 		// Start server
 		this.addr = lis.Addr()
+		this.health.setServing("", true)
 		this.emit(rpc.NewEvent(this, gopi.RPC_EVENT_SERVER_STARTED, nil))
 		this.log.Debug("<grpc.Server>{ addr=%v }", this.addr)
 		err := this.server.Serve(lis) // blocking call
+		this.health.setServing("", false)
 		this.emit(rpc.NewEvent(this, gopi.RPC_EVENT_SERVER_STOPPED, nil))
 		this.addr = nil
 		return err
@@ -118,8 +212,10 @@ func (this *server) Start() error {
 }
 
 func (this *server) Stop(halt bool) error {
-	// Stop server
+	// Stop server, reporting that services are no longer serving
+	// before waiting for requests to complete
 	if this.addr != nil {
+		this.health.setServing("", false)
 		if halt {
 			this.log.Debug2("<grpc.Server>Stop()")
 			this.server.Stop()
@@ -147,6 +243,18 @@ func (this *server) GRPCServer() *grpc.Server {
 	return this.server
 }
 
+// SetServing sets whether a service is serving requests, or whether
+// all services are serving requests when the service is empty
+func (this *server) SetServing(service string, serving bool) {
+	this.log.Debug2("<grpc.Server>SetServing{ service=%v serving=%v }", service, serving)
+	this.health.setServing(service, serving)
+}
+
+// Authorize adds identities which can call services and methods
+func (this *server) Authorize(authorization Authorization) {
+	this.auth.add(authorization)
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // EVENTS
 
@@ -219,6 +327,19 @@ func (this *server) String() string {
 ////////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
+// listen returns the socket passed by the service manager the first
+// time the server is started, or otherwise binds to the port
+func (this *server) listen() (net.Listener, error) {
+	if lis := this.listener; lis != nil {
+		this.listener = nil
+		return lis, nil
+	} else if this.path != "" {
+		return listenUnix(this.path, this.mode)
+	} else {
+		return net.Listen("tcp", portString(this.port))
+	}
+}
+
 func portString(port uint) string {
 	if port == 0 {
 		return ""
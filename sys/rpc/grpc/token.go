@@ -0,0 +1,199 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package grpc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Authenticator returns the client identity for a bearer token
+type Authenticator interface {
+	Authenticate(token string) (string, error)
+}
+
+// Authenticators tries each authenticator in turn
+type Authenticators []Authenticator
+
+// StaticTokens maps client identities to fixed tokens
+type StaticTokens map[string]string
+
+// HMACTokens authenticates tokens for an identity which are
+// signed with a shared secret, and which may expire
+type HMACTokens struct {
+	Secret []byte
+}
+
+// tokenCredentials sends a bearer token with each call
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	METADATA_AUTHORIZATION = "authorization"
+	BEARER_PREFIX          = "Bearer "
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	ErrInvalidToken = errors.New("Invalid token")
+	ErrExpiredToken = errors.New("Expired token")
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// AUTHENTICATORS
+
+// Authenticate returns the identity from the first authenticator
+// which accepts the token
+func (this Authenticators) Authenticate(token string) (string, error) {
+	err := ErrInvalidToken
+	for _, authenticator := range this {
+		if identity, err_ := authenticator.Authenticate(token); err_ == nil {
+			return identity, nil
+		} else if err_ != ErrInvalidToken {
+			err = err_
+		}
+	}
+	return "", err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// SECRETS
+
+// ReadSecret returns a secret from a file, or from an environment variable
+// when the path is empty, so that secrets are not passed as command line
+// arguments. Surrounding whitespace is removed
+func ReadSecret(path, env string) (string, error) {
+	if path == "" {
+		return strings.TrimSpace(os.Getenv(env)), nil
+	} else if data, err := ioutil.ReadFile(path); err != nil {
+		return "", err
+	} else {
+		return strings.TrimSpace(string(data)), nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STATIC TOKENS
+
+// ParseStaticTokens returns tokens from a list of identity=token pairs
+// separated by commas or newlines
+func ParseStaticTokens(value string) (StaticTokens, error) {
+	tokens := make(StaticTokens)
+	for i, pair := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		} else if kv := strings.SplitN(pair, "=", 2); len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			// Report the position rather than the value, which is a secret
+			return nil, fmt.Errorf("Invalid identity=token pair at position %v", i+1)
+		} else {
+			tokens[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+	return tokens, nil
+}
+
+// Authenticate returns the identity for a token, comparing
+// every token in constant time
+func (this StaticTokens) Authenticate(token string) (string, error) {
+	identity := ""
+	for key, value := range this {
+		if subtle.ConstantTimeCompare([]byte(value), []byte(token)) == 1 {
+			identity = key
+		}
+	}
+	if identity == "" {
+		return "", ErrInvalidToken
+	} else {
+		return identity, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// HMAC TOKENS
+
+// Token returns a signed token for an identity, which expires at a
+// time or never expires when the time is zero
+func (this HMACTokens) Token(identity string, expires time.Time) (string, error) {
+	if len(this.Secret) == 0 || identity == "" {
+		return "", gopi.ErrBadParameter
+	}
+	payload := base64.RawURLEncoding.EncodeToString([]byte(identity))
+	if expires.IsZero() {
+		payload += ".0"
+	} else {
+		payload += "." + strconv.FormatInt(expires.Unix(), 10)
+	}
+	return payload + "." + this.sign(payload), nil
+}
+
+// Authenticate returns the identity for a token if the signature
+// is valid and the token has not expired
+func (this HMACTokens) Authenticate(token string) (string, error) {
+	if len(this.Secret) == 0 {
+		return "", ErrInvalidToken
+	} else if parts := strings.Split(token, "."); len(parts) != 3 {
+		return "", ErrInvalidToken
+	} else if payload := parts[0] + "." + parts[1]; hmac.Equal([]byte(this.sign(payload)), []byte(parts[2])) == false {
+		return "", ErrInvalidToken
+	} else if identity, err := base64.RawURLEncoding.DecodeString(parts[0]); err != nil || len(identity) == 0 {
+		return "", ErrInvalidToken
+	} else if expires, err := strconv.ParseInt(parts[1], 10, 64); err != nil {
+		return "", ErrInvalidToken
+	} else if expires != 0 && time.Now().Unix() >= expires {
+		return "", ErrExpiredToken
+	} else {
+		return string(identity), nil
+	}
+}
+
+func (this HMACTokens) sign(payload string) string {
+	mac := hmac.New(sha256.New, this.Secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CLIENT CREDENTIALS
+
+// GetRequestMetadata returns the bearer token metadata for a call
+func (this tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{
+		METADATA_AUTHORIZATION: BEARER_PREFIX + this.token,
+	}, nil
+}
+
+// RequireTransportSecurity returns true when the token should
+// only be sent over a secure connection
+func (this tokenCredentials) RequireTransportSecurity() bool {
+	return this.secure
+}
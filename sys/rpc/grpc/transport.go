@@ -0,0 +1,243 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package grpc
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	bufconn "google.golang.org/grpc/test/bufconn"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// MemoryListener is an in-memory transport, where a server is opened
+// with the listener and clients are opened with the Dial method, so
+// that services can be used without network access
+type MemoryListener struct {
+	*bufconn.Listener
+}
+
+// MemoryServer serves services on a MemoryListener, so that services
+// and their clients can be tested without network access
+type MemoryServer struct {
+	log      gopi.Logger
+	listener *MemoryListener
+	server   gopi.RPCServer
+	services []gopi.Driver
+	stopped  chan error
+}
+
+// unixListener is a listener on a socket which was moved into
+// place after binding, and removes the socket when closed
+type unixListener struct {
+	*net.UnixListener
+	path string
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	DEFAULT_SOCKET_MODE    os.FileMode = 0660
+	DEFAULT_MEMORY_BUFSIZE             = 1024 * 1024
+	DEFAULT_MEMORY_TIMEOUT             = 5 * time.Second
+	UNIX_SCHEME                        = "unix:"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// MEMORY TRANSPORT
+
+// NewMemoryListener returns an in-memory transport
+func NewMemoryListener() *MemoryListener {
+	return &MemoryListener{bufconn.Listen(DEFAULT_MEMORY_BUFSIZE)}
+}
+
+// Dial returns a connection to the server, and the address is ignored
+func (this *MemoryListener) Dial(addr string, timeout time.Duration) (net.Conn, error) {
+	return this.Listener.Dial()
+}
+
+// OpenMemoryServer opens a server on a MemoryListener with services, which
+// are returned by functions given the server, and starts serving. The
+// listener in the server configuration is ignored
+func OpenMemoryServer(config Server, log gopi.Logger, services ...func(gopi.RPCServer) gopi.Config) (*MemoryServer, error) {
+	this := new(MemoryServer)
+	this.log = log
+	this.listener = NewMemoryListener()
+	this.services = make([]gopi.Driver, 0, len(services))
+	this.stopped = make(chan error, 1)
+
+	// Open the server and services
+	config.Listener = this.listener
+	if server, err := gopi.Open(config, log); err != nil {
+		return nil, err
+	} else {
+		this.server = server.(gopi.RPCServer)
+	}
+	for _, service := range services {
+		if driver, err := gopi.Open(service(this.server), log); err != nil {
+			this.closeServices()
+			this.server.Close()
+			return nil, err
+		} else {
+			this.services = append(this.services, driver)
+		}
+	}
+
+	// Serve in the background, and return once the server has started
+	events := this.server.Subscribe()
+	defer this.server.Unsubscribe(events)
+	go func() {
+		this.stopped <- this.server.Start()
+	}()
+	select {
+	case <-events:
+		return this, nil
+	case err := <-this.stopped:
+		this.closeServices()
+		this.server.Close()
+		return nil, err
+	}
+}
+
+// Server returns the server
+func (this *MemoryServer) Server() gopi.RPCServer {
+	return this.server
+}
+
+// Conn returns a client connection to the server, where the address,
+// dialer and a default timeout are set in the configuration
+func (this *MemoryServer) Conn(config ClientConn) (gopi.RPCClientConn, error) {
+	config.Addr = "memory"
+	config.Dialer = this.listener.Dial
+	if config.Timeout == 0 {
+		config.Timeout = DEFAULT_MEMORY_TIMEOUT
+	}
+	if conn, err := gopi.Open(config, this.log); err != nil {
+		return nil, err
+	} else if err := conn.(*clientconn).Connect(); err != nil {
+		conn.Close()
+		return nil, err
+	} else {
+		return conn.(gopi.RPCClientConn), nil
+	}
+}
+
+// Close ends streaming requests, stops the server and then closes
+// the services and server
+func (this *MemoryServer) Close() error {
+	for _, service := range this.services {
+		if service, ok := service.(gopi.RPCService); ok {
+			service.CancelRequests()
+		}
+	}
+	err := this.server.Stop(true)
+	<-this.stopped
+	if err_ := this.closeServices(); err == nil {
+		err = err_
+	}
+	if err_ := this.server.Close(); err == nil {
+		err = err_
+	}
+	return err
+}
+
+// closeServices closes services in the reverse order to opening
+func (this *MemoryServer) closeServices() error {
+	var err error
+	for i := len(this.services) - 1; i >= 0; i-- {
+		if err_ := this.services[i].Close(); err == nil {
+			err = err_
+		}
+	}
+	this.services = this.services[:0]
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// UNIX SOCKETS
+
+// listenUnix returns a listener on a unix socket with file permissions,
+// removing any socket left over from before. The socket is bound in a
+// private directory and has the permissions set before it is moved into
+// place, so that it is never accessible with wider permissions
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, gopi.ErrBadParameter
+		} else if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	// The private directory is next to the path, so that the socket
+	// can be renamed
+	dir, err := ioutil.TempDir(filepath.Dir(path), ".socket")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	temp := filepath.Join(dir, filepath.Base(path))
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: temp, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	listener.SetUnlinkOnClose(false)
+	if err := os.Chmod(temp, mode.Perm()); err != nil {
+		listener.Close()
+		return nil, err
+	} else if err := os.Rename(temp, path); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return &unixListener{listener, path}, nil
+}
+
+// Addr returns the path of the socket
+func (this *unixListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: this.path, Net: "unix"}
+}
+
+// Close the listener and remove the socket
+func (this *unixListener) Close() error {
+	err := this.UnixListener.Close()
+	if err_ := os.Remove(this.path); err == nil && os.IsNotExist(err_) == false {
+		err = err_
+	}
+	return err
+}
+
+// unixPath returns the socket path for an address of the form
+// unix:///path or unix:path, or an empty string otherwise
+func unixPath(addr string) string {
+	if strings.HasPrefix(addr, UNIX_SCHEME) == false {
+		return ""
+	} else if path := strings.TrimPrefix(addr, UNIX_SCHEME); strings.HasPrefix(path, "//") {
+		return strings.TrimPrefix(path, "//")
+	} else {
+		return path
+	}
+}
+
+// dialUnix returns a dialer for a unix socket, which ignores
+// the address
+func dialUnix(path string) func(string, time.Duration) (net.Conn, error) {
+	return func(_ string, timeout time.Duration) (net.Conn, error) {
+		return net.DialTimeout("unix", path, timeout)
+	}
+}
@@ -0,0 +1,157 @@
+package grpc_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	grpc "github.com/djthorpe/gopi/sys/rpc/grpc"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// UNIX SOCKETS
+
+func TestUnixSocket_000(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	path := tempDir(t)
+	defer os.RemoveAll(path)
+	socket := filepath.Join(path, "rpc.sock")
+
+	// Leave a stale socket behind, which is replaced
+	if listener, err := net.Listen("unix", socket); err != nil {
+		t.Fatal(err)
+	} else {
+		listener.(*net.UnixListener).SetUnlinkOnClose(false)
+		listener.Close()
+	}
+
+	server := openServer(t, log, grpc.Server{Path: socket, Mode: 0600})
+	_, stopped := startServer(t, server)
+	if server.Addr().Network() != "unix" {
+		t.Error("Unexpected network", server.Addr().Network())
+	} else if server.Service("test") != nil {
+		t.Error("Expected no service record for unix socket")
+	}
+	if info, err := os.Stat(socket); err != nil {
+		t.Error(err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Error("Unexpected permissions", info.Mode().Perm())
+	} else if server.Addr().String() != socket {
+		t.Error("Unexpected address", server.Addr())
+	}
+
+	// Only the socket is left in the directory
+	if files, err := ioutil.ReadDir(path); err != nil {
+		t.Error(err)
+	} else if len(files) != 1 || files[0].Name() != "rpc.sock" {
+		t.Error("Unexpected files", files)
+	}
+
+	// Dial the socket
+	for _, addr := range []string{"unix://" + socket, "unix:" + socket} {
+		conn := openClient(t, log, grpc.ClientConn{Addr: addr, Timeout: 5 * time.Second})
+		if services, err := conn.Services(); err != nil {
+			t.Error(addr, err)
+		} else if len(services) == 0 {
+			t.Error(addr, "Expected services")
+		}
+		conn.Close()
+	}
+
+	// The socket is removed when the server stops
+	stopServer(server, stopped)
+	if _, err := os.Lstat(socket); os.IsNotExist(err) == false {
+		t.Error("Expected socket to be removed, got", err)
+	}
+}
+
+func TestUnixSocket_001(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+	path := tempDir(t)
+	defer os.RemoveAll(path)
+
+	// Files which are not sockets are not replaced
+	socket := filepath.Join(path, "rpc.sock")
+	if file, err := os.Create(socket); err != nil {
+		t.Fatal(err)
+	} else {
+		file.Close()
+	}
+	server := openServer(t, log, grpc.Server{Path: socket})
+	defer server.Close()
+	if err := server.Start(); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+
+	// The socket cannot be used with a port
+	if _, err := gopi.Open(grpc.Server{Path: socket, Port: 8000}, log); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// MEMORY TRANSPORT
+
+func TestMemoryListener_000(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+
+	listener := grpc.NewMemoryListener()
+	server := openServer(t, log, grpc.Server{Listener: listener})
+	_, stopped := startServer(t, server)
+	defer stopServer(server, stopped)
+
+	conn := openClient(t, log, grpc.ClientConn{Addr: "memory", Dialer: listener.Dial, Timeout: 5 * time.Second})
+	defer conn.Close()
+	if services, err := conn.Services(); err != nil {
+		t.Error(err)
+	} else if len(services) == 0 {
+		t.Error("Expected services")
+	}
+}
+
+func TestMemoryServer_000(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+
+	server, err := grpc.OpenMemoryServer(grpc.Server{}, log)
+	if err != nil {
+		t.Fatal(err)
+	} else if server.Server().Addr() == nil {
+		t.Error("Expected server to be serving")
+	}
+	conn, err := server.Conn(grpc.ClientConn{})
+	if err != nil {
+		t.Fatal(err)
+	} else if services, err := conn.Services(); err != nil {
+		t.Error(err)
+	} else if len(services) == 0 {
+		t.Error("Expected services")
+	}
+	conn.Close()
+	if err := server.Close(); err != nil {
+		t.Error(err)
+	} else if server.Server().Addr() != nil {
+		t.Error("Expected server to be stopped")
+	}
+}
+
+func TestMemoryServer_001(t *testing.T) {
+	log := openLogger(t)
+	defer log.Close()
+
+	// Services which fail to open are returned as errors
+	_, err := grpc.OpenMemoryServer(grpc.Server{}, log, func(gopi.RPCServer) gopi.Config {
+		return grpc.Server{Port: 1, Path: "invalid"}
+	})
+	if err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+}
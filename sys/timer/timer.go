@@ -12,9 +12,11 @@ package timer
 import (
 	"fmt"
 	"reflect"
+	"sync"
 	"time"
 
 	"github.com/djthorpe/gopi"
+	evt "github.com/djthorpe/gopi/util/event"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -24,10 +26,11 @@ type Timer struct{}
 
 type timer struct {
 	log                 gopi.Logger
-	subscribers         []chan gopi.Event
+	pubsub              *evt.PubSub
 	channels            []reflect.SelectCase
 	units               map[int]*unit
 	done, reload, done2 chan struct{}
+	lock                sync.Mutex
 }
 
 type unit struct {
@@ -51,7 +54,7 @@ func (config Timer) Open(log gopi.Logger) (gopi.Driver, error) {
 
 	this := new(timer)
 	this.log = log
-	this.subscribers = make([]chan gopi.Event, 0)
+	this.pubsub = evt.NewPubSub(0)
 	this.units = make(map[int]*unit, 0)
 	this.channels = make([]reflect.SelectCase, 2)
 	this.done = make(chan struct{})
@@ -98,7 +101,8 @@ func (this *timer) Close() error {
 	// Free up resources
 	this.units = nil
 	this.channels = nil
-	this.subscribers = nil
+	this.pubsub.Close()
+	this.pubsub = nil
 
 	return nil
 }
@@ -108,6 +112,7 @@ func (this *timer) Close() error {
 
 // Schedule a timeout (one shot)
 func (this *timer) NewTimeout(duration time.Duration, userInfo interface{}) {
+	this.lock.Lock()
 	timer := time.NewTimer(duration)
 	this.channels = append(this.channels, reflect.SelectCase{
 		Dir:  reflect.SelectRecv,
@@ -117,11 +122,13 @@ func (this *timer) NewTimeout(duration time.Duration, userInfo interface{}) {
 		timer:    timer,
 		userInfo: userInfo,
 	}
+	this.lock.Unlock()
 	this.reload <- gopi.DONE
 }
 
 // Schedule an interval, which can fire immediately
 func (this *timer) NewInterval(duration time.Duration, userInfo interface{}, immediately bool) {
+	this.lock.Lock()
 	ticker := time.NewTicker(duration)
 	this.channels = append(this.channels, reflect.SelectCase{
 		Dir:  reflect.SelectRecv,
@@ -132,42 +139,56 @@ func (this *timer) NewInterval(duration time.Duration, userInfo interface{}, imm
 		userInfo: userInfo,
 	}
 	this.units[len(this.channels)-1] = unit
+	this.lock.Unlock()
 	this.reload <- gopi.DONE
 	if immediately {
 		this.emit(unit, time.Now())
 	}
 }
 
+// Cancel timeouts and intervals scheduled with userInfo
+func (this *timer) Cancel(userInfo interface{}) {
+	this.lock.Lock()
+	for i, unit := range this.units {
+		if unit.userInfo != userInfo {
+			continue
+		}
+		if unit.ticker != nil {
+			unit.ticker.Stop()
+		}
+		if unit.timer != nil {
+			unit.timer.Stop()
+		}
+		// A zero channel value is ignored by select
+		this.channels[i].Chan = reflect.Value{}
+		delete(this.units, i)
+	}
+	this.lock.Unlock()
+	this.reload <- gopi.DONE
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // STRINGIFY
 
 func (this *timer) String() string {
+	this.lock.Lock()
+	defer this.lock.Unlock()
 	return fmt.Sprintf("<sys.timer>{ timers=%v }", this.units)
 }
 
 ////////////////////////////////////////////////////////////////////////////////
 // INTERFACE - EVENTS
 
-// Subscribe to events emitted. Returns unique subscriber
-// identifier and channel on which events are emitted
-func (this *timer) Subscribe() chan gopi.Event {
+// Subscribe to events emitted. Returns channel on which events are emitted
+func (this *timer) Subscribe() <-chan gopi.Event {
 	this.log.Debug2("<sys.timer.Subscribe>{ }")
-
-	// Create a new channel for emitting events
-	subscriber := make(chan gopi.Event)
-	this.subscribers = append(this.subscribers, subscriber)
-	return subscriber
+	return this.pubsub.Subscribe()
 }
 
-// Unsubscribe from events emitted
-func (this *timer) Unsubscribe(subscriber chan gopi.Event) {
+// Unsubscribe from events emitted, which closes the channel
+func (this *timer) Unsubscribe(subscriber <-chan gopi.Event) {
 	this.log.Debug2("<sys.timer.Unsubscribe>{ }")
-
-	for i := range this.subscribers {
-		if this.subscribers[i] == subscriber {
-			this.subscribers[i] = nil
-		}
-	}
+	this.pubsub.Unsubscribe(subscriber)
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -197,12 +218,7 @@ func (this *event) String() string {
 // PRIVATE METHODS
 
 func (this *timer) emit(userInfo *unit, value time.Time) {
-	event := &event{source: this, userInfo: userInfo.userInfo, timestamp: value}
-	for _, channel := range this.subscribers {
-		if channel != nil {
-			channel <- event
-		}
-	}
+	this.pubsub.Emit(&event{source: this, userInfo: userInfo.userInfo, timestamp: value})
 }
 
 // wait_for_timers will wait for an event on any channel in the list of
@@ -211,14 +227,22 @@ func (this *timer) emit(userInfo *unit, value time.Time) {
 func (this *timer) wait_for_timers() {
 
 	for {
-		if chosen, value, ok := reflect.Select(this.channels); ok && chosen == 0 {
+		// Select on a copy of the channels, which are modified when
+		// timers are added or cancelled
+		this.lock.Lock()
+		channels := append([]reflect.SelectCase{}, this.channels...)
+		this.lock.Unlock()
+		if chosen, value, ok := reflect.Select(channels); ok && chosen == 0 {
 			// Break out
 			break
 		} else if ok && chosen == 1 {
 			// Reload
 			continue
-		} else if ok && chosen < len(this.channels) {
-			if unit, exists := this.units[chosen]; exists {
+		} else if ok {
+			this.lock.Lock()
+			unit, exists := this.units[chosen]
+			this.lock.Unlock()
+			if exists {
 				this.emit(unit, value.Interface().(time.Time))
 			}
 		}
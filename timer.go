@@ -25,4 +25,7 @@ type Timer interface {
 
 	// Schedule an interval, which can fire immediately
 	NewInterval(duration time.Duration, userInfo interface{}, immediately bool)
+
+	// Cancel timeouts and intervals scheduled with userInfo
+	Cancel(userInfo interface{})
 }
@@ -0,0 +1,239 @@
+package certificate_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	// Frameworks
+	certificate "github.com/djthorpe/gopi/util/certificate"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GENERATE
+
+func TestGenerate_000(t *testing.T) {
+	// Self-signed certificate
+	cert := generate(t, certificate.Request{CommonName: "device", Hosts: []string{"device.local", "127.0.0.1"}}, nil)
+	if cert.Leaf.Subject.CommonName != "device" || cert.Leaf.IsCA || cert.Leaf.KeyUsage&x509.KeyUsageCertSign != 0 {
+		t.Error("Unexpected certificate", cert.Leaf.Subject)
+	}
+	if len(cert.Leaf.DNSNames) != 1 || len(cert.Leaf.IPAddresses) != 1 {
+		t.Error("Unexpected hosts", cert.Leaf.DNSNames, cert.Leaf.IPAddresses)
+	}
+	if identities := certificate.Identities(cert.Leaf); len(identities) != 2 || identities[0] != "device" || identities[1] != "device.local" {
+		t.Error("Unexpected identities", identities)
+	}
+	// Self-signed certificate is trusted when it is in the pool
+	pool := x509.NewCertPool()
+	pool.AddCert(cert.Leaf)
+	if _, err := cert.Leaf.Verify(x509.VerifyOptions{Roots: pool, DNSName: "device.local"}); err != nil {
+		t.Error(err)
+	}
+	if _, _, err := certificate.Generate(certificate.Request{}, nil); err == nil {
+		t.Error("Expected error for missing common name")
+	}
+}
+
+func TestGenerate_001(t *testing.T) {
+	// Certificate signed by a certificate authority
+	ca := generate(t, certificate.Request{CommonName: "ca", CA: true}, nil)
+	cert := generate(t, certificate.Request{CommonName: "client"}, ca)
+	if cert.Leaf.IsCA {
+		t.Error("Unexpected certificate authority")
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+	if _, err := cert.Leaf.Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGenerate_002(t *testing.T) {
+	path := tempDir(t)
+	defer os.RemoveAll(path)
+	certfile, keyfile := filepath.Join(path, "ssl", "cert.pem"), filepath.Join(path, "ssl", "key.pem")
+
+	// Files are only written when they do not exist
+	if req, err := certificate.DeviceRequest(); err != nil {
+		t.Fatal(err)
+	} else if written, err := certificate.GenerateFiles(certfile, keyfile, req); err != nil {
+		t.Fatal(err)
+	} else if written == false {
+		t.Error("Expected files to be written")
+	} else if written, err := certificate.GenerateFiles(certfile, keyfile, req); err != nil {
+		t.Error(err)
+	} else if written {
+		t.Error("Expected existing files to be kept")
+	}
+	if info, err := os.Stat(keyfile); err != nil {
+		t.Error(err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Error("Unexpected key permissions", info.Mode())
+	}
+	if _, err := tls.LoadX509KeyPair(certfile, keyfile); err != nil {
+		t.Error(err)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STORE
+
+func TestStore_000(t *testing.T) {
+	path := tempDir(t)
+	defer os.RemoveAll(path)
+	certfile, keyfile := filepath.Join(path, "cert.pem"), filepath.Join(path, "key.pem")
+
+	writeFiles(t, certfile, keyfile, certificate.Request{CommonName: "first"}, nil)
+	store, err := certificate.NewStore(certfile, keyfile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert := store.Certificate(); cert == nil || cert.Leaf.Subject.CommonName != "first" {
+		t.Error("Unexpected certificate", cert)
+	}
+	if pool := store.Pool(); pool != nil {
+		t.Error("Unexpected pool", pool)
+	}
+
+	// Certificate is reloaded when the files change
+	writeFiles(t, certfile, keyfile, certificate.Request{CommonName: "second"}, nil)
+	modtime := time.Now().Add(time.Minute)
+	if err := os.Chtimes(certfile, modtime, modtime); err != nil {
+		t.Fatal(err)
+	}
+	if cert := store.Certificate(); cert == nil || cert.Leaf.Subject.CommonName != "second" {
+		t.Error("Unexpected certificate", cert)
+	}
+
+	// Key without certificate
+	if _, err := certificate.NewStore("", keyfile, ""); err == nil {
+		t.Error("Expected error for missing certificate")
+	}
+}
+
+func TestStore_001(t *testing.T) {
+	path := tempDir(t)
+	defer os.RemoveAll(path)
+
+	// Certificate authority signs server and client certificates
+	ca := generate(t, certificate.Request{CommonName: "ca", CA: true}, nil)
+	cafile := filepath.Join(path, "ca.pem")
+	writePEM(t, cafile, ca)
+	writeFiles(t, filepath.Join(path, "server.pem"), filepath.Join(path, "server.key"), certificate.Request{CommonName: "server", Hosts: []string{"127.0.0.1"}}, ca)
+	writeFiles(t, filepath.Join(path, "client.pem"), filepath.Join(path, "client.key"), certificate.Request{CommonName: "client"}, ca)
+	writeFiles(t, filepath.Join(path, "other.pem"), filepath.Join(path, "other.key"), certificate.Request{CommonName: "other"}, nil)
+
+	server, err := certificate.NewStore(filepath.Join(path, "server.pem"), filepath.Join(path, "server.key"), cafile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := certificate.NewStore(filepath.Join(path, "client.pem"), filepath.Join(path, "client.key"), cafile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	anonymous, err := certificate.NewStore("", "", cafile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := certificate.NewStore(filepath.Join(path, "other.pem"), filepath.Join(path, "other.key"), cafile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Accept connections on a local listener
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", server.ServerConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	identities := make(chan string)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				close(identities)
+				return
+			}
+			identity := ""
+			if err := conn.(*tls.Conn).Handshake(); err == nil {
+				if state := conn.(*tls.Conn).ConnectionState(); len(state.VerifiedChains) > 0 {
+					identity = state.VerifiedChains[0][0].Subject.CommonName
+				}
+			}
+			conn.Close()
+			identities <- identity
+		}
+	}()
+
+	// Client with a certificate signed by the authority is accepted, and
+	// others are rejected
+	for _, test := range []struct {
+		store    *certificate.Store
+		identity string
+	}{
+		{client, "client"},
+		{anonymous, ""},
+		{other, ""},
+	} {
+		conn, err := tls.Dial("tcp", listener.Addr().String(), test.store.ClientConfig(false))
+		if err == nil {
+			// Client handshake completes before the server verifies
+			// the client certificate
+			conn.Read(make([]byte, 1))
+			conn.Close()
+		}
+		if identity := <-identities; identity != test.identity {
+			t.Errorf("Expected identity '%v', got '%v'", test.identity, identity)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func generate(t *testing.T, req certificate.Request, parent *tls.Certificate) *tls.Certificate {
+	if cert_pem, key_pem, err := certificate.Generate(req, parent); err != nil {
+		t.Fatal(err)
+		return nil
+	} else if cert, err := tls.X509KeyPair(cert_pem, key_pem); err != nil {
+		t.Fatal(err)
+		return nil
+	} else if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return &cert
+	}
+}
+
+func writeFiles(t *testing.T, certfile, keyfile string, req certificate.Request, parent *tls.Certificate) {
+	if cert_pem, key_pem, err := certificate.Generate(req, parent); err != nil {
+		t.Fatal(err)
+	} else if err := ioutil.WriteFile(certfile, cert_pem, 0644); err != nil {
+		t.Fatal(err)
+	} else if err := ioutil.WriteFile(keyfile, key_pem, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writePEM(t *testing.T, path string, cert *tls.Certificate) {
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func tempDir(t *testing.T) string {
+	if path, err := ioutil.TempDir("", "certificate"); err != nil {
+		t.Fatal(err)
+		return ""
+	} else {
+		return path
+	}
+}
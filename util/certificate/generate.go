@@ -0,0 +1,227 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+// Generate, load and reload TLS certificates for mutual authentication
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Request describes a certificate to generate
+type Request struct {
+	// Identity of the certificate
+	CommonName   string
+	Organization string
+
+	// DNS names and IP addresses for the certificate
+	Hosts []string
+
+	// Validity, or DEFAULT_VALIDITY when zero
+	Validity time.Duration
+
+	// Whether the certificate can sign other certificates
+	CA bool
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	DEFAULT_VALIDITY     = 10 * 365 * 24 * time.Hour
+	DEFAULT_ORGANIZATION = "gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GENERATE
+
+// Generate returns a PEM encoded certificate and key for a request,
+// signed by the parent certificate or self-signed when the parent is nil
+func Generate(req Request, parent *tls.Certificate) ([]byte, []byte, error) {
+	if req.CommonName == "" {
+		return nil, nil, errors.New("Missing CommonName")
+	}
+	if req.Validity == 0 {
+		req.Validity = DEFAULT_VALIDITY
+	}
+	if req.Organization == "" {
+		req.Organization = DEFAULT_ORGANIZATION
+	}
+
+	// Generate key and serial number
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Create the template
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   req.CommonName,
+			Organization: []string{req.Organization},
+		},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(req.Validity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, host := range req.Hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	// Only a certificate authority can sign other certificates. A
+	// self-signed certificate is trusted when it is in a bundle
+	if req.CA {
+		template.IsCA = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	}
+
+	// Sign the certificate
+	signer, signer_key := template, interface{}(key)
+	if parent != nil {
+		if len(parent.Certificate) == 0 {
+			return nil, nil, errors.New("Invalid parent certificate")
+		} else if leaf, err := x509.ParseCertificate(parent.Certificate[0]); err != nil {
+			return nil, nil, err
+		} else {
+			signer, signer_key = leaf, parent.PrivateKey
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signer_key)
+	if err != nil {
+		return nil, nil, err
+	}
+	key_der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Return PEM encoded certificate and key
+	cert_pem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	key_pem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: key_der})
+	return cert_pem, key_pem, nil
+}
+
+// GenerateFiles writes a self-signed certificate and key for a request
+// when either file does not exist, and returns true if the files were
+// written
+func GenerateFiles(certfile, keyfile string, req Request) (bool, error) {
+	if exists(certfile) && exists(keyfile) {
+		return false, nil
+	}
+	cert, key, err := Generate(req, nil)
+	if err != nil {
+		return false, err
+	}
+	for _, path := range []string{certfile, keyfile} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return false, err
+		}
+	}
+	if err := ioutil.WriteFile(keyfile, key, 0600); err != nil {
+		return false, err
+	}
+	if err := ioutil.WriteFile(certfile, cert, 0644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// DeviceRequest returns a request for a device certificate, with the
+// hostname as the identity and the hostname and interface addresses
+// as hosts
+func DeviceRequest() (Request, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return Request{}, err
+	}
+	hostname = strings.TrimSuffix(hostname, ".local")
+	req := Request{
+		CommonName: hostname,
+		Hosts:      []string{hostname, hostname + ".local", "localhost"},
+	}
+	if addrs, err := net.InterfaceAddrs(); err != nil {
+		return Request{}, err
+	} else {
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok {
+				req.Hosts = append(req.Hosts, ipnet.IP.String())
+			}
+		}
+	}
+	return req, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// IDENTITY
+
+// Identities returns the common name, DNS names, email addresses
+// and URIs of a certificate
+func Identities(cert *x509.Certificate) []string {
+	if cert == nil {
+		return nil
+	}
+	identities := make([]string, 0, 1+len(cert.DNSNames)+len(cert.EmailAddresses)+len(cert.URIs))
+	if cert.Subject.CommonName != "" {
+		identities = append(identities, cert.Subject.CommonName)
+	}
+	identities = append(identities, cert.DNSNames...)
+	identities = append(identities, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+	return identities
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this Request) String() string {
+	return fmt.Sprintf("<util.certificate.Request>{ cn='%v' org='%v' hosts=%v validity=%v ca=%v }", this.CommonName, this.Organization, this.Hosts, this.Validity, this.CA)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func exists(path string) bool {
+	if _, err := os.Stat(path); err != nil {
+		return false
+	} else {
+		return true
+	}
+}
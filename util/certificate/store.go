@@ -0,0 +1,190 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package certificate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Store holds a certificate and key, and a bundle of certificate
+// authorities, which are reloaded when the files change
+type Store struct {
+	certfile, keyfile, cafile string
+	lock                      sync.Mutex
+	cert                      *tls.Certificate
+	pool                      *x509.CertPool
+	modtime                   time.Time
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+// NewStore loads a certificate and key, and a bundle of PEM encoded
+// certificate authorities. The certificate and key, or the bundle, can
+// be empty paths
+func NewStore(certfile, keyfile, cafile string) (*Store, error) {
+	if (certfile == "") != (keyfile == "") {
+		return nil, errors.New("Both certificate and key are required")
+	}
+	this := new(Store)
+	this.certfile = certfile
+	this.keyfile = keyfile
+	this.cafile = cafile
+	if err := this.Reload(); err != nil {
+		return nil, err
+	}
+	return this, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// LOAD
+
+// Reload the certificate, key and bundle from files
+func (this *Store) Reload() error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.reload()
+}
+
+// Certificate returns the certificate, or nil if there is no
+// certificate. It is reloaded if the files have changed
+func (this *Store) Certificate() *tls.Certificate {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.reloadIfModified()
+	return this.cert
+}
+
+// Pool returns the certificate authorities, or nil if there is no
+// bundle. It is reloaded if the files have changed
+func (this *Store) Pool() *x509.CertPool {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.reloadIfModified()
+	return this.pool
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONFIGURATION
+
+// ServerConfig returns configuration for a server with application
+// protocols, such as "h2", which requires and verifies client certificates
+// when there is a bundle. The certificate and bundle are reloaded on each
+// connection when the files have changed
+func (this *Store) ServerConfig(protos ...string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: protos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			config := &tls.Config{
+				MinVersion: tls.VersionTLS12,
+				NextProtos: protos,
+			}
+			if cert := this.Certificate(); cert == nil {
+				return nil, errors.New("Missing server certificate")
+			} else {
+				config.Certificates = []tls.Certificate{*cert}
+			}
+			if pool := this.Pool(); pool != nil {
+				config.ClientCAs = pool
+				config.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			return config, nil
+		},
+	}
+}
+
+// ClientConfig returns configuration for a client, which presents the
+// certificate when there is one and verifies the server against the
+// bundle, or the system certificate authorities when there is no bundle.
+// The bundle is reloaded when the configuration is created, so create
+// the configuration for each connection
+func (this *Store) ClientConfig(skipverify bool) *tls.Config {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    this.Pool(),
+	}
+	if config.RootCAs == nil {
+		config.InsecureSkipVerify = skipverify
+	}
+	if this.certfile != "" {
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return this.Certificate(), nil
+		}
+	}
+	return config
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Store) String() string {
+	return fmt.Sprintf("<util.certificate.Store>{ cert='%v' key='%v' ca='%v' }", this.certfile, this.keyfile, this.cafile)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *Store) reload() error {
+	var cert *tls.Certificate
+	var pool *x509.CertPool
+	if this.certfile != "" {
+		if cert_, err := tls.LoadX509KeyPair(this.certfile, this.keyfile); err != nil {
+			return err
+		} else if cert_.Leaf, err = x509.ParseCertificate(cert_.Certificate[0]); err != nil {
+			return err
+		} else {
+			cert = &cert_
+		}
+	}
+	if this.cafile != "" {
+		if data, err := ioutil.ReadFile(this.cafile); err != nil {
+			return err
+		} else if pool = x509.NewCertPool(); pool.AppendCertsFromPEM(data) == false {
+			return fmt.Errorf("%v: No certificates", this.cafile)
+		}
+	}
+	this.cert, this.pool, this.modtime = cert, pool, this.lastModified()
+	return nil
+}
+
+// reloadIfModified reloads the files when any file has changed, and
+// keeps the existing certificates when the files cannot be loaded
+func (this *Store) reloadIfModified() {
+	if modtime := this.lastModified(); modtime.After(this.modtime) {
+		if err := this.reload(); err != nil {
+			// Try again when the files next change
+			this.modtime = modtime
+		}
+	}
+}
+
+// lastModified returns the latest modification time of the files
+func (this *Store) lastModified() time.Time {
+	modtime := time.Time{}
+	for _, path := range []string{this.certfile, this.keyfile, this.cafile} {
+		if path == "" {
+			continue
+		} else if info, err := os.Stat(path); err == nil && info.ModTime().After(modtime) {
+			modtime = info.ModTime()
+		}
+	}
+	return modtime
+}
@@ -11,50 +11,112 @@
 package event
 
 import (
+	"sync"
+
 	gopi "github.com/djthorpe/gopi"
 )
 
 type PubSub struct {
-	subscribers []chan gopi.Event
+	lock        sync.Mutex
+	subscribers []*subscriber
+}
+
+// subscriber is a channel on which events are emitted. The done channel
+// is closed on unsubscribe to release an emit which is blocked, and the
+// channel is only closed once no emit is in progress
+type subscriber struct {
+	lock sync.Mutex
+	c    chan gopi.Event
+	done chan struct{}
 }
 
 func NewPubSub(capacity int) *PubSub {
 	this := new(PubSub)
-	this.subscribers = make([]chan gopi.Event, 0, capacity)
+	this.subscribers = make([]*subscriber, 0, capacity)
 	return this
 }
 
 func (this *PubSub) Close() {
-	for _, subscriber := range this.subscribers {
+	this.lock.Lock()
+	subscribers := this.subscribers
+	this.subscribers = nil
+	this.lock.Unlock()
+
+	for _, subscriber := range subscribers {
 		if subscriber != nil {
-			close(subscriber)
+			subscriber.close()
 		}
 	}
-	this.subscribers = nil
 }
 
 func (this *PubSub) Subscribe() <-chan gopi.Event {
+	this.lock.Lock()
+	defer this.lock.Unlock()
 	if this.subscribers == nil {
 		return nil
 	}
-	subscriber := make(chan gopi.Event)
+	subscriber := &subscriber{
+		c:    make(chan gopi.Event),
+		done: make(chan struct{}),
+	}
 	this.subscribers = append(this.subscribers, subscriber)
-	return subscriber
+	return subscriber.c
 }
 
-func (this *PubSub) Unsubscribe(subscriber <-chan gopi.Event) {
-	for i := range this.subscribers {
-		if this.subscribers[i] == subscriber {
-			close(this.subscribers[i])
+// Unsubscribe closes the channel for a subscriber. It is safe to call
+// while an event is being emitted, including from the goroutine which
+// receives on the channel
+func (this *PubSub) Unsubscribe(c <-chan gopi.Event) {
+	this.lock.Lock()
+	var unsubscribed *subscriber
+	for i, subscriber := range this.subscribers {
+		if subscriber != nil && subscriber.c == c {
+			unsubscribed = subscriber
 			this.subscribers[i] = nil
 		}
 	}
+	this.lock.Unlock()
+
+	if unsubscribed != nil {
+		unsubscribed.close()
+	}
 }
 
+// Emit an event to each subscriber, blocking until each subscriber
+// has received the event or has unsubscribed
 func (this *PubSub) Emit(evt gopi.Event) {
-	for _, subscriber := range this.subscribers {
+	this.lock.Lock()
+	subscribers := append([]*subscriber{}, this.subscribers...)
+	this.lock.Unlock()
+
+	for _, subscriber := range subscribers {
 		if subscriber != nil {
-			subscriber <- evt
+			subscriber.emit(evt)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *subscriber) emit(evt gopi.Event) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	select {
+	case <-this.done:
+		return
+	default:
+		select {
+		case this.c <- evt:
+		case <-this.done:
 		}
 	}
 }
+
+func (this *subscriber) close() {
+	// Release a blocked emit before waiting for it to complete
+	close(this.done)
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	close(this.c)
+}
@@ -122,3 +122,30 @@ FOR_LOOP:
 		t.Errorf("Expected e to be emitted to both channels")
 	}
 }
+
+func Test_007(t *testing.T) {
+	// Unsubscribe from the receiving goroutine while an emit is blocked
+	pubsub := evt.NewPubSub(0)
+	c := pubsub.Subscribe()
+	emitting, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		pubsub.Emit(&event{1})
+		close(emitting)
+		pubsub.Emit(&event{2})
+		close(done)
+	}()
+	if evt := <-c; evt == nil {
+		t.Error("Expecting event to be emitted to channel")
+	}
+	<-emitting
+	pubsub.Unsubscribe(c)
+	select {
+	case <-done:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Expecting emit to return after unsubscribe")
+	}
+	for range c {
+		// Drain until the channel is closed
+	}
+	pubsub.Emit(&event{3})
+}
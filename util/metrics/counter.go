@@ -56,6 +56,9 @@ func (this *Counter) Increment(ts time.Time, value uint) {
 
 // Return the sum and the number of samples, and total number
 func (this *Counter) Sum() (uint, int, int) {
+	this.Lock()
+	defer this.Unlock()
+
 	sum := uint(0)
 	for _, v := range this.values {
 		sum += v
@@ -63,6 +66,18 @@ func (this *Counter) Sum() (uint, int, int) {
 	return sum, len(this.values), this.len
 }
 
+// Return the summary of values counted per interval, and the mean value
+func (this *Counter) Summary() (gopi.MetricSummary, float64) {
+	this.Lock()
+	defer this.Unlock()
+
+	values := make([]float64, 0, len(this.values))
+	for _, v := range this.values {
+		values = append(values, float64(v))
+	}
+	return Summarize(values)
+}
+
 func bucketForTimestamp(ts time.Time, rate gopi.MetricRate) int {
 	switch rate {
 	case gopi.METRIC_RATE_SECOND:
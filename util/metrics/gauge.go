@@ -0,0 +1,104 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Gauge is a metric which is set to arbitrary values, and
+// implements gopi.MetricGauge
+type Gauge struct {
+	lock   sync.Mutex
+	name   string
+	labels []gopi.MetricLabel
+	rate   gopi.MetricRate
+	value  float64
+	window *Window
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+func NewGauge(rate gopi.MetricRate, name string, labels ...gopi.MetricLabel) *Gauge {
+	this := new(Gauge)
+	if this.window = NewWindow(rate); this.window == nil {
+		// Unsupported rate
+		return nil
+	}
+	this.name = name
+	this.labels = copyLabels(labels)
+	this.rate = rate
+	return this
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// SET AND ADD
+
+// Set the value of the gauge
+func (this *Gauge) Set(value float64) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.value = value
+	this.window.Observe(time.Now(), this.value)
+}
+
+// Add to the value of the gauge
+func (this *Gauge) Add(delta float64) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.value += delta
+	this.window.Observe(time.Now(), this.value)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// METRIC
+
+// Metric returns the current value, and the summary and mean of
+// values over the rate window
+func (this *Gauge) Metric() *gopi.Metric {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	summary, mean := this.window.Summary(time.Now())
+	return &gopi.Metric{
+		Rate:    this.rate,
+		Type:    gopi.METRIC_TYPE_GAUGE,
+		Name:    this.name,
+		Labels:  copyLabels(this.labels),
+		Mean:    mean,
+		Value:   this.value,
+		Summary: summary,
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Gauge) String() string {
+	return fmt.Sprintf("<util.metrics.Gauge>{ name='%v' labels=%v rate=%v }", this.name, this.labels, this.rate)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func copyLabels(labels []gopi.MetricLabel) []gopi.MetricLabel {
+	if len(labels) == 0 {
+		return nil
+	}
+	return append([]gopi.MetricLabel{}, labels...)
+}
@@ -0,0 +1,126 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Histogram counts observed values in buckets, and implements
+// gopi.MetricHistogram
+type Histogram struct {
+	lock   sync.Mutex
+	name   string
+	labels []gopi.MetricLabel
+	rate   gopi.MetricRate
+	bounds []float64
+	counts []uint
+	count  uint
+	sum    float64
+	window *Window
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	// Bucket upper bounds used when none are provided, which are
+	// suitable for durations in seconds
+	DEFAULT_BUCKETS = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+// NewHistogram returns a histogram with bucket upper bounds, which must
+// be in increasing order, or nil if the bounds or rate are invalid
+func NewHistogram(rate gopi.MetricRate, name string, bounds []float64, labels ...gopi.MetricLabel) *Histogram {
+	this := new(Histogram)
+	if this.window = NewWindow(rate); this.window == nil {
+		// Unsupported rate
+		return nil
+	}
+	if len(bounds) == 0 {
+		bounds = DEFAULT_BUCKETS
+	}
+	for i := range bounds {
+		if math.IsNaN(bounds[i]) || (i > 0 && bounds[i] <= bounds[i-1]) {
+			return nil
+		}
+	}
+	this.name = name
+	this.labels = copyLabels(labels)
+	this.rate = rate
+	this.bounds = append([]float64{}, bounds...)
+	this.counts = make([]uint, len(bounds))
+	return this
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// OBSERVE
+
+// Observe a value
+func (this *Histogram) Observe(value float64) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if i := sort.SearchFloat64s(this.bounds, value); i < len(this.bounds) {
+		this.counts[i]++
+	}
+	this.count++
+	this.sum += value
+	this.window.Observe(time.Now(), value)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// METRIC
+
+// Metric returns the cumulative buckets, count and sum of all values
+// observed, and the summary and mean of values over the rate window.
+// The last bucket has an infinite upper bound
+func (this *Histogram) Metric() *gopi.Metric {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	summary, mean := this.window.Summary(time.Now())
+	buckets := make([]gopi.MetricBucket, len(this.bounds)+1)
+	cumulative := uint(0)
+	for i, bound := range this.bounds {
+		cumulative += this.counts[i]
+		buckets[i] = gopi.MetricBucket{UpperBound: bound, Count: cumulative}
+	}
+	buckets[len(this.bounds)] = gopi.MetricBucket{UpperBound: math.Inf(+1), Count: this.count}
+	return &gopi.Metric{
+		Rate:    this.rate,
+		Type:    gopi.METRIC_TYPE_HISTOGRAM,
+		Name:    this.name,
+		Labels:  copyLabels(this.labels),
+		Mean:    mean,
+		Summary: summary,
+		Buckets: buckets,
+		Count:   this.count,
+		Sum:     this.sum,
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Histogram) String() string {
+	return fmt.Sprintf("<util.metrics.Histogram>{ name='%v' labels=%v rate=%v bounds=%v }", this.name, this.labels, this.rate, this.bounds)
+}
@@ -0,0 +1,167 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package metrics_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	metrics "github.com/djthorpe/gopi/util/metrics"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// SUMMARIZE
+
+func TestSummarize_000(t *testing.T) {
+	if summary, mean := metrics.Summarize(nil); summary.Count != 0 || mean != 0 || len(summary.Quantiles) != 0 {
+		t.Error("Unexpected summary", summary, mean)
+	}
+}
+
+func TestSummarize_001(t *testing.T) {
+	summary, mean := metrics.Summarize([]float64{5, 1, 4, 2, 3})
+	if summary.Count != 5 || summary.Min != 1 || summary.Max != 5 || mean != 3 {
+		t.Error("Unexpected summary", summary, mean)
+	}
+	expected := map[float64]float64{0.5: 3, 0.9: 4.6, 0.99: 4.96}
+	for _, q := range summary.Quantiles {
+		if value, exists := expected[q.Quantile]; exists == false {
+			t.Error("Unexpected quantile", q)
+		} else if math.Abs(value-q.Value) > 1e-9 {
+			t.Error("Unexpected quantile value", q, "expected", value)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// WINDOW
+
+func TestWindow_000(t *testing.T) {
+	if window := metrics.NewWindow(gopi.METRIC_RATE_NONE); window != nil {
+		t.Error("Expected nil window for METRIC_RATE_NONE")
+	}
+	window := metrics.NewWindow(gopi.METRIC_RATE_SECOND)
+	ts := time.Now()
+	window.Observe(ts, 10)
+	window.Observe(ts.Add(30*time.Second), 20)
+	window.Observe(ts.Add(70*time.Second), 30)
+	if values := window.Values(ts.Add(70 * time.Second)); len(values) != 2 || values[0] != 20 || values[1] != 30 {
+		t.Error("Unexpected values", values)
+	}
+	if values := window.Values(ts.Add(200 * time.Second)); len(values) != 0 {
+		t.Error("Unexpected values", values)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GAUGE
+
+func TestGauge_000(t *testing.T) {
+	gauge := metrics.NewGauge(gopi.METRIC_RATE_MINUTE, "temperature", gopi.MetricLabel{Name: "zone", Value: "cpu"})
+	gauge.Set(40)
+	gauge.Add(10)
+	gauge.Add(-20)
+	metric := gauge.Metric()
+	if metric.Type != gopi.METRIC_TYPE_GAUGE || metric.Value != 30 || metric.Mean != 40 {
+		t.Error("Unexpected metric", metric)
+	}
+	if metric.Summary.Count != 3 || metric.Summary.Min != 30 || metric.Summary.Max != 50 {
+		t.Error("Unexpected summary", metric.Summary)
+	}
+	if len(metric.Labels) != 1 || metric.Labels[0].Value != "cpu" {
+		t.Error("Unexpected labels", metric.Labels)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// HISTOGRAM
+
+func TestHistogram_000(t *testing.T) {
+	if h := metrics.NewHistogram(gopi.METRIC_RATE_MINUTE, "latency", []float64{1, 1}); h != nil {
+		t.Error("Expected nil histogram for bounds which are not increasing")
+	}
+	if h := metrics.NewHistogram(gopi.METRIC_RATE_MINUTE, "latency", nil); h == nil {
+		t.Error("Expected default bounds")
+	} else if buckets := h.Metric().Buckets; len(buckets) != len(metrics.DEFAULT_BUCKETS)+1 {
+		t.Error("Unexpected buckets", buckets)
+	}
+}
+
+func TestHistogram_001(t *testing.T) {
+	h := metrics.NewHistogram(gopi.METRIC_RATE_MINUTE, "latency", []float64{1, 5, 10})
+	for _, value := range []float64{0.5, 1, 3, 7, 20} {
+		h.Observe(value)
+	}
+	metric := h.Metric()
+	if metric.Count != 5 || metric.Sum != 31.5 {
+		t.Error("Unexpected count or sum", metric.Count, metric.Sum)
+	}
+	expected := []uint{2, 3, 4, 5}
+	if len(metric.Buckets) != len(expected) {
+		t.Fatal("Unexpected buckets", metric.Buckets)
+	}
+	for i, bucket := range metric.Buckets {
+		if bucket.Count != expected[i] {
+			t.Error("Unexpected bucket", bucket, "expected count", expected[i])
+		}
+	}
+	if math.IsInf(metric.Buckets[3].UpperBound, +1) == false {
+		t.Error("Expected infinite upper bound", metric.Buckets[3])
+	}
+	if metric.Summary.Min != 0.5 || metric.Summary.Max != 20 {
+		t.Error("Unexpected summary", metric.Summary)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// REGISTRY
+
+func TestRegistry_000(t *testing.T) {
+	registry := metrics.NewRegistry()
+	if _, err := registry.NewCounter(gopi.METRIC_TYPE_GAUGE, gopi.METRIC_RATE_SECOND, "counter"); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	counter, err := registry.NewCounter(gopi.METRIC_TYPE_NONE, gopi.METRIC_RATE_SECOND, "counter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := registry.NewGauge(gopi.METRIC_RATE_SECOND, "gauge"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := registry.NewHistogram(gopi.METRIC_RATE_SECOND, "histogram", nil); err != nil {
+		t.Fatal(err)
+	}
+	typed, err := registry.NewCounter(gopi.METRIC_TYPE_COUNTER, gopi.METRIC_RATE_SECOND, "typed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter <- 1
+	if metric := registry.Metric(counter); metric == nil || metric.Type != gopi.METRIC_TYPE_NONE {
+		t.Error("Unexpected metric", metric)
+	}
+	if metric := registry.Metric(typed); metric == nil || metric.Type != gopi.METRIC_TYPE_COUNTER {
+		t.Error("Unexpected metric", metric)
+	}
+	if counters := registry.Metrics(gopi.METRIC_TYPE_COUNTER); len(counters) != 1 || counters[0].Name != "typed" {
+		t.Error("Unexpected counters", counters)
+	}
+	if all := registry.Metrics(gopi.METRIC_TYPE_NONE); len(all) != 4 || all[0].Name != "counter" || all[3].Name != "typed" {
+		t.Error("Unexpected metrics", all)
+	}
+	if gauges := registry.Metrics(gopi.METRIC_TYPE_GAUGE); len(gauges) != 1 || gauges[0].Name != "gauge" {
+		t.Error("Unexpected gauges", gauges)
+	}
+	registry.Close()
+	if _, err := registry.NewGauge(gopi.METRIC_RATE_SECOND, "gauge"); err != gopi.ErrOutOfOrder {
+		t.Error("Expected ErrOutOfOrder, got", err)
+	}
+}
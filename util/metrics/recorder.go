@@ -0,0 +1,130 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Recorder appends load averages, memory and thermal metrics, and
+// custom metrics to a store at an interval
+type Recorder struct {
+	store    *Store
+	metrics  gopi.Metrics
+	interval time.Duration
+	log      gopi.Logger
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	DEFAULT_RECORD_INTERVAL = 10 * time.Second
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW AND CLOSE
+
+// NewRecorder starts recording metrics to a store, at an interval
+// or DEFAULT_RECORD_INTERVAL when the interval is zero
+func NewRecorder(store *Store, metrics gopi.Metrics, interval time.Duration, log gopi.Logger) *Recorder {
+	if interval == 0 {
+		interval = DEFAULT_RECORD_INTERVAL
+	}
+	this := new(Recorder)
+	this.store = store
+	this.metrics = metrics
+	this.interval = interval
+	this.log = log
+	this.done = make(chan struct{})
+	this.wg.Add(1)
+	go this.run()
+	return this
+}
+
+// Close stops recording metrics, but does not close the store
+func (this *Recorder) Close() {
+	close(this.done)
+	this.wg.Wait()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// RECORD
+
+// Record metrics at a time. Custom counters and histograms are
+// recorded as the mean and gauges as the current value
+func (this *Recorder) Record(ts time.Time) error {
+	load1, load5, load15 := this.metrics.LoadAverage()
+	values := map[string]float64{
+		Key("load_average", gopi.MetricLabel{Name: "period", Value: "1m"}):  load1,
+		Key("load_average", gopi.MetricLabel{Name: "period", Value: "5m"}):  load5,
+		Key("load_average", gopi.MetricLabel{Name: "period", Value: "15m"}): load15,
+	}
+	if host, ok := this.metrics.(gopi.HostMetrics); ok {
+		if memory, err := host.Memory(); err == nil {
+			values["memory_available_bytes"] = float64(memory.Available)
+		}
+		if zones, err := host.Thermal(); err == nil {
+			for _, zone := range zones {
+				values[Key("thermal_celcius", gopi.MetricLabel{Name: "zone", Value: zone.Name})] = zone.Celcius
+			}
+		}
+	}
+	for _, metric := range this.metrics.Metrics(gopi.METRIC_TYPE_NONE) {
+		key := Key(metric.Name, metric.Labels...)
+		if metric.Type == gopi.METRIC_TYPE_GAUGE {
+			values[key] = metric.Value
+		} else {
+			values[key] = metric.Mean
+		}
+	}
+	for key, value := range values {
+		if err := this.store.Append(ts, key, value); err != nil {
+			return fmt.Errorf("%v: %v", key, err)
+		}
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Recorder) String() string {
+	return fmt.Sprintf("<util.metrics.Recorder>{ store=%v interval=%v }", this.store, this.interval)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *Recorder) run() {
+	defer this.wg.Done()
+	ticker := time.NewTicker(this.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case ts := <-ticker.C:
+			if err := this.Record(ts); err != nil {
+				this.log.Warn("<util.metrics.Recorder>Record: %v", err)
+			}
+		case <-this.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,212 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Registry holds the counters, gauges and histograms created by a
+// metrics driver, in the order they were created
+type Registry struct {
+	lock     sync.Mutex
+	counters map[chan<- uint]*counter
+	metrics  []metric
+}
+
+type metric interface {
+	Metric() *gopi.Metric
+}
+
+type counter struct {
+	*Counter
+	name   string
+	labels []gopi.MetricLabel
+	typ    gopi.MetricType
+	rate   gopi.MetricRate
+	c      chan uint
+	done   chan struct{}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW AND CLOSE
+
+func NewRegistry() *Registry {
+	this := new(Registry)
+	this.counters = make(map[chan<- uint]*counter)
+	this.metrics = make([]metric, 0)
+	return this
+}
+
+// Close stops consuming values from counter channels, closes
+// the channels and releases the metrics
+func (this *Registry) Close() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	for _, m := range this.counters {
+		m.done <- gopi.DONE
+		<-m.done
+		close(m.c)
+	}
+
+	// Release resources
+	this.counters = nil
+	this.metrics = nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CREATE METRICS
+
+// NewCounter returns a channel on which values are counted. The type
+// should be METRIC_TYPE_NONE or METRIC_TYPE_COUNTER
+func (this *Registry) NewCounter(metric_type gopi.MetricType, metric_rate gopi.MetricRate, name string, labels ...gopi.MetricLabel) (chan<- uint, error) {
+	if metric_type != gopi.METRIC_TYPE_NONE && metric_type != gopi.METRIC_TYPE_COUNTER {
+		return nil, gopi.ErrBadParameter
+	}
+	if c := NewCounter(metric_rate); c == nil {
+		return nil, gopi.ErrBadParameter
+	} else {
+		m := &counter{
+			Counter: c,
+			name:    name,
+			labels:  copyLabels(labels),
+			typ:     metric_type,
+			rate:    metric_rate,
+			c:       make(chan uint),
+			done:    make(chan struct{}),
+		}
+		this.lock.Lock()
+		defer this.lock.Unlock()
+		if this.counters == nil {
+			// Registry has been closed
+			return nil, gopi.ErrOutOfOrder
+		}
+		this.counters[m.c] = m
+		this.metrics = append(this.metrics, m)
+
+		// Consume values
+		go m.consume()
+
+		// Return the counter
+		return m.c, nil
+	}
+}
+
+// NewGauge returns a gauge
+func (this *Registry) NewGauge(metric_rate gopi.MetricRate, name string, labels ...gopi.MetricLabel) (gopi.MetricGauge, error) {
+	if g := NewGauge(metric_rate, name, labels...); g == nil {
+		return nil, gopi.ErrBadParameter
+	} else if err := this.add(g); err != nil {
+		return nil, err
+	} else {
+		return g, nil
+	}
+}
+
+// NewHistogram returns a histogram with bucket upper bounds in
+// increasing order, or DEFAULT_BUCKETS when there are no bounds
+func (this *Registry) NewHistogram(metric_rate gopi.MetricRate, name string, bounds []float64, labels ...gopi.MetricLabel) (gopi.MetricHistogram, error) {
+	if h := NewHistogram(metric_rate, name, bounds, labels...); h == nil {
+		return nil, gopi.ErrBadParameter
+	} else if err := this.add(h); err != nil {
+		return nil, err
+	} else {
+		return h, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// RETURN METRICS
+
+// Metric returns the metric for a counter channel, or nil
+func (this *Registry) Metric(c chan<- uint) *gopi.Metric {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if m, exists := this.counters[c]; exists == false {
+		return nil
+	} else {
+		return m.Metric()
+	}
+}
+
+// Metrics returns metrics of a type, or all metrics for METRIC_TYPE_NONE
+func (this *Registry) Metrics(metric_type gopi.MetricType) []*gopi.Metric {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	metrics := make([]*gopi.Metric, 0, len(this.metrics))
+	for _, m := range this.metrics {
+		if metric := m.Metric(); metric_type == gopi.METRIC_TYPE_NONE || metric_type == metric.Type {
+			metrics = append(metrics, metric)
+		}
+	}
+	return metrics
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Registry) String() string {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return fmt.Sprintf("<util.metrics.Registry>{ metrics=%v }", len(this.metrics))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *Registry) add(m metric) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.metrics == nil {
+		// Registry has been closed
+		return gopi.ErrOutOfOrder
+	}
+	this.metrics = append(this.metrics, m)
+	return nil
+}
+
+func (m *counter) Metric() *gopi.Metric {
+	metric := &gopi.Metric{
+		Rate:   m.rate,
+		Type:   m.typ,
+		Name:   m.name,
+		Labels: copyLabels(m.labels),
+	}
+	if sum, samples, length := m.Sum(); samples > 0 {
+		metric.Mean = float64(sum) / float64(samples)
+		metric.Total = uint(float64(sum) * float64(length) / float64(samples))
+	}
+	metric.Summary, _ = m.Summary()
+	return metric
+}
+
+func (m *counter) consume() {
+FOR_LOOP:
+	for {
+		select {
+		case value := <-m.c:
+			if value > 0 {
+				m.Increment(time.Now(), value)
+			}
+		case <-m.done:
+			break FOR_LOOP
+		}
+	}
+	close(m.done)
+}
@@ -0,0 +1,447 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package metrics
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Store is an append-only time-series store. Values are appended to a
+// daily segment file for each tier, and segments older than the tier
+// retention are removed
+type Store struct {
+	lock  sync.Mutex
+	path  string
+	tiers []Tier
+	files []*os.File
+	names []string
+
+	// Values accumulated for the current interval, for each tier
+	pending []map[string]*gopi.MetricSample
+}
+
+// Tier is the interval between stored samples and how long samples
+// are retained. Values are stored without downsampling when the
+// interval is zero
+type Tier struct {
+	Interval  time.Duration
+	Retention time.Duration
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS AND GLOBAL VARIABLES
+
+const (
+	// Segment file extension and layout of segment names
+	STORE_EXT            = ".ts"
+	STORE_SEGMENT_LAYOUT = "20060102"
+	STORE_SEGMENT_PERIOD = 24 * time.Hour
+
+	// Size of a record excluding the key
+	storeRecordSize = 2 + 8 + 4 + 8*3
+)
+
+var (
+	// Raw values for a day, then minutes for a week and hours for a year
+	DEFAULT_TIERS = []Tier{
+		Tier{0, 24 * time.Hour},
+		Tier{time.Minute, 7 * 24 * time.Hour},
+		Tier{time.Hour, 365 * 24 * time.Hour},
+	}
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+// OpenStore opens or creates a store in a folder, with tiers in
+// increasing order of interval. The DEFAULT_TIERS are used when
+// no tiers are provided
+func OpenStore(path string, tiers ...Tier) (*Store, error) {
+	if len(tiers) == 0 {
+		tiers = DEFAULT_TIERS
+	}
+	for i, tier := range tiers {
+		if tier.Interval < 0 || tier.Retention <= 0 || (i > 0 && tier.Interval <= tiers[i-1].Interval) {
+			return nil, gopi.ErrBadParameter
+		}
+	}
+
+	this := new(Store)
+	this.path = path
+	this.tiers = append([]Tier{}, tiers...)
+	this.files = make([]*os.File, len(tiers))
+	this.names = make([]string, len(tiers))
+	this.pending = make([]map[string]*gopi.MetricSample, len(tiers))
+	for i, tier := range this.tiers {
+		if err := os.MkdirAll(filepath.Join(path, tier.Name()), 0755); err != nil {
+			return nil, err
+		}
+		this.pending[i] = make(map[string]*gopi.MetricSample)
+	}
+
+	// Remove expired segments
+	if err := this.Expire(time.Now()); err != nil {
+		return nil, err
+	}
+
+	return this, nil
+}
+
+// Close writes values accumulated for the current intervals
+// and closes the segment files
+func (this *Store) Close() error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	var result error
+	for i := range this.tiers {
+		for key, sample := range this.pending[i] {
+			if err := this.write(i, key, sample); err != nil && result == nil {
+				result = err
+			}
+		}
+		this.pending[i] = make(map[string]*gopi.MetricSample)
+		if this.files[i] != nil {
+			if err := this.files[i].Close(); err != nil && result == nil {
+				result = err
+			}
+			this.files[i], this.names[i] = nil, ""
+		}
+	}
+	return result
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// APPEND AND QUERY
+
+// Append a value for a metric key at a time. Values should be appended
+// in time order for each key
+func (this *Store) Append(ts time.Time, key string, value float64) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if key == "" || len(key) > math.MaxUint16 || math.IsNaN(value) {
+		return gopi.ErrBadParameter
+	}
+	for i, tier := range this.tiers {
+		if tier.Interval == 0 {
+			if err := this.write(i, key, newSample(ts, value)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Write the accumulated sample when the interval changes
+		bucket := ts.Truncate(tier.Interval)
+		if sample, exists := this.pending[i][key]; exists && sample.Timestamp.Equal(bucket) == false {
+			if err := this.write(i, key, sample); err != nil {
+				return err
+			}
+			delete(this.pending[i], key)
+		}
+		if sample, exists := this.pending[i][key]; exists {
+			merge(sample, newSample(ts, value))
+		} else {
+			this.pending[i][key] = newSample(bucket, value)
+		}
+	}
+	return nil
+}
+
+// Query returns samples for a metric key between two times, at an
+// interval or the finest interval stored when the interval is zero.
+// The tier used is the finest which retains samples back to the
+// start time
+func (this *Store) Query(key string, from, to time.Time, interval time.Duration) ([]gopi.MetricSample, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if to.Before(from) || interval < 0 {
+		return nil, gopi.ErrBadParameter
+	}
+
+	// Read samples from segments which overlap the time range
+	i := this.tierFor(from, interval)
+	samples := make(map[int64]*gopi.MetricSample)
+	if names, err := this.segments(i); err != nil {
+		return nil, err
+	} else {
+		for _, name := range names {
+			if start, err := time.Parse(STORE_SEGMENT_LAYOUT, name); err != nil {
+				continue
+			} else if start.After(to) || start.Add(STORE_SEGMENT_PERIOD).Before(from) {
+				continue
+			}
+			path := filepath.Join(this.path, this.tiers[i].Name(), name+STORE_EXT)
+			if _, err := readSegment(path, func(k string, sample *gopi.MetricSample) {
+				if k == key {
+					aggregate(samples, sample, from, to, interval)
+				}
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Include the sample for the current interval
+	if sample, exists := this.pending[i][key]; exists {
+		aggregate(samples, sample, from, to, interval)
+	}
+
+	// Return samples in time order
+	result := make([]gopi.MetricSample, 0, len(samples))
+	for _, sample := range samples {
+		result = append(result, *sample)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+	return result, nil
+}
+
+// Expire removes segments which only contain samples older than
+// the tier retention, except for segments which are open
+func (this *Store) Expire(now time.Time) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.expire(now)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// KEYS
+
+// Key returns the key for a metric name and labels, where the labels
+// are sorted by name
+func Key(name string, labels ...gopi.MetricLabel) string {
+	if len(labels) == 0 {
+		return name
+	}
+	sorted := copyLabels(labels)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	pairs := make([]string, len(sorted))
+	for i, label := range sorted {
+		pairs[i] = fmt.Sprintf("%v=%q", label.Name, label.Value)
+	}
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Store) String() string {
+	return fmt.Sprintf("<util.metrics.Store>{ path='%v' tiers=%v }", this.path, this.tiers)
+}
+
+func (t Tier) String() string {
+	return fmt.Sprintf("<util.metrics.Tier>{ interval=%v retention=%v }", t.Interval, t.Retention)
+}
+
+// Name returns the folder name for a tier
+func (t Tier) Name() string {
+	if t.Interval == 0 {
+		return "raw"
+	} else {
+		return fmt.Sprintf("%ds", t.Interval/time.Second)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// expire removes segments older than the tier retention
+func (this *Store) expire(now time.Time) error {
+	for i, tier := range this.tiers {
+		names, err := this.segments(i)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if start, err := time.Parse(STORE_SEGMENT_LAYOUT, name); err != nil {
+				continue
+			} else if name == this.names[i] || now.Sub(start.Add(STORE_SEGMENT_PERIOD)) <= tier.Retention {
+				continue
+			} else if err := os.Remove(filepath.Join(this.path, tier.Name(), name+STORE_EXT)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// write a sample to the segment for the sample timestamp, removing
+// expired segments when a new segment is opened. A truncated record
+// at the end of an existing segment is removed before appending
+func (this *Store) write(i int, key string, sample *gopi.MetricSample) error {
+	name := sample.Timestamp.UTC().Format(STORE_SEGMENT_LAYOUT)
+	if this.names[i] != name {
+		if this.files[i] != nil {
+			this.files[i].Close()
+			this.files[i], this.names[i] = nil, ""
+		}
+		path := filepath.Join(this.path, this.tiers[i].Name(), name+STORE_EXT)
+		if file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644); err != nil {
+			return err
+		} else if size, err := readSegment(path, nil); err != nil {
+			file.Close()
+			return err
+		} else if err := file.Truncate(size); err != nil {
+			file.Close()
+			return err
+		} else {
+			this.files[i], this.names[i] = file, name
+		}
+		if err := this.expire(time.Now()); err != nil {
+			return err
+		}
+	}
+
+	// Each record is written in a single call so that only the
+	// last record can be truncated
+	buf := make([]byte, storeRecordSize+len(key))
+	binary.LittleEndian.PutUint16(buf[0:], uint16(len(key)))
+	copy(buf[2:], key)
+	record := buf[2+len(key):]
+	binary.LittleEndian.PutUint64(record[0:], uint64(sample.Timestamp.UnixNano()))
+	binary.LittleEndian.PutUint32(record[8:], uint32(sample.Count))
+	binary.LittleEndian.PutUint64(record[12:], math.Float64bits(sample.Mean))
+	binary.LittleEndian.PutUint64(record[20:], math.Float64bits(sample.Min))
+	binary.LittleEndian.PutUint64(record[28:], math.Float64bits(sample.Max))
+	_, err := this.files[i].Write(buf)
+	return err
+}
+
+// segments returns the names of segments for a tier in time order
+func (this *Store) segments(i int) ([]string, error) {
+	files, err := ioutil.ReadDir(filepath.Join(this.path, this.tiers[i].Name()))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		if file.Mode().IsRegular() && filepath.Ext(file.Name()) == STORE_EXT {
+			names = append(names, strings.TrimSuffix(file.Name(), STORE_EXT))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// tierFor returns the finest tier with an interval no greater than the
+// query interval, which retains samples back to the start time
+func (this *Store) tierFor(from time.Time, interval time.Duration) int {
+	tier, age := 0, time.Since(from)
+	for i := range this.tiers {
+		if interval != 0 && this.tiers[i].Interval > interval {
+			break
+		}
+		tier = i
+		if this.tiers[i].Retention >= age {
+			break
+		}
+	}
+	return tier
+}
+
+// readSegment calls a function for each record in a segment, ignoring
+// a truncated record at the end of the segment. It returns the size of
+// the complete records in the segment
+func readSegment(path string, fn func(string, *gopi.MetricSample)) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	r := bufio.NewReader(file)
+	header := make([]byte, 2)
+	record := make([]byte, storeRecordSize-2)
+	size := int64(0)
+	for {
+		if _, err := io.ReadFull(r, header); err == io.EOF || err == io.ErrUnexpectedEOF {
+			return size, nil
+		} else if err != nil {
+			return 0, err
+		}
+		key := make([]byte, binary.LittleEndian.Uint16(header))
+		if _, err := io.ReadFull(r, key); err == io.EOF || err == io.ErrUnexpectedEOF {
+			return size, nil
+		} else if err != nil {
+			return 0, err
+		}
+		if _, err := io.ReadFull(r, record); err == io.EOF || err == io.ErrUnexpectedEOF {
+			return size, nil
+		} else if err != nil {
+			return 0, err
+		}
+		size += int64(len(header) + len(key) + len(record))
+		if fn != nil {
+			fn(string(key), &gopi.MetricSample{
+				Timestamp: time.Unix(0, int64(binary.LittleEndian.Uint64(record[0:]))),
+				Count:     uint(binary.LittleEndian.Uint32(record[8:])),
+				Mean:      math.Float64frombits(binary.LittleEndian.Uint64(record[12:])),
+				Min:       math.Float64frombits(binary.LittleEndian.Uint64(record[20:])),
+				Max:       math.Float64frombits(binary.LittleEndian.Uint64(record[28:])),
+			})
+		}
+	}
+}
+
+// aggregate a sample within a time range into samples at an interval
+func aggregate(samples map[int64]*gopi.MetricSample, sample *gopi.MetricSample, from, to time.Time, interval time.Duration) {
+	if sample.Timestamp.Before(from) || sample.Timestamp.After(to) {
+		return
+	}
+	ts := sample.Timestamp
+	if interval > 0 {
+		ts = ts.Truncate(interval)
+	}
+	if other, exists := samples[ts.UnixNano()]; exists {
+		merge(other, sample)
+	} else {
+		copy := *sample
+		copy.Timestamp = ts
+		samples[ts.UnixNano()] = &copy
+	}
+}
+
+// newSample returns a sample for a single value
+func newSample(ts time.Time, value float64) *gopi.MetricSample {
+	return &gopi.MetricSample{Timestamp: ts, Count: 1, Mean: value, Min: value, Max: value}
+}
+
+// merge a sample into another sample
+func merge(sample, other *gopi.MetricSample) {
+	count := sample.Count + other.Count
+	if count == 0 {
+		return
+	}
+	sample.Mean = (sample.Mean*float64(sample.Count) + other.Mean*float64(other.Count)) / float64(count)
+	sample.Min = math.Min(sample.Min, other.Min)
+	sample.Max = math.Max(sample.Max, other.Max)
+	sample.Count = count
+}
@@ -0,0 +1,264 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package metrics_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	mock "github.com/djthorpe/gopi/sys/hw/mock"
+	logger "github.com/djthorpe/gopi/sys/logger"
+	metrics "github.com/djthorpe/gopi/util/metrics"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// KEYS
+
+func TestKey_000(t *testing.T) {
+	if key := metrics.Key("temperature"); key != "temperature" {
+		t.Error("Unexpected key", key)
+	}
+	labels := []gopi.MetricLabel{{Name: "zone", Value: "cpu"}, {Name: "host", Value: "pi"}}
+	if key := metrics.Key("temperature", labels...); key != `temperature{host="pi",zone="cpu"}` {
+		t.Error("Unexpected key", key)
+	}
+	if labels[0].Name != "zone" {
+		t.Error("Labels were modified")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STORE
+
+func TestStore_000(t *testing.T) {
+	path := tempDir(t)
+	defer os.RemoveAll(path)
+
+	if _, err := metrics.OpenStore(path, metrics.Tier{time.Hour, time.Hour}, metrics.Tier{time.Minute, time.Hour}); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter for tiers out of order, got", err)
+	}
+	store, err := metrics.OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	// Append values over two minutes
+	start := time.Now().Truncate(time.Minute).Add(-2 * time.Minute)
+	for i := 0; i < 12; i++ {
+		if err := store.Append(start.Add(time.Duration(i)*10*time.Second), "requests", float64(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Raw values
+	if samples, err := store.Query("requests", start, start.Add(time.Hour), 0); err != nil {
+		t.Fatal(err)
+	} else if len(samples) != 12 || samples[3].Mean != 3 || samples[3].Count != 1 {
+		t.Error("Unexpected samples", samples)
+	}
+
+	// Aggregated by minute
+	if samples, err := store.Query("requests", start, start.Add(time.Hour), time.Minute); err != nil {
+		t.Fatal(err)
+	} else if len(samples) != 2 {
+		t.Error("Unexpected samples", samples)
+	} else if samples[0].Count != 6 || samples[0].Mean != 2.5 || samples[0].Min != 0 || samples[0].Max != 5 {
+		t.Error("Unexpected sample", samples[0])
+	} else if samples[1].Count != 6 || samples[1].Mean != 8.5 || samples[1].Timestamp.Equal(start.Add(time.Minute)) == false {
+		t.Error("Unexpected sample", samples[1])
+	}
+
+	// Unknown key
+	if samples, err := store.Query("other", start, start.Add(time.Hour), 0); err != nil {
+		t.Fatal(err)
+	} else if len(samples) != 0 {
+		t.Error("Unexpected samples", samples)
+	}
+}
+
+func TestStore_001(t *testing.T) {
+	path := tempDir(t)
+	defer os.RemoveAll(path)
+
+	// Only store downsampled values, which are written on close
+	tiers := []metrics.Tier{{time.Minute, 24 * time.Hour}}
+	store, err := metrics.OpenStore(path, tiers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := time.Now().Truncate(time.Minute)
+	store.Append(ts, "temperature", 40)
+	store.Append(ts.Add(time.Second), "temperature", 50)
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a truncated record, which is ignored
+	segment := filepath.Join(path, "60s", ts.UTC().Format(metrics.STORE_SEGMENT_LAYOUT)+metrics.STORE_EXT)
+	if file, err := os.OpenFile(segment, os.O_WRONLY|os.O_APPEND, 0); err != nil {
+		t.Fatal(err)
+	} else {
+		file.Write([]byte{11, 0, 't', 'e', 'm'})
+		file.Close()
+	}
+
+	// Reopen the store
+	store, err = metrics.OpenStore(path, tiers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samples, err := store.Query("temperature", ts.Add(-time.Hour), ts.Add(time.Hour), 0); err != nil {
+		t.Fatal(err)
+	} else if len(samples) != 1 || samples[0].Count != 2 || samples[0].Mean != 45 || samples[0].Max != 50 {
+		t.Error("Unexpected samples", samples)
+	}
+
+	// Append after the truncated record, which is removed when the
+	// segment is opened for writing
+	store.Append(ts.Add(time.Minute), "temperature", 60)
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	store, err = metrics.OpenStore(path, tiers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if samples, err := store.Query("temperature", ts.Add(-time.Hour), ts.Add(time.Hour), 0); err != nil {
+		t.Fatal(err)
+	} else if len(samples) != 2 || samples[0].Mean != 45 || samples[1].Count != 1 || samples[1].Mean != 60 {
+		t.Error("Unexpected samples", samples)
+	}
+}
+
+func TestStore_002(t *testing.T) {
+	path := tempDir(t)
+	defer os.RemoveAll(path)
+
+	store, err := metrics.OpenStore(path, metrics.Tier{0, time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	// Segments older than the retention are removed when a new segment is opened
+	now := time.Now()
+	store.Append(now.Add(-72*time.Hour), "load", 1)
+	if names, _ := ioutil.ReadDir(filepath.Join(path, "raw")); len(names) != 1 {
+		t.Error("Expected one segment, got", len(names))
+	}
+	store.Append(now, "load", 2)
+	if names, _ := ioutil.ReadDir(filepath.Join(path, "raw")); len(names) != 1 || names[0].Name() != now.UTC().Format(metrics.STORE_SEGMENT_LAYOUT)+metrics.STORE_EXT {
+		t.Error("Unexpected segments", names)
+	}
+	if samples, err := store.Query("load", now.Add(-96*time.Hour), now, 0); err != nil {
+		t.Fatal(err)
+	} else if len(samples) != 1 || samples[0].Mean != 2 {
+		t.Error("Unexpected samples", samples)
+	}
+}
+
+func TestStore_003(t *testing.T) {
+	path := tempDir(t)
+	defer os.RemoveAll(path)
+
+	store, err := metrics.OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	// Queries older than a day use the minute tier, queries older
+	// than a week use the hour tier
+	now := time.Now()
+	store.Append(now, "load", 1)
+	for _, from := range []time.Time{now.Add(-time.Hour), now.Add(-48 * time.Hour), now.Add(-30 * 24 * time.Hour)} {
+		if samples, err := store.Query("load", from, now, 0); err != nil {
+			t.Fatal(err)
+		} else if len(samples) != 1 || samples[0].Mean != 1 {
+			t.Error("Unexpected samples from", from, samples)
+		}
+	}
+	if _, err := store.Query("load", now, now.Add(-time.Hour), 0); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// RECORDER
+
+func TestRecorder_000(t *testing.T) {
+	path := tempDir(t)
+	defer os.RemoveAll(path)
+
+	log, err := gopi.Open(logger.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	driver, err := gopi.Open(mock.Metrics{Store: path, Interval: time.Hour}, log.(gopi.Logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer driver.Close()
+
+	store, err := metrics.OpenStore(filepath.Join(path, "recorder"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	m := driver.(gopi.Metrics)
+	if gauge, err := m.NewGauge(gopi.METRIC_RATE_MINUTE, "temperature", gopi.MetricLabel{Name: "zone", Value: "cpu"}); err != nil {
+		t.Fatal(err)
+	} else {
+		gauge.Set(42)
+	}
+	recorder := metrics.NewRecorder(store, m, time.Hour, log.(gopi.Logger))
+	defer recorder.Close()
+	now := time.Now()
+	if err := recorder.Record(now); err != nil {
+		t.Fatal(err)
+	}
+	if samples, err := store.Query(`load_average{period="5m"}`, now.Add(-time.Minute), now, 0); err != nil {
+		t.Fatal(err)
+	} else if len(samples) != 1 || samples[0].Mean != 0.2 {
+		t.Error("Unexpected samples", samples)
+	}
+	if samples, err := store.Query(`temperature{zone="cpu"}`, now.Add(-time.Minute), now, 0); err != nil {
+		t.Fatal(err)
+	} else if len(samples) != 1 || samples[0].Mean != 42 {
+		t.Error("Unexpected samples", samples)
+	}
+
+	// The driver has no history yet
+	if samples, err := driver.(gopi.MetricHistory).Series(now.Add(-time.Minute), now, 0, "temperature"); err != nil {
+		t.Fatal(err)
+	} else if len(samples) != 0 {
+		t.Error("Unexpected samples", samples)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func tempDir(t *testing.T) string {
+	if path, err := ioutil.TempDir("", "metrics"); err != nil {
+		t.Fatal(err)
+		return ""
+	} else {
+		return path
+	}
+}
@@ -0,0 +1,165 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package metrics
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Window holds the values observed over the window for a rate, which is
+// one minute for METRIC_RATE_SECOND, one hour for METRIC_RATE_MINUTE and
+// one day for METRIC_RATE_HOUR. Only the most recent values are retained
+type Window struct {
+	sync.Mutex
+	period  time.Duration
+	samples []sample
+}
+
+type sample struct {
+	ts    time.Time
+	value float64
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Maximum number of values retained in a window
+	WINDOW_MAX_SAMPLES = 1024
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	// Quantiles reported in summaries
+	SUMMARY_QUANTILES = []float64{0.5, 0.9, 0.99}
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+func NewWindow(rate gopi.MetricRate) *Window {
+	this := new(Window)
+	this.period = intervalForRate(rate) * time.Duration(numberOfBuckets(rate))
+	if this.period == 0 {
+		// Unsupported rate
+		return nil
+	}
+	this.samples = make([]sample, 0)
+	return this
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// OBSERVE AND SUMMARIZE
+
+// Observe a value at a timestamp, which should be later than any
+// previously observed value
+func (this *Window) Observe(ts time.Time, value float64) {
+	this.Lock()
+	defer this.Unlock()
+
+	this.expire(ts)
+	if len(this.samples) >= WINDOW_MAX_SAMPLES {
+		this.samples = this.samples[1:]
+	}
+	this.samples = append(this.samples, sample{ts, value})
+}
+
+// Values returns the values observed within the window
+func (this *Window) Values(ts time.Time) []float64 {
+	this.Lock()
+	defer this.Unlock()
+
+	this.expire(ts)
+	values := make([]float64, len(this.samples))
+	for i, sample := range this.samples {
+		values[i] = sample.value
+	}
+	return values
+}
+
+// Summary returns the summary of values observed within the window
+// and the mean value
+func (this *Window) Summary(ts time.Time) (gopi.MetricSummary, float64) {
+	return Summarize(this.Values(ts))
+}
+
+// Summarize returns the minimum, maximum and quantiles of a set of
+// values, and the mean value
+func Summarize(values []float64) (gopi.MetricSummary, float64) {
+	summary := gopi.MetricSummary{
+		Count: uint(len(values)),
+	}
+	if len(values) == 0 {
+		return summary, 0
+	}
+
+	// Sort the values
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	// Minimum, maximum, mean and quantiles
+	sum := float64(0)
+	for _, value := range sorted {
+		sum += value
+	}
+	summary.Min, summary.Max = sorted[0], sorted[len(sorted)-1]
+	summary.Quantiles = make([]gopi.MetricQuantile, len(SUMMARY_QUANTILES))
+	for i, q := range SUMMARY_QUANTILES {
+		summary.Quantiles[i] = gopi.MetricQuantile{Quantile: q, Value: quantile(sorted, q)}
+	}
+	return summary, sum / float64(len(sorted))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// expire removes values which are older than the window
+func (this *Window) expire(ts time.Time) {
+	i := 0
+	for i < len(this.samples) && ts.Sub(this.samples[i].ts) > this.period {
+		i++
+	}
+	if i > 0 {
+		this.samples = this.samples[i:]
+	}
+}
+
+// quantile returns the value for a quantile from sorted values, by
+// linear interpolation between the closest values
+func quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lo, hi := int(math.Floor(pos)), int(math.Ceil(pos))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+func intervalForRate(rate gopi.MetricRate) time.Duration {
+	switch rate {
+	case gopi.METRIC_RATE_SECOND:
+		return time.Second
+	case gopi.METRIC_RATE_MINUTE:
+		return time.Minute
+	case gopi.METRIC_RATE_HOUR:
+		return time.Hour
+	default:
+		return 0
+	}
+}
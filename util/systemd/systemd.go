@@ -0,0 +1,142 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+// Service manager notifications and socket activation for
+// applications which run as systemd services
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	STATE_READY    = "READY=1"    // Service has started
+	STATE_STOPPING = "STOPPING=1" // Service is shutting down
+	STATE_WATCHDOG = "WATCHDOG=1" // Service is alive
+)
+
+const (
+	// LISTEN_FDS_START is the first file descriptor passed
+	// by the service manager
+	LISTEN_FDS_START = 3
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// NOTIFY
+
+// Notify sends states to the service manager over $NOTIFY_SOCKET, and
+// returns false when the socket is not set
+func Notify(states ...string) (bool, error) {
+	path := os.Getenv("NOTIFY_SOCKET")
+	if path == "" {
+		return false, nil
+	}
+
+	// Names starting with '@' are in the abstract namespace
+	if strings.HasPrefix(path, "@") {
+		path = "\x00" + path[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(strings.Join(states, "\n"))); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// WatchdogInterval returns the interval from $WATCHDOG_USEC within which
+// the service manager expects STATE_WATCHDOG, or zero when the watchdog
+// is not enabled for this process
+func WatchdogInterval() (time.Duration, error) {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, nil
+	}
+	usec := os.Getenv("WATCHDOG_USEC")
+	if usec == "" {
+		return 0, nil
+	}
+	if value, err := strconv.ParseUint(usec, 10, 64); err != nil {
+		return 0, fmt.Errorf("WATCHDOG_USEC: %v", err)
+	} else if value == 0 {
+		return 0, fmt.Errorf("WATCHDOG_USEC: Invalid value")
+	} else {
+		return time.Duration(value) * time.Microsecond, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// SOCKET ACTIVATION
+
+// Files returns the file descriptors passed by the service manager
+// in $LISTEN_FDS, or nil when there are none for this process. The
+// environment is unset so the descriptors are only returned once
+func Files() ([]*os.File, error) {
+	pid, fds := os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS")
+	if pid == "" || fds == "" || pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	count, err := strconv.ParseUint(fds, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("LISTEN_FDS: %v", err)
+	}
+	files := make([]*os.File, count)
+	for i := range files {
+		fd := LISTEN_FDS_START + i
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		syscall.CloseOnExec(fd)
+		files[i] = os.NewFile(uintptr(fd), name)
+	}
+	return files, nil
+}
+
+// Listeners returns listeners for the sockets passed by the service
+// manager, or nil when there are none for this process
+func Listeners() ([]net.Listener, error) {
+	files, err := Files()
+	if err != nil || files == nil {
+		return nil, err
+	}
+	listeners := make([]net.Listener, len(files))
+	for i, file := range files {
+		// FileListener duplicates the file descriptor
+		listener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			for _, listener := range listeners[:i] {
+				listener.Close()
+			}
+			for _, file := range files[i+1:] {
+				file.Close()
+			}
+			return nil, fmt.Errorf("%v: %v", file.Name(), err)
+		}
+		listeners[i] = listener
+	}
+	return listeners, nil
+}
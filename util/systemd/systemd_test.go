@@ -0,0 +1,104 @@
+package systemd_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	// Frameworks
+	systemd "github.com/djthorpe/gopi/util/systemd"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// NOTIFY
+
+func TestNotify_000(t *testing.T) {
+	os.Unsetenv("NOTIFY_SOCKET")
+	if ok, err := systemd.Notify(systemd.STATE_READY); err != nil {
+		t.Error(err)
+	} else if ok {
+		t.Error("Expected no notification without NOTIFY_SOCKET")
+	}
+}
+
+func TestNotify_001(t *testing.T) {
+	path, err := ioutil.TempDir("", "systemd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+
+	// Receive notifications on a datagram socket
+	addr := &net.UnixAddr{Name: filepath.Join(path, "notify"), Net: "unixgram"}
+	conn, err := net.ListenUnixgram("unixgram", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	os.Setenv("NOTIFY_SOCKET", addr.Name)
+	defer os.Unsetenv("NOTIFY_SOCKET")
+
+	if ok, err := systemd.Notify(systemd.STATE_READY, "STATUS=Serving"); err != nil {
+		t.Fatal(err)
+	} else if ok == false {
+		t.Fatal("Expected notification")
+	}
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if n, err := conn.Read(buf); err != nil {
+		t.Error(err)
+	} else if string(buf[:n]) != "READY=1\nSTATUS=Serving" {
+		t.Errorf("Unexpected notification: %q", buf[:n])
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// WATCHDOG
+
+func TestWatchdog_000(t *testing.T) {
+	defer os.Unsetenv("WATCHDOG_USEC")
+	defer os.Unsetenv("WATCHDOG_PID")
+
+	os.Unsetenv("WATCHDOG_USEC")
+	if interval, err := systemd.WatchdogInterval(); err != nil || interval != 0 {
+		t.Error("Unexpected interval", interval, err)
+	}
+	os.Setenv("WATCHDOG_USEC", "30000000")
+	if interval, err := systemd.WatchdogInterval(); err != nil || interval != 30*time.Second {
+		t.Error("Unexpected interval", interval, err)
+	}
+
+	// The watchdog is for another process
+	os.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+	if interval, err := systemd.WatchdogInterval(); err != nil || interval != 0 {
+		t.Error("Unexpected interval", interval, err)
+	}
+	os.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	os.Setenv("WATCHDOG_USEC", "invalid")
+	if _, err := systemd.WatchdogInterval(); err == nil {
+		t.Error("Expected error for invalid WATCHDOG_USEC")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// SOCKET ACTIVATION
+
+func TestListeners_000(t *testing.T) {
+	// No sockets are passed, or sockets are for another process
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	if listeners, err := systemd.Listeners(); err != nil || listeners != nil {
+		t.Error("Unexpected listeners", listeners, err)
+	}
+	os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	os.Setenv("LISTEN_FDS", "1")
+	defer os.Unsetenv("LISTEN_PID")
+	defer os.Unsetenv("LISTEN_FDS")
+	if listeners, err := systemd.Listeners(); err != nil || listeners != nil {
+		t.Error("Unexpected listeners", listeners, err)
+	}
+}